@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIDocument builds an OpenAPI 3.0 description from the route table, so
+// the document cannot drift from the handlers that are actually served.
+func (s *Server) OpenAPIDocument() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]interface{})
+
+	for _, rt := range s.routes {
+		operation := map[string]interface{}{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+		}
+
+		var parameters []interface{}
+		for _, name := range pathParams(rt.pattern) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, name := range rt.query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "query",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if rt.request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaFor(reflect.TypeOf(rt.request), schemas)),
+			}
+		}
+
+		responses := map[string]interface{}{
+			strconv.Itoa(rt.status): map[string]interface{}{
+				"description": http.StatusText(rt.status),
+				"content":     jsonContent(schemaFor(reflect.TypeOf(rt.response), schemas)),
+			},
+		}
+		for _, status := range rt.errors {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     jsonContent(schemaFor(reflect.TypeOf(ErrorResponse{}), schemas)),
+			}
+		}
+		operation["responses"] = responses
+
+		item, ok := paths[rt.pattern].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[rt.pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Parking Lot System API",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+func operationID(rt *route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(rt.method))
+	for _, part := range strings.Split(strings.Trim(rt.pattern, "/"), "/") {
+		part = strings.Trim(part, "{}")
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func pathParams(pattern string) []string {
+	var params []string
+	for _, part := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, strings.Trim(part, "{}"))
+		}
+	}
+	return params
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaFor returns the JSON schema for t, registering named structs under
+// components/schemas and referring to them by $ref
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		// Embedded JSON documents, such as webhook deliveries
+		return map[string]interface{}{"type": "object"}
+	case t.Implements(textMarshalerType):
+		// Enums such as space types travel as their names
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map || t.Kind() == reflect.Interface:
+		return map[string]interface{}{"type": "object"}
+	case t.Kind() == reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // Reserve the name first so recursive types terminate
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	addStructFields(t, schemas, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func addStructFields(t reflect.Type, schemas map[string]interface{}, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addStructFields(field.Type, schemas, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaFor(field.Type, schemas)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			// $ref siblings are ignored by OpenAPI 3.0, so only inline schemas get descriptions
+			if _, isRef := property["$ref"]; !isRef {
+				property["description"] = doc
+			}
+		}
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,1011 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server exposes ParkingService, BillingService and PoliceService over REST/JSON
+type Server struct {
+	parking *services.ParkingService
+	billing *services.BillingService
+	police  *services.PoliceService
+	routes  []*route
+}
+
+// route describes one endpoint; the same table drives dispatch and the OpenAPI document
+type route struct {
+	method   string
+	pattern  string // Path segments in braces are parameters, e.g. /api/cars/{plate}
+	summary  string
+	query    []string
+	request  interface{} // Zero value of the JSON body type, nil when there is none
+	response interface{} // Zero value of the JSON response type
+	status   int
+	errors   []int
+	handle   func(s *Server, r *http.Request, params map[string]string) (interface{}, error)
+}
+
+// apiError carries an explicit HTTP status for request-level failures
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// NewServer creates the API. A nil billing service quotes fees at the parking
+// service's current rates, so rate changes show up without a restart.
+func NewServer(parking *services.ParkingService, billing *services.BillingService, police *services.PoliceService) *Server {
+	return &Server{
+		parking: parking,
+		billing: billing,
+		police:  police,
+		routes:  apiRoutes(),
+	}
+}
+
+func (s *Server) billingService() *services.BillingService {
+	if s.billing != nil {
+		return s.billing
+	}
+	return s.parking.GetBillingService()
+}
+
+func apiRoutes() []*route {
+	return []*route{
+		{method: http.MethodPost, pattern: "/api/park", summary: "Park a car in the first available space and issue a ticket",
+			request: ParkRequest{}, response: TicketResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusConflict}, handle: (*Server).park},
+		{method: http.MethodPost, pattern: "/api/attendants/{attendantId}/park", summary: "Park a car through an attendant, optionally with a strategy",
+			request: ParkWithAttendantRequest{}, response: ParkingDecisionResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).parkWithAttendant},
+		{method: http.MethodPost, pattern: "/api/unpark", summary: "Unpark a car, close its ticket and return the bill, or the lost-ticket penalty",
+			request: UnparkRequest{}, response: BillResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusPaymentRequired, http.StatusNotFound}, handle: (*Server).unpark},
+		{method: http.MethodPost, pattern: "/api/cars/{plate}/exit-bill", summary: "Price a parked car's stay so far as the bill to pay before leaving",
+			request: ExitBillRequest{}, response: BillResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).exitBill},
+		{method: http.MethodPost, pattern: "/api/cars/{plate}/settlement-override", summary: "Let a car leave before its bill is paid",
+			request: OverrideRequest{}, response: BillResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}, handle: (*Server).overrideSettlement},
+		{method: http.MethodPost, pattern: "/api/tickets/{ticketId}/payments", summary: "Pay all or part of a ticket's exit bill",
+			request: PaymentRequest{}, response: PaymentResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusPaymentRequired, http.StatusNotFound, http.StatusConflict}, handle: (*Server).pay},
+		{method: http.MethodGet, pattern: "/api/tickets/{ticketId}/receipt", summary: "Bill and payments of a ticket",
+			response: ReceiptResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).receipt},
+		{method: http.MethodPost, pattern: "/api/payments/{paymentId}/refunds", summary: "Refund all or part of a payment",
+			request: RefundRequest{}, response: PaymentResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).refund},
+		{method: http.MethodGet, pattern: "/api/cars/{plate}", summary: "Find a parked car",
+			response: CarLocationResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).findCar},
+		{method: http.MethodGet, pattern: "/api/cars/{plate}/directions", summary: "Directions from the gate to a parked car",
+			response: DirectionsResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).directions},
+		{method: http.MethodGet, pattern: "/api/cars/{plate}/fee", summary: "Fee accrued so far by a parked car",
+			response: FeeEstimateResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).feeEstimate},
+		{method: http.MethodGet, pattern: "/api/cars/{plate}/tickets", summary: "Parking history of a car",
+			response: []TicketResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).ticketHistory},
+		{method: http.MethodGet, pattern: "/api/lots", summary: "Status of every lot",
+			response: []LotStatusResponse{}, status: http.StatusOK, handle: (*Server).listLots},
+		{method: http.MethodPost, pattern: "/api/lots", summary: "Create a lot",
+			request: LotRequest{}, response: LotStatusResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusConflict}, handle: (*Server).createLot},
+		{method: http.MethodGet, pattern: "/api/lots/{lotId}", summary: "Status of one lot",
+			response: LotStatusResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).lotStatus},
+		{method: http.MethodGet, pattern: "/api/tickets/{ticketId}", summary: "Look up a ticket",
+			response: TicketResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).getTicket},
+		{method: http.MethodGet, pattern: "/api/tickets/{ticketId}/verification", summary: "Check a ticket ID is well formed and signed, without looking it up",
+			response: TicketVerificationResponse{}, status: http.StatusOK, handle: (*Server).verifyTicket},
+		{method: http.MethodPost, pattern: "/api/reservations", summary: "Reserve space in a lot for a future window",
+			request: ReservationRequest{}, response: ReservationResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).createReservation},
+		{method: http.MethodGet, pattern: "/api/reservations", summary: "List reservations, optionally for one lot",
+			query: []string{"lotId"}, response: []ReservationResponse{}, status: http.StatusOK, handle: (*Server).listReservations},
+		{method: http.MethodGet, pattern: "/api/reservations/{reservationId}", summary: "Look up a reservation",
+			response: ReservationResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).getReservation},
+		{method: http.MethodDelete, pattern: "/api/reservations/{reservationId}", summary: "Cancel a pending reservation",
+			response: ReservationResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound, http.StatusConflict}, handle: (*Server).cancelReservation},
+		{method: http.MethodPost, pattern: "/api/reservations/{reservationId}/arrival", summary: "Park a reserved car and issue its ticket",
+			response: TicketResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).arriveReservation},
+		{method: http.MethodPost, pattern: "/api/permits", summary: "Issue a permit to a subscriber plate",
+			request: PermitRequest{}, response: PermitResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).issuePermit},
+		{method: http.MethodGet, pattern: "/api/permits", summary: "List permits",
+			response: []PermitResponse{}, status: http.StatusOK, handle: (*Server).listPermits},
+		{method: http.MethodGet, pattern: "/api/permits/{permitId}", summary: "Look up a permit",
+			response: PermitResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).getPermit},
+		{method: http.MethodDelete, pattern: "/api/permits/{permitId}", summary: "Revoke a permit",
+			response: PermitResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound, http.StatusConflict}, handle: (*Server).revokePermit},
+		{method: http.MethodPost, pattern: "/api/permits/{permitId}/renewal", summary: "Move the end of a permit",
+			request: RenewPermitRequest{}, response: PermitResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).renewPermit},
+		{method: http.MethodPost, pattern: "/api/waitlist", summary: "Queue a car for the next free space",
+			request: ParkRequest{}, response: WaitlistEntryResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusConflict}, handle: (*Server).joinWaitlist},
+		{method: http.MethodGet, pattern: "/api/waitlist", summary: "Queued cars in the order they will be served",
+			response: []WaitlistEntryResponse{}, status: http.StatusOK, handle: (*Server).listWaitlist},
+		{method: http.MethodGet, pattern: "/api/waitlist/{entryId}", summary: "Look up a waitlist entry and its estimated wait",
+			response: WaitlistEntryResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).getWaitlistEntry},
+		{method: http.MethodDelete, pattern: "/api/waitlist/{entryId}", summary: "Leave the waitlist",
+			response: WaitlistEntryResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound, http.StatusConflict}, handle: (*Server).leaveWaitlist},
+		{method: http.MethodPost, pattern: "/api/waitlist/{entryId}/acceptance", summary: "Park in the space offered to a queued car",
+			response: TicketResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}, handle: (*Server).acceptWaitlistOffer},
+		{method: http.MethodGet, pattern: "/api/webhooks", summary: "Webhooks lot events are posted to",
+			response: []WebhookResponse{}, status: http.StatusOK, handle: (*Server).listWebhooks},
+		{method: http.MethodGet, pattern: "/api/webhooks/dead-letters", summary: "Webhook deliveries that failed every retry",
+			response: []DeadLetterResponse{}, status: http.StatusOK, handle: (*Server).listDeadLetters},
+		{method: http.MethodPost, pattern: "/api/webhooks/dead-letters/{deadLetterId}/redelivery", summary: "Post a failed webhook delivery again",
+			response: DeadLetterResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusBadGateway}, handle: (*Server).redeliverDeadLetter},
+		{method: http.MethodGet, pattern: "/api/utilization", summary: "Utilization of every lot",
+			response: []UtilizationResponse{}, status: http.StatusOK, handle: (*Server).utilization},
+		{method: http.MethodPost, pattern: "/api/occupancy/samples", summary: "Record every lot's occupancy now in its history",
+			response: []OccupancySampleResponse{}, status: http.StatusCreated, handle: (*Server).sampleOccupancy},
+		{method: http.MethodGet, pattern: "/api/occupancy/forecasts", summary: "Forecast every lot's occupancy and when it will fill",
+			query: []string{"hours"}, response: []OccupancyForecastResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest}, handle: (*Server).occupancyForecasts},
+		{method: http.MethodGet, pattern: "/api/lots/{lotId}/occupancy", summary: "Occupancy history of a lot",
+			query: []string{"from", "to"}, response: []OccupancySampleResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}, handle: (*Server).occupancyHistory},
+		{method: http.MethodGet, pattern: "/api/lots/{lotId}/occupancy/profile", summary: "A lot's average and peak occupancy by hour of the day and day of the week",
+			response: OccupancyProfileResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusNotFound}, handle: (*Server).occupancyProfile},
+		{method: http.MethodGet, pattern: "/api/lots/{lotId}/occupancy/forecast", summary: "Forecast a lot's occupancy and when it will fill",
+			query: []string{"hours"}, response: OccupancyForecastResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}, handle: (*Server).occupancyForecast},
+		{method: http.MethodGet, pattern: "/api/reports/{period}", summary: "Revenue and occupancy of every lot over a daily, weekly or monthly period",
+			query: []string{"at"}, response: ReportResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest}, handle: (*Server).report},
+		{method: http.MethodGet, pattern: "/api/attendants", summary: "List attendants",
+			response: []AttendantResponse{}, status: http.StatusOK, handle: (*Server).listAttendants},
+		{method: http.MethodPost, pattern: "/api/attendants", summary: "Register an attendant",
+			request: AttendantRequest{}, response: AttendantResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusConflict}, handle: (*Server).addAttendant},
+		{method: http.MethodGet, pattern: "/api/security-staff", summary: "List security staff",
+			response: []SecurityStaffResponse{}, status: http.StatusOK, handle: (*Server).listSecurityStaff},
+		{method: http.MethodPost, pattern: "/api/security-staff", summary: "Register a security staff member",
+			request: SecurityStaffRequest{}, response: SecurityStaffResponse{}, status: http.StatusCreated,
+			errors: []int{http.StatusBadRequest, http.StatusConflict}, handle: (*Server).addSecurityStaff},
+		{method: http.MethodPost, pattern: "/api/security-staff/{staffId}/assignment", summary: "Assign security staff to a lot",
+			request: AssignSecurityRequest{}, response: SecurityStaffResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}, handle: (*Server).assignSecurity},
+		{method: http.MethodGet, pattern: "/api/police/vehicles", summary: "Search parked vehicles by color and make",
+			query: []string{"color", "make"}, response: []VehicleInvestigationResponse{}, status: http.StatusOK,
+			handle: (*Server).policeSearch},
+		{method: http.MethodGet, pattern: "/api/police/recent", summary: "Vehicles parked in the last N minutes",
+			query: []string{"minutes"}, response: []VehicleInvestigationResponse{}, status: http.StatusOK,
+			errors: []int{http.StatusBadRequest}, handle: (*Server).policeRecent},
+		{method: http.MethodGet, pattern: "/api/openapi.json", summary: "This OpenAPI document",
+			response: map[string]interface{}{}, status: http.StatusOK, handle: (*Server).openAPI},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pathMatched := false
+	for _, rt := range s.routes {
+		params, ok := matchPattern(rt.pattern, r.URL.Path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.method != r.Method {
+			continue
+		}
+
+		body, err := rt.handle(s, r, params)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, rt.status, body)
+		return
+	}
+
+	if pathMatched {
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Status: http.StatusMethodNotAllowed, Message: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, ErrorResponse{Status: http.StatusNotFound, Message: "endpoint not found"})
+}
+
+func matchPattern(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			params[strings.Trim(part, "{}")] = pathParts[i]
+		} else if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := statusForError(err)
+	writeJSON(w, status, ErrorResponse{Status: status, Message: err.Error()})
+}
+
+// statusForError maps service errors onto HTTP status codes by their kind.
+// Errors of no known kind are failures of the server itself.
+func statusForError(err error) int {
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.status
+	case errors.Is(err, services.ErrDeliveryFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrPaymentRequired):
+		return http.StatusPaymentRequired
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func decodeBody(r *http.Request, into interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(into); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// Handlers
+
+func (s *Server) park(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req ParkRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.LicensePlate == "" {
+		return nil, badRequest("licensePlate is required")
+	}
+
+	ticket, err := s.parking.ParkCarWithTicket(req.toCar())
+	if err != nil {
+		return nil, err
+	}
+	return newTicketResponse(ticket), nil
+}
+
+func (s *Server) parkWithAttendant(r *http.Request, params map[string]string) (interface{}, error) {
+	var req ParkWithAttendantRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.LicensePlate == "" {
+		return nil, badRequest("licensePlate is required")
+	}
+
+	car := req.toCar()
+	attendantID := params["attendantId"]
+
+	var decision *models.ParkingDecision
+	var err error
+	switch strings.ToLower(req.Strategy) {
+	case "":
+		decision, err = s.parking.ParkCarWithAttendant(car, attendantID)
+	case "default":
+		decision, err = s.parking.ParkCarWithDefaultStrategy(car, attendantID)
+	case "even":
+		decision, err = s.parking.ParkCarEvenDistribution(car, attendantID)
+	case "handicap":
+		decision, err = s.parking.ParkHandicapCar(car, attendantID)
+	case "large":
+		decision, err = s.parking.ParkLargeVehicle(car, attendantID)
+	case "smart":
+		decision, err = s.parking.ParkCarSmart(car, attendantID)
+	default:
+		return nil, badRequest("unknown strategy %q", req.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParkingDecisionResponse{
+		AttendantID: decision.AttendantID,
+		LotID:       decision.LotID,
+		SpaceID:     decision.SpaceID,
+		Reason:      decision.Reason,
+	}, nil
+}
+
+func (s *Server) unpark(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req UnparkRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	unpark := s.parking.UnparkCarWithBilling
+	if req.LostTicket {
+		unpark = s.parking.UnparkCarWithLostTicket
+	}
+	_, bill, err := unpark(req.LicensePlate)
+	if err != nil {
+		return nil, err
+	}
+	return newBillResponse(bill), nil
+}
+
+func (s *Server) exitBill(r *http.Request, params map[string]string) (interface{}, error) {
+	var req ExitBillRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.parking.QuoteExitBill(params["plate"], req.LostTicket)
+	if err != nil {
+		return nil, err
+	}
+	return newBillResponse(bill), nil
+}
+
+func (s *Server) overrideSettlement(r *http.Request, params map[string]string) (interface{}, error) {
+	var req OverrideRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AttendantID == "" {
+		return nil, badRequest("attendantId is required")
+	}
+
+	bill, err := s.parking.OverrideSettlement(params["plate"], req.AttendantID)
+	if err != nil {
+		return nil, err
+	}
+	return newBillResponse(bill), nil
+}
+
+func (s *Server) pay(r *http.Request, params map[string]string) (interface{}, error) {
+	var req PaymentRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	method, err := services.ParsePaymentMethod(req.Method)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+
+	payments := s.parking.GetPaymentService()
+	payment, err := payments.Pay(params["ticketId"], method, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	balance, _ := payments.BalanceDue(payment.TicketID)
+	return newPaymentResponse(payment, balance), nil
+}
+
+func (s *Server) receipt(_ *http.Request, params map[string]string) (interface{}, error) {
+	receipt, err := s.parking.GetPaymentService().GetReceipt(params["ticketId"])
+	if err != nil {
+		return nil, err
+	}
+	return newReceiptResponse(receipt), nil
+}
+
+func (s *Server) refund(r *http.Request, params map[string]string) (interface{}, error) {
+	var req RefundRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	payments := s.parking.GetPaymentService()
+	payment, err := payments.Refund(params["paymentId"], req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	balance, _ := payments.BalanceDue(payment.TicketID)
+	return newPaymentResponse(payment, balance), nil
+}
+
+func (s *Server) findCar(_ *http.Request, params map[string]string) (interface{}, error) {
+	location, err := s.parking.FindCarWithLocation(params["plate"])
+	if err != nil {
+		return nil, err
+	}
+
+	return CarLocationResponse{
+		Car:         newCarResponse(location.Car),
+		LotID:       location.LotID,
+		SpaceID:     location.SpaceID,
+		Level:       location.Level,
+		Row:         location.Row,
+		Position:    location.Position,
+		ParkedAt:    location.ParkedAt,
+		AttendantID: location.AttendantID,
+	}, nil
+}
+
+func (s *Server) directions(_ *http.Request, params map[string]string) (interface{}, error) {
+	directions, err := s.parking.ProvideDirectionsToDriver(params["plate"])
+	if err != nil {
+		return nil, err
+	}
+	return DirectionsResponse{LicensePlate: params["plate"], Directions: directions}, nil
+}
+
+func (s *Server) feeEstimate(_ *http.Request, params map[string]string) (interface{}, error) {
+	ticket, err := s.parking.GetActiveTicket(params["plate"])
+	if err != nil {
+		return nil, err
+	}
+
+	var car *models.Car
+	if space, err := s.parking.FindCar(ticket.LicensePlate); err == nil {
+		car = space.GetParkedCar()
+	}
+
+	now := s.parking.GetClock().Now()
+	fee := s.billingService().EstimateFee(ticket, car, now)
+	if permit, err := s.parking.GetPermitService().GetPermit(ticket.PermitID); err == nil {
+		fee = s.billingService().GeneratePermitBill(ticket, car, permit.ID, permit.EndsAt()).TotalAmount
+	}
+	return FeeEstimateResponse{
+		LicensePlate:    ticket.LicensePlate,
+		TicketID:        ticket.ID,
+		DurationSeconds: now.Sub(ticket.ParkedAt).Seconds(),
+		CurrentFee:      fee,
+	}, nil
+}
+
+func (s *Server) ticketHistory(_ *http.Request, params map[string]string) (interface{}, error) {
+	history, err := s.parking.GetParkingHistory(params["plate"])
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := make([]TicketResponse, 0, len(history))
+	for _, ticket := range history {
+		tickets = append(tickets, newTicketResponse(ticket))
+	}
+	return tickets, nil
+}
+
+func (s *Server) listLots(_ *http.Request, _ map[string]string) (interface{}, error) {
+	lots := make([]LotStatusResponse, 0)
+	for _, util := range s.parking.GetLotUtilization() {
+		lot, err := s.parking.GetLotStatus(util.LotID)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, newLotStatusResponse(lot))
+	}
+	return lots, nil
+}
+
+func (s *Server) createLot(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req LotRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.ID == "" {
+		return nil, badRequest("id is required")
+	}
+
+	var lot *models.ParkingLot
+	if req.Layout != nil {
+		if req.Capacity != 0 || len(req.SpaceTypes) > 0 {
+			return nil, badRequest("layout cannot be combined with capacity or spaceTypes")
+		}
+		var err error
+		if lot, err = models.NewParkingLotWithLayout(req.ID, *req.Layout); err != nil {
+			return nil, badRequest("%v", err)
+		}
+	} else if len(req.SpaceTypes) > 0 {
+		if req.Capacity != 0 && req.Capacity != len(req.SpaceTypes) {
+			return nil, badRequest("capacity must match the number of space types")
+		}
+		spaceTypes := make([]models.SpaceType, len(req.SpaceTypes))
+		for i, name := range req.SpaceTypes {
+			spaceType, err := models.ParseSpaceType(name)
+			if err != nil {
+				return nil, badRequest("%v", err)
+			}
+			spaceTypes[i] = spaceType
+		}
+		lot = models.NewParkingLotWithSpaceTypes(req.ID, spaceTypes)
+	} else {
+		if req.Capacity <= 0 {
+			return nil, badRequest("capacity must be positive")
+		}
+		lot = models.NewParkingLot(req.ID, req.Capacity)
+	}
+
+	if err := s.parking.AddLot(lot); err != nil {
+		return nil, err
+	}
+	return newLotStatusResponse(lot), nil
+}
+
+func (s *Server) lotStatus(_ *http.Request, params map[string]string) (interface{}, error) {
+	lot, err := s.parking.GetLotStatus(params["lotId"])
+	if err != nil {
+		return nil, err
+	}
+	return newLotStatusResponse(lot), nil
+}
+
+func (s *Server) getTicket(_ *http.Request, params map[string]string) (interface{}, error) {
+	ticket, err := s.parking.GetTicket(params["ticketId"])
+	if err != nil {
+		return nil, err
+	}
+	return newTicketResponse(ticket), nil
+}
+
+func (s *Server) verifyTicket(_ *http.Request, params map[string]string) (interface{}, error) {
+	verification := TicketVerificationResponse{TicketID: params["ticketId"], Valid: true}
+	if err := s.parking.VerifyTicketID(params["ticketId"]); err != nil {
+		verification.Valid = false
+		verification.Reason = err.Error()
+	}
+	return verification, nil
+}
+
+func (s *Server) createReservation(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req ReservationRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.LicensePlate == "" {
+		return nil, badRequest("licensePlate is required")
+	}
+	if req.LotID == "" {
+		return nil, badRequest("lotId is required")
+	}
+
+	reservation, err := s.parking.GetReservationService().Book(req.toCar(), req.LotID, req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+	return newReservationResponse(reservation), nil
+}
+
+func (s *Server) listReservations(r *http.Request, _ map[string]string) (interface{}, error) {
+	reservations := make([]ReservationResponse, 0)
+	for _, reservation := range s.parking.GetReservationService().GetReservations(r.URL.Query().Get("lotId")) {
+		reservations = append(reservations, newReservationResponse(reservation))
+	}
+	return reservations, nil
+}
+
+func (s *Server) getReservation(_ *http.Request, params map[string]string) (interface{}, error) {
+	reservation, err := s.parking.GetReservationService().GetReservation(params["reservationId"])
+	if err != nil {
+		return nil, err
+	}
+	return newReservationResponse(reservation), nil
+}
+
+func (s *Server) cancelReservation(_ *http.Request, params map[string]string) (interface{}, error) {
+	reservation, err := s.parking.GetReservationService().Cancel(params["reservationId"])
+	if err != nil {
+		return nil, err
+	}
+	return newReservationResponse(reservation), nil
+}
+
+func (s *Server) arriveReservation(_ *http.Request, params map[string]string) (interface{}, error) {
+	ticket, err := s.parking.GetReservationService().Arrive(params["reservationId"])
+	if err != nil {
+		return nil, err
+	}
+	return newTicketResponse(ticket), nil
+}
+
+func (s *Server) issuePermit(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req PermitRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.LicensePlate == "" {
+		return nil, badRequest("licensePlate is required")
+	}
+
+	permit, err := s.parking.GetPermitService().Issue(&models.Permit{
+		LicensePlate: req.LicensePlate,
+		HolderName:   req.HolderName,
+		ValidFrom:    req.ValidFrom,
+		ValidUntil:   req.ValidUntil,
+		LotIDs:       req.LotIDs,
+		SpaceID:      req.SpaceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPermitResponse(permit, s.parking.GetClock().Now()), nil
+}
+
+func (s *Server) listWebhooks(_ *http.Request, _ map[string]string) (interface{}, error) {
+	webhooks := make([]WebhookResponse, 0)
+	for _, webhook := range s.parking.GetWebhookService().GetWebhooks() {
+		webhooks = append(webhooks, newWebhookResponse(webhook))
+	}
+	return webhooks, nil
+}
+
+func (s *Server) listDeadLetters(_ *http.Request, _ map[string]string) (interface{}, error) {
+	letters := make([]DeadLetterResponse, 0)
+	for _, letter := range s.parking.GetWebhookService().GetDeadLetters() {
+		letters = append(letters, newDeadLetterResponse(letter))
+	}
+	return letters, nil
+}
+
+// redeliverDeadLetter answers 502 with the endpoint's failure when it refuses again
+func (s *Server) redeliverDeadLetter(_ *http.Request, params map[string]string) (interface{}, error) {
+	letter, err := s.parking.GetWebhookService().Redeliver(params["deadLetterId"])
+	if err != nil {
+		return nil, err
+	}
+	return newDeadLetterResponse(letter), nil
+}
+
+func (s *Server) listPermits(_ *http.Request, _ map[string]string) (interface{}, error) {
+	permits := make([]PermitResponse, 0)
+	for _, permit := range s.parking.GetPermitService().GetPermits() {
+		permits = append(permits, newPermitResponse(permit, s.parking.GetClock().Now()))
+	}
+	return permits, nil
+}
+
+func (s *Server) getPermit(_ *http.Request, params map[string]string) (interface{}, error) {
+	permit, err := s.parking.GetPermitService().GetPermit(params["permitId"])
+	if err != nil {
+		return nil, err
+	}
+	return newPermitResponse(permit, s.parking.GetClock().Now()), nil
+}
+
+func (s *Server) revokePermit(_ *http.Request, params map[string]string) (interface{}, error) {
+	permit, err := s.parking.GetPermitService().Revoke(params["permitId"])
+	if err != nil {
+		return nil, err
+	}
+	return newPermitResponse(permit, s.parking.GetClock().Now()), nil
+}
+
+func (s *Server) renewPermit(r *http.Request, params map[string]string) (interface{}, error) {
+	var req RenewPermitRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	permit, err := s.parking.GetPermitService().Renew(params["permitId"], req.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+	return newPermitResponse(permit, s.parking.GetClock().Now()), nil
+}
+
+func (s *Server) joinWaitlist(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req ParkRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.LicensePlate == "" {
+		return nil, badRequest("licensePlate is required")
+	}
+
+	entry, err := s.parking.GetWaitlistService().Join(req.toCar())
+	if err != nil {
+		return nil, err
+	}
+	return s.newWaitlistEntryResponse(entry), nil
+}
+
+func (s *Server) listWaitlist(_ *http.Request, _ map[string]string) (interface{}, error) {
+	entries := make([]WaitlistEntryResponse, 0)
+	for _, entry := range s.parking.GetWaitlistService().GetQueue() {
+		entries = append(entries, s.newWaitlistEntryResponse(entry))
+	}
+	return entries, nil
+}
+
+func (s *Server) getWaitlistEntry(_ *http.Request, params map[string]string) (interface{}, error) {
+	entry, err := s.parking.GetWaitlistService().GetEntry(params["entryId"])
+	if err != nil {
+		return nil, err
+	}
+	return s.newWaitlistEntryResponse(entry), nil
+}
+
+func (s *Server) leaveWaitlist(_ *http.Request, params map[string]string) (interface{}, error) {
+	entry, err := s.parking.GetWaitlistService().Leave(params["entryId"])
+	if err != nil {
+		return nil, err
+	}
+	return s.newWaitlistEntryResponse(entry), nil
+}
+
+func (s *Server) acceptWaitlistOffer(_ *http.Request, params map[string]string) (interface{}, error) {
+	ticket, err := s.parking.GetWaitlistService().Accept(params["entryId"])
+	if err != nil {
+		return nil, err
+	}
+	return newTicketResponse(ticket), nil
+}
+
+// newWaitlistEntryResponse adds the entry's place in the queue and estimated wait
+func (s *Server) newWaitlistEntryResponse(entry *models.WaitlistEntry) WaitlistEntryResponse {
+	waitlist := s.parking.GetWaitlistService()
+	response := WaitlistEntryResponse{
+		ID:       entry.ID,
+		Car:      newCarResponse(entry.Car),
+		Priority: entry.Priority,
+		JoinedAt: entry.JoinedAt,
+		Status:   string(entry.Status),
+		LotID:    entry.LotID,
+		SpaceID:  entry.SpaceID,
+		TicketID: entry.TicketID,
+	}
+	if entry.Status == models.WaitlistOffered {
+		expires := entry.OfferExpiresAt
+		response.OfferExpiresAt = &expires
+	}
+	if position, err := waitlist.Position(entry.ID); err == nil {
+		response.Position = position
+	}
+	if wait, err := waitlist.EstimatedWait(entry.ID); err == nil {
+		response.EstimatedWaitSeconds = wait.Seconds()
+	}
+	return response
+}
+
+func (s *Server) utilization(_ *http.Request, _ map[string]string) (interface{}, error) {
+	utilizations := make([]UtilizationResponse, 0)
+	for _, util := range s.parking.GetLotUtilization() {
+		utilizations = append(utilizations, UtilizationResponse{
+			LotID:           util.LotID,
+			TotalSpaces:     util.TotalSpaces,
+			OccupiedSpaces:  util.OccupiedSpaces,
+			AvailableSpaces: util.AvailableSpaces,
+			ParkedVehicles:  util.ParkedVehicles,
+			UtilizationRate: util.UtilizationRate,
+		})
+	}
+	return utilizations, nil
+}
+
+func (s *Server) sampleOccupancy(_ *http.Request, _ map[string]string) (interface{}, error) {
+	return newOccupancySampleResponses(s.parking.GetOccupancyService().Sample()), nil
+}
+
+func (s *Server) occupancyHistory(r *http.Request, params map[string]string) (interface{}, error) {
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.parking.GetOccupancyService().GetSeries(params["lotId"], from, to)
+	if err != nil {
+		return nil, err
+	}
+	return newOccupancySampleResponses(series), nil
+}
+
+func (s *Server) occupancyProfile(_ *http.Request, params map[string]string) (interface{}, error) {
+	profile, err := s.parking.GetOccupancyService().GetProfile(params["lotId"])
+	if err != nil {
+		return nil, err
+	}
+	return newOccupancyProfileResponse(profile), nil
+}
+
+func (s *Server) occupancyForecast(r *http.Request, params map[string]string) (interface{}, error) {
+	horizon, err := forecastHorizon(r)
+	if err != nil {
+		return nil, err
+	}
+	forecast, err := s.parking.GetOccupancyService().Forecast(params["lotId"], horizon)
+	if err != nil {
+		return nil, err
+	}
+	return newOccupancyForecastResponse(forecast), nil
+}
+
+func (s *Server) occupancyForecasts(r *http.Request, _ map[string]string) (interface{}, error) {
+	horizon, err := forecastHorizon(r)
+	if err != nil {
+		return nil, err
+	}
+	forecasts, err := s.parking.GetOccupancyService().ForecastAll(horizon)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]OccupancyForecastResponse, 0, len(forecasts))
+	for _, forecast := range forecasts {
+		responses = append(responses, newOccupancyForecastResponse(forecast))
+	}
+	return responses, nil
+}
+
+// report covers the period containing the at query parameter, or now
+func (s *Server) report(r *http.Request, params map[string]string) (interface{}, error) {
+	at, err := queryTime(r, "at")
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = s.parking.GetClock().Now()
+	}
+	report, err := s.parking.GetReportService().Generate(params["period"], at)
+	if err != nil {
+		return nil, err
+	}
+	return newReportResponse(report), nil
+}
+
+// forecastHorizon reads the hours query parameter, 24 when absent
+func forecastHorizon(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("hours")
+	if value == "" {
+		return 24 * time.Hour, nil
+	}
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours < 1 {
+		return 0, badRequest("hours must be a positive integer")
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
+
+// queryTime reads an optional RFC 3339 query parameter
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, badRequest("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+func (s *Server) listAttendants(_ *http.Request, _ map[string]string) (interface{}, error) {
+	attendants := make([]AttendantResponse, 0)
+	for _, attendant := range s.parking.GetAttendants() {
+		attendants = append(attendants, newAttendantResponse(attendant))
+	}
+	return attendants, nil
+}
+
+func (s *Server) addAttendant(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req AttendantRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.ID == "" || req.Name == "" {
+		return nil, badRequest("id and name are required")
+	}
+	if s.parking.FindAttendantByID(req.ID) != nil {
+		return nil, &apiError{status: http.StatusConflict, message: "attendant already exists"}
+	}
+
+	attendant := models.NewParkingAttendant(req.ID, req.Name, req.LotID)
+	s.parking.AddAttendant(attendant)
+	return newAttendantResponse(attendant), nil
+}
+
+func (s *Server) listSecurityStaff(_ *http.Request, _ map[string]string) (interface{}, error) {
+	staff := make([]SecurityStaffResponse, 0)
+	for _, member := range s.parking.GetSecurityStaff() {
+		staff = append(staff, newSecurityStaffResponse(member))
+	}
+	return staff, nil
+}
+
+func (s *Server) addSecurityStaff(r *http.Request, _ map[string]string) (interface{}, error) {
+	var req SecurityStaffRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.ID == "" || req.Name == "" {
+		return nil, badRequest("id and name are required")
+	}
+	if s.parking.FindSecurityStaffByID(req.ID) != nil {
+		return nil, &apiError{status: http.StatusConflict, message: "security staff already exists"}
+	}
+
+	staff := models.NewSecurityStaff(req.ID, req.Name, req.Position)
+	s.parking.AddSecurityStaff(staff)
+	return newSecurityStaffResponse(staff), nil
+}
+
+func (s *Server) assignSecurity(r *http.Request, params map[string]string) (interface{}, error) {
+	var req AssignSecurityRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	if err := s.parking.AssignSecurityToLot(params["staffId"], req.LotID); err != nil {
+		return nil, err
+	}
+	return newSecurityStaffResponse(s.parking.FindSecurityStaffByID(params["staffId"])), nil
+}
+
+func (s *Server) policeSearch(r *http.Request, _ map[string]string) (interface{}, error) {
+	query := r.URL.Query()
+	vehicles, err := s.police.FindCarsByColorAndMake(query.Get("color"), query.Get("make"))
+	if err != nil {
+		return nil, err
+	}
+	return newVehicleList(vehicles), nil
+}
+
+func (s *Server) policeRecent(r *http.Request, _ map[string]string) (interface{}, error) {
+	minutes, err := strconv.Atoi(r.URL.Query().Get("minutes"))
+	if err != nil || minutes < 0 {
+		return nil, badRequest("minutes must be a non-negative integer")
+	}
+
+	vehicles, err := s.police.FindCarsParkedInLastMinutes(minutes)
+	if err != nil {
+		return nil, err
+	}
+	return newVehicleList(vehicles), nil
+}
+
+func newVehicleList(vehicles []*services.VehicleInvestigationInfo) []VehicleInvestigationResponse {
+	list := make([]VehicleInvestigationResponse, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		list = append(list, newVehicleInvestigationResponse(vehicle))
+	}
+	return list
+}
+
+func (s *Server) openAPI(_ *http.Request, _ map[string]string) (interface{}, error) {
+	return s.OpenAPIDocument(), nil
+}
@@ -0,0 +1,695 @@
+package api
+
+import (
+	"encoding/json"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"strings"
+	"time"
+)
+
+// Request and response bodies for the HTTP API. These replace the
+// map[string]interface{} values returned by the service layer.
+
+type ParkRequest struct {
+	LicensePlate string `json:"licensePlate"`
+	DriverName   string `json:"driverName"`
+	Color        string `json:"color,omitempty"`
+	Make         string `json:"make,omitempty"`
+	Size         string `json:"size,omitempty" doc:"motorcycle, small, medium or large; defaults to medium"`
+	IsHandicap   bool   `json:"isHandicap,omitempty"`
+	SpacesNeeded int    `json:"spacesNeeded,omitempty" doc:"contiguous spaces the vehicle occupies, e.g. 3 for a bus; defaults to 1"`
+}
+
+type UnparkRequest struct {
+	LicensePlate string `json:"licensePlate"`
+	LostTicket   bool   `json:"lostTicket,omitempty" doc:"the driver cannot present a ticket; bills the lost-ticket penalty"`
+}
+
+type TicketResponse struct {
+	ID           string    `json:"id"`
+	LicensePlate string    `json:"licensePlate"`
+	LotID        string    `json:"lotId"`
+	SpaceID      string    `json:"spaceId"`
+	ParkedAt     time.Time `json:"parkedAt"`
+	IsActive     bool      `json:"isActive"`
+	AttendantID  string    `json:"attendantId,omitempty"`
+	PermitID     string    `json:"permitId,omitempty" doc:"permit covering the visit"`
+	VehicleSize  string    `json:"vehicleSize,omitempty"`
+	IsHandicap   bool      `json:"isHandicap,omitempty"`
+}
+
+type TicketVerificationResponse struct {
+	TicketID string `json:"ticketId"`
+	Valid    bool   `json:"valid"`
+	Reason   string `json:"reason,omitempty" doc:"why a gate would refuse the ticket"`
+}
+
+type BillResponse struct {
+	TicketID        string               `json:"ticketId"`
+	LicensePlate    string               `json:"licensePlate"`
+	ParkedAt        time.Time            `json:"parkedAt"`
+	UnparkedAt      time.Time            `json:"unparkedAt"`
+	DurationSeconds float64              `json:"durationSeconds"`
+	HourlyRate      float64              `json:"hourlyRate"`
+	MinimumCharge   float64              `json:"minimumCharge"`
+	TotalAmount     float64              `json:"totalAmount"`
+	Tariff          string               `json:"tariff"`
+	LineItems       []LineItemResponse   `json:"lineItems"`
+	BaseAmount      float64              `json:"baseAmount" doc:"tariff price before vehicle adjustments"`
+	Adjustments     []AdjustmentResponse `json:"adjustments,omitempty"`
+	Penalties       []AdjustmentResponse `json:"penalties,omitempty"`
+	LostTicket      bool                 `json:"lostTicket"`
+	OverstaySeconds float64              `json:"overstaySeconds" doc:"time beyond the lot's maximum stay"`
+
+	SettlementOverride string `json:"settlementOverride,omitempty" doc:"attendant who let the car leave before the bill was paid"`
+	PermitID           string `json:"permitId,omitempty" doc:"permit that covered all or the start of the stay"`
+}
+
+type ExitBillRequest struct {
+	LostTicket bool `json:"lostTicket,omitempty" doc:"bill the lost-ticket penalty instead of the stay"`
+}
+
+type OverrideRequest struct {
+	AttendantID string `json:"attendantId"`
+}
+
+type PaymentRequest struct {
+	Method string  `json:"method" doc:"cash, card or wallet"`
+	Amount float64 `json:"amount" doc:"cash beyond the balance due is returned as change"`
+}
+
+type RefundRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+type PaymentResponse struct {
+	ID          string           `json:"id"`
+	TicketID    string           `json:"ticketId"`
+	Method      string           `json:"method"`
+	Amount      float64          `json:"amount" doc:"applied to the bill"`
+	Change      float64          `json:"change,omitempty"`
+	Provider    string           `json:"provider"`
+	ProviderRef string           `json:"providerRef"`
+	PaidAt      time.Time        `json:"paidAt"`
+	Refunds     []RefundResponse `json:"refunds,omitempty"`
+	BalanceDue  float64          `json:"balanceDue" doc:"left to pay on the bill"`
+}
+
+type RefundResponse struct {
+	Amount     float64   `json:"amount"`
+	RefundedAt time.Time `json:"refundedAt"`
+}
+
+type ReceiptResponse struct {
+	Bill       BillResponse      `json:"bill"`
+	Payments   []PaymentResponse `json:"payments"`
+	AmountPaid float64           `json:"amountPaid"`
+	BalanceDue float64           `json:"balanceDue"`
+	IssuedAt   time.Time         `json:"issuedAt"`
+}
+
+type AdjustmentResponse struct {
+	Reason string  `json:"reason"`
+	Amount float64 `json:"amount" doc:"negative for discounts"`
+}
+
+type LineItemResponse struct {
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Amount      float64   `json:"amount"`
+}
+
+type FeeEstimateResponse struct {
+	LicensePlate    string  `json:"licensePlate"`
+	TicketID        string  `json:"ticketId"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	CurrentFee      float64 `json:"currentFee"`
+}
+
+type CarResponse struct {
+	LicensePlate string `json:"licensePlate"`
+	DriverName   string `json:"driverName"`
+	Color        string `json:"color"`
+	Make         string `json:"make"`
+	Size         string `json:"size"`
+	IsHandicap   bool   `json:"isHandicap"`
+}
+
+type CarLocationResponse struct {
+	Car         CarResponse `json:"car"`
+	LotID       string      `json:"lotId"`
+	SpaceID     string      `json:"spaceId"`
+	Level       string      `json:"level,omitempty"`
+	Row         string      `json:"row"`
+	Position    int         `json:"position"`
+	ParkedAt    time.Time   `json:"parkedAt"`
+	AttendantID string      `json:"attendantId,omitempty"`
+}
+
+type DirectionsResponse struct {
+	LicensePlate string `json:"licensePlate"`
+	Directions   string `json:"directions"`
+}
+
+type LotRequest struct {
+	ID         string            `json:"id"`
+	Capacity   int               `json:"capacity,omitempty" doc:"number of spaces; may be omitted when spaceTypes is given"`
+	SpaceTypes []string          `json:"spaceTypes,omitempty" doc:"type of each space in order: motorcycle, compact, regular or large; all large when omitted"`
+	Layout     *models.LotLayout `json:"layout,omitempty" doc:"levels, rows and spaces of the lot; replaces capacity and spaceTypes"`
+}
+
+type LotStatusResponse struct {
+	ID              string    `json:"id"`
+	Capacity        int       `json:"capacity"`
+	OccupiedSpaces  int       `json:"occupiedSpaces"`
+	AvailableSpaces int       `json:"availableSpaces"`
+	ParkedVehicles  int       `json:"parkedVehicles"`
+	IsFull          bool      `json:"isFull"`
+	UtilizationRate float64   `json:"utilizationRate"`
+	HeldSpaces      int       `json:"heldSpaces" doc:"free spaces kept for reservations"`
+	RaisedAlerts    []float64 `json:"raisedAlerts,omitempty" doc:"occupancy alert thresholds the lot is past, as shares from 0 to 1"`
+}
+
+type OccupancySampleResponse struct {
+	LotID          string    `json:"lotId"`
+	At             time.Time `json:"at"`
+	OccupiedSpaces int       `json:"occupiedSpaces"`
+	TotalSpaces    int       `json:"totalSpaces"`
+	Occupancy      float64   `json:"occupancy" doc:"share of spaces in use, from 0 to 1"`
+}
+
+type OccupancyBucketResponse struct {
+	Hour             *int    `json:"hour,omitempty" doc:"hour of the day, 0 to 23, for hourly buckets"`
+	Weekday          string  `json:"weekday,omitempty" doc:"day of the week, for daily buckets"`
+	Samples          int     `json:"samples"`
+	AverageOccupancy float64 `json:"averageOccupancy"`
+	PeakOccupancy    float64 `json:"peakOccupancy"`
+}
+
+type OccupancyProfileResponse struct {
+	LotID     string                    `json:"lotId"`
+	Samples   int                       `json:"samples"`
+	From      time.Time                 `json:"from"`
+	To        time.Time                 `json:"to"`
+	ByHour    []OccupancyBucketResponse `json:"byHour"`
+	ByWeekday []OccupancyBucketResponse `json:"byWeekday" doc:"Sunday first"`
+}
+
+type OccupancyForecastPointResponse struct {
+	At        time.Time `json:"at"`
+	Occupancy float64   `json:"occupancy"`
+}
+
+type OccupancyForecastResponse struct {
+	LotID            string                           `json:"lotId"`
+	At               time.Time                        `json:"at"`
+	CurrentOccupancy float64                          `json:"currentOccupancy"`
+	Method           string                           `json:"method" doc:"seasonal when the lot has history for the hour, otherwise trend"`
+	FullAt           *time.Time                       `json:"fullAt,omitempty" doc:"when the lot is expected to fill; absent if not within the horizon"`
+	FullOccupancy    float64                          `json:"fullOccupancy" doc:"occupancy counted as full"`
+	Points           []OccupancyForecastPointResponse `json:"points"`
+}
+
+type LotReportResponse struct {
+	LotID                  string         `json:"lotId,omitempty"`
+	Tickets                int            `json:"tickets" doc:"stays that ended in the period"`
+	Revenue                float64        `json:"revenue" doc:"what those stays were billed"`
+	AverageDurationSeconds float64        `json:"averageDurationSeconds"`
+	TicketsBySize          map[string]int `json:"ticketsBySize" doc:"tickets by vehicle size: Motorcycle, Small, Medium or Large"`
+	HandicapTickets        int            `json:"handicapTickets"`
+	PeakOccupancy          float64        `json:"peakOccupancy" doc:"highest sampled share of spaces in use, from 0 to 1"`
+	PeakAt                 *time.Time     `json:"peakAt,omitempty" doc:"absent when no occupancy was sampled in the period"`
+}
+
+type ReportResponse struct {
+	Period      string              `json:"period"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Lots        []LotReportResponse `json:"lots"`
+	Total       LotReportResponse   `json:"total" doc:"every lot together"`
+}
+
+type UtilizationResponse struct {
+	LotID           string  `json:"lotId"`
+	TotalSpaces     int     `json:"totalSpaces"`
+	OccupiedSpaces  int     `json:"occupiedSpaces"`
+	AvailableSpaces int     `json:"availableSpaces"`
+	ParkedVehicles  int     `json:"parkedVehicles"`
+	UtilizationRate float64 `json:"utilizationRate"`
+}
+
+type AttendantRequest struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	LotID string `json:"lotId"`
+}
+
+type AttendantResponse struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	LotID    string `json:"lotId"`
+	IsActive bool   `json:"isActive"`
+}
+
+type ParkWithAttendantRequest struct {
+	ParkRequest
+	Strategy string `json:"strategy,omitempty" doc:"even, handicap, large, smart or default for the configured default strategy; defaults to the attendant's first-free logic"`
+}
+
+type ParkingDecisionResponse struct {
+	AttendantID string `json:"attendantId"`
+	LotID       string `json:"lotId"`
+	SpaceID     string `json:"spaceId"`
+	Reason      string `json:"reason"`
+}
+
+type SecurityStaffRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Position string `json:"position"`
+}
+
+type SecurityStaffResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Position    string `json:"position"`
+	IsActive    bool   `json:"isActive"`
+	AssignedLot string `json:"assignedLot,omitempty"`
+}
+
+type AssignSecurityRequest struct {
+	LotID string `json:"lotId"`
+}
+
+type VehicleInvestigationResponse struct {
+	Car           CarResponse `json:"car"`
+	LotID         string      `json:"lotId"`
+	SpaceID       string      `json:"spaceId"`
+	ParkedAt      time.Time   `json:"parkedAt"`
+	AttendantID   string      `json:"attendantId,omitempty"`
+	AttendantName string      `json:"attendantName,omitempty"`
+}
+
+type ReservationRequest struct {
+	ParkRequest
+	LotID string    `json:"lotId"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type ReservationResponse struct {
+	ID        string      `json:"id"`
+	Car       CarResponse `json:"car"`
+	LotID     string      `json:"lotId"`
+	Start     time.Time   `json:"start"`
+	End       time.Time   `json:"end"`
+	Spaces    int         `json:"spaces"`
+	Status    string      `json:"status" doc:"pending, arrived, expired or cancelled"`
+	TicketID  string      `json:"ticketId,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+type PermitRequest struct {
+	LicensePlate string    `json:"licensePlate"`
+	HolderName   string    `json:"holderName"`
+	ValidFrom    time.Time `json:"validFrom"`
+	ValidUntil   time.Time `json:"validUntil"`
+	LotIDs       []string  `json:"lotIds,omitempty" doc:"lots the permit is valid in; every lot when empty"`
+	SpaceID      int       `json:"spaceId,omitempty" doc:"space kept for the holder in the permit's only lot"`
+}
+
+type RenewPermitRequest struct {
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+type PermitResponse struct {
+	ID           string    `json:"id"`
+	LicensePlate string    `json:"licensePlate"`
+	HolderName   string    `json:"holderName"`
+	ValidFrom    time.Time `json:"validFrom"`
+	ValidUntil   time.Time `json:"validUntil"`
+	LotIDs       []string  `json:"lotIds,omitempty"`
+	SpaceID      int       `json:"spaceId,omitempty"`
+	RevokedAt    time.Time `json:"revokedAt,omitempty"`
+	IsValid      bool      `json:"isValid" doc:"the permit covers stays now"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type WebhookResponse struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	LotIDs []string `json:"lotIds,omitempty" doc:"every lot when empty"`
+	Events []string `json:"events,omitempty" doc:"event types sent; every type when empty"`
+	Signed bool     `json:"signed" doc:"deliveries carry an X-Parking-Signature header"`
+}
+
+type DeadLetterResponse struct {
+	ID          string          `json:"id"`
+	WebhookID   string          `json:"webhookId"`
+	URL         string          `json:"url"`
+	EventType   string          `json:"eventType"`
+	LotID       string          `json:"lotId,omitempty"`
+	Body        json.RawMessage `json:"body" doc:"the event as posted"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError"`
+	FailedAt    time.Time       `json:"failedAt"`
+	DeliveredAt *time.Time      `json:"deliveredAt,omitempty" doc:"set once a redelivery succeeded"`
+}
+
+type WaitlistEntryResponse struct {
+	ID                   string      `json:"id"`
+	Car                  CarResponse `json:"car"`
+	Priority             bool        `json:"priority" doc:"handicap drivers and permit holders are served first"`
+	JoinedAt             time.Time   `json:"joinedAt"`
+	Status               string      `json:"status" doc:"waiting, offered, parked, expired or left"`
+	Position             int         `json:"position,omitempty" doc:"place among the cars still waiting for an offer"`
+	EstimatedWaitSeconds float64     `json:"estimatedWaitSeconds" doc:"expected wait for an offer"`
+	LotID                string      `json:"lotId,omitempty" doc:"lot of the offered space"`
+	SpaceID              int         `json:"spaceId,omitempty" doc:"offered space"`
+	OfferExpiresAt       *time.Time  `json:"offerExpiresAt,omitempty"`
+	TicketID             string      `json:"ticketId,omitempty"`
+}
+
+type ErrorResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func (r *ParkRequest) toCar() *models.Car {
+	car := models.NewCar(r.LicensePlate, r.DriverName)
+	if r.Color != "" {
+		car.SetColor(r.Color)
+	}
+	if r.Make != "" {
+		car.SetMake(r.Make)
+	}
+	car.SetVehicleSize(parseVehicleSize(r.Size))
+	car.SetHandicapStatus(r.IsHandicap)
+	if r.SpacesNeeded > 0 {
+		car.SetSpacesNeeded(r.SpacesNeeded)
+	}
+	return car
+}
+
+func parseVehicleSize(size string) models.VehicleSize {
+	switch strings.ToLower(size) {
+	case "small":
+		return models.SmallVehicle
+	case "large":
+		return models.LargeVehicle
+	case "motorcycle":
+		return models.MotorcycleVehicle
+	default:
+		return models.MediumVehicle
+	}
+}
+
+func newTicketResponse(ticket *models.ParkingTicket) TicketResponse {
+	return TicketResponse{
+		ID:           ticket.ID,
+		LicensePlate: ticket.LicensePlate,
+		LotID:        ticket.LotID,
+		SpaceID:      ticket.SpaceID,
+		ParkedAt:     ticket.ParkedAt,
+		IsActive:     ticket.IsActive,
+		AttendantID:  ticket.AttendantID,
+		PermitID:     ticket.PermitID,
+		VehicleSize:  ticket.VehicleSize,
+		IsHandicap:   ticket.IsHandicap,
+	}
+}
+
+func newBillResponse(bill *services.Bill) BillResponse {
+	items := make([]LineItemResponse, len(bill.LineItems))
+	for i, item := range bill.LineItems {
+		items[i] = LineItemResponse{Description: item.Description, Start: item.Start, End: item.End, Amount: item.Amount}
+	}
+	adjustments := newAdjustmentResponses(bill.Adjustments)
+	penalties := newAdjustmentResponses(bill.Penalties)
+
+	return BillResponse{
+		TicketID:        bill.TicketID,
+		LicensePlate:    bill.LicensePlate,
+		ParkedAt:        bill.ParkedAt,
+		UnparkedAt:      bill.UnparkedAt,
+		DurationSeconds: bill.Duration.Seconds(),
+		HourlyRate:      bill.HourlyRate,
+		MinimumCharge:   bill.MinimumCharge,
+		TotalAmount:     bill.TotalAmount,
+		Tariff:          bill.Tariff,
+		LineItems:       items,
+		BaseAmount:      bill.BaseAmount,
+		Adjustments:     adjustments,
+		Penalties:       penalties,
+		LostTicket:      bill.LostTicket,
+		OverstaySeconds: bill.Overstay.Seconds(),
+
+		SettlementOverride: bill.SettlementOverride,
+		PermitID:           bill.PermitID,
+	}
+}
+
+func newPaymentResponse(payment *services.Payment, balanceDue float64) PaymentResponse {
+	response := PaymentResponse{
+		ID:          payment.ID,
+		TicketID:    payment.TicketID,
+		Method:      string(payment.Method),
+		Amount:      payment.Amount,
+		Change:      payment.Change,
+		Provider:    payment.Provider,
+		ProviderRef: payment.ProviderRef,
+		PaidAt:      payment.PaidAt,
+		BalanceDue:  balanceDue,
+	}
+	for _, refund := range payment.Refunds {
+		response.Refunds = append(response.Refunds, RefundResponse{Amount: refund.Amount, RefundedAt: refund.RefundedAt})
+	}
+	return response
+}
+
+func newReceiptResponse(receipt *services.Receipt) ReceiptResponse {
+	payments := make([]PaymentResponse, 0, len(receipt.Payments))
+	for _, payment := range receipt.Payments {
+		payments = append(payments, newPaymentResponse(payment, receipt.BalanceDue))
+	}
+	return ReceiptResponse{
+		Bill:       newBillResponse(receipt.Bill),
+		Payments:   payments,
+		AmountPaid: receipt.AmountPaid,
+		BalanceDue: receipt.BalanceDue,
+		IssuedAt:   receipt.IssuedAt,
+	}
+}
+
+func newAdjustmentResponses(adjustments []services.Adjustment) []AdjustmentResponse {
+	var responses []AdjustmentResponse
+	for _, adjustment := range adjustments {
+		responses = append(responses, AdjustmentResponse{Reason: adjustment.Reason, Amount: adjustment.Amount})
+	}
+	return responses
+}
+
+func newCarResponse(car *models.Car) CarResponse {
+	return CarResponse{
+		LicensePlate: car.LicensePlate,
+		DriverName:   car.DriverName,
+		Color:        car.Color,
+		Make:         car.Make,
+		Size:         car.GetVehicleSizeString(),
+		IsHandicap:   car.IsHandicap,
+	}
+}
+
+func newOccupancySampleResponses(samples []models.OccupancySample) []OccupancySampleResponse {
+	responses := make([]OccupancySampleResponse, 0, len(samples))
+	for _, sample := range samples {
+		responses = append(responses, OccupancySampleResponse{
+			LotID:          sample.LotID,
+			At:             sample.At,
+			OccupiedSpaces: sample.OccupiedSpaces,
+			TotalSpaces:    sample.TotalSpaces,
+			Occupancy:      sample.Occupancy(),
+		})
+	}
+	return responses
+}
+
+func newOccupancyProfileResponse(profile *models.OccupancyProfile) OccupancyProfileResponse {
+	response := OccupancyProfileResponse{
+		LotID:     profile.LotID,
+		Samples:   profile.Samples,
+		From:      profile.From,
+		To:        profile.To,
+		ByHour:    make([]OccupancyBucketResponse, 0, len(profile.ByHour)),
+		ByWeekday: make([]OccupancyBucketResponse, 0, len(profile.ByWeekday)),
+	}
+	for hour, bucket := range profile.ByHour {
+		hour := hour
+		response.ByHour = append(response.ByHour, OccupancyBucketResponse{
+			Hour: &hour, Samples: bucket.Samples, AverageOccupancy: bucket.AverageOccupancy, PeakOccupancy: bucket.PeakOccupancy,
+		})
+	}
+	for day, bucket := range profile.ByWeekday {
+		response.ByWeekday = append(response.ByWeekday, OccupancyBucketResponse{
+			Weekday: time.Weekday(day).String(), Samples: bucket.Samples, AverageOccupancy: bucket.AverageOccupancy, PeakOccupancy: bucket.PeakOccupancy,
+		})
+	}
+	return response
+}
+
+func newOccupancyForecastResponse(forecast *models.OccupancyForecast) OccupancyForecastResponse {
+	response := OccupancyForecastResponse{
+		LotID:            forecast.LotID,
+		At:               forecast.Current.At,
+		CurrentOccupancy: forecast.Current.Occupancy(),
+		Method:           forecast.Method,
+		FullAt:           forecast.FullAt,
+		FullOccupancy:    forecast.Threshold,
+		Points:           make([]OccupancyForecastPointResponse, 0, len(forecast.Points)),
+	}
+	for _, point := range forecast.Points {
+		response.Points = append(response.Points, OccupancyForecastPointResponse{At: point.At, Occupancy: point.Occupancy})
+	}
+	return response
+}
+
+func newLotReportResponse(report *services.LotReport) LotReportResponse {
+	response := LotReportResponse{
+		LotID:                  report.LotID,
+		Tickets:                report.Tickets,
+		Revenue:                report.Revenue,
+		AverageDurationSeconds: report.AverageDuration.Seconds(),
+		TicketsBySize:          report.TicketsBySize,
+		HandicapTickets:        report.HandicapTickets,
+		PeakOccupancy:          report.PeakOccupancy,
+	}
+	if !report.PeakAt.IsZero() {
+		peakAt := report.PeakAt
+		response.PeakAt = &peakAt
+	}
+	return response
+}
+
+func newReportResponse(report *services.Report) ReportResponse {
+	response := ReportResponse{
+		Period:      report.Period,
+		From:        report.From,
+		To:          report.To,
+		GeneratedAt: report.GeneratedAt,
+		Lots:        make([]LotReportResponse, 0, len(report.Lots)),
+		Total:       newLotReportResponse(report.Total),
+	}
+	for _, lot := range report.Lots {
+		response.Lots = append(response.Lots, newLotReportResponse(lot))
+	}
+	return response
+}
+
+func newLotStatusResponse(lot *models.ParkingLot) LotStatusResponse {
+	util := models.CalculateLotUtilization(lot)
+	status := LotStatusResponse{
+		ID:              lot.ID,
+		Capacity:        util.TotalSpaces,
+		OccupiedSpaces:  util.OccupiedSpaces,
+		AvailableSpaces: util.AvailableSpaces,
+		ParkedVehicles:  util.ParkedVehicles,
+		IsFull:          util.AvailableSpaces == 0,
+		UtilizationRate: util.UtilizationRate,
+		HeldSpaces:      lot.GetHeldSpaces(),
+	}
+	for _, threshold := range lot.GetRaisedThresholds() {
+		status.RaisedAlerts = append(status.RaisedAlerts, threshold.Occupancy)
+	}
+	return status
+}
+
+func newWebhookResponse(webhook *models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:     webhook.ID,
+		URL:    webhook.URL,
+		LotIDs: webhook.LotIDs,
+		Events: webhook.Events,
+		Signed: webhook.Secret != "",
+	}
+}
+
+func newDeadLetterResponse(letter *models.DeadLetter) DeadLetterResponse {
+	res := DeadLetterResponse{
+		ID:        letter.ID,
+		WebhookID: letter.WebhookID,
+		URL:       letter.URL,
+		EventType: letter.EventType,
+		LotID:     letter.LotID,
+		Body:      letter.Body,
+		Attempts:  letter.Attempts,
+		LastError: letter.LastError,
+		FailedAt:  letter.FailedAt,
+	}
+	if !letter.IsPending() {
+		delivered := letter.DeliveredAt
+		res.DeliveredAt = &delivered
+	}
+	return res
+}
+
+func newPermitResponse(permit *models.Permit, now time.Time) PermitResponse {
+	return PermitResponse{
+		ID:           permit.ID,
+		LicensePlate: permit.LicensePlate,
+		HolderName:   permit.HolderName,
+		ValidFrom:    permit.ValidFrom,
+		ValidUntil:   permit.ValidUntil,
+		LotIDs:       permit.LotIDs,
+		SpaceID:      permit.SpaceID,
+		RevokedAt:    permit.RevokedAt,
+		IsValid:      permit.IsValidAt(now),
+		CreatedAt:    permit.CreatedAt,
+	}
+}
+
+func newReservationResponse(reservation *models.Reservation) ReservationResponse {
+	return ReservationResponse{
+		ID:        reservation.ID,
+		Car:       newCarResponse(reservation.Car),
+		LotID:     reservation.LotID,
+		Start:     reservation.Start,
+		End:       reservation.End,
+		Spaces:    reservation.Spaces,
+		Status:    string(reservation.Status),
+		TicketID:  reservation.TicketID,
+		CreatedAt: reservation.CreatedAt,
+	}
+}
+
+func newAttendantResponse(attendant *models.ParkingAttendant) AttendantResponse {
+	return AttendantResponse{
+		ID:       attendant.ID,
+		Name:     attendant.Name,
+		LotID:    attendant.LotID,
+		IsActive: attendant.IsActive,
+	}
+}
+
+func newSecurityStaffResponse(staff *models.SecurityStaff) SecurityStaffResponse {
+	return SecurityStaffResponse{
+		ID:          staff.ID,
+		Name:        staff.Name,
+		Position:    staff.Position,
+		IsActive:    staff.IsActive,
+		AssignedLot: staff.AssignedLot,
+	}
+}
+
+func newVehicleInvestigationResponse(info *services.VehicleInvestigationInfo) VehicleInvestigationResponse {
+	return VehicleInvestigationResponse{
+		Car:           newCarResponse(info.Car),
+		LotID:         info.LotID,
+		SpaceID:       info.SpaceID,
+		ParkedAt:      info.ParkedAt,
+		AttendantID:   info.AttendantID,
+		AttendantName: info.AttendantName,
+	}
+}
@@ -0,0 +1,1507 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"parking-lot-system/api"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/simulation"
+	"parking-lot-system/storage"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: parking [--state DIR | --server URL] [--config FILE] [--output table|json|csv] <command> [args]
+
+Commands:
+  lot create <lot-id> <capacity>     Create a parking lot of untyped spaces
+  lot create <lot-id> --types T      Create a lot from space types, e.g. compact:10,regular:20,large:4
+  lot create <lot-id> --layout FILE  Create a lot from a JSON layout of levels, rows and spaces
+  park <plate> [flags]               Park a car and issue a ticket
+                                     (--driver, --color, --make, --size motorcycle|small|medium|large,
+                                      --handicap, --spaces N for vehicles taking N contiguous spaces)
+  unpark <plate> [--lost-ticket]     Unpark a car and print its bill
+  pay <plate> [flags]                Pay the exit bill before unparking
+                                     (--method cash|card|wallet, --amount N for part payments,
+                                      --lost-ticket to pay the lost-ticket penalty)
+  refund <payment-id> <amount>       Refund all or part of a payment
+  receipt <ticket-id>                Show a ticket's bill and payments
+  override <plate> <attendant-id>    Let a car leave before its bill is paid
+  reserve <plate> <lot-id> --from T --to T [park flags]
+                                     Reserve space for a future window
+                                     (times as RFC 3339 or "2006-01-02 15:04" local time)
+  reservation list [--lot L]         List reservations
+  reservation cancel <id>            Cancel a pending reservation
+  arrive <reservation-id>            Park a reserved car and issue its ticket
+  waitlist join <plate> [park flags] Queue a car turned away by full lots
+  waitlist list                      Queued cars with their estimated wait
+  waitlist accept <entry-id>         Park in the space offered to a queued car
+  waitlist leave <entry-id>          Take a car off the waitlist
+  permit issue <plate> [flags]       Issue a permit; its visits are not billed hourly
+                                     (--holder, --from T, --until T or --months N (default 1),
+                                      --lots L1,L2, --space N for a dedicated space)
+  permit list                        List permits
+  permit revoke <permit-id>          End a permit now
+  permit renew <permit-id> --until T Move the end of a permit
+  webhook list                       Webhooks from the --config file that lot events are posted to
+  webhook dead-letters               Deliveries that failed every retry
+  webhook redeliver <id>             Post a failed delivery again
+  webhook receive [--addr :9090] [--secret S] [--fail N]
+                                     Run a local endpoint that prints the deliveries it gets
+                                     (--fail N answers the first N with 503 to exercise retries)
+  find <plate>                       Locate a parked car
+  ticket show <ticket-id>            Show a ticket
+  ticket verify <ticket-id>          Check a ticket ID as a gate scanner would (exit status 1 if refused)
+  bill <plate>                       Fee accrued so far by a parked car
+  police search [--color C] [--make M]
+                                     Search parked vehicles
+  report                             Occupancy of every lot
+  report daily|weekly|monthly [--date D]
+                                     Revenue, stays by vehicle size, handicap use and peak occupancy
+                                     per lot (D is any day in the period; defaults to today)
+  occupancy sample                   Record every lot's occupancy now in its history
+  occupancy history <lot-id> [--from T] [--to T]
+                                     Occupancy samples of a lot
+  occupancy profile <lot-id>         Average and peak occupancy by hour of day and day of week
+  occupancy forecast [lot-id] [--hours 24]
+                                     Predicted occupancy and when each lot will fill
+  repl                               Interactive prompt for gate staff
+  serve [--addr :8080]               Serve the HTTP API from the state directory
+                                     (SIGHUP reloads the --config file;
+                                      --sample-interval 5m sets how often occupancy is recorded;
+                                      --report-dir DIR writes daily, weekly and monthly reports there
+                                      as each period closes, in --report-formats csv,json,text)
+  config check <file>                Validate a configuration file
+  config reload                      Re-apply the --config file to the running state
+  simulate [config-file] [flags]     Replay synthetic traffic through the configured lots on a virtual
+                                     clock and compare strategies side by side
+                                     (--strategies even,smart, --hours N, --arrivals poisson:RATE,
+                                      --dwell exp:2h|uniform:30m-3h|fixed:1h|empirical:30m,2h,
+                                      --mix medium:70,large:20,handicap:10, --patience 10m, --seed N)
+`
+
+// errUsage marks command-line mistakes, which exit with status 2 and print usage
+var errUsage = errors.New("usage")
+
+// session is the state shared by the commands of one invocation or REPL
+type session struct {
+	client *Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	parking    *services.ParkingService // nil when talking to a remote server
+	stateDir   string
+	configPath string // Applied to the state on open and on reload
+}
+
+// Run executes the command line in args and returns the process exit status
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("parking", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	stateDir := global.String("state", envOr("PARKING_STATE", "parking-state"), "directory holding the persisted state")
+	serverURL := global.String("server", os.Getenv("PARKING_SERVER"), "URL of a running parking server")
+	output := global.String("output", OutputTable, "output format: table, json or csv")
+	configPath := global.String("config", os.Getenv("PARKING_CONFIG"), "YAML or JSON file describing lots, rates and staff")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if !validOutput(*output) {
+		fmt.Fprintf(stderr, "error: unknown output format %q\n", *output)
+		return 2
+	}
+	if global.NArg() == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	s := &session{output: *output, stdin: stdin, stdout: stdout, stderr: stderr, stateDir: *stateDir, configPath: *configPath}
+	if *serverURL != "" && *configPath != "" {
+		fmt.Fprintln(stderr, "error: --config applies to a state directory, not a remote server")
+		return 2
+	}
+	if *serverURL != "" {
+		s.client = NewRemoteClient(*serverURL)
+	} else {
+		closeState, err := s.openState()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		defer func() {
+			if err := closeState(); err != nil {
+				fmt.Fprintf(stderr, "error: %v\n", err)
+			}
+		}()
+	}
+
+	return s.exitStatus(s.execute(global.Args()))
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// openState restores the service from the state directory and wires a local client to it
+func (s *session) openState() (func() error, error) {
+	store, err := storage.NewFileStore(s.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	parking, err := services.RestoreParkingService(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	s.parking = parking
+	s.client = newLocalClient(newAPIServer(parking))
+	if s.configPath != "" {
+		if _, err := s.reloadConfig(); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return func() error {
+		// Finish webhook deliveries first so failures are dead-lettered in the snapshot
+		parking.GetWebhookService().Close()
+		// Compact the log so the state directory does not grow with every invocation
+		if err := parking.SaveSnapshot(); err != nil {
+			store.Close()
+			return err
+		}
+		return store.Close()
+	}, nil
+}
+
+func newAPIServer(parking *services.ParkingService) *api.Server {
+	return api.NewServer(parking, nil, services.NewPoliceService(parking))
+}
+
+func (s *session) exitStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(s.stderr, "error: %v\n", err)
+		fmt.Fprint(s.stderr, usage)
+		return 2
+	default:
+		fmt.Fprintf(s.stderr, "error: %v\n", err)
+		return 1
+	}
+}
+
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+func (s *session) execute(args []string) error {
+	if len(args) == 0 {
+		return usageError("missing command")
+	}
+
+	command, rest := args[0], args[1:]
+	var res *result
+	var err error
+	switch command {
+	case "lot":
+		res, err = s.lotCommand(rest)
+	case "park":
+		res, err = s.parkCommand(rest)
+	case "unpark":
+		res, err = s.unparkCommand(rest)
+	case "pay":
+		res, err = s.payCommand(rest)
+	case "refund":
+		res, err = s.refundCommand(rest)
+	case "receipt":
+		res, err = s.receiptCommand(rest)
+	case "override":
+		res, err = s.overrideCommand(rest)
+	case "reserve":
+		res, err = s.reserveCommand(rest)
+	case "reservation":
+		res, err = s.reservationCommand(rest)
+	case "arrive":
+		res, err = s.arriveCommand(rest)
+	case "waitlist":
+		res, err = s.waitlistCommand(rest)
+	case "permit":
+		res, err = s.permitCommand(rest)
+	case "webhook":
+		if len(rest) > 0 && rest[0] == "receive" {
+			return s.receiveWebhooks(rest[1:])
+		}
+		res, err = s.webhookCommand(rest)
+	case "find":
+		res, err = s.findCommand(rest)
+	case "ticket":
+		res, err = s.ticketCommand(rest)
+	case "bill":
+		res, err = s.billCommand(rest)
+	case "police":
+		res, err = s.policeCommand(rest)
+	case "report":
+		res, err = s.reportCommand(rest)
+	case "occupancy":
+		res, err = s.occupancyCommand(rest)
+	case "repl":
+		return s.repl()
+	case "serve":
+		return s.serve(rest)
+	case "config":
+		res, err = s.configCommand(rest)
+	case "simulate":
+		res, err = s.simulateCommand(rest)
+	case "help":
+		fmt.Fprint(s.stdout, usage)
+		return nil
+	default:
+		return usageError("unknown command %q", command)
+	}
+	if err != nil {
+		return err
+	}
+	return render(s.stdout, s.output, res)
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usageError("%v", err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func expectArgs(args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usageError("expected %d argument(s): %v", len(names), names)
+	}
+	return nil
+}
+
+func (s *session) lotCommand(args []string) (*result, error) {
+	if len(args) == 0 || args[0] != "create" {
+		return nil, usageError("expected: lot create <lot-id> <capacity>")
+	}
+	fs := flag.NewFlagSet("lot create", flag.ContinueOnError)
+	types := fs.String("types", "", "comma-separated space types in order, each optionally type:count")
+	layoutFile := fs.String("layout", "", "JSON file describing levels, rows and spaces")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.LotRequest{}
+	if *layoutFile != "" {
+		if err := expectArgs(positional, "lot-id"); err != nil {
+			return nil, err
+		}
+		if req.Layout, err = readLayout(*layoutFile); err != nil {
+			return nil, err
+		}
+	} else if *types != "" {
+		if err := expectArgs(positional, "lot-id"); err != nil {
+			return nil, err
+		}
+		if req.SpaceTypes, err = expandSpaceTypes(*types); err != nil {
+			return nil, usageError("%v", err)
+		}
+	} else {
+		if err := expectArgs(positional, "lot-id", "capacity"); err != nil {
+			return nil, err
+		}
+		if req.Capacity, err = strconv.Atoi(positional[1]); err != nil {
+			return nil, usageError("capacity must be a number")
+		}
+	}
+	req.ID = positional[0]
+
+	lot, err := s.client.CreateLot(req)
+	if err != nil {
+		return nil, err
+	}
+	return lotsResult([]api.LotStatusResponse{*lot}, lot), nil
+}
+
+func readLayout(path string) (*models.LotLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var layout models.LotLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("invalid layout %s: %v", path, err)
+	}
+	return &layout, nil
+}
+
+// expandSpaceTypes turns "compact:2,large" into [compact compact large]
+func expandSpaceTypes(spec string) ([]string, error) {
+	var spaceTypes []string
+	for _, part := range strings.Split(spec, ",") {
+		name, countText, hasCount := strings.Cut(strings.TrimSpace(part), ":")
+		count := 1
+		if hasCount {
+			var err error
+			if count, err = strconv.Atoi(countText); err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid space count in %q", part)
+			}
+		}
+		for i := 0; i < count; i++ {
+			spaceTypes = append(spaceTypes, name)
+		}
+	}
+	return spaceTypes, nil
+}
+
+func (s *session) parkCommand(args []string) (*result, error) {
+	fs := flag.NewFlagSet("park", flag.ContinueOnError)
+	car := carFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "plate"); err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.client.Park(car(positional[0]))
+	if err != nil {
+		return nil, err
+	}
+	return ticketResult(ticket), nil
+}
+
+// carFlags defines the flags describing a vehicle and returns a function
+// building the request for a plate once they are parsed
+func carFlags(fs *flag.FlagSet) func(plate string) api.ParkRequest {
+	driver := fs.String("driver", "", "driver name")
+	color := fs.String("color", "", "car color")
+	make := fs.String("make", "", "car make")
+	size := fs.String("size", "medium", "motorcycle, small, medium or large")
+	handicap := fs.Bool("handicap", false, "driver is handicapped")
+	spaces := fs.Int("spaces", 1, "contiguous spaces the vehicle occupies")
+	return func(plate string) api.ParkRequest {
+		return api.ParkRequest{
+			LicensePlate: plate,
+			DriverName:   *driver,
+			Color:        *color,
+			Make:         *make,
+			Size:         *size,
+			IsHandicap:   *handicap,
+			SpacesNeeded: *spaces,
+		}
+	}
+}
+
+func (s *session) unparkCommand(args []string) (*result, error) {
+	fs := flag.NewFlagSet("unpark", flag.ContinueOnError)
+	lostTicket := fs.Bool("lost-ticket", false, "the driver cannot present a ticket")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "plate"); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.client.Unpark(positional[0], *lostTicket)
+	if err != nil {
+		return nil, err
+	}
+	return billResult(bill), nil
+}
+
+func billResult(bill *api.BillResponse) *result {
+	return &result{
+		value:   bill,
+		headers: []string{"TICKET", "PLATE", "PARKED AT", "UNPARKED AT", "DURATION", "TARIFF", "AMOUNT", "FLAGS"},
+		rows: [][]string{{
+			bill.TicketID, bill.LicensePlate, formatTime(bill.ParkedAt), formatTime(bill.UnparkedAt),
+			formatSeconds(bill.DurationSeconds), bill.Tariff, formatMoney(bill.TotalAmount), billFlags(bill),
+		}},
+	}
+}
+
+// payCommand prices the stay up to now and pays the balance, or --amount of it
+func (s *session) payCommand(args []string) (*result, error) {
+	fs := flag.NewFlagSet("pay", flag.ContinueOnError)
+	method := fs.String("method", "card", "cash, card or wallet")
+	amount := fs.Float64("amount", 0, "amount to pay; the whole balance when omitted")
+	lostTicket := fs.Bool("lost-ticket", false, "the driver cannot present a ticket")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "plate"); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.client.ExitBill(positional[0], *lostTicket)
+	if err != nil {
+		return nil, err
+	}
+	if *amount == 0 {
+		receipt, err := s.client.Receipt(bill.TicketID)
+		if err != nil {
+			return nil, err
+		}
+		*amount = receipt.BalanceDue
+	}
+
+	payment, err := s.client.Pay(bill.TicketID, api.PaymentRequest{Method: *method, Amount: *amount})
+	if err != nil {
+		return nil, err
+	}
+	return paymentResult(payment), nil
+}
+
+func (s *session) refundCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "payment-id", "amount"); err != nil {
+		return nil, err
+	}
+	amount, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return nil, usageError("amount must be a number")
+	}
+
+	payment, err := s.client.Refund(args[0], amount)
+	if err != nil {
+		return nil, err
+	}
+	return paymentResult(payment), nil
+}
+
+func paymentResult(payment *api.PaymentResponse) *result {
+	refunded := 0.0
+	for _, refund := range payment.Refunds {
+		refunded += refund.Amount
+	}
+	return &result{
+		value:   payment,
+		headers: []string{"PAYMENT", "TICKET", "METHOD", "AMOUNT", "CHANGE", "REFUNDED", "BALANCE DUE"},
+		rows: [][]string{{
+			payment.ID, payment.TicketID, payment.Method, formatMoney(payment.Amount),
+			formatMoney(payment.Change), formatMoney(refunded), formatMoney(payment.BalanceDue),
+		}},
+	}
+}
+
+func (s *session) receiptCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "ticket-id"); err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.client.Receipt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   receipt,
+		headers: []string{"TICKET", "PLATE", "TOTAL", "PAID", "BALANCE DUE", "PAYMENTS", "FLAGS"},
+		rows: [][]string{{
+			receipt.Bill.TicketID, receipt.Bill.LicensePlate, formatMoney(receipt.Bill.TotalAmount),
+			formatMoney(receipt.AmountPaid), formatMoney(receipt.BalanceDue),
+			strconv.Itoa(len(receipt.Payments)), billFlags(&receipt.Bill),
+		}},
+	}, nil
+}
+
+func (s *session) overrideCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "plate", "attendant-id"); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.client.OverrideSettlement(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	return billResult(bill), nil
+}
+
+func (s *session) reserveCommand(args []string) (*result, error) {
+	fs := flag.NewFlagSet("reserve", flag.ContinueOnError)
+	from := fs.String("from", "", "start of the window")
+	to := fs.String("to", "", "end of the window")
+	car := carFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "plate", "lot-id"); err != nil {
+		return nil, err
+	}
+	start, err := parseWindowTime("--from", *from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseWindowTime("--to", *to)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.client.Reserve(api.ReservationRequest{
+		ParkRequest: car(positional[0]),
+		LotID:       positional[1],
+		Start:       start,
+		End:         end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservationsResult([]api.ReservationResponse{*reservation}, reservation), nil
+}
+
+// parseWindowTime accepts RFC 3339 or a minute-precision local time
+func parseWindowTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, usageError("%s is required", name)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		return time.Time{}, usageError("%s must be RFC 3339 or \"2006-01-02 15:04\"", name)
+	}
+	return t, nil
+}
+
+func (s *session) reservationCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: reservation list [--lot L] or reservation cancel <id>")
+	}
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("reservation list", flag.ContinueOnError)
+		lotID := fs.String("lot", "", "only reservations for this lot")
+		positional, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		if err := expectArgs(positional); err != nil {
+			return nil, err
+		}
+
+		reservations, err := s.client.Reservations(*lotID)
+		if err != nil {
+			return nil, err
+		}
+		return reservationsResult(reservations, reservations), nil
+	case "cancel":
+		if err := expectArgs(args[1:], "reservation-id"); err != nil {
+			return nil, err
+		}
+
+		reservation, err := s.client.CancelReservation(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return reservationsResult([]api.ReservationResponse{*reservation}, reservation), nil
+	default:
+		return nil, usageError("unknown reservation command %q", args[0])
+	}
+}
+
+func (s *session) arriveCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "reservation-id"); err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.client.Arrive(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return ticketResult(ticket), nil
+}
+
+func (s *session) waitlistCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: waitlist join|list|accept|leave")
+	}
+	switch args[0] {
+	case "join":
+		fs := flag.NewFlagSet("waitlist join", flag.ContinueOnError)
+		car := carFlags(fs)
+		positional, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		if err := expectArgs(positional, "plate"); err != nil {
+			return nil, err
+		}
+		entry, err := s.client.JoinWaitlist(car(positional[0]))
+		if err != nil {
+			return nil, err
+		}
+		return waitlistResult([]api.WaitlistEntryResponse{*entry}, entry), nil
+	case "list":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		entries, err := s.client.Waitlist()
+		if err != nil {
+			return nil, err
+		}
+		return waitlistResult(entries, entries), nil
+	case "accept":
+		if err := expectArgs(args[1:], "entry-id"); err != nil {
+			return nil, err
+		}
+		ticket, err := s.client.AcceptWaitlistOffer(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return ticketResult(ticket), nil
+	case "leave":
+		if err := expectArgs(args[1:], "entry-id"); err != nil {
+			return nil, err
+		}
+		entry, err := s.client.LeaveWaitlist(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return waitlistResult([]api.WaitlistEntryResponse{*entry}, entry), nil
+	default:
+		return nil, usageError("unknown waitlist command %q", args[0])
+	}
+}
+
+func waitlistResult(entries []api.WaitlistEntryResponse, value interface{}) *result {
+	res := &result{
+		value:   value,
+		headers: []string{"ENTRY", "PLATE", "STATUS", "POSITION", "EST. WAIT", "OFFERED SPACE", "OFFER EXPIRES"},
+	}
+	for _, entry := range entries {
+		position, offered, expires := "", "", ""
+		if entry.Position > 0 {
+			position = strconv.Itoa(entry.Position)
+		}
+		if entry.SpaceID > 0 {
+			offered = fmt.Sprintf("%s/%d", entry.LotID, entry.SpaceID)
+		}
+		if entry.OfferExpiresAt != nil {
+			expires = formatTime(*entry.OfferExpiresAt)
+		}
+		res.rows = append(res.rows, []string{
+			entry.ID, entry.Car.LicensePlate, entry.Status, position,
+			formatSeconds(entry.EstimatedWaitSeconds), offered, expires,
+		})
+	}
+	return res
+}
+
+func (s *session) webhookCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: webhook list|dead-letters|redeliver|receive")
+	}
+	switch args[0] {
+	case "list":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		webhooks, err := s.client.Webhooks()
+		if err != nil {
+			return nil, err
+		}
+		res := &result{value: webhooks, headers: []string{"WEBHOOK", "URL", "LOTS", "EVENTS", "SIGNED"}}
+		for _, webhook := range webhooks {
+			res.rows = append(res.rows, []string{
+				webhook.ID, webhook.URL, strings.Join(webhook.LotIDs, ","),
+				strings.Join(webhook.Events, ","), strconv.FormatBool(webhook.Signed),
+			})
+		}
+		return res, nil
+	case "dead-letters":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		letters, err := s.client.DeadLetters()
+		if err != nil {
+			return nil, err
+		}
+		return deadLettersResult(letters, letters), nil
+	case "redeliver":
+		if err := expectArgs(args[1:], "dead-letter-id"); err != nil {
+			return nil, err
+		}
+		letter, err := s.client.RedeliverDeadLetter(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return deadLettersResult([]api.DeadLetterResponse{*letter}, letter), nil
+	default:
+		return nil, usageError("unknown webhook command %q", args[0])
+	}
+}
+
+func deadLettersResult(letters []api.DeadLetterResponse, value interface{}) *result {
+	res := &result{
+		value:   value,
+		headers: []string{"DEAD LETTER", "WEBHOOK", "EVENT", "LOT", "ATTEMPTS", "LAST ERROR", "FAILED", "DELIVERED"},
+	}
+	for _, letter := range letters {
+		delivered := ""
+		if letter.DeliveredAt != nil {
+			delivered = formatTime(*letter.DeliveredAt)
+		}
+		res.rows = append(res.rows, []string{
+			letter.ID, letter.WebhookID, letter.EventType, letter.LotID, strconv.Itoa(letter.Attempts),
+			letter.LastError, formatTime(letter.FailedAt), delivered,
+		})
+	}
+	return res
+}
+
+// receiveWebhooks serves a local webhook endpoint until the process is stopped
+func (s *session) receiveWebhooks(args []string) error {
+	fs := flag.NewFlagSet("webhook receive", flag.ContinueOnError)
+	addr := fs.String("addr", ":9090", "listen address")
+	secret := fs.String("secret", "", "refuse deliveries not signed with this secret")
+	fail := fs.Int("fail", 0, "answer the first N deliveries with 503")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional); err != nil {
+		return err
+	}
+
+	receiver := services.NewWebhookReceiver(*secret)
+	receiver.FailNext(*fail)
+	receiver.OnDelivery = func(delivery services.ReceivedWebhook) {
+		fmt.Fprintf(s.stdout, "📨 %s %s %s %s\n", formatTime(delivery.At), delivery.EventType, delivery.LotID, delivery.Payload)
+	}
+	fmt.Fprintf(s.stdout, "👂 Receiving webhooks on %s\n", *addr)
+	return http.ListenAndServe(*addr, receiver)
+}
+
+func (s *session) permitCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: permit issue|list|revoke|renew")
+	}
+	switch args[0] {
+	case "issue":
+		return s.issuePermit(args[1:])
+	case "list":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		permits, err := s.client.Permits()
+		if err != nil {
+			return nil, err
+		}
+		return permitsResult(permits, permits), nil
+	case "revoke":
+		if err := expectArgs(args[1:], "permit-id"); err != nil {
+			return nil, err
+		}
+		permit, err := s.client.RevokePermit(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return permitsResult([]api.PermitResponse{*permit}, permit), nil
+	case "renew":
+		fs := flag.NewFlagSet("permit renew", flag.ContinueOnError)
+		until := fs.String("until", "", "new end of the permit")
+		positional, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		if err := expectArgs(positional, "permit-id"); err != nil {
+			return nil, err
+		}
+		end, err := parseWindowTime("--until", *until)
+		if err != nil {
+			return nil, err
+		}
+		permit, err := s.client.RenewPermit(positional[0], end)
+		if err != nil {
+			return nil, err
+		}
+		return permitsResult([]api.PermitResponse{*permit}, permit), nil
+	default:
+		return nil, usageError("unknown permit command %q", args[0])
+	}
+}
+
+func (s *session) issuePermit(args []string) (*result, error) {
+	fs := flag.NewFlagSet("permit issue", flag.ContinueOnError)
+	holder := fs.String("holder", "", "permit holder's name")
+	from := fs.String("from", "", "start of the permit; now when omitted")
+	until := fs.String("until", "", "end of the permit")
+	months := fs.Int("months", 1, "length of the permit in months when --until is omitted")
+	lots := fs.String("lots", "", "comma-separated lots the permit is valid in; every lot when omitted")
+	space := fs.Int("space", 0, "space kept for the holder in the permit's only lot")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "plate"); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if *from != "" {
+		if start, err = parseWindowTime("--from", *from); err != nil {
+			return nil, err
+		}
+	}
+	end := start.AddDate(0, *months, 0)
+	if *until != "" {
+		if end, err = parseWindowTime("--until", *until); err != nil {
+			return nil, err
+		}
+	}
+	var lotIDs []string
+	if *lots != "" {
+		lotIDs = strings.Split(*lots, ",")
+	}
+
+	permit, err := s.client.IssuePermit(api.PermitRequest{
+		LicensePlate: positional[0],
+		HolderName:   *holder,
+		ValidFrom:    start,
+		ValidUntil:   end,
+		LotIDs:       lotIDs,
+		SpaceID:      *space,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permitsResult([]api.PermitResponse{*permit}, permit), nil
+}
+
+func permitsResult(permits []api.PermitResponse, value interface{}) *result {
+	res := &result{
+		value:   value,
+		headers: []string{"PERMIT", "PLATE", "HOLDER", "FROM", "UNTIL", "LOTS", "SPACE", "VALID"},
+	}
+	for _, permit := range permits {
+		lots, space := strings.Join(permit.LotIDs, ","), ""
+		if lots == "" {
+			lots = "all"
+		}
+		if permit.SpaceID > 0 {
+			space = strconv.Itoa(permit.SpaceID)
+		}
+		until := permit.ValidUntil
+		if !permit.RevokedAt.IsZero() && permit.RevokedAt.Before(until) {
+			until = permit.RevokedAt
+		}
+		res.rows = append(res.rows, []string{
+			permit.ID, permit.LicensePlate, permit.HolderName, formatTime(permit.ValidFrom),
+			formatTime(until), lots, space, strconv.FormatBool(permit.IsValid),
+		})
+	}
+	return res
+}
+
+func reservationsResult(reservations []api.ReservationResponse, value interface{}) *result {
+	res := &result{
+		value:   value,
+		headers: []string{"RESERVATION", "PLATE", "LOT", "FROM", "TO", "SPACES", "STATUS", "TICKET"},
+	}
+	for _, reservation := range reservations {
+		res.rows = append(res.rows, []string{
+			reservation.ID, reservation.Car.LicensePlate, reservation.LotID, formatTime(reservation.Start),
+			formatTime(reservation.End), strconv.Itoa(reservation.Spaces), reservation.Status, reservation.TicketID,
+		})
+	}
+	return res
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// billFlags lists the penalties an operator should notice on a bill
+func billFlags(bill *api.BillResponse) string {
+	var flags []string
+	if bill.LostTicket {
+		flags = append(flags, "lost ticket")
+	}
+	if bill.OverstaySeconds > 0 {
+		flags = append(flags, "overstay "+formatSeconds(bill.OverstaySeconds))
+	}
+	if bill.SettlementOverride != "" {
+		flags = append(flags, "unpaid exit by "+bill.SettlementOverride)
+	}
+	if bill.PermitID != "" {
+		flags = append(flags, "permit "+bill.PermitID)
+	}
+	return strings.Join(flags, ", ")
+}
+
+func (s *session) findCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "plate"); err != nil {
+		return nil, err
+	}
+
+	location, err := s.client.FindCar(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   location,
+		headers: []string{"PLATE", "LOT", "SPACE", "LEVEL", "ROW", "POSITION", "PARKED AT", "ATTENDANT"},
+		rows: [][]string{{
+			location.Car.LicensePlate, location.LotID, location.SpaceID, location.Level, location.Row,
+			strconv.Itoa(location.Position), formatTime(location.ParkedAt), location.AttendantID,
+		}},
+	}, nil
+}
+
+func (s *session) ticketCommand(args []string) (*result, error) {
+	if len(args) == 0 || (args[0] != "show" && args[0] != "verify") {
+		return nil, usageError("expected: ticket show|verify <ticket-id>")
+	}
+	if err := expectArgs(args[1:], "ticket-id"); err != nil {
+		return nil, err
+	}
+
+	if args[0] == "verify" {
+		verification, err := s.client.VerifyTicket(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if !verification.Valid {
+			return nil, errors.New(verification.Reason)
+		}
+		return &result{
+			value:   verification,
+			headers: []string{"TICKET", "VALID"},
+			rows:    [][]string{{verification.TicketID, "yes"}},
+		}, nil
+	}
+
+	ticket, err := s.client.Ticket(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return ticketResult(ticket), nil
+}
+
+func (s *session) billCommand(args []string) (*result, error) {
+	if err := expectArgs(args, "plate"); err != nil {
+		return nil, err
+	}
+
+	fee, err := s.client.FeeEstimate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   fee,
+		headers: []string{"TICKET", "PLATE", "DURATION", "FEE SO FAR"},
+		rows: [][]string{{
+			fee.TicketID, fee.LicensePlate,
+			(time.Duration(fee.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+			formatMoney(fee.CurrentFee),
+		}},
+	}, nil
+}
+
+func (s *session) policeCommand(args []string) (*result, error) {
+	if len(args) == 0 || args[0] != "search" {
+		return nil, usageError("expected: police search [--color C] [--make M]")
+	}
+	fs := flag.NewFlagSet("police search", flag.ContinueOnError)
+	color := fs.String("color", "", "car color")
+	make := fs.String("make", "", "car make")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional); err != nil {
+		return nil, err
+	}
+
+	vehicles, err := s.client.PoliceSearch(*color, *make)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		value:   vehicles,
+		headers: []string{"PLATE", "COLOR", "MAKE", "LOT", "SPACE", "PARKED AT", "ATTENDANT"},
+	}
+	for _, vehicle := range vehicles {
+		res.rows = append(res.rows, []string{
+			vehicle.Car.LicensePlate, vehicle.Car.Color, vehicle.Car.Make, vehicle.LotID,
+			vehicle.SpaceID, formatTime(vehicle.ParkedAt), vehicle.AttendantName,
+		})
+	}
+	return res, nil
+}
+
+func (s *session) reportCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		lots, err := s.client.Lots()
+		if err != nil {
+			return nil, err
+		}
+		return lotsResult(lots, lots), nil
+	}
+
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	dateFlag := fs.String("date", "", "any day in the period")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(positional, "period"); err != nil {
+		return nil, err
+	}
+	var at time.Time
+	if *dateFlag != "" {
+		if at, err = parseReportDate(*dateFlag); err != nil {
+			return nil, err
+		}
+	}
+	report, err := s.client.Report(positional[0], at)
+	if err != nil {
+		return nil, err
+	}
+	return reportResult(report), nil
+}
+
+// parseReportDate accepts a local date as well as the window time formats
+func parseReportDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return parseWindowTime("--date", value)
+}
+
+// reportSizes are the vehicle size columns of a report, smallest first
+var reportSizes = []string{"Motorcycle", "Small", "Medium", "Large"}
+
+func reportResult(report *api.ReportResponse) *result {
+	res := &result{value: report, headers: []string{"LOT", "TICKETS", "REVENUE", "AVG STAY"}}
+	for _, size := range reportSizes {
+		res.headers = append(res.headers, strings.ToUpper(size))
+	}
+	res.headers = append(res.headers, "HANDICAP", "PEAK", "PEAK AT")
+
+	total := report.Total
+	total.LotID = "TOTAL"
+	for _, lot := range append(report.Lots, total) {
+		row := []string{lot.LotID, strconv.Itoa(lot.Tickets), formatMoney(lot.Revenue), formatSeconds(lot.AverageDurationSeconds)}
+		for _, size := range reportSizes {
+			row = append(row, strconv.Itoa(lot.TicketsBySize[size]))
+		}
+		peak, peakAt := "", ""
+		if lot.PeakAt != nil {
+			peak, peakAt = formatOccupancy(lot.PeakOccupancy), formatTime(*lot.PeakAt)
+		}
+		res.rows = append(res.rows, append(row, strconv.Itoa(lot.HandicapTickets), peak, peakAt))
+	}
+	return res
+}
+
+func (s *session) occupancyCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: occupancy sample|history|profile|forecast")
+	}
+	switch args[0] {
+	case "sample":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		samples, err := s.client.SampleOccupancy()
+		if err != nil {
+			return nil, err
+		}
+		return occupancySamplesResult(samples), nil
+	case "history":
+		fs := flag.NewFlagSet("occupancy history", flag.ContinueOnError)
+		fromFlag := fs.String("from", "", "first sample time")
+		toFlag := fs.String("to", "", "last sample time")
+		positional, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		if err := expectArgs(positional, "lot-id"); err != nil {
+			return nil, err
+		}
+		var from, to time.Time
+		if *fromFlag != "" {
+			if from, err = parseWindowTime("--from", *fromFlag); err != nil {
+				return nil, err
+			}
+		}
+		if *toFlag != "" {
+			if to, err = parseWindowTime("--to", *toFlag); err != nil {
+				return nil, err
+			}
+		}
+		samples, err := s.client.OccupancyHistory(positional[0], from, to)
+		if err != nil {
+			return nil, err
+		}
+		return occupancySamplesResult(samples), nil
+	case "profile":
+		if err := expectArgs(args[1:], "lot-id"); err != nil {
+			return nil, err
+		}
+		profile, err := s.client.OccupancyProfile(args[1])
+		if err != nil {
+			return nil, err
+		}
+		res := &result{value: profile, headers: []string{"PERIOD", "SAMPLES", "AVERAGE", "PEAK"}}
+		for _, bucket := range append(profile.ByHour, profile.ByWeekday...) {
+			if bucket.Samples == 0 {
+				continue
+			}
+			period := bucket.Weekday
+			if bucket.Hour != nil {
+				period = fmt.Sprintf("%02d:00", *bucket.Hour)
+			}
+			res.rows = append(res.rows, []string{
+				period, strconv.Itoa(bucket.Samples),
+				formatOccupancy(bucket.AverageOccupancy), formatOccupancy(bucket.PeakOccupancy),
+			})
+		}
+		return res, nil
+	case "forecast":
+		fs := flag.NewFlagSet("occupancy forecast", flag.ContinueOnError)
+		hours := fs.Int("hours", 24, "hours ahead to forecast")
+		positional, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		lotID := ""
+		if len(positional) > 0 {
+			if err := expectArgs(positional, "lot-id"); err != nil {
+				return nil, err
+			}
+			lotID = positional[0]
+		}
+		forecasts, err := s.client.OccupancyForecasts(lotID, *hours)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{value: forecasts, headers: []string{"LOT", "NOW", "PEAK", "PEAK AT", "FULL AT", "METHOD"}}
+		for _, forecast := range forecasts {
+			peak := api.OccupancyForecastPointResponse{At: forecast.At, Occupancy: forecast.CurrentOccupancy}
+			for _, point := range forecast.Points {
+				if point.Occupancy > peak.Occupancy {
+					peak = point
+				}
+			}
+			fullAt := ""
+			if forecast.FullAt != nil {
+				fullAt = formatTime(*forecast.FullAt)
+			}
+			res.rows = append(res.rows, []string{
+				forecast.LotID, formatOccupancy(forecast.CurrentOccupancy), formatOccupancy(peak.Occupancy),
+				formatTime(peak.At), fullAt, forecast.Method,
+			})
+		}
+		return res, nil
+	default:
+		return nil, usageError("unknown occupancy command %q", args[0])
+	}
+}
+
+func occupancySamplesResult(samples []api.OccupancySampleResponse) *result {
+	res := &result{value: samples, headers: []string{"LOT", "AT", "OCCUPIED", "TOTAL", "OCCUPANCY"}}
+	for _, sample := range samples {
+		res.rows = append(res.rows, []string{
+			sample.LotID, formatTime(sample.At), strconv.Itoa(sample.OccupiedSpaces),
+			strconv.Itoa(sample.TotalSpaces), formatOccupancy(sample.Occupancy),
+		})
+	}
+	return res
+}
+
+func ticketResult(ticket *api.TicketResponse) *result {
+	return &result{
+		value:   ticket,
+		headers: []string{"TICKET", "PLATE", "LOT", "SPACE", "PARKED AT", "ACTIVE"},
+		rows: [][]string{{
+			ticket.ID, ticket.LicensePlate, ticket.LotID, ticket.SpaceID,
+			formatTime(ticket.ParkedAt), strconv.FormatBool(ticket.IsActive),
+		}},
+	}
+}
+
+func lotsResult(lots []api.LotStatusResponse, value interface{}) *result {
+	res := &result{
+		value:   value,
+		headers: []string{"LOT", "CAPACITY", "OCCUPIED", "AVAILABLE", "VEHICLES", "UTILIZATION", "FULL"},
+	}
+	for _, lot := range lots {
+		res.rows = append(res.rows, []string{
+			lot.ID, strconv.Itoa(lot.Capacity), strconv.Itoa(lot.OccupiedSpaces),
+			strconv.Itoa(lot.AvailableSpaces), strconv.Itoa(lot.ParkedVehicles), fmt.Sprintf("%.1f%%", lot.UtilizationRate),
+			strconv.FormatBool(lot.IsFull),
+		})
+	}
+	return res
+}
+
+func (s *session) serve(args []string) error {
+	if s.parking == nil {
+		return errors.New("serve runs against a state directory, not another server")
+	}
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	snapshotEvery := fs.Duration("snapshot-interval", 5*time.Minute, "how often to snapshot the state")
+	sampleEvery := fs.Duration("sample-interval", 5*time.Minute, "how often to record the lots' occupancy")
+	reportDir := fs.String("report-dir", "", "directory to write reports to as each period closes")
+	reportFormats := fs.String("report-formats", "csv,json,text", "comma-separated report formats")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional); err != nil {
+		return err
+	}
+
+	stop := s.parking.StartPeriodicSnapshots(*snapshotEvery)
+	defer stop()
+	stopExpiry := s.parking.GetReservationService().StartExpiry(time.Minute)
+	defer stopExpiry()
+	stopOffers := s.parking.GetWaitlistService().StartExpiry(30 * time.Second)
+	defer stopOffers()
+	stopSampling := s.parking.GetOccupancyService().StartSampling(*sampleEvery)
+	defer stopSampling()
+	if *reportDir != "" {
+		schedule := services.ReportSchedule{Dir: *reportDir, Formats: strings.Split(*reportFormats, ",")}
+		if _, err := s.parking.GetReportService().WriteDue(schedule); err != nil {
+			return err
+		}
+		stopReports := s.parking.GetReportService().StartSchedule(schedule, time.Minute)
+		defer stopReports()
+	}
+	if s.configPath != "" {
+		hangups := make(chan os.Signal, 1)
+		signal.Notify(hangups, syscall.SIGHUP)
+		defer signal.Stop(hangups)
+		go func() {
+			for range hangups {
+				if changes, err := s.reloadConfig(); err != nil {
+					fmt.Fprintf(s.stderr, "error: reloading %s: %v\n", s.configPath, err)
+				} else {
+					fmt.Fprintf(s.stdout, "🔄 Reloaded %s: %d added, %d updated\n", s.configPath, len(changes.Added), len(changes.Updated))
+				}
+			}
+		}()
+	}
+
+	fmt.Fprintf(s.stdout, "🚀 Serving parking API on %s (state in %s)\n", *addr, s.stateDir)
+	return http.ListenAndServe(*addr, newAPIServer(s.parking))
+}
+
+func (s *session) configCommand(args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, usageError("expected: config check <file> or config reload")
+	}
+
+	switch args[0] {
+	case "check":
+		if err := expectArgs(args[1:], "file"); err != nil {
+			return nil, err
+		}
+		cfg, err := config.LoadFile(args[1])
+		if err != nil {
+			return nil, err
+		}
+		summary := struct {
+			File            string `json:"file"`
+			Lots            int    `json:"lots"`
+			Attendants      int    `json:"attendants"`
+			SecurityStaff   int    `json:"securityStaff"`
+			DefaultStrategy string `json:"defaultStrategy,omitempty"`
+		}{args[1], len(cfg.Lots), len(cfg.Attendants), len(cfg.SecurityStaff), cfg.DefaultStrategy}
+		return &result{
+			value:   summary,
+			headers: []string{"FILE", "LOTS", "ATTENDANTS", "SECURITY STAFF", "DEFAULT STRATEGY"},
+			rows: [][]string{{
+				args[1], strconv.Itoa(len(cfg.Lots)), strconv.Itoa(len(cfg.Attendants)),
+				strconv.Itoa(len(cfg.SecurityStaff)), cfg.DefaultStrategy,
+			}},
+		}, nil
+	case "reload":
+		if err := expectArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		if s.parking == nil || s.configPath == "" {
+			return nil, errors.New("config reload needs --config and a state directory")
+		}
+		changes, err := s.reloadConfig()
+		if err != nil {
+			return nil, err
+		}
+		return changesResult(changes), nil
+	default:
+		return nil, usageError("unknown config command %q", args[0])
+	}
+}
+
+// reloadConfig applies the --config file to the local state, warning about
+// edits that cannot take effect while the state exists
+func (s *session) reloadConfig() (*config.Changes, error) {
+	cfg, err := config.LoadFile(s.configPath)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := cfg.Apply(s.parking)
+	if err != nil {
+		return nil, err
+	}
+	for _, ignored := range changes.Ignored {
+		fmt.Fprintf(s.stderr, "warning: %s\n", ignored)
+	}
+	return changes, nil
+}
+
+func changesResult(changes *config.Changes) *result {
+	res := &result{value: changes, headers: []string{"CHANGE", "ITEM"}}
+	for _, item := range changes.Added {
+		res.rows = append(res.rows, []string{"added", item})
+	}
+	for _, item := range changes.Updated {
+		res.rows = append(res.rows, []string{"updated", item})
+	}
+	for _, item := range changes.Ignored {
+		res.rows = append(res.rows, []string{"ignored", item})
+	}
+	return res
+}
+
+// simulationSummary is one strategy's simulation result as printed with --output json
+type simulationSummary struct {
+	Strategy                string                 `json:"strategy"`
+	Arrivals                int                    `json:"arrivals"`
+	Parked                  int                    `json:"parked"`
+	TurnedAway              int                    `json:"turnedAway"`
+	Queued                  int                    `json:"queued"`
+	Abandoned               int                    `json:"abandoned"`
+	TurnAwayRate            float64                `json:"turnAwayRate"`
+	Utilization             float64                `json:"utilization"`
+	Revenue                 float64                `json:"revenue"`
+	AverageQueueWaitSeconds float64                `json:"averageQueueWaitSeconds"`
+	MaxQueueWaitSeconds     float64                `json:"maxQueueWaitSeconds"`
+	Lots                    []simulation.LotResult `json:"lots"`
+}
+
+func (s *session) simulateCommand(args []string) (*result, error) {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	strategies := fs.String("strategies", "even,handicap,large,smart", "comma-separated strategies to compare")
+	hours := fs.Float64("hours", 24, "simulated hours")
+	arrivals := fs.String("arrivals", "poisson:30", "arrivals per hour as poisson:RATE, or a gap distribution")
+	dwell := fs.String("dwell", "exp:2h", "dwell-time distribution")
+	mix := fs.String("mix", "", "vehicle mix, e.g. medium:70,large:20,motorcycle:5,handicap:5")
+	patience := fs.Duration("patience", 0, "how long turned-away cars queue for a space")
+	seed := fs.Int64("seed", 1, "random seed; the same seed replays the same arrivals")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	configPath := s.configPath
+	if len(positional) > 0 {
+		if err := expectArgs(positional, "config-file"); err != nil {
+			return nil, err
+		}
+		configPath = positional[0]
+	}
+	if configPath == "" {
+		return nil, usageError("simulate needs a configuration file describing the lots")
+	}
+
+	cfg, err := config.LoadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	scenario := simulation.Scenario{
+		NewService: cfg.NewParkingService,
+		Duration:   time.Duration(*hours * float64(time.Hour)),
+		Patience:   *patience,
+		Seed:       *seed,
+	}
+	if scenario.Arrivals, err = simulation.ParseArrivals(*arrivals); err != nil {
+		return nil, usageError("%v", err)
+	}
+	if scenario.Dwell, err = simulation.ParseDistribution(*dwell); err != nil {
+		return nil, usageError("%v", err)
+	}
+	if *mix != "" {
+		if scenario.Mix, err = simulation.ParseVehicleMix(*mix); err != nil {
+			return nil, usageError("%v", err)
+		}
+	}
+	var compared []models.ParkingStrategy
+	for _, name := range strings.Split(*strategies, ",") {
+		strategy, err := models.ParseStrategy(name)
+		if err != nil {
+			return nil, usageError("%v", err)
+		}
+		compared = append(compared, strategy)
+	}
+
+	results, err := simulation.Compare(scenario, compared...)
+	if err != nil {
+		return nil, err
+	}
+	return simulationResult(results), nil
+}
+
+func simulationResult(results []*simulation.Result) *result {
+	summaries := make([]simulationSummary, 0, len(results))
+	res := &result{
+		headers: []string{
+			"STRATEGY", "ARRIVALS", "PARKED", "TURNED AWAY", "TURN-AWAY RATE", "UTILIZATION",
+			"LOT UTILIZATION", "REVENUE", "AVG QUEUE WAIT", "MAX QUEUE WAIT",
+		},
+	}
+	for _, r := range results {
+		summaries = append(summaries, simulationSummary{
+			Strategy: r.Strategy, Arrivals: r.Arrivals, Parked: r.Parked, TurnedAway: r.TurnedAway,
+			Queued: r.Queued, Abandoned: r.Abandoned, TurnAwayRate: r.TurnAwayRate,
+			Utilization: r.Utilization, Revenue: r.Revenue,
+			AverageQueueWaitSeconds: r.AverageQueueWait.Seconds(), MaxQueueWaitSeconds: r.MaxQueueWait.Seconds(),
+			Lots: r.Lots,
+		})
+		var lots []string
+		for _, lot := range r.Lots {
+			lots = append(lots, lot.LotID+" "+formatOccupancy(lot.Utilization))
+		}
+		res.rows = append(res.rows, []string{
+			r.Strategy, strconv.Itoa(r.Arrivals), strconv.Itoa(r.Parked), strconv.Itoa(r.TurnedAway + r.Abandoned),
+			formatOccupancy(r.TurnAwayRate), formatOccupancy(r.Utilization),
+			strings.Join(lots, " "), formatMoney(r.Revenue),
+			r.AverageQueueWait.Round(time.Second).String(), r.MaxQueueWait.Round(time.Second).String(),
+		})
+	}
+	res.value = summaries
+	return res
+}
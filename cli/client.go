@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"parking-lot-system/api"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to the HTTP API. Against a running server it uses the network;
+// against a local state directory it calls the same handlers in-process, so
+// both modes go through identical request handling and produce identical output.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewRemoteClient(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func newLocalClient(handler http.Handler) *Client {
+	return &Client{
+		baseURL: "http://local",
+		http:    &http.Client{Transport: handlerTransport{handler: handler}},
+	}
+}
+
+// handlerTransport serves requests from an http.Handler without a listener
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+	t.handler.ServeHTTP(recorder, req)
+	return &http.Response{
+		StatusCode: recorder.status,
+		Header:     recorder.header,
+		Body:       io.NopCloser(&recorder.body),
+		Request:    req,
+	}, nil
+}
+
+type responseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (c *Client) do(method, path string, body, into interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var apiErr api.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Message == "" {
+			return fmt.Errorf("server returned %s", resp.Status)
+		}
+		return fmt.Errorf("%s", apiErr.Message)
+	}
+	if into == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+func (c *Client) CreateLot(req api.LotRequest) (*api.LotStatusResponse, error) {
+	var lot api.LotStatusResponse
+	err := c.do(http.MethodPost, "/api/lots", req, &lot)
+	return &lot, err
+}
+
+func (c *Client) Lots() ([]api.LotStatusResponse, error) {
+	var lots []api.LotStatusResponse
+	err := c.do(http.MethodGet, "/api/lots", nil, &lots)
+	return lots, err
+}
+
+func (c *Client) Park(req api.ParkRequest) (*api.TicketResponse, error) {
+	var ticket api.TicketResponse
+	err := c.do(http.MethodPost, "/api/park", req, &ticket)
+	return &ticket, err
+}
+
+func (c *Client) Unpark(licensePlate string, lostTicket bool) (*api.BillResponse, error) {
+	var bill api.BillResponse
+	err := c.do(http.MethodPost, "/api/unpark", api.UnparkRequest{LicensePlate: licensePlate, LostTicket: lostTicket}, &bill)
+	return &bill, err
+}
+
+func (c *Client) ExitBill(licensePlate string, lostTicket bool) (*api.BillResponse, error) {
+	var bill api.BillResponse
+	err := c.do(http.MethodPost, "/api/cars/"+url.PathEscape(licensePlate)+"/exit-bill", api.ExitBillRequest{LostTicket: lostTicket}, &bill)
+	return &bill, err
+}
+
+func (c *Client) OverrideSettlement(licensePlate, attendantID string) (*api.BillResponse, error) {
+	var bill api.BillResponse
+	err := c.do(http.MethodPost, "/api/cars/"+url.PathEscape(licensePlate)+"/settlement-override", api.OverrideRequest{AttendantID: attendantID}, &bill)
+	return &bill, err
+}
+
+func (c *Client) Pay(ticketID string, req api.PaymentRequest) (*api.PaymentResponse, error) {
+	var payment api.PaymentResponse
+	err := c.do(http.MethodPost, "/api/tickets/"+url.PathEscape(ticketID)+"/payments", req, &payment)
+	return &payment, err
+}
+
+func (c *Client) Refund(paymentID string, amount float64) (*api.PaymentResponse, error) {
+	var payment api.PaymentResponse
+	err := c.do(http.MethodPost, "/api/payments/"+url.PathEscape(paymentID)+"/refunds", api.RefundRequest{Amount: amount}, &payment)
+	return &payment, err
+}
+
+func (c *Client) Receipt(ticketID string) (*api.ReceiptResponse, error) {
+	var receipt api.ReceiptResponse
+	err := c.do(http.MethodGet, "/api/tickets/"+url.PathEscape(ticketID)+"/receipt", nil, &receipt)
+	return &receipt, err
+}
+
+func (c *Client) Reserve(req api.ReservationRequest) (*api.ReservationResponse, error) {
+	var reservation api.ReservationResponse
+	err := c.do(http.MethodPost, "/api/reservations", req, &reservation)
+	return &reservation, err
+}
+
+func (c *Client) Reservations(lotID string) ([]api.ReservationResponse, error) {
+	query := url.Values{}
+	query.Set("lotId", lotID)
+
+	var reservations []api.ReservationResponse
+	err := c.do(http.MethodGet, "/api/reservations?"+query.Encode(), nil, &reservations)
+	return reservations, err
+}
+
+func (c *Client) CancelReservation(reservationID string) (*api.ReservationResponse, error) {
+	var reservation api.ReservationResponse
+	err := c.do(http.MethodDelete, "/api/reservations/"+url.PathEscape(reservationID), nil, &reservation)
+	return &reservation, err
+}
+
+func (c *Client) Arrive(reservationID string) (*api.TicketResponse, error) {
+	var ticket api.TicketResponse
+	err := c.do(http.MethodPost, "/api/reservations/"+url.PathEscape(reservationID)+"/arrival", nil, &ticket)
+	return &ticket, err
+}
+
+func (c *Client) JoinWaitlist(req api.ParkRequest) (*api.WaitlistEntryResponse, error) {
+	var entry api.WaitlistEntryResponse
+	err := c.do(http.MethodPost, "/api/waitlist", req, &entry)
+	return &entry, err
+}
+
+func (c *Client) Waitlist() ([]api.WaitlistEntryResponse, error) {
+	var entries []api.WaitlistEntryResponse
+	err := c.do(http.MethodGet, "/api/waitlist", nil, &entries)
+	return entries, err
+}
+
+func (c *Client) LeaveWaitlist(entryID string) (*api.WaitlistEntryResponse, error) {
+	var entry api.WaitlistEntryResponse
+	err := c.do(http.MethodDelete, "/api/waitlist/"+url.PathEscape(entryID), nil, &entry)
+	return &entry, err
+}
+
+func (c *Client) AcceptWaitlistOffer(entryID string) (*api.TicketResponse, error) {
+	var ticket api.TicketResponse
+	err := c.do(http.MethodPost, "/api/waitlist/"+url.PathEscape(entryID)+"/acceptance", nil, &ticket)
+	return &ticket, err
+}
+
+func (c *Client) Webhooks() ([]api.WebhookResponse, error) {
+	var webhooks []api.WebhookResponse
+	err := c.do(http.MethodGet, "/api/webhooks", nil, &webhooks)
+	return webhooks, err
+}
+
+func (c *Client) DeadLetters() ([]api.DeadLetterResponse, error) {
+	var letters []api.DeadLetterResponse
+	err := c.do(http.MethodGet, "/api/webhooks/dead-letters", nil, &letters)
+	return letters, err
+}
+
+func (c *Client) RedeliverDeadLetter(deadLetterID string) (*api.DeadLetterResponse, error) {
+	var letter api.DeadLetterResponse
+	err := c.do(http.MethodPost, "/api/webhooks/dead-letters/"+url.PathEscape(deadLetterID)+"/redelivery", nil, &letter)
+	return &letter, err
+}
+
+func (c *Client) IssuePermit(req api.PermitRequest) (*api.PermitResponse, error) {
+	var permit api.PermitResponse
+	err := c.do(http.MethodPost, "/api/permits", req, &permit)
+	return &permit, err
+}
+
+func (c *Client) Permits() ([]api.PermitResponse, error) {
+	var permits []api.PermitResponse
+	err := c.do(http.MethodGet, "/api/permits", nil, &permits)
+	return permits, err
+}
+
+func (c *Client) RevokePermit(permitID string) (*api.PermitResponse, error) {
+	var permit api.PermitResponse
+	err := c.do(http.MethodDelete, "/api/permits/"+url.PathEscape(permitID), nil, &permit)
+	return &permit, err
+}
+
+func (c *Client) RenewPermit(permitID string, until time.Time) (*api.PermitResponse, error) {
+	var permit api.PermitResponse
+	err := c.do(http.MethodPost, "/api/permits/"+url.PathEscape(permitID)+"/renewal", api.RenewPermitRequest{ValidUntil: until}, &permit)
+	return &permit, err
+}
+
+func (c *Client) FindCar(licensePlate string) (*api.CarLocationResponse, error) {
+	var location api.CarLocationResponse
+	err := c.do(http.MethodGet, "/api/cars/"+url.PathEscape(licensePlate), nil, &location)
+	return &location, err
+}
+
+func (c *Client) FeeEstimate(licensePlate string) (*api.FeeEstimateResponse, error) {
+	var fee api.FeeEstimateResponse
+	err := c.do(http.MethodGet, "/api/cars/"+url.PathEscape(licensePlate)+"/fee", nil, &fee)
+	return &fee, err
+}
+
+func (c *Client) Ticket(ticketID string) (*api.TicketResponse, error) {
+	var ticket api.TicketResponse
+	err := c.do(http.MethodGet, "/api/tickets/"+url.PathEscape(ticketID), nil, &ticket)
+	return &ticket, err
+}
+
+func (c *Client) VerifyTicket(ticketID string) (*api.TicketVerificationResponse, error) {
+	var verification api.TicketVerificationResponse
+	err := c.do(http.MethodGet, "/api/tickets/"+url.PathEscape(ticketID)+"/verification", nil, &verification)
+	return &verification, err
+}
+
+func (c *Client) SampleOccupancy() ([]api.OccupancySampleResponse, error) {
+	var samples []api.OccupancySampleResponse
+	err := c.do(http.MethodPost, "/api/occupancy/samples", nil, &samples)
+	return samples, err
+}
+
+func (c *Client) OccupancyHistory(lotID string, from, to time.Time) ([]api.OccupancySampleResponse, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format(time.RFC3339))
+	}
+	var samples []api.OccupancySampleResponse
+	err := c.do(http.MethodGet, "/api/lots/"+url.PathEscape(lotID)+"/occupancy?"+query.Encode(), nil, &samples)
+	return samples, err
+}
+
+// Report fetches the daily, weekly or monthly report for the period containing at
+func (c *Client) Report(period string, at time.Time) (*api.ReportResponse, error) {
+	query := url.Values{}
+	if !at.IsZero() {
+		query.Set("at", at.Format(time.RFC3339))
+	}
+	var report api.ReportResponse
+	err := c.do(http.MethodGet, "/api/reports/"+url.PathEscape(period)+"?"+query.Encode(), nil, &report)
+	return &report, err
+}
+
+func (c *Client) OccupancyProfile(lotID string) (*api.OccupancyProfileResponse, error) {
+	var profile api.OccupancyProfileResponse
+	err := c.do(http.MethodGet, "/api/lots/"+url.PathEscape(lotID)+"/occupancy/profile", nil, &profile)
+	return &profile, err
+}
+
+// OccupancyForecasts forecasts one lot, or every lot when lotID is empty
+func (c *Client) OccupancyForecasts(lotID string, hours int) ([]api.OccupancyForecastResponse, error) {
+	query := "?hours=" + strconv.Itoa(hours)
+	if lotID == "" {
+		var forecasts []api.OccupancyForecastResponse
+		err := c.do(http.MethodGet, "/api/occupancy/forecasts"+query, nil, &forecasts)
+		return forecasts, err
+	}
+	var forecast api.OccupancyForecastResponse
+	if err := c.do(http.MethodGet, "/api/lots/"+url.PathEscape(lotID)+"/occupancy/forecast"+query, nil, &forecast); err != nil {
+		return nil, err
+	}
+	return []api.OccupancyForecastResponse{forecast}, nil
+}
+
+func (c *Client) PoliceSearch(color, make string) ([]api.VehicleInvestigationResponse, error) {
+	query := url.Values{}
+	query.Set("color", color)
+	query.Set("make", make)
+
+	var vehicles []api.VehicleInvestigationResponse
+	err := c.do(http.MethodGet, "/api/police/vehicles?"+query.Encode(), nil, &vehicles)
+	return vehicles, err
+}
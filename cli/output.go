@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats accepted by --output
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// result is what every command produces: the typed API value for JSON, and
+// the same data flattened into rows for table and CSV output
+type result struct {
+	value   interface{}
+	headers []string
+	rows    [][]string
+}
+
+func validOutput(format string) bool {
+	return format == OutputTable || format == OutputJSON || format == OutputCSV
+}
+
+func render(w io.Writer, format string, res *result) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(res.value)
+	case OutputCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(res.headers); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(res.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		if len(res.rows) == 0 {
+			_, err := fmt.Fprintln(w, "(no results)")
+			return err
+		}
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(res.headers, "\t"))
+		for _, row := range res.rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// formatOccupancy prints a share of spaces from 0 to 1 as a percentage
+func formatOccupancy(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
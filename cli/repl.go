@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const replPrompt = "parking> "
+
+// repl reads commands line by line so gate staff can work without
+// retyping global flags; errors are reported and the prompt continues
+func (s *session) repl() error {
+	fmt.Fprintln(s.stdout, "Welcome to Parking Lot System! Type 'help' for commands, 'exit' to quit.")
+
+	scanner := bufio.NewScanner(s.stdin)
+	for {
+		fmt.Fprint(s.stdout, replPrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(s.stdout)
+			return scanner.Err()
+		}
+
+		args, err := splitLine(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(s.stderr, "error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "repl", "serve":
+			fmt.Fprintf(s.stderr, "error: %s is not available inside the prompt\n", args[0])
+			continue
+		}
+
+		if err := s.execute(args); err != nil {
+			fmt.Fprintf(s.stderr, "error: %v\n", err)
+		}
+	}
+}
+
+// splitLine splits a REPL line on whitespace, keeping double-quoted text
+// together so driver names such as "Jane Doe" survive
+func splitLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasToken := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasToken = true
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if hasToken {
+				args = append(args, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteRune(r)
+			hasToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, errors.New("unterminated quote")
+	}
+	if hasToken {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,415 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Changes reports what Apply did to a running service
+type Changes struct {
+	Added   []string `json:"added"`   // New lots, attendants and staff, e.g. "lot LOT2"
+	Updated []string `json:"updated"` // Settings replaced in place: "rates", "default strategy", "payment provider"
+	Ignored []string `json:"ignored"` // Edits that cannot be applied without losing occupancy
+}
+
+// NewParkingService builds a ready service from the configuration
+func (c *Config) NewParkingService() (*services.ParkingService, error) {
+	ps := services.NewParkingService()
+	if _, err := c.Apply(ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Apply brings a running service in line with the configuration without
+// disturbing parked cars. Lots, attendants and staff missing from the service
+// are added and rates, the default strategy, the payment provider, the
+// reservation policy and quotas, the waitlist policy, occupancy thresholds,
+// webhooks and the ticket ID secret are replaced. Existing lots are never rebuilt, and nothing is removed.
+func (c *Config) Apply(ps *services.ParkingService) (*Changes, error) {
+	changes := &Changes{}
+
+	if billing := c.billing(ps.GetBillingService()); billing != nil {
+		current := ps.GetBillingService()
+		ratesChanged := !reflect.DeepEqual(current.DefaultTariff(), billing.DefaultTariff())
+		tariffsChanged := !reflect.DeepEqual(current.LotTariffs(), billing.LotTariffs())
+		pricingChanged := !reflect.DeepEqual(current.GetPricingPolicy(), billing.GetPricingPolicy())
+		penaltiesChanged := !reflect.DeepEqual(current.GetPenaltyPolicy(), billing.GetPenaltyPolicy())
+		if ratesChanged {
+			changes.Updated = append(changes.Updated, "rates")
+		}
+		if tariffsChanged {
+			changes.Updated = append(changes.Updated, "lot tariffs")
+		}
+		if pricingChanged {
+			changes.Updated = append(changes.Updated, "pricing")
+		}
+		if penaltiesChanged {
+			changes.Updated = append(changes.Updated, "penalties")
+		}
+		if ratesChanged || tariffsChanged || pricingChanged || penaltiesChanged {
+			ps.SetBillingService(billing)
+		}
+	}
+
+	if c.Payments != nil {
+		payments := ps.GetPaymentService()
+		if current := payments.Provider(); current == nil || current.Name() != c.Payments.Provider {
+			// Validate accepts only the local provider
+			payments.SetProvider(services.NewLocalPaymentProvider())
+			changes.Updated = append(changes.Updated, "payment provider")
+		}
+	}
+
+	if c.Reservations != nil {
+		policy := services.ReservationPolicy{HoldBefore: c.Reservations.HoldBefore, GracePeriod: c.Reservations.GracePeriod}
+		if policy.GracePeriod == 0 {
+			policy.GracePeriod = services.DefaultReservationPolicy.GracePeriod
+		}
+		reservations := ps.GetReservationService()
+		if reservations.GetPolicy() != policy {
+			reservations.SetPolicy(policy)
+			changes.Updated = append(changes.Updated, "reservation policy")
+		}
+	}
+
+	if c.Waitlist != nil {
+		policy := services.WaitlistPolicy{OfferTimeout: c.Waitlist.OfferTimeout}
+		if policy.OfferTimeout == 0 {
+			policy.OfferTimeout = services.DefaultWaitlistPolicy.OfferTimeout
+		}
+		waitlist := ps.GetWaitlistService()
+		if waitlist.GetPolicy() != policy {
+			waitlist.SetPolicy(policy)
+			changes.Updated = append(changes.Updated, "waitlist policy")
+		}
+	}
+
+	if c.Webhooks != nil {
+		if err := c.Webhooks.apply(ps.GetWebhookService(), changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Tickets != nil {
+		current, ok := ps.GetTicketIDGenerator().(*models.TicketIDs)
+		if !ok || !current.SignedWith(c.Tickets.Secret) {
+			ps.SetTicketIDGenerator(models.NewTicketIDs([]byte(c.Tickets.Secret)))
+			changes.Updated = append(changes.Updated, "ticket ids")
+		}
+	}
+
+	quotas := ps.GetReservationService().LotQuotas()
+	quotasChanged := false
+	for _, lotConfig := range c.Lots {
+		if lotConfig.Reservable == nil {
+			continue
+		}
+		if spaces, ok := quotas[lotConfig.ID]; !ok || spaces != *lotConfig.Reservable {
+			ps.GetReservationService().SetLotQuota(lotConfig.ID, *lotConfig.Reservable)
+			quotasChanged = true
+		}
+	}
+	if quotasChanged {
+		changes.Updated = append(changes.Updated, "reservation quotas")
+	}
+
+	if c.DefaultStrategy != "" {
+		strategy, err := models.ParseStrategy(c.DefaultStrategy)
+		if err != nil {
+			return nil, err
+		}
+		if ps.GetDefaultStrategy().GetStrategyName() != strategy.GetStrategyName() {
+			ps.SetDefaultStrategy(strategy)
+			changes.Updated = append(changes.Updated, "default strategy")
+		}
+	}
+
+	for _, lotConfig := range c.Lots {
+		lot, err := lotConfig.build()
+		if err != nil {
+			return nil, err
+		}
+
+		err = ps.AddLot(lot)
+		if err == nil {
+			changes.Added = append(changes.Added, "lot "+lotConfig.ID)
+			continue
+		}
+		if !errors.Is(err, services.ErrLotExists) {
+			return nil, err
+		}
+		existing, err := ps.GetLotStatus(lotConfig.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(existing.GetSpaceSpecs(), lot.GetSpaceSpecs()) {
+			changes.Ignored = append(changes.Ignored, fmt.Sprintf("lot %s: space changes are not applied to an existing lot", lotConfig.ID))
+		}
+	}
+
+	thresholdsChanged := false
+	for _, lotConfig := range c.Lots {
+		if lotConfig.Thresholds == nil {
+			continue
+		}
+		thresholds := make([]models.OccupancyThreshold, len(lotConfig.Thresholds))
+		for i, threshold := range lotConfig.Thresholds {
+			thresholds[i] = threshold.build().WithDefaults()
+		}
+		sort.SliceStable(thresholds, func(i, j int) bool { return thresholds[i].Occupancy < thresholds[j].Occupancy })
+		lot, err := ps.GetLotStatus(lotConfig.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(lot.GetOccupancyThresholds(), thresholds) {
+			if err := ps.SetOccupancyThresholds(lotConfig.ID, thresholds); err != nil {
+				return nil, err
+			}
+			thresholdsChanged = true
+		}
+	}
+	if thresholdsChanged {
+		changes.Updated = append(changes.Updated, "occupancy thresholds")
+	}
+
+	for _, attendant := range c.Attendants {
+		if ps.FindAttendantByID(attendant.ID) != nil {
+			continue
+		}
+		ps.AddAttendant(models.NewParkingAttendant(attendant.ID, attendant.Name, attendant.Lot))
+		changes.Added = append(changes.Added, "attendant "+attendant.ID)
+	}
+
+	for _, member := range c.SecurityStaff {
+		if ps.FindSecurityStaffByID(member.ID) != nil {
+			continue
+		}
+		ps.AddSecurityStaff(models.NewSecurityStaff(member.ID, member.Name, member.Position))
+		if member.Lot != "" {
+			if err := ps.AssignSecurityToLot(member.ID, member.Lot); err != nil {
+				return nil, err
+			}
+		}
+		changes.Added = append(changes.Added, "security staff "+member.ID)
+	}
+
+	return changes, nil
+}
+
+// apply replaces the retry policy and registers new or edited endpoints
+func (w *WebhooksConfig) apply(webhooks *services.WebhookService, changes *Changes) error {
+	policy := services.WebhookPolicy{
+		MaxAttempts:    w.MaxAttempts,
+		InitialBackoff: w.InitialBackoff,
+		MaxBackoff:     w.MaxBackoff,
+		Timeout:        w.Timeout,
+	}
+	defaults := services.DefaultWebhookPolicy
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = defaults.InitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = defaults.MaxBackoff
+	}
+	if policy.Timeout == 0 {
+		policy.Timeout = defaults.Timeout
+	}
+	if webhooks.GetPolicy() != policy {
+		webhooks.SetPolicy(policy)
+		changes.Updated = append(changes.Updated, "webhook policy")
+	}
+
+	registered := make(map[string]*models.Webhook)
+	for _, webhook := range webhooks.GetWebhooks() {
+		registered[webhook.ID] = webhook
+	}
+	updated := false
+	for _, endpoint := range w.Endpoints {
+		webhook := models.Webhook{
+			ID:     endpoint.ID,
+			URL:    endpoint.URL,
+			Secret: endpoint.Secret,
+			LotIDs: append([]string(nil), endpoint.Lots...),
+			Events: append([]string(nil), endpoint.Events...),
+		}
+		existing, ok := registered[endpoint.ID]
+		if ok && reflect.DeepEqual(*existing, webhook) {
+			continue
+		}
+		if _, err := webhooks.Register(webhook); err != nil {
+			return err
+		}
+		if ok {
+			updated = true
+		} else {
+			changes.Added = append(changes.Added, "webhook "+endpoint.ID)
+		}
+	}
+	if updated {
+		changes.Updated = append(changes.Updated, "webhooks")
+	}
+	return nil
+}
+
+// billing builds the billing service the configuration asks for, or returns
+// nil when it sets no rates, tariffs, pricing or penalties. The current default tariff
+// is kept when the configuration does not name one.
+func (c *Config) billing(current *services.BillingService) *services.BillingService {
+	tariffs := make(map[string]services.Tariff)
+	for _, tariff := range c.Tariffs {
+		tariffs[tariff.Name] = tariff.build()
+	}
+
+	var billing *services.BillingService
+	switch {
+	case c.DefaultTariff != "":
+		billing = services.NewBillingServiceWithTariff(tariffs[c.DefaultTariff])
+	case c.Rates != nil:
+		billing = services.NewBillingService(c.Rates.HourlyRate, c.Rates.MinimumCharge)
+	default:
+		billing = services.NewBillingServiceWithTariff(current.DefaultTariff())
+	}
+
+	configured := false
+	for _, lot := range c.Lots {
+		if lot.Tariff != "" {
+			billing.SetLotTariff(lot.ID, tariffs[lot.Tariff])
+			configured = true
+		}
+	}
+	if c.Pricing != nil {
+		billing.SetPricingPolicy(c.Pricing.build())
+		configured = true
+	}
+	if penalties, ok := c.penalties(); ok {
+		billing.SetPenaltyPolicy(penalties)
+		configured = true
+	}
+	if !configured && c.DefaultTariff == "" && c.Rates == nil {
+		return nil
+	}
+	return billing
+}
+
+func (p *PricingConfig) build() services.PricingPolicy {
+	policy := services.PricingPolicy{
+		AccessibleDiscount: p.AccessibleDiscount,
+		AccessibleExempt:   p.AccessibleExempt,
+	}
+	if len(p.SizeMultipliers) > 0 {
+		policy.SizeMultipliers = make(map[models.VehicleSize]float64)
+		for name, multiplier := range p.SizeMultipliers {
+			// Size names were checked by Validate
+			size, _ := models.ParseVehicleSize(name)
+			policy.SizeMultipliers[size] = multiplier
+		}
+	}
+	return policy
+}
+
+// penalties gathers the penalty settings and per-lot maximum stays
+func (c *Config) penalties() (services.PenaltyPolicy, bool) {
+	var policy services.PenaltyPolicy
+	configured := c.Penalties != nil
+	if configured {
+		policy.LostTicketFee = c.Penalties.LostTicketFee
+		policy.OverstayFee = c.Penalties.OverstayFee
+		policy.OverstayHourlyRate = c.Penalties.OverstayHourlyRate
+	}
+	for _, lot := range c.Lots {
+		if lot.MaxStay > 0 {
+			if policy.MaxStay == nil {
+				policy.MaxStay = make(map[string]time.Duration)
+			}
+			policy.MaxStay[lot.ID] = lot.MaxStay
+			configured = true
+		}
+	}
+	return policy, configured
+}
+
+func (t ThresholdConfig) build() models.OccupancyThreshold {
+	return models.OccupancyThreshold{Occupancy: t.Occupancy, Hysteresis: t.Hysteresis}
+}
+
+func (t TariffConfig) build() services.Tariff {
+	tariff := &services.RuleTariff{
+		TariffName:     t.Name,
+		FirstHour:      t.FirstHour,
+		Increment:      t.Increment,
+		IncrementPrice: t.IncrementPrice,
+		WeekendPrice:   t.WeekendPrice,
+		DailyCap:       t.DailyCap,
+		GracePeriod:    t.GracePeriod,
+	}
+	if t.Night != nil {
+		// Clock times were checked by Validate
+		start, _ := parseClock(t.Night.Start)
+		end, _ := parseClock(t.Night.End)
+		tariff.Night = &services.TimeBand{Start: start, End: end, Price: t.Night.Price}
+	}
+	return tariff
+}
+
+// parseClock turns a clock time such as 22:30 into the offset from midnight
+func parseClock(clock string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time %q, expected HH:MM", clock)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+func (l LotConfig) build() (*models.ParkingLot, error) {
+	switch {
+	case len(l.Levels) > 0:
+		layout := models.LotLayout{}
+		for _, level := range l.Levels {
+			levelLayout := models.LevelLayout{Name: level.Name}
+			for _, row := range level.Rows {
+				specs, err := expandSpaces(row.Spaces)
+				if err != nil {
+					return nil, err
+				}
+				levelLayout.Rows = append(levelLayout.Rows, models.RowLayout{Name: row.Name, Spaces: specs})
+			}
+			layout.Levels = append(layout.Levels, levelLayout)
+		}
+		return models.NewParkingLotWithLayout(l.ID, layout)
+	case len(l.Spaces) > 0:
+		specs, err := expandSpaces(l.Spaces)
+		if err != nil {
+			return nil, err
+		}
+		return models.NewParkingLotWithSpecs(l.ID, specs), nil
+	default:
+		return models.NewParkingLot(l.ID, l.Capacity), nil
+	}
+}
+
+func expandSpaces(groups []SpaceGroup) ([]models.SpaceSpec, error) {
+	var specs []models.SpaceSpec
+	for _, group := range groups {
+		spaceType, err := models.ParseSpaceType(group.Type)
+		if err != nil {
+			return nil, err
+		}
+		count := group.Count
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			specs = append(specs, models.SpaceSpec{Type: spaceType, Accessible: group.Accessible, DistanceToExit: group.DistanceToExit})
+		}
+	}
+	return specs, nil
+}
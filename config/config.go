@@ -0,0 +1,243 @@
+// Package config describes a parking deployment in a YAML or JSON file: its
+// lots and their layouts, billing rates, attendants, security staff and the
+// default parking strategy.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the parsed form of a configuration file. JSON files are read by
+// the same parser, since JSON documents are valid YAML.
+type Config struct {
+	DefaultStrategy string              `yaml:"defaultStrategy"`
+	Rates           *RatesConfig        `yaml:"rates"`
+	DefaultTariff   string              `yaml:"defaultTariff"` // Replaces rates for lots without their own tariff
+	Tariffs         []TariffConfig      `yaml:"tariffs"`
+	Pricing         *PricingConfig      `yaml:"pricing"`
+	Penalties       *PenaltiesConfig    `yaml:"penalties"`
+	Payments        *PaymentsConfig     `yaml:"payments"`
+	Reservations    *ReservationsConfig `yaml:"reservations"`
+	Waitlist        *WaitlistConfig     `yaml:"waitlist"`
+	Webhooks        *WebhooksConfig     `yaml:"webhooks"`
+	Tickets         *TicketsConfig      `yaml:"tickets"`
+	Lots            []LotConfig         `yaml:"lots"`
+	Attendants      []AttendantConfig   `yaml:"attendants"`
+	SecurityStaff   []StaffConfig       `yaml:"securityStaff"`
+
+	root *yaml.Node // Source document, used to place validation errors on lines
+}
+
+type RatesConfig struct {
+	HourlyRate    float64 `yaml:"hourlyRate"`
+	MinimumCharge float64 `yaml:"minimumCharge"`
+}
+
+// TariffConfig is a named rule-based tariff; see services.RuleTariff
+type TariffConfig struct {
+	Name           string        `yaml:"name"`
+	FirstHour      float64       `yaml:"firstHour"`
+	Increment      time.Duration `yaml:"increment"` // e.g. 15m
+	IncrementPrice float64       `yaml:"incrementPrice"`
+	Night          *NightConfig  `yaml:"night"`
+	WeekendPrice   *float64      `yaml:"weekendPrice"`
+	DailyCap       float64       `yaml:"dailyCap"`
+	GracePeriod    time.Duration `yaml:"gracePeriod"`
+}
+
+type NightConfig struct {
+	Start string  `yaml:"start"` // Clock time such as 22:00
+	End   string  `yaml:"end"`
+	Price float64 `yaml:"price"`
+}
+
+// PricingConfig adjusts prices by vehicle; see services.PricingPolicy
+type PricingConfig struct {
+	SizeMultipliers    map[string]float64 `yaml:"sizeMultipliers"` // Keyed by motorcycle, small, medium or large
+	AccessibleDiscount float64            `yaml:"accessibleDiscount"`
+	AccessibleExempt   bool               `yaml:"accessibleExempt"`
+}
+
+// PenaltiesConfig prices lost tickets and overstays; see services.PenaltyPolicy
+type PenaltiesConfig struct {
+	LostTicketFee      float64 `yaml:"lostTicketFee"` // The tariff's daily price when zero
+	OverstayFee        float64 `yaml:"overstayFee"`
+	OverstayHourlyRate float64 `yaml:"overstayHourlyRate"`
+}
+
+// PaymentsConfig names the payment provider. Once set, cars must settle
+// their bill before unparking unless an attendant overrides the check.
+type PaymentsConfig struct {
+	Provider string `yaml:"provider"` // Only "local", which settles payments in process
+}
+
+// ReservationsConfig sets when reserved spaces are held from walk-ins; see
+// services.ReservationPolicy
+type ReservationsConfig struct {
+	HoldBefore  time.Duration `yaml:"holdBefore"`  // How early before the window spaces are held
+	GracePeriod time.Duration `yaml:"gracePeriod"` // How long a no-show keeps its spaces; defaults to 15m
+}
+
+// WaitlistConfig sets how long a queued driver has to take an offered space;
+// see services.WaitlistPolicy
+type WaitlistConfig struct {
+	OfferTimeout time.Duration `yaml:"offerTimeout"` // Defaults to 10m
+}
+
+// TicketsConfig signs ticket IDs so gates can reject forged tickets; see
+// models.TicketIDs
+type TicketsConfig struct {
+	Secret string `yaml:"secret"` // At least 16 characters; IDs carry only a check character when empty
+}
+
+// WebhooksConfig posts lot events to HTTP endpoints and sets how failed
+// deliveries are retried; see services.WebhookService
+type WebhooksConfig struct {
+	MaxAttempts    int             `yaml:"maxAttempts"`    // Defaults to 5
+	InitialBackoff time.Duration   `yaml:"initialBackoff"` // Wait before the first retry, doubled for each later one; defaults to 1s
+	MaxBackoff     time.Duration   `yaml:"maxBackoff"`     // Defaults to 1m
+	Timeout        time.Duration   `yaml:"timeout"`        // Per attempt; defaults to 10s
+	Endpoints      []WebhookConfig `yaml:"endpoints"`
+}
+
+type WebhookConfig struct {
+	ID     string   `yaml:"id"`
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"` // Signs deliveries with HMAC-SHA256; unsigned when empty
+	Lots   []string `yaml:"lots"`   // Every lot when empty
+	Events []string `yaml:"events"` // Event types such as lot_full; every type when empty
+}
+
+// LotConfig describes a lot by exactly one of capacity (untyped spaces),
+// spaces (typed spaces in one run) or levels (a full layout)
+type LotConfig struct {
+	ID       string        `yaml:"id"`
+	Capacity int           `yaml:"capacity"`
+	Spaces   []SpaceGroup  `yaml:"spaces"`
+	Levels   []LevelConfig `yaml:"levels"`
+	Tariff   string        `yaml:"tariff"`  // Name of a tariff; the default when empty
+	MaxStay  time.Duration `yaml:"maxStay"` // Longest stay before overstay penalties; no limit when zero
+
+	Reservable *int              `yaml:"reservable"` // Spaces that may be reserved at once; all of them when unset
+	Thresholds []ThresholdConfig `yaml:"thresholds"` // Occupancy alerts; see models.OccupancyThreshold
+}
+
+// ThresholdConfig is an occupancy alert, as a share of the lot from 0 to 1
+type ThresholdConfig struct {
+	Occupancy  float64 `yaml:"occupancy"`  // e.g. 0.8 for 80%
+	Hysteresis float64 `yaml:"hysteresis"` // How far occupancy must fall to clear the alert; defaults to 0.05
+}
+
+type LevelConfig struct {
+	Name string      `yaml:"name"`
+	Rows []RowConfig `yaml:"rows"`
+}
+
+type RowConfig struct {
+	Name   string       `yaml:"name"`
+	Spaces []SpaceGroup `yaml:"spaces"`
+}
+
+// SpaceGroup is a run of identical spaces
+type SpaceGroup struct {
+	Type           string  `yaml:"type"`
+	Count          int     `yaml:"count"` // Defaults to 1
+	Accessible     bool    `yaml:"accessible"`
+	DistanceToExit float64 `yaml:"distanceToExit"`
+}
+
+type AttendantConfig struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	Lot  string `yaml:"lot"`
+}
+
+type StaffConfig struct {
+	ID       string `yaml:"id"`
+	Name     string `yaml:"name"`
+	Position string `yaml:"position"`
+	Lot      string `yaml:"lot"` // Optional lot assignment
+}
+
+// LoadFile reads, parses and validates a configuration file
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates a configuration document. Unknown fields are
+// rejected so that typos do not silently fall back to defaults.
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	cfg.root = &root
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// line returns the source line of the value at path, a sequence of mapping
+// keys and sequence indexes. Missing elements resolve to the closest parent.
+func (c *Config) line(path ...interface{}) int {
+	if c.root == nil {
+		return 0
+	}
+	node := c.root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, step := range path {
+		next := childNode(node, step)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node.Line
+}
+
+func childNode(node *yaml.Node, step interface{}) *yaml.Node {
+	switch key := step.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case int:
+		if node.Kind == yaml.SequenceNode && key < len(node.Content) {
+			return node.Content[key]
+		}
+	}
+	return nil
+}
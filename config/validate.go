@@ -0,0 +1,349 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+	"sort"
+	"strings"
+)
+
+// ValidationError is a problem with one value of the configuration file
+type ValidationError struct {
+	Line    int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidationErrors collects every problem found, so a file can be fixed in one pass
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+type validator struct {
+	cfg  *Config
+	errs ValidationErrors
+}
+
+func (v *validator) fail(path []interface{}, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{Line: v.cfg.line(path...), Message: fmt.Sprintf(format, args...)})
+}
+
+// at builds a path below base without aliasing base's backing array
+func at(base []interface{}, steps ...interface{}) []interface{} {
+	path := make([]interface{}, 0, len(base)+len(steps))
+	return append(append(path, base...), steps...)
+}
+
+// Validate checks the configuration and returns ValidationErrors when it is invalid
+func (c *Config) Validate() error {
+	v := &validator{cfg: c}
+
+	if c.DefaultStrategy != "" {
+		if _, err := models.ParseStrategy(c.DefaultStrategy); err != nil {
+			v.fail(at(nil, "defaultStrategy"), "%v", err)
+		}
+	}
+
+	if c.Rates != nil {
+		if c.Rates.HourlyRate <= 0 {
+			v.fail(at(nil, "rates", "hourlyRate"), "hourly rate must be positive")
+		}
+		if c.Rates.MinimumCharge < 0 {
+			v.fail(at(nil, "rates", "minimumCharge"), "minimum charge cannot be negative")
+		}
+	}
+
+	tariffs := make(map[string]bool)
+	for i, tariff := range c.Tariffs {
+		path := at(nil, "tariffs", i)
+		switch {
+		case tariff.Name == "":
+			v.fail(path, "tariff name cannot be empty")
+		case tariffs[tariff.Name]:
+			v.fail(at(path, "name"), "duplicate tariff %q", tariff.Name)
+		}
+		tariffs[tariff.Name] = true
+		v.validateTariff(path, tariff)
+	}
+	if c.DefaultTariff != "" {
+		if c.Rates != nil {
+			v.fail(at(nil, "defaultTariff"), "set either rates or defaultTariff, not both")
+		}
+		if !tariffs[c.DefaultTariff] {
+			v.fail(at(nil, "defaultTariff"), "tariff %q is not defined", c.DefaultTariff)
+		}
+	}
+
+	if c.Pricing != nil {
+		for _, name := range sortedKeys(c.Pricing.SizeMultipliers) {
+			path := at(nil, "pricing", "sizeMultipliers", name)
+			if _, err := models.ParseVehicleSize(name); err != nil {
+				v.fail(path, "%v", err)
+			}
+			if c.Pricing.SizeMultipliers[name] < 0 {
+				v.fail(path, "size multiplier cannot be negative")
+			}
+		}
+		if c.Pricing.AccessibleDiscount < 0 || c.Pricing.AccessibleDiscount > 1 {
+			v.fail(at(nil, "pricing", "accessibleDiscount"), "accessible discount must be between 0 and 1")
+		}
+	}
+
+	if p := c.Penalties; p != nil {
+		if p.LostTicketFee < 0 {
+			v.fail(at(nil, "penalties", "lostTicketFee"), "lost ticket fee cannot be negative")
+		}
+		if p.OverstayFee < 0 {
+			v.fail(at(nil, "penalties", "overstayFee"), "overstay fee cannot be negative")
+		}
+		if p.OverstayHourlyRate < 0 {
+			v.fail(at(nil, "penalties", "overstayHourlyRate"), "overstay hourly rate cannot be negative")
+		}
+	}
+
+	if c.Payments != nil && c.Payments.Provider != "local" {
+		v.fail(at(nil, "payments", "provider"), "unknown payment provider %q", c.Payments.Provider)
+	}
+
+	if r := c.Reservations; r != nil {
+		if r.HoldBefore < 0 {
+			v.fail(at(nil, "reservations", "holdBefore"), "hold before cannot be negative")
+		}
+		if r.GracePeriod < 0 {
+			v.fail(at(nil, "reservations", "gracePeriod"), "grace period cannot be negative")
+		}
+	}
+
+	if c.Waitlist != nil && c.Waitlist.OfferTimeout < 0 {
+		v.fail(at(nil, "waitlist", "offerTimeout"), "offer timeout cannot be negative")
+	}
+
+	if w := c.Webhooks; w != nil {
+		v.validateWebhooks(w)
+	}
+
+	if c.Tickets != nil && c.Tickets.Secret != "" && len(c.Tickets.Secret) < 16 {
+		v.fail(at(nil, "tickets", "secret"), "ticket secret must be at least 16 characters")
+	}
+
+	lots := make(map[string]bool)
+	for i, lot := range c.Lots {
+		path := at(nil, "lots", i)
+		switch {
+		case lot.ID == "":
+			v.fail(path, "lot id cannot be empty")
+		case lots[lot.ID]:
+			v.fail(at(path, "id"), "duplicate lot %q", lot.ID)
+		}
+		lots[lot.ID] = true
+		v.validateLot(path, lot)
+		if lot.Tariff != "" && !tariffs[lot.Tariff] {
+			v.fail(at(path, "tariff"), "tariff %q is not defined", lot.Tariff)
+		}
+		if lot.MaxStay < 0 {
+			v.fail(at(path, "maxStay"), "maximum stay cannot be negative")
+		}
+		if lot.Reservable != nil && *lot.Reservable < 0 {
+			v.fail(at(path, "reservable"), "reservable spaces cannot be negative")
+		}
+		for j, threshold := range lot.Thresholds {
+			if err := threshold.build().Validate(); err != nil {
+				v.fail(at(path, "thresholds", j), "%v", err)
+			}
+		}
+	}
+
+	attendants := make(map[string]bool)
+	for i, attendant := range c.Attendants {
+		path := at(nil, "attendants", i)
+		switch {
+		case attendant.ID == "":
+			v.fail(path, "attendant id cannot be empty")
+		case attendants[attendant.ID]:
+			v.fail(at(path, "id"), "duplicate attendant %q", attendant.ID)
+		}
+		attendants[attendant.ID] = true
+		if attendant.Name == "" {
+			v.fail(path, "attendant name cannot be empty")
+		}
+		if !lots[attendant.Lot] {
+			v.fail(at(path, "lot"), "attendant lot %q is not defined", attendant.Lot)
+		}
+	}
+
+	staff := make(map[string]bool)
+	for i, member := range c.SecurityStaff {
+		path := at(nil, "securityStaff", i)
+		switch {
+		case member.ID == "":
+			v.fail(path, "security staff id cannot be empty")
+		case staff[member.ID]:
+			v.fail(at(path, "id"), "duplicate security staff %q", member.ID)
+		}
+		staff[member.ID] = true
+		if member.Name == "" {
+			v.fail(path, "security staff name cannot be empty")
+		}
+		if member.Lot != "" && !lots[member.Lot] {
+			v.fail(at(path, "lot"), "security staff lot %q is not defined", member.Lot)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *validator) validateTariff(path []interface{}, tariff TariffConfig) {
+	prices := map[string]float64{
+		"firstHour":      tariff.FirstHour,
+		"incrementPrice": tariff.IncrementPrice,
+		"dailyCap":       tariff.DailyCap,
+	}
+	if tariff.WeekendPrice != nil {
+		prices["weekendPrice"] = *tariff.WeekendPrice
+	}
+	for _, field := range []string{"firstHour", "incrementPrice", "weekendPrice", "dailyCap"} {
+		if price, ok := prices[field]; ok && price < 0 {
+			v.fail(at(path, field), "%s cannot be negative", field)
+		}
+	}
+	if tariff.Increment < 0 {
+		v.fail(at(path, "increment"), "increment cannot be negative")
+	}
+	if tariff.GracePeriod < 0 {
+		v.fail(at(path, "gracePeriod"), "grace period cannot be negative")
+	}
+
+	if night := tariff.Night; night != nil {
+		if _, err := parseClock(night.Start); err != nil {
+			v.fail(at(path, "night", "start"), "%v", err)
+		}
+		if _, err := parseClock(night.End); err != nil {
+			v.fail(at(path, "night", "end"), "%v", err)
+		}
+		if night.Price < 0 {
+			v.fail(at(path, "night", "price"), "night price cannot be negative")
+		}
+	}
+}
+
+func (v *validator) validateWebhooks(w *WebhooksConfig) {
+	if w.MaxAttempts < 0 {
+		v.fail(at(nil, "webhooks", "maxAttempts"), "max attempts cannot be negative")
+	}
+	if w.InitialBackoff < 0 {
+		v.fail(at(nil, "webhooks", "initialBackoff"), "initial backoff cannot be negative")
+	}
+	if w.MaxBackoff < 0 {
+		v.fail(at(nil, "webhooks", "maxBackoff"), "max backoff cannot be negative")
+	}
+	if w.Timeout < 0 {
+		v.fail(at(nil, "webhooks", "timeout"), "timeout cannot be negative")
+	}
+
+	ids := make(map[string]bool)
+	for i, endpoint := range w.Endpoints {
+		path := at(nil, "webhooks", "endpoints", i)
+		switch {
+		case endpoint.ID == "":
+			v.fail(path, "webhook id cannot be empty")
+		case ids[endpoint.ID]:
+			v.fail(at(path, "id"), "duplicate webhook %q", endpoint.ID)
+		}
+		ids[endpoint.ID] = true
+		if parsed, err := url.Parse(endpoint.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			v.fail(at(path, "url"), "webhook URL must be an http or https URL")
+		}
+		for j, name := range endpoint.Events {
+			if _, err := events.ParseType(name); err != nil {
+				v.fail(at(path, "events", j), "%v", err)
+			}
+		}
+	}
+}
+
+func (v *validator) validateLot(path []interface{}, lot LotConfig) {
+	sources := 0
+	for _, set := range []bool{lot.Capacity != 0, len(lot.Spaces) > 0, len(lot.Levels) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		v.fail(path, "lot %q must set exactly one of capacity, spaces or levels", lot.ID)
+		return
+	}
+
+	if lot.Capacity < 0 {
+		v.fail(at(path, "capacity"), "capacity must be positive")
+	}
+	v.validateSpaces(at(path, "spaces"), lot.Spaces)
+
+	levels := make(map[string]bool)
+	for i, level := range lot.Levels {
+		levelPath := at(path, "levels", i)
+		switch {
+		case level.Name == "":
+			v.fail(levelPath, "level name cannot be empty")
+		case levels[level.Name]:
+			v.fail(at(levelPath, "name"), "duplicate level %q", level.Name)
+		}
+		levels[level.Name] = true
+		if len(level.Rows) == 0 {
+			v.fail(levelPath, "level %q must have at least one row", level.Name)
+		}
+
+		rows := make(map[string]bool)
+		for j, row := range level.Rows {
+			rowPath := at(levelPath, "rows", j)
+			switch {
+			case row.Name == "":
+				v.fail(rowPath, "row name cannot be empty")
+			case rows[row.Name]:
+				v.fail(at(rowPath, "name"), "duplicate row %q on level %q", row.Name, level.Name)
+			}
+			rows[row.Name] = true
+			if len(row.Spaces) == 0 {
+				v.fail(rowPath, "row %q must have at least one space", row.Name)
+			}
+			v.validateSpaces(at(rowPath, "spaces"), row.Spaces)
+		}
+	}
+}
+
+func (v *validator) validateSpaces(path []interface{}, groups []SpaceGroup) {
+	for i, group := range groups {
+		groupPath := at(path, i)
+		if _, err := models.ParseSpaceType(group.Type); err != nil {
+			v.fail(at(groupPath, "type"), "%v", err)
+		}
+		if group.Count < 0 {
+			v.fail(at(groupPath, "count"), "space count cannot be negative")
+		}
+		if group.DistanceToExit < 0 {
+			v.fail(at(groupPath, "distanceToExit"), "distance to exit cannot be negative")
+		}
+	}
+}
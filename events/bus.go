@@ -0,0 +1,173 @@
+package events
+
+import (
+	"parking-lot-system/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuffer is how many events a subscriber may fall behind by before
+// further events to it are dropped
+const DefaultBuffer = 256
+
+// Filter picks the events a subscriber receives
+type Filter struct {
+	LotIDs []string // Every lot when empty
+	Types  []Type   // Every type when empty
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(event Event) bool {
+	return (len(f.LotIDs) == 0 || containsString(f.LotIDs, event.LotID)) &&
+		(len(f.Types) == 0 || containsType(f.Types, event.Type))
+}
+
+// Bus delivers published events to its subscribers. Delivery is
+// asynchronous: each subscriber has its own buffered queue and goroutine, so
+// Publish never waits on a handler and a slow subscriber only loses its own
+// events once its buffer is full.
+type Bus struct {
+	subscribers []*Subscription
+	clock       models.Clock // Stamps published events; SystemClock when nil
+	mu          sync.RWMutex // Guards subscribers and clock; held while queueing so unsubscribing cannot race a send
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// SetClock stamps events published from now on with the time on clock
+func (b *Bus) SetClock(clock models.Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = clock
+}
+
+func (b *Bus) now() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock.Now()
+}
+
+// Subscription is one subscriber's queue of events
+type Subscription struct {
+	bus     *Bus
+	filter  Filter
+	queue   chan Event
+	done    chan struct{}
+	dropped uint64
+	once    sync.Once
+
+	overflow func(Event) // Called with each event dropped because the queue was full
+}
+
+// Subscribe calls handler, on a goroutine of its own, for every later event
+// matching the filter, in the order they were published
+func (b *Bus) Subscribe(filter Filter, handler func(Event)) *Subscription {
+	return b.SubscribeBuffered(filter, DefaultBuffer, handler)
+}
+
+// SubscribeBuffered is Subscribe with a queue of the given size
+func (b *Bus) SubscribeBuffered(filter Filter, buffer int, handler func(Event)) *Subscription {
+	return b.SubscribeWithOverflow(filter, buffer, handler, nil)
+}
+
+// SubscribeWithOverflow is SubscribeBuffered that hands each event dropped
+// from a full queue to overflow instead of losing it. overflow runs on the
+// publisher's goroutine, so it must be quick and must not publish.
+func (b *Bus) SubscribeWithOverflow(filter Filter, buffer int, handler func(Event), overflow func(Event)) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	sub := &Subscription{
+		bus:      b,
+		filter:   filter,
+		queue:    make(chan Event, buffer),
+		done:     make(chan struct{}),
+		overflow: overflow,
+	}
+	go func() {
+		defer close(sub.done)
+		for event := range sub.queue {
+			handler(event)
+		}
+	}()
+
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, sub)
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish stamps the event with the current time and queues it for every
+// matching subscriber without blocking
+func (b *Bus) Publish(eventType Type, lotID string, payload interface{}) {
+	b.PublishEvent(Event{Type: eventType, LotID: lotID, At: b.now(), Payload: payload})
+}
+
+// PublishEvent queues an event that is already stamped
+func (b *Bus) PublishEvent(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+			if sub.overflow != nil {
+				sub.overflow(event)
+			}
+		}
+	}
+}
+
+// Unsubscribe stops new events reaching the subscriber. Events already
+// queued are still handled; Done is closed once they have been.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		for i, sub := range s.bus.subscribers {
+			if sub == s {
+				s.bus.subscribers = append(s.bus.subscribers[:i], s.bus.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(s.queue)
+		s.bus.mu.Unlock()
+	})
+}
+
+// Done is closed when the subscriber has handled its last event after Unsubscribe
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped counts the events lost because the subscriber's queue was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(types []Type, eventType Type) bool {
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"fmt"
+	"parking-lot-system/models"
+	"time"
+)
+
+// Type names what happened; subscribers filter on it
+type Type string
+
+const (
+	CarParked               Type = "car_parked"
+	CarUnparked             Type = "car_unparked"
+	TicketIssued            Type = "ticket_issued"
+	BillGenerated           Type = "bill_generated"
+	LotFull                 Type = "lot_full"
+	LotAvailable            Type = "lot_available"
+	ThresholdCrossed        Type = "threshold_crossed"
+	AttendantAssigned       Type = "attendant_assigned"
+	SuspiciousPlateDetected Type = "suspicious_plate_detected"
+)
+
+// Types lists every event type
+func Types() []Type {
+	return []Type{
+		CarParked, CarUnparked, TicketIssued, BillGenerated, LotFull,
+		LotAvailable, ThresholdCrossed, AttendantAssigned, SuspiciousPlateDetected,
+	}
+}
+
+// ParseType accepts the name of an event type, such as lot_full
+func ParseType(name string) (Type, error) {
+	for _, eventType := range Types() {
+		if string(eventType) == name {
+			return eventType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type %q", name)
+}
+
+// Event is one thing that happened in a lot. Payload holds the struct of the
+// same name as Type, e.g. a CarParkedPayload for CarParked.
+type Event struct {
+	Type    Type        `json:"type"`
+	LotID   string      `json:"lotId,omitempty"` // Empty for events not tied to one lot
+	At      time.Time   `json:"at"`
+	Payload interface{} `json:"payload"`
+}
+
+// CarParkedPayload is the vehicle and every space it took
+type CarParkedPayload struct {
+	Car      models.Car `json:"car"`
+	SpaceIDs []int      `json:"spaceIds"`
+}
+
+// CarUnparkedPayload is the vehicle and every space it freed
+type CarUnparkedPayload struct {
+	Car      models.Car `json:"car"`
+	SpaceIDs []int      `json:"spaceIds"`
+}
+
+// TicketIssuedPayload is the ticket as issued
+type TicketIssuedPayload struct {
+	Ticket models.ParkingTicket `json:"ticket"`
+}
+
+// BillGeneratedPayload summarises a bill; the full bill stays with the
+// billing and payment services
+type BillGeneratedPayload struct {
+	TicketID     string  `json:"ticketId"`
+	LicensePlate string  `json:"licensePlate"`
+	TotalAmount  float64 `json:"totalAmount"`
+	Tariff       string  `json:"tariff"`
+	LostTicket   bool    `json:"lostTicket"`
+	PermitID     string  `json:"permitId,omitempty"`
+}
+
+// LotFullPayload and LotAvailablePayload give the lot's occupancy at the change
+type LotFullPayload struct {
+	Capacity        int `json:"capacity"`
+	AvailableSpaces int `json:"availableSpaces"`
+}
+
+type LotAvailablePayload struct {
+	Capacity        int `json:"capacity"`
+	AvailableSpaces int `json:"availableSpaces"`
+}
+
+// ThresholdCrossedPayload is an occupancy alert threshold being crossed;
+// occupancy and threshold are fractions of the lot's capacity
+type ThresholdCrossedPayload struct {
+	Threshold float64 `json:"threshold"`
+	Occupancy float64 `json:"occupancy"`
+	Rising    bool    `json:"rising"` // False when occupancy fell back below the threshold
+}
+
+// AttendantAssignedPayload is an attendant taking on a lot or, when
+// LicensePlate is set, parking a car
+type AttendantAssignedPayload struct {
+	AttendantID   string `json:"attendantId"`
+	AttendantName string `json:"attendantName"`
+	LicensePlate  string `json:"licensePlate,omitempty"`
+	SpaceID       string `json:"spaceId,omitempty"`
+}
+
+// SuspiciousPlateDetectedPayload is a car parked with a plate that looks fraudulent
+type SuspiciousPlateDetectedPayload struct {
+	LicensePlate string `json:"licensePlate"`
+	SpaceIDs     []int  `json:"spaceIds"`
+}
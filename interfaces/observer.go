@@ -2,12 +2,20 @@ package interfaces
 
 import "fmt"
 
-// Observer pattern for parking lot notifications
+// Observer pattern for parking lot notifications. The parking service also
+// publishes these, and every other lot event, on its events.Bus.
 type ParkingLotObserver interface {
 	OnLotFull(lotID string)
 	OnLotAvailable(lotID string)
 }
 
+// ThresholdObserver is a ParkingLotObserver that also wants occupancy
+// threshold alerts. Threshold and occupancy are shares of the lot from 0 to
+// 1; rising is false when occupancy has fallen back below the threshold.
+type ThresholdObserver interface {
+	OnThresholdCrossed(lotID string, threshold, occupancy float64, rising bool)
+}
+
 // Owner observer implementation
 type OwnerObserver struct {
 	OwnerName string
@@ -27,6 +35,14 @@ func (o *OwnerObserver) OnLotAvailable(lotID string) {
 	fmt.Printf("📢 OWNER NOTIFICATION: Parking lot %s has space available! Remove the full sign.\n", lotID)
 }
 
+func (o *OwnerObserver) OnThresholdCrossed(lotID string, threshold, occupancy float64, rising bool) {
+	if rising {
+		fmt.Printf("📢 OWNER NOTIFICATION: Parking lot %s is %.0f%% occupied, past the %.0f%% alert.\n", lotID, occupancy*100, threshold*100)
+	} else {
+		fmt.Printf("📢 OWNER NOTIFICATION: Parking lot %s is back to %.0f%% occupied, clearing the %.0f%% alert.\n", lotID, occupancy*100, threshold*100)
+	}
+}
+
 // NEW: Security observer implementation
 type SecurityObserver struct {
 	SecurityStaffName string
@@ -49,3 +65,12 @@ func (s *SecurityObserver) OnLotAvailable(lotID string) {
 	fmt.Printf("✅ SECURITY UPDATE: Parking lot %s has available spaces. Normal traffic flow resumed.\n", lotID)
 	fmt.Printf("Security Staff %s (ID: %s) - Return to regular positions.\n", s.SecurityStaffName, s.StaffID)
 }
+
+func (s *SecurityObserver) OnThresholdCrossed(lotID string, threshold, occupancy float64, rising bool) {
+	if rising {
+		fmt.Printf("🚨 SECURITY ALERT: Parking lot %s is %.0f%% occupied, past the %.0f%% alert.\n", lotID, occupancy*100, threshold*100)
+		fmt.Printf("Security Staff %s (ID: %s) - Prepare to manage overflow traffic.\n", s.SecurityStaffName, s.StaffID)
+	} else {
+		fmt.Printf("✅ SECURITY UPDATE: Parking lot %s is back to %.0f%% occupied, clearing the %.0f%% alert.\n", lotID, occupancy*100, threshold*100)
+	}
+}
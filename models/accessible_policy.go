@@ -0,0 +1,31 @@
+package models
+
+// AccessiblePolicy decides when cars without a handicap permit may use
+// accessible spaces. The zero value keeps accessible spaces for permit
+// holders only.
+type AccessiblePolicy struct {
+	// AllowOverflow lets other cars take accessible spaces once the lot is nearly full
+	AllowOverflow bool
+	// OverflowThreshold is the share of occupied spaces, from 0 to 1, at which overflow starts
+	OverflowThreshold float64
+}
+
+// NearestAccessibleSpace finds the free accessible space closest to an exit
+// across all lots. Ties go to the earlier lot, then the lower space.
+func NearestAccessibleSpace(lots []*ParkingLot, car *Car) (*ParkingLot, *ParkingSpace) {
+	var bestLot *ParkingLot
+	var bestSpace *ParkingSpace
+
+	for _, lot := range lots {
+		space := lot.NearestAccessibleSpace(car)
+		if space == nil {
+			continue
+		}
+		if bestSpace == nil || space.DistanceToExit < bestSpace.DistanceToExit {
+			bestLot = lot
+			bestSpace = space
+		}
+	}
+
+	return bestLot, bestSpace
+}
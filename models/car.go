@@ -1,11 +1,16 @@
 package models
 
+import (
+	"strings"
+)
+
 type VehicleSize int
 
 const (
-	SmallVehicle  VehicleSize = iota // 0
-	MediumVehicle                    // 1
-	LargeVehicle                     // 2
+	SmallVehicle      VehicleSize = iota // 0
+	MediumVehicle                        // 1
+	LargeVehicle                         // 2
+	MotorcycleVehicle                    // 3
 )
 
 type Car struct {
@@ -15,6 +20,7 @@ type Car struct {
 	IsHandicap   bool
 	Color        string
 	Make         string
+	SpacesNeeded int // Contiguous spaces the vehicle occupies, e.g. 3 for a bus
 }
 
 func NewCar(licensePlate, driverName string) *Car {
@@ -25,6 +31,7 @@ func NewCar(licensePlate, driverName string) *Car {
 		IsHandicap:   false,
 		Color:        "Unknown",
 		Make:         "Unknown",
+		SpacesNeeded: 1,
 	}
 }
 
@@ -32,6 +39,18 @@ func (c *Car) SetVehicleSize(size VehicleSize) {
 	c.Size = size
 }
 
+func (c *Car) SetSpacesNeeded(spaces int) {
+	c.SpacesNeeded = spaces
+}
+
+// RequiredSpaces returns how many contiguous spaces the car takes, at least one
+func (c *Car) RequiredSpaces() int {
+	if c.SpacesNeeded < 1 {
+		return 1
+	}
+	return c.SpacesNeeded
+}
+
 func (c *Car) SetHandicapStatus(isHandicap bool) {
 	c.IsHandicap = isHandicap
 }
@@ -44,11 +63,29 @@ func (c *Car) GetVehicleSizeString() string {
 		return "Medium" // FIXED: Correct return value for MediumVehicle
 	case LargeVehicle:
 		return "Large"
+	case MotorcycleVehicle:
+		return "Motorcycle"
 	default:
 		return "Medium" // FIXED: Default fallback to Medium
 	}
 }
 
+// ParseVehicleSize returns the size with the given name: motorcycle, small, medium or large
+func ParseVehicleSize(name string) (VehicleSize, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "motorcycle":
+		return MotorcycleVehicle, nil
+	case "small":
+		return SmallVehicle, nil
+	case "medium":
+		return MediumVehicle, nil
+	case "large":
+		return LargeVehicle, nil
+	default:
+		return 0, invalid("unknown vehicle size %q", name)
+	}
+}
+
 func (c *Car) SetColor(color string) {
 	c.Color = color
 }
@@ -7,20 +7,22 @@ type CarLocation struct {
 	Car         *Car
 	LotID       string
 	SpaceID     string
+	Level       string
 	Row         string
 	Position    int
 	ParkedAt    time.Time
 	AttendantID string
 }
 
-func NewCarLocation(car *Car, lotID, spaceID, row string, position int, attendantID string) *CarLocation {
+// NewCarLocation places a car parked at parkedAt
+func NewCarLocation(car *Car, lotID, spaceID, row string, position int, attendantID string, parkedAt time.Time) *CarLocation {
 	return &CarLocation{
 		Car:         car,
 		LotID:       lotID,
 		SpaceID:     spaceID,
 		Row:         row,
 		Position:    position,
-		ParkedAt:    time.Now(),
+		ParkedAt:    parkedAt,
 		AttendantID: attendantID,
 	}
 }
@@ -30,6 +32,7 @@ func (cl *CarLocation) GetLocationInfo() map[string]interface{} {
 		"LicensePlate": cl.Car.LicensePlate,
 		"LotID":        cl.LotID,
 		"SpaceID":      cl.SpaceID,
+		"Level":        cl.Level,
 		"Row":          cl.Row,
 		"Position":     cl.Position,
 		"ParkedAt":     cl.ParkedAt,
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells lots, services and stores the time, so a FakeClock can stand
+// in for the system clock in tests and simulations
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock reads the machine's clock; it is the clock of everything not given another
+var SystemClock Clock = systemClock{}
+
+// FakeClock is a Clock that moves only when told to, so hours or days of
+// parking can pass instantly
+type FakeClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d and returns the new time
+func (c *FakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set moves the clock to t, backwards if need be
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// clockNow reads clock, falling back to the system clock for values built
+// without one
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure. Every error the models and services return for a bad
+// request or a state that refuses it is of one kind, so callers such as the
+// HTTP API tell them apart with errors.Is instead of by their wording.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflicts with the current state")
+	ErrInvalid  = errors.New("invalid request")
+)
+
+// Failures callers commonly check for
+var (
+	ErrLotFull              = NewError(ErrConflict, "parking lot is full")
+	ErrSpaceNotFound        = NewError(ErrNotFound, "parking space not found")
+	ErrSpaceOccupied        = NewError(ErrConflict, "parking space is already occupied")
+	ErrAlreadyParked        = NewError(ErrConflict, "vehicle is already parked in this lot")
+	ErrNoLots               = NewError(ErrConflict, "no parking lots available")
+	ErrNoContiguousSpaces   = NewError(ErrConflict, "no available contiguous spaces for this vehicle")
+	ErrOnlyReservedSpaces   = NewError(ErrConflict, "no available space outside reserved capacity")
+	ErrAttendantInactive    = NewError(ErrConflict, "attendant is not active")
+	ErrInvalidTicketID      = NewError(ErrInvalid, "ticket id is not in the TK- format")
+	ErrTicketNotFound       = NewError(ErrNotFound, "ticket not found")
+	ErrActiveTicketNotFound = NewError(ErrNotFound, "active ticket not found")
+)
+
+// kindError is a failure of one kind, worded for people
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// NewError returns an error reading message that errors.Is matches to kind
+func NewError(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return NewError(ErrNotFound, fmt.Sprintf(format, args...))
+}
+
+func conflict(format string, args ...interface{}) error {
+	return NewError(ErrConflict, fmt.Sprintf(format, args...))
+}
+
+func invalid(format string, args ...interface{}) error {
+	return NewError(ErrInvalid, fmt.Sprintf(format, args...))
+}
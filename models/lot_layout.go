@@ -0,0 +1,119 @@
+package models
+
+// LotLayout is the physical arrangement of a lot: levels hold rows, and rows
+// hold spaces at positions numbered from 1. Spaces are numbered across the
+// whole lot in level, row, position order.
+type LotLayout struct {
+	Levels []LevelLayout `json:"levels"`
+}
+
+type LevelLayout struct {
+	Name string      `json:"name"`
+	Rows []RowLayout `json:"rows"`
+}
+
+type RowLayout struct {
+	Name   string      `json:"name"`
+	Spaces []SpaceSpec `json:"spaces"`
+}
+
+// Spaces in untyped lots are grouped into lettered rows of this many positions
+const defaultRowLength = 25
+
+func (l LotLayout) Validate() error {
+	if len(l.Levels) == 0 {
+		return invalid("layout must have at least one level")
+	}
+
+	levels := make(map[string]bool)
+	for _, level := range l.Levels {
+		if level.Name == "" {
+			return invalid("level name cannot be empty")
+		}
+		if levels[level.Name] {
+			return invalid("duplicate level %q", level.Name)
+		}
+		levels[level.Name] = true
+
+		if len(level.Rows) == 0 {
+			return invalid("level %q must have at least one row", level.Name)
+		}
+		rows := make(map[string]bool)
+		for _, row := range level.Rows {
+			if row.Name == "" {
+				return invalid("row name cannot be empty on level %q", level.Name)
+			}
+			if rows[row.Name] {
+				return invalid("duplicate row %q on level %q", row.Name, level.Name)
+			}
+			rows[row.Name] = true
+
+			if len(row.Spaces) == 0 {
+				return invalid("row %q on level %q must have at least one space", row.Name, level.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Specs flattens the layout into one spec per space, in space number order
+func (l LotLayout) Specs() []SpaceSpec {
+	var specs []SpaceSpec
+	for _, level := range l.Levels {
+		for _, row := range level.Rows {
+			for i, spec := range row.Spaces {
+				spec.Level = level.Name
+				spec.Row = row.Name
+				spec.Position = i + 1
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return specs
+}
+
+// NewParkingLotWithLayout creates a lot whose spaces follow the layout
+func NewParkingLotWithLayout(id string, layout LotLayout) (*ParkingLot, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	return NewParkingLotWithSpecs(id, layout.Specs()), nil
+}
+
+// GetLayout rebuilds the lot's layout from its spaces
+func (pl *ParkingLot) GetLayout() LotLayout {
+	var layout LotLayout
+	for _, spec := range pl.GetSpaceSpecs() {
+		levels := layout.Levels
+		if len(levels) == 0 || levels[len(levels)-1].Name != spec.Level {
+			layout.Levels = append(layout.Levels, LevelLayout{Name: spec.Level})
+		}
+		level := &layout.Levels[len(layout.Levels)-1]
+
+		if len(level.Rows) == 0 || level.Rows[len(level.Rows)-1].Name != spec.Row {
+			level.Rows = append(level.Rows, RowLayout{Name: spec.Row})
+		}
+		row := &level.Rows[len(level.Rows)-1]
+
+		spec.Level, spec.Row, spec.Position = "", "", 0
+		row.Spaces = append(row.Spaces, spec)
+	}
+	return layout
+}
+
+// defaultLocation places a space of an untyped lot: one unnamed level with
+// rows A, B, C... of 25 spaces each, as the lot was always described
+func defaultLocation(spaceID int) (row string, position int) {
+	index := (spaceID - 1) / defaultRowLength
+	return rowLabel(index), (spaceID-1)%defaultRowLength + 1
+}
+
+// rowLabel returns A..Z, then AA, AB and so on
+func rowLabel(index int) string {
+	label := ""
+	for index >= 0 {
+		label = string(rune('A'+index%26)) + label
+		index = index/26 - 1
+	}
+	return label
+}
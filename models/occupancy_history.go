@@ -0,0 +1,191 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// OccupancySample is how full a lot was at one instant
+type OccupancySample struct {
+	LotID          string    `json:"lotId"`
+	At             time.Time `json:"at"`
+	OccupiedSpaces int       `json:"occupiedSpaces"`
+	TotalSpaces    int       `json:"totalSpaces"`
+}
+
+// SampleOccupancy reads the lot's occupancy as of at
+func SampleOccupancy(lot *ParkingLot, at time.Time) OccupancySample {
+	return OccupancySample{LotID: lot.ID, At: at, OccupiedSpaces: lot.GetOccupiedSpaces(), TotalSpaces: lot.Capacity}
+}
+
+// Occupancy is the share of the lot's spaces in use, from 0 to 1
+func (s OccupancySample) Occupancy() float64 {
+	if s.TotalSpaces == 0 {
+		return 0
+	}
+	return float64(s.OccupiedSpaces) / float64(s.TotalSpaces)
+}
+
+// OccupancyBucket aggregates the samples falling in one hour of the day or
+// one day of the week
+type OccupancyBucket struct {
+	Samples          int     `json:"samples"`
+	AverageOccupancy float64 `json:"averageOccupancy"`
+	PeakOccupancy    float64 `json:"peakOccupancy"`
+}
+
+func (b *OccupancyBucket) add(occupancy float64) {
+	b.AverageOccupancy = (b.AverageOccupancy*float64(b.Samples) + occupancy) / float64(b.Samples+1)
+	b.Samples++
+	if occupancy > b.PeakOccupancy {
+		b.PeakOccupancy = occupancy
+	}
+}
+
+// OccupancyProfile is a lot's typical occupancy by hour of the day and day
+// of the week, in the time zone the samples were taken in
+type OccupancyProfile struct {
+	LotID     string              `json:"lotId"`
+	Samples   int                 `json:"samples"`
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	ByHour    [24]OccupancyBucket `json:"byHour"`    // Indexed by hour, 0 to 23
+	ByWeekday [7]OccupancyBucket  `json:"byWeekday"` // Indexed by time.Weekday, Sunday first
+
+	byHourOfWeek [7][24]OccupancyBucket
+}
+
+// BuildOccupancyProfile aggregates a lot's samples
+func BuildOccupancyProfile(lotID string, samples []OccupancySample) *OccupancyProfile {
+	profile := &OccupancyProfile{LotID: lotID}
+	for _, sample := range samples {
+		if profile.Samples == 0 || sample.At.Before(profile.From) {
+			profile.From = sample.At
+		}
+		if sample.At.After(profile.To) {
+			profile.To = sample.At
+		}
+		profile.Samples++
+
+		occupancy := sample.Occupancy()
+		profile.ByHour[sample.At.Hour()].add(occupancy)
+		profile.ByWeekday[sample.At.Weekday()].add(occupancy)
+		profile.byHourOfWeek[sample.At.Weekday()][sample.At.Hour()].add(occupancy)
+	}
+	return profile
+}
+
+// typicalAt is the average occupancy seen at the same hour on the same
+// weekday, or at the same hour on any day when that weekday has no samples
+func (p *OccupancyProfile) typicalAt(t time.Time) (float64, bool) {
+	if bucket := p.byHourOfWeek[t.Weekday()][t.Hour()]; bucket.Samples > 0 {
+		return bucket.AverageOccupancy, true
+	}
+	if bucket := p.ByHour[t.Hour()]; bucket.Samples > 0 {
+		return bucket.AverageOccupancy, true
+	}
+	return 0, false
+}
+
+// Forecast methods, from the most to the least history needed
+const (
+	ForecastSeasonal = "seasonal" // Typical occupancy for the hour, corrected by how far today is from typical
+	ForecastTrend    = "trend"    // The recent rate of filling or emptying, carried forward
+)
+
+// OccupancyForecastPoint is the occupancy predicted for one instant
+type OccupancyForecastPoint struct {
+	At        time.Time `json:"at"`
+	Occupancy float64   `json:"occupancy"`
+}
+
+// OccupancyForecast predicts a lot's occupancy hour by hour and when it
+// will fill
+type OccupancyForecast struct {
+	LotID     string                   `json:"lotId"`
+	Current   OccupancySample          `json:"current"`
+	Method    string                   `json:"method"`
+	Points    []OccupancyForecastPoint `json:"points"`
+	FullAt    *time.Time               `json:"fullAt,omitempty"` // Nil when the lot is not expected to fill within the horizon
+	Threshold float64                  `json:"threshold"`        // Occupancy counted as full
+}
+
+// seasonalHistory is the history a profile needs before it is trusted over
+// the recent trend, so the hour has been seen on an earlier day
+const seasonalHistory = 24 * time.Hour
+
+// forecastDecay is how much of today's gap from typical occupancy carries
+// into each following hour
+const forecastDecay = 0.5
+
+// ForecastOccupancy predicts occupancy every hour from the current sample up
+// to horizon. Given a profile of a day or more it starts from the typical
+// occupancy for each hour and adds today's gap from typical, halving it every
+// hour ahead. Otherwise it extends the trend of the recent samples. The lot
+// counts as full from threshold on; FullAt interpolates between the hourly
+// points.
+func ForecastOccupancy(profile *OccupancyProfile, recent []OccupancySample, current OccupancySample, horizon time.Duration, threshold float64) *OccupancyForecast {
+	forecast := &OccupancyForecast{LotID: current.LotID, Current: current, Method: ForecastSeasonal, Threshold: threshold}
+	now := current.At
+	occupancy := current.Occupancy()
+
+	typicalNow, seasonal := profile.typicalAt(now)
+	seasonal = seasonal && profile.To.Sub(profile.From) >= seasonalHistory
+	var perHour float64
+	if !seasonal {
+		forecast.Method = ForecastTrend
+		perHour = trendPerHour(append(append([]OccupancySample(nil), recent...), current))
+	}
+
+	if occupancy >= threshold {
+		full := now
+		forecast.FullAt = &full
+	}
+	// Fill times interpolate between unclamped predictions, since clamping at
+	// 1 would push them to the next whole hour
+	previous := OccupancyForecastPoint{At: now, Occupancy: occupancy}
+	for ahead := time.Hour; ahead <= horizon; ahead += time.Hour {
+		at := now.Add(ahead)
+		predicted := occupancy + perHour*ahead.Hours()
+		if seasonal {
+			typical, ok := profile.typicalAt(at)
+			if !ok {
+				typical = typicalNow
+			}
+			predicted = typical + (occupancy-typicalNow)*math.Pow(forecastDecay, ahead.Hours())
+		}
+		forecast.Points = append(forecast.Points, OccupancyForecastPoint{At: at, Occupancy: math.Max(0, math.Min(1, predicted))})
+
+		if forecast.FullAt == nil && predicted >= threshold {
+			share := (threshold - previous.Occupancy) / (predicted - previous.Occupancy)
+			full := previous.At.Add(time.Duration(share * float64(time.Hour)))
+			forecast.FullAt = &full
+		}
+		previous = OccupancyForecastPoint{At: at, Occupancy: predicted}
+	}
+	return forecast
+}
+
+// trendPerHour fits a straight line through the samples by least squares
+// and returns its slope in occupancy per hour
+func trendPerHour(samples []OccupancySample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	origin := samples[0].At
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.At.Sub(origin).Hours()
+		y := sample.Occupancy()
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"sort"
+)
+
+// DefaultThresholdHysteresis is the hysteresis of thresholds that set none
+const DefaultThresholdHysteresis = 0.05
+
+// OccupancyThreshold raises an alert once the share of a lot's spaces in use
+// reaches Occupancy. The alert clears only when the share falls below
+// Occupancy minus Hysteresis, so cars coming and going at the boundary do not
+// make it flap.
+type OccupancyThreshold struct {
+	Occupancy  float64 `json:"occupancy"`            // Share of occupied spaces, from 0 to 1
+	Hysteresis float64 `json:"hysteresis,omitempty"` // DefaultThresholdHysteresis when zero
+}
+
+// Validate checks the threshold is a share of the lot its hysteresis can clear
+func (t OccupancyThreshold) Validate() error {
+	if t.Occupancy <= 0 || t.Occupancy > 1 {
+		return invalid("threshold occupancy must be above 0 and at most 1")
+	}
+	if t.Hysteresis < 0 || t.Hysteresis >= t.Occupancy {
+		return invalid("threshold hysteresis must be at least 0 and below its occupancy")
+	}
+	return nil
+}
+
+// WithDefaults fills in the default hysteresis, keeping it below the threshold
+func (t OccupancyThreshold) WithDefaults() OccupancyThreshold {
+	if t.Hysteresis == 0 {
+		t.Hysteresis = DefaultThresholdHysteresis
+		if t.Hysteresis >= t.Occupancy {
+			t.Hysteresis = t.Occupancy / 2
+		}
+	}
+	return t
+}
+
+// thresholdState remembers whether a threshold's alert is raised, so each
+// crossing is reported once. Being full is the threshold at every space with
+// no hysteresis.
+type thresholdState struct {
+	OccupancyThreshold
+	raised bool
+	full   bool // Reported to observers as full and available rather than as a threshold
+}
+
+// update raises or clears the alert for the current occupancy and reports
+// whether it changed
+func (s *thresholdState) update(occupancy float64) bool {
+	if !s.raised && occupancy >= s.Occupancy {
+		s.raised = true
+		return true
+	}
+	if s.raised && occupancy < s.Occupancy-s.Hysteresis {
+		s.raised = false
+		return true
+	}
+	return false
+}
+
+// thresholdCrossing is one alert raised or cleared by a park or unpark
+type thresholdCrossing struct {
+	threshold OccupancyThreshold
+	occupancy float64
+	rising    bool
+	full      bool
+}
+
+// SetOccupancyThresholds replaces the lot's alert thresholds. Alerts for
+// thresholds the lot is already past are raised without notifying anyone.
+func (pl *ParkingLot) SetOccupancyThresholds(thresholds []OccupancyThreshold) error {
+	states := make([]*thresholdState, 0, len(thresholds))
+	for _, threshold := range thresholds {
+		if err := threshold.Validate(); err != nil {
+			return err
+		}
+		states = append(states, &thresholdState{OccupancyThreshold: threshold.WithDefaults()})
+	}
+	sort.SliceStable(states, func(i, j int) bool { return states[i].Occupancy < states[j].Occupancy })
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	occupancy := pl.occupancyLocked()
+	for _, state := range states {
+		state.raised = occupancy >= state.Occupancy
+	}
+	pl.thresholds = append(states, pl.fullState())
+	return nil
+}
+
+// GetOccupancyThresholds returns the lot's alert thresholds, lowest first
+func (pl *ParkingLot) GetOccupancyThresholds() []OccupancyThreshold {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	var thresholds []OccupancyThreshold
+	for _, state := range pl.thresholds {
+		if !state.full {
+			thresholds = append(thresholds, state.OccupancyThreshold)
+		}
+	}
+	return thresholds
+}
+
+// GetRaisedThresholds returns the thresholds whose alerts are raised, lowest first
+func (pl *ParkingLot) GetRaisedThresholds() []OccupancyThreshold {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	var raised []OccupancyThreshold
+	for _, state := range pl.thresholds {
+		if !state.full && state.raised {
+			raised = append(raised, state.OccupancyThreshold)
+		}
+	}
+	return raised
+}
+
+// fullState returns the state of the lot being full, always the last threshold
+func (pl *ParkingLot) fullState() *thresholdState {
+	return pl.thresholds[len(pl.thresholds)-1]
+}
+
+// occupancyLocked is the share of the lot's spaces in use; a lot without
+// spaces counts as full
+func (pl *ParkingLot) occupancyLocked() float64 {
+	if len(pl.Spaces) == 0 {
+		return 1
+	}
+	return float64(len(pl.Spaces)-pl.availableSpacesLocked()) / float64(len(pl.Spaces))
+}
+
+// checkThresholdsLocked updates every threshold for the current occupancy and
+// returns the crossings in the order occupancy passed them
+func (pl *ParkingLot) checkThresholdsLocked() []thresholdCrossing {
+	occupancy := pl.occupancyLocked()
+	var crossings []thresholdCrossing
+	for _, state := range pl.thresholds {
+		if state.update(occupancy) {
+			crossings = append(crossings, thresholdCrossing{
+				threshold: state.OccupancyThreshold, occupancy: occupancy, rising: state.raised, full: state.full,
+			})
+		}
+	}
+	if len(crossings) > 0 && !crossings[0].rising {
+		for i, j := 0, len(crossings)-1; i < j; i, j = i+1, j-1 {
+			crossings[i], crossings[j] = crossings[j], crossings[i]
+		}
+	}
+	return crossings
+}
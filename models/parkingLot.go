@@ -1,16 +1,51 @@
 package models
 
 import (
-	"errors"
 	"parking-lot-system/interfaces"
+	"sync"
+	"time"
 )
 
+// OccupancyListener is told about every space change made through the lot,
+// in the order the changes happened, so they can be made durable
+type OccupancyListener interface {
+	OnSpaceOccupied(lotID string, spaceID int, car *Car, parkedAt time.Time)
+	OnSpaceReleased(lotID string, spaceID int, licensePlate string, releasedAt time.Time)
+}
+
+// VehicleListener is told about each vehicle that parks in or leaves the lot,
+// with every space it takes or frees. Unlike OccupancyListener it is called
+// after the lot is unlocked, so it may park cars or otherwise use the lot.
+type VehicleListener interface {
+	OnVehicleParked(lotID string, spaceIDs []int, car *Car)
+	OnVehicleLeft(lotID string, spaceIDs []int, car *Car)
+}
+
 type ParkingLot struct {
-	ID        string
-	Capacity  int
-	Spaces    []*ParkingSpace
-	observers []interfaces.ParkingLotObserver
-	wasFull   bool // Track previous state to avoid duplicate notifications
+	ID               string
+	Capacity         int
+	Spaces           []*ParkingSpace
+	observers        []interfaces.ParkingLotObserver
+	thresholds       []*thresholdState // Alert thresholds, lowest first; the last is the lot being full
+	listeners        []OccupancyListener
+	vehicleListeners []VehicleListener
+	accessiblePolicy AccessiblePolicy
+	held             int                  // Free spaces kept for reservations; walk-ins cannot take them
+	dedicated        map[int][]dedication // Periods spaces are kept for permit holders, by space ID
+	offered          map[int]offerHold    // Spaces kept for waitlisted cars until their offers run out, by space ID
+	clock            Clock                // Times parks and permit dedications; SystemClock when nil
+	mu               sync.RWMutex
+}
+
+// SpaceSpec describes one space of a lot being built. Spaces without a row
+// get the default lettered rows of an untyped lot.
+type SpaceSpec struct {
+	Type           SpaceType `json:"type"`
+	Accessible     bool      `json:"accessible,omitempty"`
+	DistanceToExit float64   `json:"distanceToExit,omitempty"`
+	Level          string    `json:"level,omitempty"`
+	Row            string    `json:"row,omitempty"`
+	Position       int       `json:"position,omitempty"`
 }
 
 func NewParkingLot(id string, capacity int) *ParkingLot {
@@ -19,8 +54,8 @@ func NewParkingLot(id string, capacity int) *ParkingLot {
 		Capacity:  capacity,
 		Spaces:    make([]*ParkingSpace, capacity),
 		observers: make([]interfaces.ParkingLotObserver, 0),
-		wasFull:   false,
 	}
+	lot.thresholds = []*thresholdState{{OccupancyThreshold: OccupancyThreshold{Occupancy: 1}, full: true}}
 
 	for i := 0; i < capacity; i++ {
 		lot.Spaces[i] = NewParkingSpace(i + 1)
@@ -29,11 +64,79 @@ func NewParkingLot(id string, capacity int) *ParkingLot {
 	return lot
 }
 
+// NewParkingLotWithSpaceTypes creates a lot with one space per entry, numbered
+// from 1 in the given order so neighbouring entries are contiguous spaces
+func NewParkingLotWithSpaceTypes(id string, spaceTypes []SpaceType) *ParkingLot {
+	specs := make([]SpaceSpec, len(spaceTypes))
+	for i, spaceType := range spaceTypes {
+		specs[i] = SpaceSpec{Type: spaceType}
+	}
+	return NewParkingLotWithSpecs(id, specs)
+}
+
+// NewParkingLotWithSpecs creates a lot with one space per spec, numbered from 1 in order
+func NewParkingLotWithSpecs(id string, specs []SpaceSpec) *ParkingLot {
+	lot := NewParkingLot(id, len(specs))
+	for i, spec := range specs {
+		lot.Spaces[i].Type = spec.Type
+		lot.Spaces[i].IsAccessible = spec.Accessible
+		lot.Spaces[i].DistanceToExit = spec.DistanceToExit
+		if spec.Row != "" {
+			lot.Spaces[i].Level = spec.Level
+			lot.Spaces[i].Row = spec.Row
+			lot.Spaces[i].Position = spec.Position
+		}
+	}
+	return lot
+}
+
+// GetSpaceTypes returns the type of every space in space order
+func (pl *ParkingLot) GetSpaceTypes() []SpaceType {
+	types := make([]SpaceType, len(pl.Spaces))
+	for i, space := range pl.Spaces {
+		types[i] = space.Type
+	}
+	return types
+}
+
+// GetSpaceSpecs returns the specs the lot's spaces were built from, in space order
+func (pl *ParkingLot) GetSpaceSpecs() []SpaceSpec {
+	specs := make([]SpaceSpec, len(pl.Spaces))
+	for i, space := range pl.Spaces {
+		specs[i] = SpaceSpec{
+			Type:           space.Type,
+			Accessible:     space.IsAccessible,
+			DistanceToExit: space.DistanceToExit,
+			Level:          space.Level,
+			Row:            space.Row,
+			Position:       space.Position,
+		}
+	}
+	return specs
+}
+
+// SetAccessiblePolicy controls when cars without a handicap permit may use accessible spaces
+func (pl *ParkingLot) SetAccessiblePolicy(policy AccessiblePolicy) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.accessiblePolicy = policy
+}
+
+func (pl *ParkingLot) GetAccessiblePolicy() AccessiblePolicy {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	return pl.accessiblePolicy
+}
+
 func (pl *ParkingLot) AddObserver(observer interfaces.ParkingLotObserver) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
 	pl.observers = append(pl.observers, observer)
 }
 
 func (pl *ParkingLot) RemoveObserver(observer interfaces.ParkingLotObserver) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
 	for i, obs := range pl.observers {
 		if obs == observer {
 			pl.observers = append(pl.observers[:i], pl.observers[i+1:]...)
@@ -42,48 +145,598 @@ func (pl *ParkingLot) RemoveObserver(observer interfaces.ParkingLotObserver) {
 	}
 }
 
-func (pl *ParkingLot) notifyObservers(isFull bool) {
-	for _, observer := range pl.observers {
-		if isFull {
-			observer.OnLotFull(pl.ID)
+// AddOccupancyListener registers a listener called for every park and unpark
+func (pl *ParkingLot) AddOccupancyListener(listener OccupancyListener) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.listeners = append(pl.listeners, listener)
+}
+
+// AddVehicleListener registers a listener called once per vehicle parked or leaving
+func (pl *ParkingLot) AddVehicleListener(listener VehicleListener) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.vehicleListeners = append(pl.vehicleListeners, listener)
+}
+
+func (pl *ParkingLot) vehicleListenerSnapshotLocked() []VehicleListener {
+	listeners := make([]VehicleListener, len(pl.vehicleListeners))
+	copy(listeners, pl.vehicleListeners)
+	return listeners
+}
+
+// runIDsLocked returns the IDs of the spaces a car parked from start on holds
+func (pl *ParkingLot) runIDsLocked(start int, car *Car) []int {
+	spaceIDs := make([]int, 0, car.RequiredSpaces())
+	for _, space := range pl.Spaces[start : start+car.RequiredSpaces()] {
+		spaceIDs = append(spaceIDs, space.ID)
+	}
+	return spaceIDs
+}
+
+// notifyVehicleParked must be called without holding pl.mu
+func (pl *ParkingLot) notifyVehicleParked(listeners []VehicleListener, spaceIDs []int, car *Car) {
+	for _, listener := range listeners {
+		listener.OnVehicleParked(pl.ID, spaceIDs, car)
+	}
+}
+
+// SetClock times the lot's parks and permit dedications with clock
+func (pl *ParkingLot) SetClock(clock Clock) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.clock = clock
+	for _, space := range pl.Spaces {
+		space.SetClock(clock)
+	}
+}
+
+// GetClock returns the lot's clock, SystemClock unless SetClock was called
+func (pl *ParkingLot) GetClock() Clock {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	if pl.clock == nil {
+		return SystemClock
+	}
+	return pl.clock
+}
+
+// RestoreSpace puts a car back into a space with its original arrival time.
+// It is used when rebuilding a lot from storage and does not notify anyone.
+func (pl *ParkingLot) RestoreSpace(spaceID int, car *Car, parkedAt time.Time) error {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	i := pl.indexOfLocked(spaceID)
+	if i < 0 {
+		return ErrSpaceNotFound
+	}
+
+	// Spaces of one multi-space vehicle are restored one at a time, so link
+	// each to its neighbours by plate whatever order they arrive in
+	extension := i > 0 && holdsPlate(pl.Spaces[i-1], car.LicensePlate)
+	if !pl.Spaces[i].occupy(car, parkedAt, extension) {
+		return ErrSpaceOccupied
+	}
+	if i+1 < len(pl.Spaces) && holdsPlate(pl.Spaces[i+1], car.LicensePlate) {
+		pl.Spaces[i+1].setExtension(true)
+	}
+	occupancy := pl.occupancyLocked()
+	for _, state := range pl.thresholds {
+		state.raised = occupancy >= state.Occupancy
+	}
+	return nil
+}
+
+func holdsPlate(space *ParkingSpace, licensePlate string) bool {
+	car := space.GetParkedCar()
+	return car != nil && car.LicensePlate == licensePlate
+}
+
+func (pl *ParkingLot) indexOfLocked(spaceID int) int {
+	for i, space := range pl.Spaces {
+		if space.ID == spaceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordParkedLocked must be called with pl.mu held so listeners see changes in order
+func (pl *ParkingLot) recordParkedLocked(space *ParkingSpace, car *Car) {
+	_, parkedAt := space.GetOccupancy()
+	for _, listener := range pl.listeners {
+		listener.OnSpaceOccupied(pl.ID, space.ID, car, parkedAt)
+	}
+}
+
+// notifyObservers must be called without holding pl.mu so observers may query
+// the lot. Threshold crossings reach only observers that want them.
+func (pl *ParkingLot) notifyObservers(observers []interfaces.ParkingLotObserver, crossings []thresholdCrossing) {
+	for _, crossing := range crossings {
+		for _, observer := range observers {
+			switch {
+			case crossing.full && crossing.rising:
+				observer.OnLotFull(pl.ID)
+			case crossing.full:
+				observer.OnLotAvailable(pl.ID)
+			default:
+				if thresholds, ok := observer.(interfaces.ThresholdObserver); ok {
+					thresholds.OnThresholdCrossed(pl.ID, crossing.threshold.Occupancy, crossing.occupancy, crossing.rising)
+				}
+			}
+		}
+	}
+}
+
+// dedication keeps a space for one permit holder over the permit's validity
+type dedication struct {
+	licensePlate string
+	from, until  time.Time
+}
+
+// DedicateSpace keeps the space for the plate over [from, until). Other cars
+// cannot park in it meanwhile, even when it is free. Dedicating the space to
+// the same plate again replaces the earlier period; a period that is empty or
+// already over withdraws it. Periods that have ended are forgotten.
+func (pl *ParkingLot) DedicateSpace(spaceID int, licensePlate string, from, until time.Time) error {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.indexOfLocked(spaceID) < 0 {
+		return ErrSpaceNotFound
+	}
+	if pl.dedicated == nil {
+		pl.dedicated = make(map[int][]dedication)
+	}
+	now := clockNow(pl.clock)
+	pl.forgetEndedDedicationsLocked(now)
+
+	periods := pl.dedicated[spaceID][:0]
+	for _, existing := range pl.dedicated[spaceID] {
+		if existing.licensePlate != licensePlate {
+			periods = append(periods, existing)
+		}
+	}
+	if until.After(from) && until.After(now) {
+		periods = append(periods, dedication{licensePlate: licensePlate, from: from, until: until})
+	}
+	if len(periods) == 0 {
+		delete(pl.dedicated, spaceID)
+	} else {
+		pl.dedicated[spaceID] = periods
+	}
+	return nil
+}
+
+// forgetEndedDedicationsLocked drops the periods over by now, and spaces left
+// with none
+func (pl *ParkingLot) forgetEndedDedicationsLocked(now time.Time) {
+	for spaceID, periods := range pl.dedicated {
+		current := periods[:0]
+		for _, kept := range periods {
+			if kept.until.After(now) {
+				current = append(current, kept)
+			}
+		}
+		if len(current) == 0 {
+			delete(pl.dedicated, spaceID)
 		} else {
-			observer.OnLotAvailable(pl.ID)
+			pl.dedicated[spaceID] = current
+		}
+	}
+}
+
+// GetDedicatedSpaces maps the spaces kept for permit holders now to their plates
+func (pl *ParkingLot) GetDedicatedSpaces() map[int]string {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	now := clockNow(pl.clock)
+	spaces := make(map[int]string)
+	for spaceID, periods := range pl.dedicated {
+		for _, kept := range periods {
+			if kept.covers(now) {
+				spaces[spaceID] = kept.licensePlate
+			}
+		}
+	}
+	return spaces
+}
+
+func (d dedication) covers(t time.Time) bool {
+	return !t.Before(d.from) && t.Before(d.until)
+}
+
+// dedicatedToOtherLocked reports whether the space is kept for a permit holder other than the car's
+func (pl *ParkingLot) dedicatedToOtherLocked(space *ParkingSpace, car *Car) bool {
+	now := clockNow(pl.clock)
+	for _, kept := range pl.dedicated[space.ID] {
+		if kept.licensePlate != car.LicensePlate && kept.covers(now) {
+			return true
 		}
 	}
+	return false
+}
+
+// offerHold keeps a space for the waitlisted car it was offered to
+type offerHold struct {
+	licensePlate string
+	until        time.Time
+}
+
+// KeepForOffer keeps the space for the plate until the offer runs out. Offers
+// are kept apart from permit dedications, so neither replaces the other, and
+// a space is offered to one car at a time.
+func (pl *ParkingLot) KeepForOffer(spaceID int, licensePlate string, until time.Time) error {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.indexOfLocked(spaceID) < 0 {
+		return ErrSpaceNotFound
+	}
+	now := clockNow(pl.clock)
+	for id, kept := range pl.offered {
+		if !now.Before(kept.until) {
+			delete(pl.offered, id)
+		}
+	}
+	if pl.offered == nil {
+		pl.offered = make(map[int]offerHold)
+	}
+	pl.offered[spaceID] = offerHold{licensePlate: licensePlate, until: until}
+	return nil
+}
+
+// ReleaseOffer frees the space if it is kept for the plate's offer
+func (pl *ParkingLot) ReleaseOffer(spaceID int, licensePlate string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if kept, ok := pl.offered[spaceID]; ok && kept.licensePlate == licensePlate {
+		delete(pl.offered, spaceID)
+	}
+}
+
+// offeredToOtherLocked reports whether the space is kept for a waitlisted car other than this one
+func (pl *ParkingLot) offeredToOtherLocked(space *ParkingSpace, car *Car) bool {
+	kept, ok := pl.offered[space.ID]
+	return ok && kept.licensePlate != car.LicensePlate && clockNow(pl.clock).Before(kept.until)
+}
+
+// SetHeldSpaces keeps that many free spaces for reservations. Walk-ins are
+// only given spaces while more than that many are free.
+func (pl *ParkingLot) SetHeldSpaces(count int) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.held = count
+}
+
+func (pl *ParkingLot) GetHeldSpaces() int {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	return pl.held
+}
+
+// GetUnreservedSpaces counts the free spaces walk-ins may take
+func (pl *ParkingLot) GetUnreservedSpaces() int {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	if free := pl.availableSpacesLocked() - pl.held; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// leavesHeldSpacesLocked reports whether parking the car still leaves the held spaces free
+func (pl *ParkingLot) leavesHeldSpacesLocked(car *Car) bool {
+	return pl.availableSpacesLocked()-car.RequiredSpaces() >= pl.held
 }
 
 // Enhanced methods with notifications
 func (pl *ParkingLot) ParkCar(car *Car) error {
-	for _, space := range pl.Spaces {
-		if space.Park(car) {
-			// Check if lot became full after parking
-			if pl.IsFull() && !pl.wasFull {
-				pl.wasFull = true
-				pl.notifyObservers(true)
+	_, err := pl.AssignSpace(car)
+	return err
+}
+
+// AssignSpace atomically reserves the best-fitting free spaces for the car and
+// returns the first of them. Vehicles needing several spaces get a contiguous run.
+func (pl *ParkingLot) AssignSpace(car *Car) (*ParkingSpace, error) {
+	return pl.assignSpace(car, false)
+}
+
+// AssignReservedSpace parks a car that holds a reservation, using the spaces
+// held for it and releasing that hold
+func (pl *ParkingLot) AssignReservedSpace(car *Car) (*ParkingSpace, error) {
+	return pl.assignSpace(car, true)
+}
+
+func (pl *ParkingLot) assignSpace(car *Car, reserved bool) (*ParkingSpace, error) {
+	pl.mu.Lock()
+	if pl.findCarLocked(car.LicensePlate) != nil {
+		pl.mu.Unlock()
+		return nil, ErrAlreadyParked
+	}
+	for {
+		var start int
+		if reserved {
+			start = pl.findAnyRunLocked(car)
+		} else {
+			start = pl.findRunLocked(car)
+		}
+		if start < 0 {
+			break
+		}
+		// A space parked directly, outside the lot lock, can make the run stale; search again
+		if space := pl.occupyRunLocked(start, car); space != nil {
+			if reserved {
+				pl.held -= car.RequiredSpaces()
+				if pl.held < 0 {
+					pl.held = 0
+				}
+			}
+			observers, crossings := pl.checkOccupancyLocked()
+			listeners := pl.vehicleListenerSnapshotLocked()
+			spaceIDs := pl.runIDsLocked(start, car)
+			pl.mu.Unlock()
+			pl.notifyObservers(observers, crossings)
+			pl.notifyVehicleParked(listeners, spaceIDs, car)
+			return space, nil
+		}
+	}
+	full := pl.availableSpacesLocked() == 0
+	blockedByHold := !reserved && !pl.leavesHeldSpacesLocked(car)
+	pl.mu.Unlock()
+
+	if full {
+		return nil, ErrLotFull
+	}
+	if blockedByHold {
+		return nil, ErrOnlyReservedSpaces
+	}
+	return nil, conflict("no available space fits this vehicle")
+}
+
+// ParkCarInSpace atomically parks the car starting at the given space if it,
+// and any further spaces the car needs, are still free and fit the car
+func (pl *ParkingLot) ParkCarInSpace(car *Car, spaceID int) (*ParkingSpace, error) {
+	pl.mu.Lock()
+	start := pl.indexOfLocked(spaceID)
+	if start < 0 {
+		pl.mu.Unlock()
+		return nil, ErrSpaceNotFound
+	}
+	if pl.findCarLocked(car.LicensePlate) != nil {
+		pl.mu.Unlock()
+		return nil, ErrAlreadyParked
+	}
+	if err := pl.checkRunLocked(start, car); err != nil {
+		pl.mu.Unlock()
+		return nil, err
+	}
+	if !pl.leavesHeldSpacesLocked(car) {
+		pl.mu.Unlock()
+		return nil, ErrOnlyReservedSpaces
+	}
+
+	space := pl.occupyRunLocked(start, car)
+	if space == nil {
+		pl.mu.Unlock()
+		return nil, ErrSpaceOccupied
+	}
+	observers, crossings := pl.checkOccupancyLocked()
+	listeners := pl.vehicleListenerSnapshotLocked()
+	spaceIDs := pl.runIDsLocked(start, car)
+	pl.mu.Unlock()
+	pl.notifyObservers(observers, crossings)
+	pl.notifyVehicleParked(listeners, spaceIDs, car)
+	return space, nil
+}
+
+// findRunLocked returns the index of the first space of a free run a walk-in
+// car may take, or -1 when none fits or only held spaces are left
+func (pl *ParkingLot) findRunLocked(car *Car) int {
+	if !pl.leavesHeldSpacesLocked(car) {
+		return -1
+	}
+	return pl.findAnyRunLocked(car)
+}
+
+// findAnyRunLocked returns the index of the first space of a free run that fits
+// the car, or -1. Handicap drivers get the accessible space nearest the exit
+// when there is one. Otherwise runs made only of the smallest suitable type
+// are preferred, so large spaces stay free for the vehicles that need them.
+func (pl *ParkingLot) findAnyRunLocked(car *Car) int {
+	if car.IsHandicap {
+		if start := pl.nearestAccessibleLocked(car); start >= 0 {
+			return start
+		}
+	}
+
+	allowAccessible := pl.mayUseAccessibleLocked(car)
+	minimum := MinimumSpaceType(car.Size)
+	for limit := minimum; limit <= LargeSpace; limit++ {
+		if start := pl.findRunUpToLocked(car, minimum, limit, allowAccessible); start >= 0 {
+			return start
+		}
+	}
+	return -1
+}
+
+func (pl *ParkingLot) findRunUpToLocked(car *Car, minimum, limit SpaceType, allowAccessible bool) int {
+	needed := car.RequiredSpaces()
+	run := 0
+	for i, space := range pl.Spaces {
+		if run > 0 && !space.inSameRow(pl.Spaces[i-1]) {
+			run = 0
+		}
+		if space.Type >= minimum && space.Type <= limit && space.IsAvailable() && (allowAccessible || !space.IsAccessible) &&
+			!pl.dedicatedToOtherLocked(space, car) && !pl.offeredToOtherLocked(space, car) {
+			run++
+			if run == needed {
+				return i - needed + 1
+			}
+		} else {
+			run = 0
+		}
+	}
+	return -1
+}
+
+func (pl *ParkingLot) checkRunLocked(start int, car *Car) error {
+	needed := car.RequiredSpaces()
+	if start+needed > len(pl.Spaces) {
+		return ErrNoContiguousSpaces
+	}
+	for _, space := range pl.Spaces[start : start+needed] {
+		if !space.inSameRow(pl.Spaces[start]) {
+			return ErrNoContiguousSpaces
+		}
+		if !space.IsAvailable() {
+			return ErrSpaceOccupied
+		}
+		if !space.Fits(car) {
+			return invalid("parking space is not suitable for this vehicle")
+		}
+		if space.IsAccessible && !pl.mayUseAccessibleLocked(car) {
+			return conflict("parking space is reserved for accessible parking")
+		}
+		if pl.dedicatedToOtherLocked(space, car) {
+			return conflict("parking space is dedicated to a permit holder")
+		}
+		if pl.offeredToOtherLocked(space, car) {
+			return conflict("parking space is offered to a waitlisted car")
+		}
+	}
+	return nil
+}
+
+// mayUseAccessibleLocked applies the accessible policy to a car
+func (pl *ParkingLot) mayUseAccessibleLocked(car *Car) bool {
+	if car.IsHandicap {
+		return true
+	}
+	if !pl.accessiblePolicy.AllowOverflow || pl.Capacity == 0 {
+		return false
+	}
+	occupied := float64(pl.Capacity-pl.availableSpacesLocked()) / float64(pl.Capacity)
+	return occupied >= pl.accessiblePolicy.OverflowThreshold
+}
+
+// nearestAccessibleLocked returns the index of the free accessible space closest
+// to the exit that starts a run fitting the car, or -1
+func (pl *ParkingLot) nearestAccessibleLocked(car *Car) int {
+	best := -1
+	for i, space := range pl.Spaces {
+		if !space.IsAccessible || pl.checkRunLocked(i, car) != nil {
+			continue
+		}
+		if best < 0 || space.DistanceToExit < pl.Spaces[best].DistanceToExit {
+			best = i
+		}
+	}
+	return best
+}
+
+// NearestAccessibleSpace returns the free accessible space closest to the exit
+// that the car fits in, or nil, leaving held spaces to reservations
+func (pl *ParkingLot) NearestAccessibleSpace(car *Car) *ParkingSpace {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	if !pl.leavesHeldSpacesLocked(car) {
+		return nil
+	}
+	if i := pl.nearestAccessibleLocked(car); i >= 0 {
+		return pl.Spaces[i]
+	}
+	return nil
+}
+
+// occupyRunLocked parks the car in the spaces from start on and returns the
+// first one, or rolls back and returns nil if any of them was taken meanwhile
+func (pl *ParkingLot) occupyRunLocked(start int, car *Car) *ParkingSpace {
+	run := pl.Spaces[start : start+car.RequiredSpaces()]
+	parkedAt := clockNow(pl.clock)
+	for i, space := range run {
+		if !space.occupy(car, parkedAt, i > 0) {
+			for _, taken := range run[:i] {
+				taken.Unpark()
 			}
 			return nil
 		}
 	}
-	return errors.New("parking lot is full")
+	for _, space := range run {
+		pl.recordParkedLocked(space, car)
+	}
+	return run[0]
+}
+
+// checkOccupancyLocked updates the lot's thresholds and returns the crossings
+// and the observers to tell about them, if any
+func (pl *ParkingLot) checkOccupancyLocked() ([]interfaces.ParkingLotObserver, []thresholdCrossing) {
+	crossings := pl.checkThresholdsLocked()
+	if len(crossings) == 0 {
+		return nil, nil
+	}
+	return pl.observerSnapshotLocked(), crossings
+}
+
+func (pl *ParkingLot) observerSnapshotLocked() []interfaces.ParkingLotObserver {
+	observers := make([]interfaces.ParkingLotObserver, len(pl.observers))
+	copy(observers, pl.observers)
+	return observers
 }
 
 func (pl *ParkingLot) UnparkCar(licensePlate string) (*Car, error) {
-	wasFullBeforeUnpark := pl.IsFull()
+	pl.mu.Lock()
+	return pl.unparkLocked(licensePlate, pl.Spaces)
+}
 
-	for _, space := range pl.Spaces {
-		if space.IsOccupied && space.ParkedCar != nil &&
-			space.ParkedCar.LicensePlate == licensePlate {
-			car := space.Unpark()
-
-			// Check if lot became available after unparking
-			if wasFullBeforeUnpark && !pl.IsFull() {
-				pl.wasFull = false
-				pl.notifyObservers(false)
+// UnparkCarFromSpace releases the car parked from the given space on, and
+// nothing else, so undoing a park frees the spaces that park took
+func (pl *ParkingLot) UnparkCarFromSpace(licensePlate string, spaceID int) (*Car, error) {
+	pl.mu.Lock()
+	start := pl.indexOfLocked(spaceID)
+	if start < 0 {
+		pl.mu.Unlock()
+		return nil, ErrSpaceNotFound
+	}
+	if car := pl.Spaces[start].GetParkedCar(); car == nil || car.LicensePlate != licensePlate || pl.Spaces[start].IsExtension() {
+		pl.mu.Unlock()
+		return nil, notFound("car not found in parking space")
+	}
+	return pl.unparkLocked(licensePlate, pl.Spaces[start:])
+}
+
+// unparkLocked releases the plate from the first of spaces holding it, and
+// from the further spaces of a multi-space vehicle. It unlocks the lot.
+func (pl *ParkingLot) unparkLocked(licensePlate string, spaces []*ParkingSpace) (*Car, error) {
+	releasedAt := clockNow(pl.clock)
+
+	// A multi-space vehicle is released from every space it holds
+	var car *Car
+	var freed []int
+	for _, space := range spaces {
+		if unparked := space.unparkIf(licensePlate); unparked != nil {
+			car = unparked
+			freed = append(freed, space.ID)
+			for _, listener := range pl.listeners {
+				listener.OnSpaceReleased(pl.ID, space.ID, licensePlate, releasedAt)
+			}
+			if len(freed) == car.RequiredSpaces() {
+				break
 			}
-			return car, nil
 		}
 	}
-	return nil, errors.New("car not found in parking lot")
+	if car == nil {
+		pl.mu.Unlock()
+		return nil, notFound("car not found in parking lot")
+	}
+
+	// Check if lot became available, or fell below thresholds, after unparking
+	observers, crossings := pl.checkOccupancyLocked()
+	listeners := pl.vehicleListenerSnapshotLocked()
+	pl.mu.Unlock()
+	pl.notifyObservers(observers, crossings)
+	for _, listener := range listeners {
+		listener.OnVehicleLeft(pl.ID, freed, car)
+	}
+	return car, nil
 }
 
 // Lot status methods
@@ -92,33 +745,68 @@ func (pl *ParkingLot) IsFull() bool {
 }
 
 func (pl *ParkingLot) GetAvailableSpaces() int {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	return pl.availableSpacesLocked()
+}
+
+func (pl *ParkingLot) availableSpacesLocked() int {
 	count := 0
 	for _, space := range pl.Spaces {
-		if !space.IsOccupied {
+		if space.IsAvailable() {
 			count++
 		}
 	}
 	return count
 }
 
-func (pl *ParkingLot) FindAvailableSpace() *ParkingSpace {
-	for _, space := range pl.Spaces {
-		if !space.IsOccupied {
-			return space
+// FindAvailableSpace returns the first space the car would be parked in,
+// or nil if no free run of suitable spaces exists outside those held for
+// reservations. A nil car matches any free space.
+func (pl *ParkingLot) FindAvailableSpace(car *Car) *ParkingSpace {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	if car == nil {
+		for _, space := range pl.Spaces {
+			if space.IsAvailable() {
+				return space
+			}
 		}
+		return nil
+	}
+	if start := pl.findRunLocked(car); start >= 0 {
+		return pl.Spaces[start]
 	}
 	return nil
 }
 
-
+// GetOccupiedSpaces counts spaces, so a vehicle spanning three spaces counts three times
 func (pl *ParkingLot) GetOccupiedSpaces() int {
 	return pl.Capacity - pl.GetAvailableSpaces()
 }
 
+// GetParkedVehicleCount counts vehicles, however many spaces each one takes
+func (pl *ParkingLot) GetParkedVehicleCount() int {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	count := 0
+	for _, space := range pl.Spaces {
+		if !space.IsAvailable() && !space.IsExtension() {
+			count++
+		}
+	}
+	return count
+}
+
 func (pl *ParkingLot) FindCar(licensePlate string) *ParkingSpace {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	return pl.findCarLocked(licensePlate)
+}
+
+func (pl *ParkingLot) findCarLocked(licensePlate string) *ParkingSpace {
 	for _, space := range pl.Spaces {
-		if space.IsOccupied && space.ParkedCar != nil &&
-			space.ParkedCar.LicensePlate == licensePlate {
+		if car := space.GetParkedCar(); car != nil && car.LicensePlate == licensePlate {
 			return space
 		}
 	}
@@ -1,33 +1,68 @@
 package models
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type ParkingSpace struct {
-	ID         int
-	IsOccupied bool
-	ParkedCar  *Car
-	ParkedAt   time.Time
+	ID             int
+	Type           SpaceType
+	IsAccessible   bool    // Designated for drivers with a handicap permit
+	DistanceToExit float64 // Metres to the nearest pedestrian exit, used to rank accessible spaces
+	Level          string
+	Row            string
+	Position       int // 1-based position within the row
+	IsOccupied     bool
+	ParkedCar      *Car
+	ParkedAt       time.Time
+	extension      bool         // Holds the rear of a vehicle whose allocation starts in an earlier space
+	clock          Clock        // Times Park; SystemClock when nil
+	mu             sync.RWMutex // Guards IsOccupied, ParkedCar, ParkedAt, extension and clock
 }
 
+// NewParkingSpace creates an untyped space. It is a large space so that every
+// vehicle fits, which keeps lots created without space types working as before.
 func NewParkingSpace(id int) *ParkingSpace {
+	return NewTypedParkingSpace(id, LargeSpace)
+}
+
+func NewTypedParkingSpace(id int, spaceType SpaceType) *ParkingSpace {
+	row, position := defaultLocation(id)
 	return &ParkingSpace{
 		ID:         id,
+		Type:       spaceType,
+		Row:        row,
+		Position:   position,
 		IsOccupied: false,
 		ParkedCar:  nil,
 	}
 }
 
 func (ps *ParkingSpace) Park(car *Car) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	if ps.IsOccupied {
 		return false
 	}
 	ps.IsOccupied = true
 	ps.ParkedCar = car
-	ps.ParkedAt = time.Now()
+	ps.ParkedAt = clockNow(ps.clock)
 	return true
 }
 
+// SetClock times later parks with clock; a lot sets it on all its spaces
+func (ps *ParkingSpace) SetClock(clock Clock) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.clock = clock
+}
+
 func (ps *ParkingSpace) Unpark() *Car {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	if !ps.IsOccupied {
 		return nil
 	}
@@ -36,42 +71,116 @@ func (ps *ParkingSpace) Unpark() *Car {
 	ps.IsOccupied = false
 	ps.ParkedCar = nil
 	ps.ParkedAt = time.Time{}
+	ps.extension = false
+
+	return car
+}
+
+// occupy parks the car with an explicit arrival time, as part of a
+// multi-space allocation when extension is set
+func (ps *ParkingSpace) occupy(car *Car, parkedAt time.Time, extension bool) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.IsOccupied {
+		return false
+	}
+	ps.IsOccupied = true
+	ps.ParkedCar = car
+	ps.ParkedAt = parkedAt
+	ps.extension = extension
+	return true
+}
+
+func (ps *ParkingSpace) setExtension(extension bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.extension = extension
+}
+
+// unparkIf atomically frees the space only if it still holds the given plate
+func (ps *ParkingSpace) unparkIf(licensePlate string) *Car {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if !ps.IsOccupied || ps.ParkedCar == nil || ps.ParkedCar.LicensePlate != licensePlate {
+		return nil
+	}
+
+	car := ps.ParkedCar
+	ps.IsOccupied = false
+	ps.ParkedCar = nil
+	ps.ParkedAt = time.Time{}
+	ps.extension = false
 
 	return car
 }
 
 func (ps *ParkingSpace) GetParkedCar() *Car {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
 	return ps.ParkedCar
 }
 
+// IsAvailable reports whether the space is free, safe for concurrent use
+func (ps *ParkingSpace) IsAvailable() bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return !ps.IsOccupied
+}
+
+// IsExtension reports whether the space holds the rear of a multi-space vehicle.
+// Code listing vehicles should skip such spaces so each vehicle is counted once.
+func (ps *ParkingSpace) IsExtension() bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.extension
+}
+
+// Fits reports whether the car's size suits this space
+func (ps *ParkingSpace) Fits(car *Car) bool {
+	return ps.Type.Fits(car.Size)
+}
+
+// GetOccupancy returns the parked car and its arrival time as one consistent read
+func (ps *ParkingSpace) GetOccupancy() (*Car, time.Time) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.ParkedCar, ps.ParkedAt
+}
+
 func (ps *ParkingSpace) GetLocationDetails() map[string]interface{} {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	return map[string]interface{}{
 		"SpaceID":  ps.ID,
 		"ParkedAt": ps.ParkedAt,
 	}
 }
 
-// UC16: Get row assignment based on space ID
+// UC16: Get row assignment from the lot layout
 func (ps *ParkingSpace) GetRowAssignment() string {
-	// Map space IDs to rows (A, B, C, D)
-	// Simple mapping: spaces 1-25 = A, 26-50 = B, 51-75 = C, 76-100 = D
-	if ps.ID <= 25 {
-		return "A"
-	} else if ps.ID <= 50 {
-		return "B"
-	} else if ps.ID <= 75 {
-		return "C"
-	} else {
-		return "D"
-	}
+	return ps.Row
+}
+
+// inSameRow reports whether two spaces are on the same level and row, which
+// a vehicle spanning several spaces requires
+func (ps *ParkingSpace) inSameRow(other *ParkingSpace) bool {
+	return ps.Level == other.Level && ps.Row == other.Row
 }
 
 // UC16: Get detailed location info including row
 func (ps *ParkingSpace) GetDetailedLocationInfo() map[string]interface{} {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	return map[string]interface{}{
-		"SpaceID":  ps.ID,
-		"Row":      ps.GetRowAssignment(),
-		"ParkedAt": ps.ParkedAt,
+		"SpaceID":    ps.ID,
+		"Level":      ps.Level,
+		"Row":        ps.GetRowAssignment(),
+		"Position":   ps.Position,
+		"ParkedAt":   ps.ParkedAt,
 		"IsOccupied": ps.IsOccupied,
 	}
 }
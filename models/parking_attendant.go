@@ -1,7 +1,6 @@
 package models
 
 import (
-	"errors"
 	"fmt"
 )
 
@@ -45,13 +44,13 @@ type ParkingDecision struct {
 
 func (pa *ParkingAttendant) MakeParkingDecision(lots []*ParkingLot, car *Car) (*ParkingDecision, error) {
 	if !pa.IsActive {
-		return nil, errors.New("attendant is not active")
+		return nil, ErrAttendantInactive
 	}
 
 	// Simple decision logic: find first available space
 	for _, lot := range lots {
 		if !lot.IsFull() {
-			space := lot.FindAvailableSpace()
+			space := lot.FindAvailableSpace(car)
 			if space != nil {
 				return &ParkingDecision{
 					AttendantID: pa.ID,
@@ -63,13 +62,13 @@ func (pa *ParkingAttendant) MakeParkingDecision(lots []*ParkingLot, car *Car) (*
 		}
 	}
 
-	return nil, errors.New("no available parking spaces")
+	return nil, conflict("no available parking spaces")
 }
 
 // Enhanced parking decision with strategy support
 func (pa *ParkingAttendant) MakeParkingDecisionWithStrategy(lots []*ParkingLot, car *Car, strategy ParkingStrategy) (*ParkingDecision, error) {
 	if !pa.IsActive {
-		return nil, errors.New("attendant is not active")
+		return nil, ErrAttendantInactive
 	}
 
 	if strategy == nil {
@@ -84,9 +83,9 @@ func (pa *ParkingAttendant) MakeParkingDecisionWithStrategy(lots []*ParkingLot,
 	}
 
 	// Find an available space in the selected lot
-	space := selectedLot.FindAvailableSpace()
+	space := selectedLot.FindAvailableSpace(car)
 	if space == nil {
-		return nil, errors.New("no available space in selected lot")
+		return nil, conflict("no available space in selected lot")
 	}
 
 	return &ParkingDecision{
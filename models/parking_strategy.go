@@ -1,6 +1,8 @@
 package models
 
-import "errors"
+import (
+	"strings"
+)
 
 // ParkingStrategy interface for different parking allocation strategies
 type ParkingStrategy interface {
@@ -8,6 +10,23 @@ type ParkingStrategy interface {
 	GetStrategyName() string
 }
 
+// ParseStrategy returns the strategy with the given short name: even,
+// handicap, large or smart
+func ParseStrategy(name string) (ParkingStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "even":
+		return NewEvenDistributionStrategy(), nil
+	case "handicap":
+		return NewHandicapPriorityStrategy(), nil
+	case "large":
+		return NewLargeVehicleStrategy(), nil
+	case "smart":
+		return NewSmartParkingStrategy(), nil
+	default:
+		return nil, invalid("unknown strategy %q", name)
+	}
+}
+
 // EvenDistributionStrategy implements even distribution across parking lots
 type EvenDistributionStrategy struct{}
 
@@ -21,25 +40,24 @@ func (eds *EvenDistributionStrategy) GetStrategyName() string {
 
 func (eds *EvenDistributionStrategy) FindParkingLot(lots []*ParkingLot, car *Car) (*ParkingLot, error) {
 	if len(lots) == 0 {
-		return nil, errors.New("no parking lots available")
+		return nil, ErrNoLots
 	}
 
 	var bestLot *ParkingLot
 	maxAvailable := -1
 
-	// Find the lot with the most available spaces for even distribution
+	// Find the lot with the most available spaces for even distribution,
+	// not counting spaces held for reservations
 	for _, lot := range lots {
-		if !lot.IsFull() {
-			available := lot.GetAvailableSpaces()
-			if available > maxAvailable {
-				maxAvailable = available
-				bestLot = lot
-			}
+		available := lot.GetUnreservedSpaces()
+		if available > 0 && available > maxAvailable {
+			maxAvailable = available
+			bestLot = lot
 		}
 	}
 
 	if bestLot == nil {
-		return nil, errors.New("no available parking spaces in any lot")
+		return nil, conflict("no available parking spaces in any lot")
 	}
 
 	return bestLot, nil
@@ -51,6 +69,7 @@ type LotUtilization struct {
 	TotalSpaces     int
 	OccupiedSpaces  int
 	AvailableSpaces int
+	ParkedVehicles  int // Lower than OccupiedSpaces when vehicles span several spaces
 	UtilizationRate float64
 }
 
@@ -69,11 +88,12 @@ func CalculateLotUtilization(lot *ParkingLot) *LotUtilization {
 		TotalSpaces:     total,
 		OccupiedSpaces:  occupied,
 		AvailableSpaces: available,
+		ParkedVehicles:  lot.GetParkedVehicleCount(),
 		UtilizationRate: utilizationRate,
 	}
 }
 
-// HandicapPriorityStrategy prioritizes nearest available spaces for handicap drivers
+// HandicapPriorityStrategy sends handicap drivers to the nearest free accessible space
 type HandicapPriorityStrategy struct{}
 
 func NewHandicapPriorityStrategy() *HandicapPriorityStrategy {
@@ -86,7 +106,7 @@ func (hps *HandicapPriorityStrategy) GetStrategyName() string {
 
 func (hps *HandicapPriorityStrategy) FindParkingLot(lots []*ParkingLot, car *Car) (*ParkingLot, error) {
 	if len(lots) == 0 {
-		return nil, errors.New("no parking lots available")
+		return nil, ErrNoLots
 	}
 
 	if !car.IsHandicap {
@@ -95,16 +115,19 @@ func (hps *HandicapPriorityStrategy) FindParkingLot(lots []*ParkingLot, car *Car
 		return strategy.FindParkingLot(lots, car)
 	}
 
-	// For handicap drivers, prioritize lots with nearest available spaces
-	// In this implementation, we'll prioritize the first available lot
-	// In a real system, this would consider physical distance/proximity
+	// For handicap drivers, pick the lot holding the accessible space nearest an exit
+	if lot, _ := NearestAccessibleSpace(lots, car); lot != nil {
+		return lot, nil
+	}
+
+	// No accessible space is free, so fall back to the first lot the car fits in
 	for _, lot := range lots {
-		if !lot.IsFull() {
+		if lot.FindAvailableSpace(car) != nil {
 			return lot, nil
 		}
 	}
 
-	return nil, errors.New("no available parking spaces for handicap vehicle")
+	return nil, conflict("no available parking spaces for handicap vehicle")
 }
 
 // LargeVehicleStrategy directs large cars to lots with most free space
@@ -120,17 +143,18 @@ func (lvs *LargeVehicleStrategy) GetStrategyName() string {
 
 func (lvs *LargeVehicleStrategy) FindParkingLot(lots []*ParkingLot, car *Car) (*ParkingLot, error) {
 	if len(lots) == 0 {
-		return nil, errors.New("no parking lots available")
+		return nil, ErrNoLots
 	}
 
 	var bestLot *ParkingLot
 	maxAvailable := -1
 
 	if car.Size == LargeVehicle {
-		// For large vehicles, find lot with most available spaces
+		// For large vehicles, find the lot with most available spaces among
+		// those that still have a run of large spaces long enough for the vehicle
 		for _, lot := range lots {
-			if !lot.IsFull() {
-				available := lot.GetAvailableSpaces()
+			if lot.FindAvailableSpace(car) != nil {
+				available := lot.GetUnreservedSpaces()
 				if available > maxAvailable {
 					maxAvailable = available
 					bestLot = lot
@@ -144,7 +168,7 @@ func (lvs *LargeVehicleStrategy) FindParkingLot(lots []*ParkingLot, car *Car) (*
 	}
 
 	if bestLot == nil {
-		return nil, errors.New("no available parking spaces for large vehicle")
+		return nil, conflict("no available parking spaces for large vehicle")
 	}
 
 	return bestLot, nil
@@ -163,7 +187,7 @@ func (sps *SmartParkingStrategy) GetStrategyName() string {
 
 func (sps *SmartParkingStrategy) FindParkingLot(lots []*ParkingLot, car *Car) (*ParkingLot, error) {
 	if len(lots) == 0 {
-		return nil, errors.New("no parking lots available")
+		return nil, ErrNoLots
 	}
 
 	// Prioritize handicap drivers first
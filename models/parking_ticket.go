@@ -1,7 +1,6 @@
 package models
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -14,15 +13,24 @@ type ParkingTicket struct {
 	UnparkedAt   time.Time
 	IsActive     bool
 	AttendantID  string
+	PermitID     string // Set when a permit covered the visit
+	VehicleSize  string // Size name recorded at entry, e.g. "Medium"; empty on older tickets
+	IsHandicap   bool
 }
 
 func NewParkingTicket(licensePlate, lotID, spaceID string) *ParkingTicket {
+	return NewParkingTicketWithID(defaultTicketIDs.NewTicketID(), licensePlate, lotID, spaceID, SystemClock.Now())
+}
+
+// NewParkingTicketWithID creates a ticket for a car parked at parkedAt, under
+// an ID from another TicketIDGenerator
+func NewParkingTicketWithID(id, licensePlate, lotID, spaceID string, parkedAt time.Time) *ParkingTicket {
 	return &ParkingTicket{
-		ID:           generateTicketID(licensePlate, lotID, spaceID),
+		ID:           id,
 		LicensePlate: licensePlate,
 		LotID:        lotID,
 		SpaceID:      spaceID,
-		ParkedAt:     time.Now(),
+		ParkedAt:     parkedAt,
 		IsActive:     true,
 		AttendantID:  "",
 	}
@@ -34,23 +42,42 @@ func NewParkingTicketWithAttendant(licensePlate, lotID, spaceID, attendantID str
 	return ticket
 }
 
-func generateTicketID(licensePlate, lotID, spaceID string) string {
-	return fmt.Sprintf("%s_%s_%s_%d", licensePlate, lotID, spaceID, time.Now().Unix())
+// RecordVehicle notes the car's size and handicap status for reporting
+func (pt *ParkingTicket) RecordVehicle(car *Car) {
+	pt.VehicleSize = car.GetVehicleSizeString()
+	pt.IsHandicap = car.IsHandicap
 }
 
+// GetParkingDuration is the length of the stay on the system clock; services
+// on another clock use GetParkingDurationAt
 func (pt *ParkingTicket) GetParkingDuration() time.Duration {
+	return pt.GetParkingDurationAt(SystemClock.Now())
+}
+
+// GetParkingDurationAt is the length of the stay, counting an active one up to now
+func (pt *ParkingTicket) GetParkingDurationAt(now time.Time) time.Duration {
 	if pt.IsActive {
-		return time.Since(pt.ParkedAt)
+		return now.Sub(pt.ParkedAt)
 	}
 	return pt.UnparkedAt.Sub(pt.ParkedAt)
 }
 
 func (pt *ParkingTicket) CompleteParking() {
+	pt.CompleteParkingAt(SystemClock.Now())
+}
+
+// CompleteParkingAt closes the ticket with the car leaving at unparkedAt
+func (pt *ParkingTicket) CompleteParkingAt(unparkedAt time.Time) {
 	pt.IsActive = false
-	pt.UnparkedAt = time.Now()
+	pt.UnparkedAt = unparkedAt
 }
 
 func (pt *ParkingTicket) GetTicketInfo() map[string]interface{} {
+	return pt.GetTicketInfoAt(SystemClock.Now())
+}
+
+// GetTicketInfoAt describes the ticket, counting an active stay up to now
+func (pt *ParkingTicket) GetTicketInfoAt(now time.Time) map[string]interface{} {
 	return map[string]interface{}{
 		"ID":           pt.ID,
 		"LicensePlate": pt.LicensePlate,
@@ -60,6 +87,9 @@ func (pt *ParkingTicket) GetTicketInfo() map[string]interface{} {
 		"UnparkedAt":   pt.UnparkedAt,
 		"IsActive":     pt.IsActive,
 		"AttendantID":  pt.AttendantID,
-		"Duration":     pt.GetParkingDuration(),
+		"PermitID":     pt.PermitID,
+		"VehicleSize":  pt.VehicleSize,
+		"IsHandicap":   pt.IsHandicap,
+		"Duration":     pt.GetParkingDurationAt(now),
 	}
 }
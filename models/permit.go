@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Permit lets a subscriber's car park without hourly billing over its validity
+type Permit struct {
+	ID           string
+	LicensePlate string
+	HolderName   string
+	ValidFrom    time.Time
+	ValidUntil   time.Time
+	LotIDs       []string // Lots the permit is valid in; every lot when empty
+	SpaceID      int      // Space kept free for the holder in the permit's only lot; 0 when none
+	RevokedAt    time.Time
+	CreatedAt    time.Time
+}
+
+// EndsAt is when the permit stops covering stays: its expiry, or its
+// revocation if that came first
+func (p *Permit) EndsAt() time.Time {
+	if !p.RevokedAt.IsZero() && p.RevokedAt.Before(p.ValidUntil) {
+		return p.RevokedAt
+	}
+	return p.ValidUntil
+}
+
+func (p *Permit) IsValidAt(t time.Time) bool {
+	return !t.Before(p.ValidFrom) && t.Before(p.EndsAt())
+}
+
+func (p *Permit) AllowsLot(lotID string) bool {
+	if len(p.LotIDs) == 0 {
+		return true
+	}
+	for _, allowed := range p.LotIDs {
+		if allowed == lotID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasDedicatedSpace reports whether a space is kept for the holder
+func (p *Permit) HasDedicatedSpace() bool {
+	return p.SpaceID > 0
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ReservationStatus is where a reservation is in its life
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"   // Booked, car not yet arrived
+	ReservationArrived   ReservationStatus = "arrived"   // Converted into a ticket
+	ReservationExpired   ReservationStatus = "expired"   // No-show past the grace period
+	ReservationCancelled ReservationStatus = "cancelled" // Withdrawn before arrival
+)
+
+// Reservation books space in a lot for a car over a future window
+type Reservation struct {
+	ID        string
+	Car       *Car
+	LotID     string
+	Start     time.Time
+	End       time.Time
+	Spaces    int // Spaces the car needs, held in the lot while the reservation is pending
+	Status    ReservationStatus
+	TicketID  string // Set on arrival
+	CreatedAt time.Time
+}
+
+// Overlaps reports whether the reservation's window intersects [start, end)
+func (r *Reservation) Overlaps(start, end time.Time) bool {
+	return r.Start.Before(end) && start.Before(r.End)
+}
+
+func (r *Reservation) IsPending() bool {
+	return r.Status == ReservationPending
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SpaceType is the physical size class of a parking space. Types are ordered
+// from smallest to largest; a vehicle fits any space at least as large as
+// the smallest type it needs.
+type SpaceType int
+
+const (
+	MotorcycleSpace SpaceType = iota // 0
+	CompactSpace                     // 1
+	RegularSpace                     // 2
+	LargeSpace                       // 3
+)
+
+func (st SpaceType) String() string {
+	switch st {
+	case MotorcycleSpace:
+		return "motorcycle"
+	case CompactSpace:
+		return "compact"
+	case RegularSpace:
+		return "regular"
+	case LargeSpace:
+		return "large"
+	default:
+		return fmt.Sprintf("SpaceType(%d)", int(st))
+	}
+}
+
+func ParseSpaceType(name string) (SpaceType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "motorcycle":
+		return MotorcycleSpace, nil
+	case "compact":
+		return CompactSpace, nil
+	case "regular":
+		return RegularSpace, nil
+	case "large":
+		return LargeSpace, nil
+	default:
+		return 0, invalid("unknown space type %q", name)
+	}
+}
+
+// MarshalText stores space types by name so persisted state stays readable
+func (st SpaceType) MarshalText() ([]byte, error) {
+	return []byte(st.String()), nil
+}
+
+func (st *SpaceType) UnmarshalText(text []byte) error {
+	parsed, err := ParseSpaceType(string(text))
+	if err != nil {
+		return err
+	}
+	*st = parsed
+	return nil
+}
+
+// MinimumSpaceType returns the smallest space type a vehicle of this size fits in
+func MinimumSpaceType(size VehicleSize) SpaceType {
+	switch size {
+	case MotorcycleVehicle:
+		return MotorcycleSpace
+	case SmallVehicle:
+		return CompactSpace
+	case LargeVehicle:
+		return LargeSpace
+	default:
+		return RegularSpace
+	}
+}
+
+// Fits reports whether a car of the given size can use a space of this type
+func (st SpaceType) Fits(size VehicleSize) bool {
+	return st >= MinimumSpaceType(size)
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Ticket IDs read "TK-" followed by 13 characters of random data, a 6
+// character signature when the generator has a secret, and a check
+// character. They are written in Crockford's base 32, which leaves out
+// letters easily mistaken for digits.
+const (
+	ticketIDPrefix        = "TK-"
+	ticketIDAlphabet      = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	ticketIDRandomLength  = 13 // 64 bits
+	ticketIDSignatureSize = 6  // 30 bits of the HMAC-SHA256 of the random part
+)
+
+// TicketIDGenerator makes the IDs of new tickets. Generators that can also
+// tell their own IDs from forgeries implement TicketIDVerifier.
+type TicketIDGenerator interface {
+	NewTicketID() string
+}
+
+// TicketIDVerifier checks a ticket ID without looking the ticket up, as a
+// gate scanner does offline
+type TicketIDVerifier interface {
+	VerifyTicketID(id string) error
+}
+
+// TicketIDs generates opaque ticket IDs from random data. IDs carry a check
+// character against typing mistakes and, given a secret, a signature only
+// holders of the secret can produce.
+type TicketIDs struct {
+	secret []byte
+	random io.Reader
+	mu     sync.Mutex // Guards reads from random
+}
+
+// NewTicketIDs creates a generator drawing on crypto/rand; a nil or empty
+// secret leaves IDs unsigned
+func NewTicketIDs(secret []byte) *TicketIDs {
+	return NewTicketIDsFrom(secret, rand.Reader)
+}
+
+// NewTicketIDsFrom creates a generator drawing on random, so a seeded source
+// gives the same IDs every run
+func NewTicketIDsFrom(secret []byte, random io.Reader) *TicketIDs {
+	return &TicketIDs{secret: append([]byte(nil), secret...), random: random}
+}
+
+// defaultTicketIDs makes the IDs of tickets created by NewParkingTicket
+var defaultTicketIDs = NewTicketIDs(nil)
+
+func (g *TicketIDs) NewTicketID() string {
+	var data [8]byte
+	g.mu.Lock()
+	_, err := io.ReadFull(g.random, data[:])
+	g.mu.Unlock()
+	if err != nil {
+		// crypto/rand does not fail on supported platforms; an exhausted test source is a bug
+		panic("ticket id source failed: " + err.Error())
+	}
+
+	body := encodeTicketIDBits(data[:], ticketIDRandomLength)
+	if len(g.secret) > 0 {
+		body += g.signature(body)
+	}
+	return ticketIDPrefix + body + string(ticketIDCheckCharacter(body))
+}
+
+// SignedWith reports whether the generator signs IDs with secret
+func (g *TicketIDs) SignedWith(secret string) bool {
+	return hmac.Equal(g.secret, []byte(secret))
+}
+
+// VerifyTicketID checks the ID's check character and, when the generator has
+// a secret, its signature
+func (g *TicketIDs) VerifyTicketID(id string) error {
+	body, err := checkTicketID(id)
+	if err != nil {
+		return err
+	}
+	if len(g.secret) == 0 {
+		return nil
+	}
+	if len(body) != ticketIDRandomLength+ticketIDSignatureSize {
+		return invalid("ticket id is not signed")
+	}
+	random := body[:ticketIDRandomLength]
+	if !hmac.Equal([]byte(g.signature(random)), []byte(body[ticketIDRandomLength:])) {
+		return invalid("ticket id signature does not match")
+	}
+	return nil
+}
+
+func (g *TicketIDs) signature(random string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(random))
+	return encodeTicketIDBits(mac.Sum(nil), ticketIDSignatureSize)
+}
+
+// CheckTicketID checks an ID's form and check character, catching mistyped
+// IDs without the secret a signature check needs
+func CheckTicketID(id string) error {
+	_, err := checkTicketID(id)
+	return err
+}
+
+// checkTicketID returns the ID's random part and signature
+func checkTicketID(id string) (string, error) {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !strings.HasPrefix(id, ticketIDPrefix) {
+		return "", ErrInvalidTicketID
+	}
+	body := id[len(ticketIDPrefix):]
+	if n := len(body) - 1; n != ticketIDRandomLength && n != ticketIDRandomLength+ticketIDSignatureSize {
+		return "", ErrInvalidTicketID
+	}
+	for _, c := range body {
+		if !strings.ContainsRune(ticketIDAlphabet, c) {
+			return "", ErrInvalidTicketID
+		}
+	}
+	last := len(body) - 1
+	if ticketIDCheckCharacter(body[:last]) != body[last] {
+		return "", invalid("ticket id check character does not match")
+	}
+	return body[:last], nil
+}
+
+// encodeTicketIDBits writes the leading bits of data as n base 32 characters
+func encodeTicketIDBits(data []byte, n int) string {
+	out := make([]byte, n)
+	for i := range out {
+		value := 0
+		for bit := i * 5; bit < i*5+5; bit++ {
+			value <<= 1
+			if bit/8 < len(data) && data[bit/8]&(0x80>>(bit%8)) != 0 {
+				value |= 1
+			}
+		}
+		out[i] = ticketIDAlphabet[value]
+	}
+	return string(out)
+}
+
+// ticketIDCheckCharacter is the Luhn mod 32 check character, which catches
+// every single mistyped character and most swapped neighbours
+func ticketIDCheckCharacter(body string) byte {
+	const n = len(ticketIDAlphabet)
+	sum := 0
+	factor := 2
+	for i := len(body) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(ticketIDAlphabet, body[i])
+		sum += addend/n + addend%n
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return ticketIDAlphabet[(n-sum%n)%n]
+}
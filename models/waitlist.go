@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// WaitlistStatus is where a queued car is in its wait for a space
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting WaitlistStatus = "waiting" // Queued for the next free space
+	WaitlistOffered WaitlistStatus = "offered" // A space is kept for the car until the offer expires
+	WaitlistParked  WaitlistStatus = "parked"  // Took the offered space
+	WaitlistExpired WaitlistStatus = "expired" // Did not take the offered space in time
+	WaitlistLeft    WaitlistStatus = "left"    // Gave up waiting
+)
+
+// WaitlistEntry is a car queued for a space while every lot is full
+type WaitlistEntry struct {
+	ID             string
+	Car            *Car
+	Priority       bool // Handicap drivers and permit holders are served first
+	JoinedAt       time.Time
+	Status         WaitlistStatus
+	LotID          string    // Lot of the offered space
+	SpaceID        int       // First space offered to the car
+	OfferedAt      time.Time // Set with the offer
+	OfferExpiresAt time.Time
+	TicketID       string // Set once the car parks
+}
+
+// IsQueued reports whether the car is still waiting or holding an offer
+func (e *WaitlistEntry) IsQueued() bool {
+	return e.Status == WaitlistWaiting || e.Status == WaitlistOffered
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook is an HTTP endpoint lot events are posted to as JSON
+type Webhook struct {
+	ID     string
+	URL    string
+	Secret string   // Signs every delivery; deliveries are unsigned without one
+	LotIDs []string // Every lot when empty
+	Events []string // Names of the event types to send; every type when empty
+}
+
+// DeadLetter is an event delivery a webhook did not accept after every retry
+type DeadLetter struct {
+	ID          string
+	WebhookID   string
+	URL         string
+	EventType   string
+	LotID       string
+	Body        json.RawMessage // The JSON posted, sent again as is on redelivery
+	Attempts    int
+	LastError   string
+	FailedAt    time.Time
+	DeliveredAt time.Time // Set once a redelivery succeeds
+}
+
+// IsPending reports whether the delivery still has to be made
+func (d *DeadLetter) IsPending() bool {
+	return d.DeliveredAt.IsZero()
+}
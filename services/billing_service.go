@@ -2,14 +2,26 @@ package services
 
 import (
 	"fmt"
-	"math"
 	"parking-lot-system/models"
+	"strings"
+	"sync"
 	"time"
 )
 
+// BillingService prices stays with the tariff of the lot they were in,
+// falling back to the default tariff, then adjusts the price for the vehicle.
+// Without a default tariff it charges HourlyRate per started hour with
+// MinimumCharge as the floor.
 type BillingService struct {
 	HourlyRate    float64
 	MinimumCharge float64
+
+	tariff     Tariff
+	lotTariffs map[string]Tariff
+	policy     PricingPolicy
+	penalties  PenaltyPolicy
+	clock      models.Clock // Times bills of cars still parked; SystemClock when nil
+	mu         sync.RWMutex // Guards tariff, lotTariffs, policy, penalties and clock
 }
 
 func NewBillingService(hourlyRate, minimumCharge float64) *BillingService {
@@ -19,61 +31,270 @@ func NewBillingService(hourlyRate, minimumCharge float64) *BillingService {
 	}
 }
 
+// NewBillingServiceWithTariff creates a billing service whose default tariff is tariff
+func NewBillingServiceWithTariff(tariff Tariff) *BillingService {
+	return &BillingService{tariff: tariff}
+}
+
+func (bs *BillingService) DefaultTariff() Tariff {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	if bs.tariff == nil {
+		return &HourlyTariff{HourlyRate: bs.HourlyRate, MinimumCharge: bs.MinimumCharge}
+	}
+	return bs.tariff
+}
+
+// SetLotTariff prices stays in the lot with tariff; nil restores the default
+func (bs *BillingService) SetLotTariff(lotID string, tariff Tariff) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if tariff == nil {
+		delete(bs.lotTariffs, lotID)
+		return
+	}
+	if bs.lotTariffs == nil {
+		bs.lotTariffs = make(map[string]Tariff)
+	}
+	bs.lotTariffs[lotID] = tariff
+}
+
+func (bs *BillingService) SetClock(clock models.Clock) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.clock = clock
+}
+
+// now is the time on the billing clock
+func (bs *BillingService) now() time.Time {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	if bs.clock == nil {
+		return time.Now()
+	}
+	return bs.clock.Now()
+}
+
+// TariffFor returns the tariff that prices stays in the lot
+func (bs *BillingService) TariffFor(lotID string) Tariff {
+	bs.mu.RLock()
+	tariff, ok := bs.lotTariffs[lotID]
+	bs.mu.RUnlock()
+	if ok {
+		return tariff
+	}
+	return bs.DefaultTariff()
+}
+
+func (bs *BillingService) SetPricingPolicy(policy PricingPolicy) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.policy = policy
+}
+
+func (bs *BillingService) GetPricingPolicy() PricingPolicy {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.policy
+}
+
+func (bs *BillingService) SetPenaltyPolicy(penalties PenaltyPolicy) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.penalties = penalties
+}
+
+func (bs *BillingService) GetPenaltyPolicy() PenaltyPolicy {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.penalties
+}
+
+// LotTariffs returns the lots with their own tariff
+func (bs *BillingService) LotTariffs() map[string]Tariff {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	tariffs := make(map[string]Tariff, len(bs.lotTariffs))
+	for lotID, tariff := range bs.lotTariffs {
+		tariffs[lotID] = tariff
+	}
+	return tariffs
+}
+
 type Bill struct {
 	TicketID      string
 	LicensePlate  string
+	LotID         string
 	ParkedAt      time.Time
 	UnparkedAt    time.Time
 	Duration      time.Duration
-	HourlyRate    float64
-	TotalAmount   float64
-	MinimumCharge float64
+	HourlyRate    float64 // Set for hourly tariffs only
+	TotalAmount   float64 // BaseAmount plus adjustments and penalties
+	MinimumCharge float64 // Set for hourly tariffs only
+	Tariff        string
+	LineItems     []LineItem
+	BaseAmount    float64 // Tariff price before adjustments
+	Adjustments   []Adjustment
+	Penalties     []Adjustment  // Lost-ticket and overstay charges, never discounted
+	LostTicket    bool          // Billed without the ticket; BaseAmount is zero
+	Overstay      time.Duration // Time beyond the lot's maximum stay, if any
+
+	SettlementOverride string // Attendant who let the car leave before the bill was paid
+	PermitID           string // Permit that covered all or the start of the stay
 }
 
+// CalculateFee prices a stay of the given length ending now with the default tariff
 func (bs *BillingService) CalculateFee(duration time.Duration) float64 {
-	hours := duration.Hours()
+	now := bs.now()
+	return totalOf(bs.DefaultTariff().Charge(now.Add(-duration), now))
+}
+
+// EstimateFee prices a ticket as if the car left at the given time. The car
+// may be nil, in which case no vehicle adjustments apply.
+func (bs *BillingService) EstimateFee(ticket *models.ParkingTicket, car *models.Car, at time.Time) float64 {
+	return bs.price(ticket, car, at).TotalAmount
+}
+
+// GenerateBill bills a ticket at the tariff price
+func (bs *BillingService) GenerateBill(ticket *models.ParkingTicket) *Bill {
+	return bs.GenerateBillForCar(ticket, nil)
+}
 
-	if hours < 1.0 {
-		return bs.MinimumCharge
+// GenerateBillForCar bills a ticket with the pricing policy applied to the car
+func (bs *BillingService) GenerateBillForCar(ticket *models.ParkingTicket, car *models.Car) *Bill {
+	unparkedAt := ticket.UnparkedAt
+	if ticket.IsActive {
+		unparkedAt = bs.now()
 	}
+	return bs.price(ticket, car, unparkedAt)
+}
 
-	// Round up to next hour for billing
-	billingHours := math.Ceil(hours)
-	total := billingHours * bs.HourlyRate
+func (bs *BillingService) price(ticket *models.ParkingTicket, car *models.Car, until time.Time) *Bill {
+	tariff := bs.TariffFor(ticket.LotID)
+	items := tariff.Charge(ticket.ParkedAt, until)
 
-	if total < bs.MinimumCharge {
-		return bs.MinimumCharge
+	bill := &Bill{
+		TicketID:     ticket.ID,
+		LicensePlate: ticket.LicensePlate,
+		LotID:        ticket.LotID,
+		ParkedAt:     ticket.ParkedAt,
+		UnparkedAt:   until,
+		Duration:     until.Sub(ticket.ParkedAt),
+		Tariff:       tariff.Name(),
+		LineItems:    items,
+		BaseAmount:   totalOf(items),
+	}
+	if hourly, ok := tariff.(*HourlyTariff); ok {
+		bill.HourlyRate = hourly.HourlyRate
+		bill.MinimumCharge = hourly.MinimumCharge
 	}
 
-	return total
+	if car != nil {
+		bill.Adjustments = bs.GetPricingPolicy().adjustmentsFor(car, bill.BaseAmount)
+	}
+	penalties := bs.GetPenaltyPolicy()
+	if bill.Overstay = penalties.overstay(ticket.LotID, bill.Duration); bill.Overstay > 0 {
+		bill.Penalties = append(bill.Penalties, penalties.overstayPenalty(ticket.LotID, bill.Overstay))
+	}
+	bill.total()
+	return bill
 }
 
-func (bs *BillingService) GenerateBill(ticket *models.ParkingTicket) *Bill {
-	duration := ticket.GetParkingDuration()
-	totalAmount := bs.CalculateFee(duration)
+// GeneratePermitBill bills a stay covered by a permit until coveredUntil.
+// Only time parked after that is charged, at the lot's tariff, so a permit
+// that expired mid-stay falls back to normal billing for the rest.
+func (bs *BillingService) GeneratePermitBill(ticket *models.ParkingTicket, car *models.Car, permitID string, coveredUntil time.Time) *Bill {
+	unparkedAt := ticket.UnparkedAt
+	if ticket.IsActive {
+		unparkedAt = bs.now()
+	}
+	covered := LineItem{
+		Description: "Permit " + permitID,
+		Start:       ticket.ParkedAt,
+		End:         unparkedAt,
+	}
 
-	return &Bill{
-		TicketID:      ticket.ID,
-		LicensePlate:  ticket.LicensePlate,
-		ParkedAt:      ticket.ParkedAt,
-		UnparkedAt:    ticket.UnparkedAt,
-		Duration:      duration,
-		HourlyRate:    bs.HourlyRate,
-		TotalAmount:   totalAmount,
-		MinimumCharge: bs.MinimumCharge,
+	if !unparkedAt.After(coveredUntil) {
+		return &Bill{
+			TicketID:     ticket.ID,
+			LicensePlate: ticket.LicensePlate,
+			LotID:        ticket.LotID,
+			ParkedAt:     ticket.ParkedAt,
+			UnparkedAt:   unparkedAt,
+			Duration:     unparkedAt.Sub(ticket.ParkedAt),
+			Tariff:       "Permit",
+			LineItems:    []LineItem{covered},
+			PermitID:     permitID,
+		}
+	}
+
+	uncovered := *ticket
+	uncovered.ParkedAt = coveredUntil
+	bill := bs.price(&uncovered, car, unparkedAt)
+	covered.End = coveredUntil
+	bill.ParkedAt = ticket.ParkedAt
+	bill.Duration = unparkedAt.Sub(ticket.ParkedAt)
+	bill.LineItems = append([]LineItem{covered}, bill.LineItems...)
+	bill.PermitID = permitID
+	return bill
+}
+
+// GenerateLostTicketBill bills a stay whose ticket cannot be presented. The
+// lost-ticket fee replaces the tariff price, since the entry time is unproven.
+func (bs *BillingService) GenerateLostTicketBill(ticket *models.ParkingTicket) *Bill {
+	unparkedAt := ticket.UnparkedAt
+	if ticket.IsActive {
+		unparkedAt = bs.now()
+	}
+	tariff := bs.TariffFor(ticket.LotID)
+
+	bill := &Bill{
+		TicketID:     ticket.ID,
+		LicensePlate: ticket.LicensePlate,
+		LotID:        ticket.LotID,
+		ParkedAt:     ticket.ParkedAt,
+		UnparkedAt:   unparkedAt,
+		Duration:     unparkedAt.Sub(ticket.ParkedAt),
+		Tariff:       tariff.Name(),
+		LostTicket:   true,
+		Penalties: []Adjustment{{
+			Reason: "Lost ticket",
+			Amount: bs.GetPenaltyPolicy().lostTicketFee(tariff, ticket.ParkedAt),
+		}},
+	}
+	bill.total()
+	return bill
+}
+
+func (b *Bill) total() {
+	b.TotalAmount = b.BaseAmount
+	for _, adjustment := range b.Adjustments {
+		b.TotalAmount += adjustment.Amount
+	}
+	for _, penalty := range b.Penalties {
+		b.TotalAmount += penalty.Amount
 	}
 }
 
 func (b *Bill) GetBillSummary() map[string]interface{} {
 	return map[string]interface{}{
-		"TicketID":      b.TicketID,
-		"LicensePlate":  b.LicensePlate,
-		"ParkedAt":      b.ParkedAt.Format("2006-01-02 15:04:05"),
-		"UnparkedAt":    b.UnparkedAt.Format("2006-01-02 15:04:05"),
-		"Duration":      b.Duration.String(),
-		"HourlyRate":    b.HourlyRate,
-		"TotalAmount":   b.TotalAmount,
-		"MinimumCharge": b.MinimumCharge,
+		"TicketID":           b.TicketID,
+		"LicensePlate":       b.LicensePlate,
+		"ParkedAt":           b.ParkedAt.Format("2006-01-02 15:04:05"),
+		"UnparkedAt":         b.UnparkedAt.Format("2006-01-02 15:04:05"),
+		"Duration":           b.Duration.String(),
+		"HourlyRate":         b.HourlyRate,
+		"TotalAmount":        b.TotalAmount,
+		"MinimumCharge":      b.MinimumCharge,
+		"Tariff":             b.Tariff,
+		"LineItems":          b.LineItems,
+		"BaseAmount":         b.BaseAmount,
+		"Adjustments":        b.Adjustments,
+		"Penalties":          b.Penalties,
+		"LostTicket":         b.LostTicket,
+		"Overstay":           b.Overstay.String(),
+		"SettlementOverride": b.SettlementOverride,
+		"PermitID":           b.PermitID,
 	}
 }
 
@@ -87,7 +308,8 @@ License Plate: %s
 Parked At: %s
 Unparked At: %s
 Duration: %s
-Hourly Rate: $%.2f
+Tariff: %s
+%s---------------------------------
 Total Amount: $%.2f
 =================================
 Thank you for using our parking!
@@ -97,7 +319,22 @@ Thank you for using our parking!
 		b.ParkedAt.Format("2006-01-02 15:04:05"),
 		b.UnparkedAt.Format("2006-01-02 15:04:05"),
 		b.Duration.String(),
-		b.HourlyRate,
+		b.Tariff,
+		b.lineItemText(),
 		b.TotalAmount,
 	)
 }
+
+func (b *Bill) lineItemText() string {
+	var text strings.Builder
+	for _, item := range b.LineItems {
+		fmt.Fprintf(&text, "  %-30s $%.2f\n", item.Description, item.Amount)
+	}
+	for _, adjustment := range b.Adjustments {
+		fmt.Fprintf(&text, "  %-30s $%.2f\n", adjustment.Reason, adjustment.Amount)
+	}
+	for _, penalty := range b.Penalties {
+		fmt.Fprintf(&text, "  %-30s $%.2f\n", penalty.Reason, penalty.Amount)
+	}
+	return text.String()
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"parking-lot-system/models"
+)
+
+// Kinds of failure. Errors returned by the services are of one kind, which
+// callers such as the HTTP API check with errors.Is; the kinds the models
+// package shares mean the same for errors coming from lots and spaces.
+var (
+	ErrNotFound        = models.ErrNotFound
+	ErrConflict        = models.ErrConflict
+	ErrInvalid         = models.ErrInvalid
+	ErrPaymentRequired = errors.New("payment required")
+	ErrDeliveryFailed  = errors.New("delivery failed") // An outside endpoint did not take what was sent
+)
+
+// Failures callers commonly check for
+var (
+	ErrCarNotFound            = models.NewError(ErrNotFound, "car not found")
+	ErrLotNotFound            = models.NewError(ErrNotFound, "lot not found")
+	ErrLotExists              = models.NewError(ErrConflict, "lot already exists")
+	ErrAttendantNotFound      = models.NewError(ErrNotFound, "attendant not found")
+	ErrTicketNotFound         = models.ErrTicketNotFound
+	ErrActiveTicketNotFound   = models.ErrActiveTicketNotFound
+	ErrActiveTicketExists     = models.NewError(ErrConflict, "vehicle already has an active ticket")
+	ErrNoAvailableSpace       = models.NewError(ErrConflict, "no available parking space")
+	ErrNilCar                 = models.NewError(ErrInvalid, "car cannot be nil")
+	ErrEmptyLicensePlate      = models.NewError(ErrInvalid, "license plate cannot be empty")
+	ErrBillNotFound           = models.NewError(ErrNotFound, "bill not found for ticket")
+	ErrBillNotSettled         = models.NewError(ErrPaymentRequired, "bill is not settled")
+	ErrNoPaymentProvider      = models.NewError(ErrConflict, "no payment provider configured")
+	ErrPermitNotFound         = models.NewError(ErrNotFound, "permit not found")
+	ErrPermitNotActive        = models.NewError(ErrConflict, "permit is not active")
+	ErrReservationNotFound    = models.NewError(ErrNotFound, "reservation not found")
+	ErrReservationNotActive   = models.NewError(ErrConflict, "reservation is not active")
+	ErrWaitlistEntryNotFound  = models.NewError(ErrNotFound, "waitlist entry not found")
+	ErrWaitlistEntryNotActive = models.NewError(ErrConflict, "waitlist entry is not active")
+)
+
+// Older wordings of the failures above, kept for the callers that show them
+var (
+	errParkingLotNotFound  = models.NewError(ErrLotNotFound, "parking lot not found")
+	errCarNotInAnyLot      = models.NewError(ErrCarNotFound, "car not found in any parking lot")
+	errNoActiveTicketByCar = models.NewError(ErrActiveTicketNotFound, "active ticket not found for car")
+	errNoActiveTicketFound = models.NewError(ErrActiveTicketNotFound, "no active ticket found for this vehicle")
+)
+
+func notFound(format string, args ...interface{}) error {
+	return models.NewError(ErrNotFound, fmt.Sprintf(format, args...))
+}
+
+func conflict(format string, args ...interface{}) error {
+	return models.NewError(ErrConflict, fmt.Sprintf(format, args...))
+}
+
+func invalid(format string, args ...interface{}) error {
+	return models.NewError(ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+func paymentRequired(format string, args ...interface{}) error {
+	return models.NewError(ErrPaymentRequired, fmt.Sprintf(format, args...))
+}
+
+// notSettled words ErrBillNotSettled with what is still to be done
+func notSettled(format string, args ...interface{}) error {
+	return models.NewError(ErrBillNotSettled, fmt.Sprintf(format, args...))
+}
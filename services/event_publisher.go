@@ -0,0 +1,68 @@
+package services
+
+import (
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+)
+
+// lotEventPublisher turns one lot's observer and vehicle notifications into
+// events on the service's bus
+type lotEventPublisher struct {
+	bus *events.Bus
+	lot *models.ParkingLot
+}
+
+func (p *lotEventPublisher) OnLotFull(lotID string) {
+	p.bus.Publish(events.LotFull, lotID, events.LotFullPayload{
+		Capacity: p.lot.Capacity, AvailableSpaces: p.lot.GetAvailableSpaces(),
+	})
+}
+
+func (p *lotEventPublisher) OnLotAvailable(lotID string) {
+	p.bus.Publish(events.LotAvailable, lotID, events.LotAvailablePayload{
+		Capacity: p.lot.Capacity, AvailableSpaces: p.lot.GetAvailableSpaces(),
+	})
+}
+
+func (p *lotEventPublisher) OnThresholdCrossed(lotID string, threshold, occupancy float64, rising bool) {
+	p.bus.Publish(events.ThresholdCrossed, lotID, events.ThresholdCrossedPayload{
+		Threshold: threshold, Occupancy: occupancy, Rising: rising,
+	})
+}
+
+// OnVehicleParked also flags plates the police would find suspicious
+func (p *lotEventPublisher) OnVehicleParked(lotID string, spaceIDs []int, car *models.Car) {
+	p.bus.Publish(events.CarParked, lotID, events.CarParkedPayload{Car: *car, SpaceIDs: spaceIDs})
+	if isSuspiciousLicensePlate(car.LicensePlate) {
+		p.bus.Publish(events.SuspiciousPlateDetected, lotID, events.SuspiciousPlateDetectedPayload{
+			LicensePlate: car.LicensePlate, SpaceIDs: spaceIDs,
+		})
+	}
+}
+
+func (p *lotEventPublisher) OnVehicleLeft(lotID string, spaceIDs []int, car *models.Car) {
+	p.bus.Publish(events.CarUnparked, lotID, events.CarUnparkedPayload{Car: *car, SpaceIDs: spaceIDs})
+}
+
+// publishBill reports a bill generated for a car leaving or quoted at the exit
+func (ps *ParkingService) publishBill(bill *Bill) {
+	ps.bus.Publish(events.BillGenerated, bill.LotID, events.BillGeneratedPayload{
+		TicketID:     bill.TicketID,
+		LicensePlate: bill.LicensePlate,
+		TotalAmount:  bill.TotalAmount,
+		Tariff:       bill.Tariff,
+		LostTicket:   bill.LostTicket,
+		PermitID:     bill.PermitID,
+	})
+}
+
+// publishAttendantAssigned reports the attendant taking on a lot or, given a
+// plate, parking that car
+func (ps *ParkingService) publishAttendantAssigned(attendant *models.ParkingAttendant, lotID, licensePlate, spaceID string) {
+	ps.bus.Publish(events.AttendantAssigned, lotID, events.AttendantAssignedPayload{
+		AttendantID:   attendant.ID,
+		AttendantName: attendant.Name,
+		LicensePlate:  licensePlate,
+		SpaceID:       spaceID,
+	})
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"parking-lot-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OccupancyPolicy sets how long occupancy samples are kept and how full a
+// lot must be for forecasts to call it full
+type OccupancyPolicy struct {
+	Retention     time.Duration
+	FullOccupancy float64 // Share of spaces, from 0 to 1
+}
+
+// DefaultOccupancyPolicy keeps eight weeks of samples, enough for several of
+// each weekday, and calls a lot full when every space is taken
+var DefaultOccupancyPolicy = OccupancyPolicy{Retention: 8 * 7 * 24 * time.Hour, FullOccupancy: 1}
+
+// trendWindow is how far back the trend forecast looks when a lot has no
+// profile for the hour
+const trendWindow = time.Hour
+
+// OccupancyService samples every lot's occupancy into a time series, builds
+// hour-of-day and day-of-week profiles from it and forecasts when each lot
+// will fill
+type OccupancyService struct {
+	parking *ParkingService
+	samples map[string][]models.OccupancySample // By lot, oldest first
+	policy  OccupancyPolicy
+	journal func(entry *JournalEntry)
+	mu      sync.Mutex // Guards samples and policy; taken before any lot lock
+}
+
+func newOccupancyService(parking *ParkingService) *OccupancyService {
+	return &OccupancyService{
+		parking: parking,
+		samples: make(map[string][]models.OccupancySample),
+		policy:  DefaultOccupancyPolicy,
+		journal: parking.journal,
+	}
+}
+
+func (oc *OccupancyService) SetPolicy(policy OccupancyPolicy) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	oc.policy = policy
+}
+
+func (oc *OccupancyService) GetPolicy() OccupancyPolicy {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return oc.policy
+}
+
+// Sample records every lot's occupancy as of now and drops samples older
+// than the retention
+func (oc *OccupancyService) Sample() []models.OccupancySample {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	// Read under the lock so that samples taken at the same time are
+	// appended in the order of their readings
+	now := oc.parking.now()
+	var taken []models.OccupancySample
+	for _, lot := range oc.parking.getLots() {
+		taken = append(taken, models.SampleOccupancy(lot, now))
+	}
+	for _, sample := range taken {
+		oc.samples[sample.LotID] = append(oc.samples[sample.LotID], sample)
+		saved := sample
+		oc.journal(&JournalEntry{Type: EntryOccupancySampled, LotID: sample.LotID, Sample: &saved})
+	}
+	oc.pruneLocked(now)
+	return taken
+}
+
+// StartSampling samples every interval until stop is called
+func (oc *OccupancyService) StartSampling(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				oc.Sample()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// GetSeries returns the lot's samples taken from from up to to, oldest
+// first. A zero from or to leaves that end open.
+func (oc *OccupancyService) GetSeries(lotID string, from, to time.Time) ([]models.OccupancySample, error) {
+	if oc.parking.findLotByID(lotID) == nil {
+		return nil, errParkingLotNotFound
+	}
+
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	series := make([]models.OccupancySample, 0)
+	for _, sample := range oc.samples[lotID] {
+		if (!from.IsZero() && sample.At.Before(from)) || (!to.IsZero() && sample.At.After(to)) {
+			continue
+		}
+		series = append(series, sample)
+	}
+	return series, nil
+}
+
+// GetProfile aggregates the lot's samples by hour of the day and day of the week
+func (oc *OccupancyService) GetProfile(lotID string) (*models.OccupancyProfile, error) {
+	series, err := oc.GetSeries(lotID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildOccupancyProfile(lotID, series), nil
+}
+
+// Forecast predicts the lot's occupancy hour by hour up to horizon from its
+// occupancy now, and when it will fill
+func (oc *OccupancyService) Forecast(lotID string, horizon time.Duration) (*models.OccupancyForecast, error) {
+	if horizon <= 0 {
+		return nil, invalid("forecast horizon must be positive")
+	}
+	lot := oc.parking.findLotByID(lotID)
+	if lot == nil {
+		return nil, errParkingLotNotFound
+	}
+	current := models.SampleOccupancy(lot, oc.parking.now())
+
+	profile, err := oc.GetProfile(lotID)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := oc.GetSeries(lotID, current.At.Add(-trendWindow), current.At)
+	if err != nil {
+		return nil, err
+	}
+	return models.ForecastOccupancy(profile, recent, current, horizon, oc.GetPolicy().FullOccupancy), nil
+}
+
+// ForecastAll forecasts every lot, in the order the lots were added
+func (oc *OccupancyService) ForecastAll(horizon time.Duration) ([]*models.OccupancyForecast, error) {
+	forecasts := make([]*models.OccupancyForecast, 0)
+	for _, lot := range oc.parking.getLots() {
+		forecast, err := oc.Forecast(lot.ID, horizon)
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts, nil
+}
+
+func (oc *OccupancyService) pruneLocked(now time.Time) {
+	if oc.policy.Retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-oc.policy.Retention)
+	for lotID, series := range oc.samples {
+		kept := 0
+		for kept < len(series) && series[kept].At.Before(cutoff) {
+			kept++
+		}
+		if kept > 0 {
+			oc.samples[lotID] = append([]models.OccupancySample(nil), series[kept:]...)
+		}
+	}
+}
+
+// restore loads persisted samples, dropping those past the retention. A
+// sample journalled while a snapshot was being taken can be in both the
+// snapshot and the log, so a lot's samples are kept once per time, and the
+// log need not follow the snapshot in time, so each lot's series is sorted.
+func (oc *OccupancyService) restore(samples []*models.OccupancySample) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	type sampleKey struct {
+		lotID string
+		at    int64
+	}
+	seen := make(map[sampleKey]bool)
+	for lotID, series := range oc.samples {
+		for _, sample := range series {
+			seen[sampleKey{lotID, sample.At.UnixNano()}] = true
+		}
+	}
+	for _, sample := range samples {
+		key := sampleKey{sample.LotID, sample.At.UnixNano()}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		oc.samples[sample.LotID] = append(oc.samples[sample.LotID], *sample)
+	}
+	for _, series := range oc.samples {
+		sort.SliceStable(series, func(i, j int) bool {
+			return series[i].At.Before(series[j].At)
+		})
+	}
+	oc.pruneLocked(oc.parking.now())
+}
+
+// snapshot copies every sample, lot by lot
+func (oc *OccupancyService) snapshot() []*models.OccupancySample {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	var samples []*models.OccupancySample
+	for _, lot := range oc.parking.getLots() {
+		for _, sample := range oc.samples[lot.ID] {
+			saved := sample
+			samples = append(samples, &saved)
+		}
+	}
+	return samples
+}
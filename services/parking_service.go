@@ -3,40 +3,143 @@ package services
 import (
 	"errors"
 	"fmt"
+	"parking-lot-system/events"
 	"parking-lot-system/interfaces"
 	"parking-lot-system/models"
+	"strconv"
+	"sync"
+	"time"
 )
 
 type ParkingService struct {
-	lots            []*models.ParkingLot
-	securityStaff   []*models.SecurityStaff
-	attendants      []*models.ParkingAttendant
-	defaultStrategy models.ParkingStrategy
+	lots               []*models.ParkingLot
+	securityStaff      []*models.SecurityStaff
+	attendants         []*models.ParkingAttendant
+	defaultStrategy    models.ParkingStrategy
+	billing            *BillingService
+	payments           *PaymentService
+	reservations       *ReservationService
+	permits            *PermitService
+	waitlist           *WaitlistService
+	occupancy          *OccupancyService
+	reports            *ReportService
+	webhooks           *WebhookService
+	tickets            TicketRepository
+	ticketIDs          models.TicketIDGenerator
+	bus                *events.Bus
+	store              StateStore // Optional, set by RestoreParkingService
+	occupancyListeners []models.OccupancyListener
+	mu                 sync.RWMutex // Guards lots, securityStaff, attendants, defaultStrategy, billing and ticketIDs
+	clock              models.Clock
+	clockMu            sync.RWMutex // Guards clock apart from mu, since the other services read the time under their own locks
 }
 
 func NewParkingService() *ParkingService {
-	return &ParkingService{
+	return NewParkingServiceWithRepository(NewInMemoryTicketRepository())
+}
+
+// NewParkingServiceWithRepository creates a service backed by the given ticket store
+func NewParkingServiceWithRepository(tickets TicketRepository) *ParkingService {
+	ps := &ParkingService{
 		lots:            make([]*models.ParkingLot, 0),
 		securityStaff:   make([]*models.SecurityStaff, 0),
 		attendants:      make([]*models.ParkingAttendant, 0),
 		defaultStrategy: models.NewEvenDistributionStrategy(),
+		billing:         NewBillingService(10.0, 5.0), // $10/hour, $5 minimum
+		payments:        NewPaymentService(),
+		tickets:         tickets,
+		ticketIDs:       models.NewTicketIDs(nil),
+		bus:             events.NewBus(),
+		clock:           models.SystemClock,
 	}
+	ps.payments.journal = ps.journal
+	ps.payments.now = ps.now
+	ps.reservations = newReservationService(ps)
+	ps.permits = newPermitService(ps)
+	ps.waitlist = newWaitlistService(ps)
+	ps.occupancy = newOccupancyService(ps)
+	ps.reports = newReportService(ps)
+	ps.webhooks = newWebhookService(ps)
+	return ps
 }
 
-func (ps *ParkingService) AddLot(lot *models.ParkingLot) {
+// AddLot puts the lot in service unless a lot with its ID already is. The lot
+// runs on the service's clock from then on.
+func (ps *ParkingService) AddLot(lot *models.ParkingLot) error {
+	ps.mu.Lock()
+	for _, existing := range ps.lots {
+		if existing.ID == lot.ID {
+			ps.mu.Unlock()
+			return models.NewError(ErrLotExists, fmt.Sprintf("parking lot %s already exists", lot.ID))
+		}
+	}
+	lot.SetClock(ps.GetClock())
 	ps.lots = append(ps.lots, lot)
+	store := ps.store
+	listeners := make([]models.OccupancyListener, len(ps.occupancyListeners))
+	copy(listeners, ps.occupancyListeners)
+	ps.mu.Unlock()
+
+	if store != nil {
+		appendEntry(store, &JournalEntry{Type: EntryLotAdded, LotID: lot.ID, Capacity: lot.Capacity, SpaceSpecs: lot.GetSpaceSpecs()})
+		for _, space := range lot.Spaces {
+			if car, parkedAt := space.GetOccupancy(); car != nil {
+				parked := *car
+				appendEntry(store, &JournalEntry{Type: EntrySpaceOccupied, LotID: lot.ID, SpaceID: space.ID, Car: &parked, At: parkedAt})
+			}
+		}
+	}
+	for _, listener := range listeners {
+		lot.AddOccupancyListener(listener)
+	}
+	ps.permits.dedicatedSpacesIn(lot)
+	lot.AddVehicleListener(ps.waitlist)
+	publisher := &lotEventPublisher{bus: ps.bus, lot: lot}
+	lot.AddObserver(publisher)
+	lot.AddVehicleListener(publisher)
+	return nil
+}
+
+// AddOccupancyListener attaches the listener to every current and future lot
+func (ps *ParkingService) AddOccupancyListener(listener models.OccupancyListener) {
+	ps.mu.Lock()
+	ps.occupancyListeners = append(ps.occupancyListeners, listener)
+	ps.mu.Unlock()
+
+	for _, lot := range ps.getLots() {
+		lot.AddOccupancyListener(listener)
+	}
+}
+
+// getLots returns a snapshot of the lots so callers can iterate without holding the lock
+func (ps *ParkingService) getLots() []*models.ParkingLot {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	lots := make([]*models.ParkingLot, len(ps.lots))
+	copy(lots, ps.lots)
+	return lots
 }
 
 // Security staff management
 func (ps *ParkingService) AddSecurityStaff(staff *models.SecurityStaff) {
+	ps.mu.Lock()
 	ps.securityStaff = append(ps.securityStaff, staff)
+	ps.mu.Unlock()
+
+	ps.journalStaff(staff)
 }
 
 func (ps *ParkingService) GetSecurityStaff() []*models.SecurityStaff {
-	return ps.securityStaff
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	staff := make([]*models.SecurityStaff, len(ps.securityStaff))
+	copy(staff, ps.securityStaff)
+	return staff
 }
 
 func (ps *ParkingService) FindSecurityStaffByID(staffID string) *models.SecurityStaff {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
 	for _, staff := range ps.securityStaff {
 		if staff.ID == staffID {
 			return staff
@@ -48,28 +151,51 @@ func (ps *ParkingService) FindSecurityStaffByID(staffID string) *models.Security
 func (ps *ParkingService) AssignSecurityToLot(staffID, lotID string) error {
 	staff := ps.FindSecurityStaffByID(staffID)
 	if staff == nil {
-		return errors.New("security staff not found")
+		return notFound("security staff not found")
 	}
 
 	lot := ps.findLotByID(lotID)
 	if lot == nil {
-		return errors.New("lot not found")
+		return ErrLotNotFound
 	}
 
+	ps.mu.Lock()
 	staff.AssignToLot(lotID)
+	ps.mu.Unlock()
+
+	ps.journalStaff(staff)
 	return nil
 }
 
+func (ps *ParkingService) journalStaff(staff *models.SecurityStaff) {
+	ps.mu.RLock()
+	saved := *staff
+	ps.mu.RUnlock()
+	ps.journal(&JournalEntry{Type: EntryStaffSaved, Staff: &saved})
+}
+
 // Attendant management
 func (ps *ParkingService) AddAttendant(attendant *models.ParkingAttendant) {
+	ps.mu.Lock()
 	ps.attendants = append(ps.attendants, attendant)
+	ps.mu.Unlock()
+
+	saved := *attendant
+	ps.journal(&JournalEntry{Type: EntryAttendantSaved, Attendant: &saved})
+	ps.publishAttendantAssigned(&saved, saved.LotID, "", "")
 }
 
 func (ps *ParkingService) GetAttendants() []*models.ParkingAttendant {
-	return ps.attendants
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	attendants := make([]*models.ParkingAttendant, len(ps.attendants))
+	copy(attendants, ps.attendants)
+	return attendants
 }
 
 func (ps *ParkingService) FindAttendantByID(attendantID string) *models.ParkingAttendant {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
 	for _, attendant := range ps.attendants {
 		if attendant.ID == attendantID {
 			return attendant
@@ -80,46 +206,72 @@ func (ps *ParkingService) FindAttendantByID(attendantID string) *models.ParkingA
 
 func (ps *ParkingService) ParkCarWithAttendant(car *models.Car, attendantID string) (*models.ParkingDecision, error) {
 	if car == nil {
-		return nil, errors.New("car cannot be nil")
+		return nil, ErrNilCar
 	}
 
 	attendant := ps.FindAttendantByID(attendantID)
 	if attendant == nil {
-		return nil, errors.New("attendant not found")
+		return nil, ErrAttendantNotFound
 	}
 
-	decision, err := attendant.MakeParkingDecision(ps.lots, car)
+	decision, err := attendant.MakeParkingDecision(ps.getLots(), car)
 	if err != nil {
 		return nil, err
 	}
 
-	// Execute the parking decision
+	return ps.executeDecision(car, decision)
+}
+
+// executeDecision parks the car in the decided space, falling back to any free
+// space in the same lot if another gate claimed it in the meantime
+func (ps *ParkingService) executeDecision(car *models.Car, decision *models.ParkingDecision) (*models.ParkingDecision, error) {
 	lot := ps.findLotByID(decision.LotID)
 	if lot == nil {
-		return nil, errors.New("lot specified in decision not found")
+		return nil, notFound("lot specified in decision not found")
 	}
 
-	err = lot.ParkCar(car)
-	if err != nil {
-		return nil, err
+	parked := false
+	if spaceID, err := strconv.Atoi(decision.SpaceID); err == nil {
+		_, err := lot.ParkCarInSpace(car, spaceID)
+		parked = err == nil
+	}
+	if !parked {
+		space, err := lot.AssignSpace(car)
+		if err != nil {
+			return nil, err
+		}
+		decision.SpaceID = fmt.Sprintf("%d", space.ID)
 	}
 
+	if attendant := ps.FindAttendantByID(decision.AttendantID); attendant != nil {
+		ps.publishAttendantAssigned(attendant, lot.ID, car.LicensePlate, decision.SpaceID)
+	}
 	return decision, nil
 }
 
 func (ps *ParkingService) AddObserverToLot(lotID string, observer interfaces.ParkingLotObserver) error {
 	lot := ps.findLotByID(lotID)
 	if lot == nil {
-		return errors.New("lot not found")
+		return ErrLotNotFound
 	}
 	lot.AddObserver(observer)
 	return nil
 }
 
+// SetOccupancyThresholds replaces the lot's occupancy alert thresholds;
+// observers and event subscribers are told when occupancy crosses them
+func (ps *ParkingService) SetOccupancyThresholds(lotID string, thresholds []models.OccupancyThreshold) error {
+	lot := ps.findLotByID(lotID)
+	if lot == nil {
+		return ErrLotNotFound
+	}
+	return lot.SetOccupancyThresholds(thresholds)
+}
+
 func (ps *ParkingService) RemoveObserverFromLot(lotID string, observer interfaces.ParkingLotObserver) error {
 	lot := ps.findLotByID(lotID)
 	if lot == nil {
-		return errors.New("lot not found")
+		return ErrLotNotFound
 	}
 	lot.RemoveObserver(observer)
 	return nil
@@ -127,57 +279,57 @@ func (ps *ParkingService) RemoveObserverFromLot(lotID string, observer interface
 
 func (ps *ParkingService) ParkCar(car *models.Car) error {
 	if car == nil {
-		return errors.New("car cannot be nil")
+		return ErrNilCar
 	}
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if err := lot.ParkCar(car); err == nil {
 			return nil
 		}
 	}
 
-	return errors.New("no available parking space")
+	return ErrNoAvailableSpace
 }
 
 func (ps *ParkingService) UnparkCar(licensePlate string) (*models.Car, error) {
 	if licensePlate == "" {
-		return nil, errors.New("license plate cannot be empty")
+		return nil, ErrEmptyLicensePlate
 	}
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if car, err := lot.UnparkCar(licensePlate); err == nil {
 			return car, nil
 		}
 	}
 
-	return nil, errors.New("car not found in any parking lot")
+	return nil, errCarNotInAnyLot
 }
 
 func (ps *ParkingService) FindCar(licensePlate string) (*models.ParkingSpace, error) {
 
 	if licensePlate == "" {
-		return nil, errors.New("license plate cannot be empty")
+		return nil, ErrEmptyLicensePlate
 	}
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if space := lot.FindCar(licensePlate); space != nil {
 			return space, nil
 		}
 	}
 
-	return nil, errors.New("car not found")
+	return nil, ErrCarNotFound
 }
 
 func (ps *ParkingService) GetLotStatus(lotID string) (*models.ParkingLot, error) {
 	lot := ps.findLotByID(lotID)
 	if lot == nil {
-		return nil, errors.New("lot not found")
+		return nil, ErrLotNotFound
 	}
 	return lot, nil
 }
 
 func (ps *ParkingService) IsAnyLotFull() bool {
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if lot.IsFull() {
 			return true
 		}
@@ -186,7 +338,7 @@ func (ps *ParkingService) IsAnyLotFull() bool {
 }
 
 func (ps *ParkingService) findLotByID(lotID string) *models.ParkingLot {
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if lot.ID == lotID {
 			return lot
 		}
@@ -199,39 +351,33 @@ func (ps *ParkingService) findLotByID(lotID string) *models.ParkingLot {
 // UC7: Enhanced car finding functionality
 func (ps *ParkingService) FindCarWithLocation(licensePlate string) (*models.CarLocation, error) {
 	if licensePlate == "" {
-		return nil, errors.New("license plate cannot be empty")
+		return nil, ErrEmptyLicensePlate
 	}
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if space := lot.FindCar(licensePlate); space != nil {
-			// Convert space.ID from int to string
-			spaceIDStr := fmt.Sprintf("%d", space.ID)
-
-			// Extract row and position from space ID if available
-			row := ""
-			position := 0
-			if len(spaceIDStr) > 0 {
-				row = string(spaceIDStr[0]) // First character as row
-				if len(spaceIDStr) > 1 {
-					// Try to parse position from remaining characters
-					if pos := spaceIDStr[1:]; len(pos) > 0 {
-						position = int(pos[0] - '0')
-					}
-				}
+			car, parkedAt := space.GetOccupancy()
+			if car == nil {
+				// Unparked by another gate after FindCar returned
+				continue
 			}
 
-			return models.NewCarLocation(
-				space.ParkedCar,
+			// Row and position come from the lot layout
+			location := models.NewCarLocation(
+				car,
 				lot.ID,
-				spaceIDStr, // Now correctly passing string
-				row,
-				position,
+				fmt.Sprintf("%d", space.ID),
+				space.Row,
+				space.Position,
 				"", // Attendant ID - could be enhanced later
-			), nil
+				parkedAt,
+			)
+			location.Level = space.Level
+			return location, nil
 		}
 	}
 
-	return nil, errors.New("car not found")
+	return nil, ErrCarNotFound
 }
 
 func (ps *ParkingService) ProvideDirectionsToDriver(licensePlate string) (string, error) {
@@ -240,14 +386,21 @@ func (ps *ParkingService) ProvideDirectionsToDriver(licensePlate string) (string
 		return "", err
 	}
 
+	level := ""
+	if location.Level != "" {
+		level = fmt.Sprintf("   🏢 Level: %s\n", location.Level)
+	}
+
 	directions := fmt.Sprintf(
 		"🗺️  Your car %s is located in:\n"+
 			"   📍 Lot: %s\n"+
+			"%s"+
 			"   🅿️  Space: %s\n"+
 			"   📝 Row: %s, Position: %d\n"+
 			"   ⏰ Parked at: %s",
 		licensePlate,
 		location.LotID,
+		level,
 		location.SpaceID,
 		location.Row,
 		location.Position,
@@ -258,141 +411,511 @@ func (ps *ParkingService) ProvideDirectionsToDriver(licensePlate string) (string
 }
 
 // UC8: Billing and time tracking functionality
-type TicketManager struct {
-	tickets map[string]*models.ParkingTicket
+func (ps *ParkingService) ParkCarWithTicket(car *models.Car) (*models.ParkingTicket, error) {
+	if car == nil {
+		return nil, ErrNilCar
+	}
+
+	if _, err := ps.tickets.FindActiveByPlate(car.LicensePlate); err == nil {
+		return nil, ErrActiveTicketExists
+	}
+
+	permit := ps.permits.ValidPermit(car.LicensePlate, ps.now())
+	if permit != nil && permit.HasDedicatedSpace() {
+		if lot := ps.findLotByID(permit.LotIDs[0]); lot != nil {
+			if space, err := lot.ParkCarInSpace(car, permit.SpaceID); err == nil {
+				return ps.issueTicket(lot, space, car, permit.ID)
+			}
+		}
+	}
+
+	for _, lot := range ps.lotsForPermit(ps.lotsInParkingOrder(car), permit) {
+		space, err := lot.AssignSpace(car)
+		if err != nil {
+			continue
+		}
+		permitID := ""
+		if permit != nil && permit.AllowsLot(lot.ID) {
+			permitID = permit.ID
+		}
+		return ps.issueTicket(lot, space, car, permitID)
+	}
+
+	return nil, ErrNoAvailableSpace
 }
 
-func NewTicketManager() *TicketManager {
-	return &TicketManager{
-		tickets: make(map[string]*models.ParkingTicket),
+// lotsForPermit moves the lots the permit is valid in to the front, so
+// holders park on their permit when they can and pay normally elsewhere
+func (ps *ParkingService) lotsForPermit(lots []*models.ParkingLot, permit *models.Permit) []*models.ParkingLot {
+	if permit == nil {
+		return lots
 	}
+	ordered := make([]*models.ParkingLot, 0, len(lots))
+	var others []*models.ParkingLot
+	for _, lot := range lots {
+		if permit.AllowsLot(lot.ID) {
+			ordered = append(ordered, lot)
+		} else {
+			others = append(others, lot)
+		}
+	}
+	return append(ordered, others...)
 }
 
-var globalTicketManager = NewTicketManager()
+// parkReservedWithTicket parks a car arriving on a reservation into the
+// spaces the lot holds for it
+func (ps *ParkingService) parkReservedWithTicket(lot *models.ParkingLot, car *models.Car) (*models.ParkingTicket, error) {
+	if _, err := ps.tickets.FindActiveByPlate(car.LicensePlate); err == nil {
+		return nil, ErrActiveTicketExists
+	}
 
-func (ps *ParkingService) ParkCarWithTicket(car *models.Car) (*models.ParkingTicket, error) {
-	if car == nil {
-		return nil, errors.New("car cannot be nil")
+	space, err := lot.AssignReservedSpace(car)
+	if err != nil {
+		return nil, err
 	}
+	return ps.issueTicket(lot, space, car, "")
+}
 
-	for _, lot := range ps.lots {
-		if !lot.IsFull() {
-			space := lot.FindAvailableSpace()
-			if space != nil {
-				err := lot.ParkCar(car)
-				if err != nil {
-					continue
-				}
+// issueTicket stores the ticket for a car just parked, freeing the space again
+// if the ticket cannot be saved. permitID marks visits covered by a permit.
+func (ps *ParkingService) issueTicket(lot *models.ParkingLot, space *models.ParkingSpace, car *models.Car, permitID string) (*models.ParkingTicket, error) {
+	// Create and store ticket - Convert space.ID to string
+	spaceIDStr := fmt.Sprintf("%d", space.ID)
+	_, parkedAt := space.GetOccupancy()
+	ticket := models.NewParkingTicketWithID(ps.GetTicketIDGenerator().NewTicketID(), car.LicensePlate, lot.ID, spaceIDStr, parkedAt)
+	ticket.PermitID = permitID
+	ticket.RecordVehicle(car)
+	if err := ps.tickets.Save(ticket); err != nil {
+		lot.UnparkCarFromSpace(car.LicensePlate, space.ID)
+		return nil, err
+	}
+	ps.journalTicket(ticket)
+	ps.bus.Publish(events.TicketIssued, lot.ID, events.TicketIssuedPayload{Ticket: *ticket})
 
-				// Create and store ticket - Convert space.ID to string
-				spaceIDStr := fmt.Sprintf("%d", space.ID)
-				ticket := models.NewParkingTicket(car.LicensePlate, lot.ID, spaceIDStr)
-				globalTicketManager.tickets[ticket.ID] = ticket
+	return ticket, nil
+}
 
-				return ticket, nil
-			}
-		}
+// lotsInParkingOrder puts the lot with the nearest free accessible space
+// first for handicap drivers; other cars try lots in the order they were added
+func (ps *ParkingService) lotsInParkingOrder(car *models.Car) []*models.ParkingLot {
+	lots := ps.getLots()
+	if !car.IsHandicap {
+		return lots
 	}
 
-	return nil, errors.New("no available parking space")
+	nearest, _ := models.NearestAccessibleSpace(lots, car)
+	if nearest == nil {
+		return lots
+	}
+	ordered := []*models.ParkingLot{nearest}
+	for _, lot := range lots {
+		if lot != nearest {
+			ordered = append(ordered, lot)
+		}
+	}
+	return ordered
 }
 
 func (ps *ParkingService) UnparkCarWithBilling(licensePlate string) (*models.Car, *Bill, error) {
 	if licensePlate == "" {
-		return nil, nil, errors.New("license plate cannot be empty")
+		return nil, nil, ErrEmptyLicensePlate
 	}
 
-	// Find and complete ticket
-	var ticket *models.ParkingTicket
-	for _, t := range globalTicketManager.tickets {
-		if t.LicensePlate == licensePlate && t.IsActive {
-			ticket = t
-			break
-		}
+	// Find ticket
+	active, err := ps.tickets.FindActiveByPlate(licensePlate)
+	if err != nil {
+		return nil, nil, errNoActiveTicketByCar
 	}
-
-	if ticket == nil {
-		return nil, nil, errors.New("active ticket not found for car")
+	settled, err := ps.settledBill(active, false)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Unpark the car
+	// Unpark the car - only one concurrent caller can free the space
 	car, err := ps.UnparkCar(licensePlate)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Complete ticket and generate bill
-	ticket.CompleteParking()
-	billingService := NewBillingService(10.0, 5.0) // $10/hour, $5 minimum
-	bill := billingService.GenerateBill(ticket)
+	ticket, err := ps.tickets.CompleteActive(licensePlate, ps.now())
+	if err != nil {
+		return nil, nil, errNoActiveTicketByCar
+	}
+	ps.journalTicket(ticket)
+	if settled != nil {
+		return car, settled, nil
+	}
+	bill := ps.billFor(ticket, car)
+	ps.payments.RecordBill(bill)
+	ps.publishBill(bill)
 
 	return car, bill, nil
 }
-func (ps *ParkingService) GetParkingHistory(licensePlate string) ([]*models.ParkingTicket, error) {
-	var history []*models.ParkingTicket
 
-	for _, ticket := range globalTicketManager.tickets {
-		if ticket.LicensePlate == licensePlate {
-			history = append(history, ticket)
+// UnparkCarWithLostTicket releases a car whose driver cannot present the
+// ticket. The plate must be parked; the bill is the lost-ticket penalty.
+func (ps *ParkingService) UnparkCarWithLostTicket(licensePlate string) (*models.Car, *Bill, error) {
+	if licensePlate == "" {
+		return nil, nil, ErrEmptyLicensePlate
+	}
+
+	ticket, err := ps.lostTicketFor(licensePlate)
+	if err != nil {
+		return nil, nil, err
+	}
+	settled, err := ps.settledBill(ticket, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	car, err := ps.UnparkCar(licensePlate)
+	if err != nil {
+		return nil, nil, err
+	}
+	ticket, err = ps.tickets.CompleteActive(licensePlate, ps.now())
+	if err != nil {
+		return nil, nil, errNoActiveTicketByCar
+	}
+	ps.journalTicket(ticket)
+	if settled != nil {
+		return car, settled, nil
+	}
+	bill := ps.GetBillingService().GenerateLostTicketBill(ticket)
+	ps.payments.RecordBill(bill)
+	ps.publishBill(bill)
+	return car, bill, nil
+}
+
+// lostTicketFor returns the car's active ticket, first recording one from its
+// space when it was parked without a ticket, so the penalty has a ticket to be
+// billed and paid against
+func (ps *ParkingService) lostTicketFor(licensePlate string) (*models.ParkingTicket, error) {
+	if ticket, err := ps.tickets.FindActiveByPlate(licensePlate); err == nil {
+		return ticket, nil
+	}
+	for _, lot := range ps.getLots() {
+		space := lot.FindCar(licensePlate)
+		if space == nil {
+			continue
+		}
+		car, parkedAt := space.GetOccupancy()
+		if car == nil {
+			// Another gate released it after FindCar returned
+			continue
+		}
+		ticket := models.NewParkingTicketWithID(ps.GetTicketIDGenerator().NewTicketID(), licensePlate, lot.ID, fmt.Sprintf("%d", space.ID), parkedAt)
+		ticket.RecordVehicle(car)
+		if err := ps.tickets.Save(ticket); err != nil {
+			return nil, err
+		}
+		ps.journalTicket(ticket)
+		return ticket, nil
+	}
+	return nil, ErrCarNotFound
+}
+
+// QuoteExitBill prices the car's active ticket as if it left now and makes
+// that the bill payments are taken against. With lostTicket the bill is the
+// lost-ticket penalty instead.
+func (ps *ParkingService) QuoteExitBill(licensePlate string, lostTicket bool) (*Bill, error) {
+	bill, err := ps.exitBill(licensePlate, lostTicket)
+	if err != nil {
+		return nil, err
+	}
+	ps.payments.RecordBill(bill)
+	return bill, nil
+}
+
+func (ps *ParkingService) exitBill(licensePlate string, lostTicket bool) (*Bill, error) {
+	var ticket *models.ParkingTicket
+	var err error
+	if lostTicket {
+		ticket, err = ps.lostTicketFor(licensePlate)
+	} else if ticket, err = ps.tickets.FindActiveByPlate(licensePlate); err != nil {
+		err = errNoActiveTicketByCar
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bill := ps.priceExit(ticket, lostTicket)
+	ps.publishBill(bill)
+	return bill, nil
+}
+
+// priceExit bills the active ticket as if the car left now
+func (ps *ParkingService) priceExit(ticket *models.ParkingTicket, lostTicket bool) *Bill {
+	if lostTicket {
+		return ps.GetBillingService().GenerateLostTicketBill(ticket)
+	}
+	var car *models.Car
+	if space, err := ps.FindCar(ticket.LicensePlate); err == nil {
+		car = space.GetParkedCar()
+	}
+	return ps.billFor(ticket, car)
+}
+
+// billFor bills a ticket for the time its permit, if any, did not cover
+func (ps *ParkingService) billFor(ticket *models.ParkingTicket, car *models.Car) *Bill {
+	billing := ps.GetBillingService()
+	if ticket.PermitID != "" {
+		if permit, err := ps.permits.GetPermit(ticket.PermitID); err == nil {
+			return billing.GeneratePermitBill(ticket, car, permit.ID, permit.EndsAt())
 		}
 	}
+	return billing.GenerateBillForCar(ticket, car)
+}
+
+// OverrideSettlement lets the car leave without paying. The attendant's ID is
+// recorded on the exit bill, which stays open for later payment.
+func (ps *ParkingService) OverrideSettlement(licensePlate, attendantID string) (*Bill, error) {
+	if ps.FindAttendantByID(attendantID) == nil {
+		return nil, ErrAttendantNotFound
+	}
+
+	bill, err := ps.exitBill(licensePlate, false)
+	if err != nil {
+		return nil, err
+	}
+	bill.SettlementOverride = attendantID
+	ps.payments.RecordBill(bill)
+	return bill, nil
+}
+
+// settledBill prices the stay as the car leaves, with the lost-ticket penalty
+// if lostTicket, and returns that bill once payments cover it. Paying an
+// earlier quote only counts towards it. It returns nil when the service does
+// not take payments or a permit still covers the stay.
+func (ps *ParkingService) settledBill(ticket *models.ParkingTicket, lostTicket bool) (*Bill, error) {
+	if !ps.payments.RequiresSettlement() {
+		return nil, nil
+	}
+	if ticket.PermitID != "" {
+		if permit, err := ps.permits.GetPermit(ticket.PermitID); err == nil && ps.now().Before(permit.EndsAt()) {
+			return nil, nil
+		}
+	}
+	return ps.payments.settle(ps.priceExit(ticket, lostTicket))
+}
+
+// GetEventBus returns the bus the service publishes its lots' events on
+func (ps *ParkingService) GetEventBus() *events.Bus {
+	return ps.bus
+}
+
+func (ps *ParkingService) GetWebhookService() *WebhookService {
+	return ps.webhooks
+}
+
+func (ps *ParkingService) GetPaymentService() *PaymentService {
+	return ps.payments
+}
+
+func (ps *ParkingService) GetPermitService() *PermitService {
+	return ps.permits
+}
+
+func (ps *ParkingService) GetWaitlistService() *WaitlistService {
+	return ps.waitlist
+}
+
+// GetOccupancyService returns the service keeping the lots' occupancy history and forecasts
+func (ps *ParkingService) GetOccupancyService() *OccupancyService {
+	return ps.occupancy
+}
+
+// GetReportService returns the service building revenue and occupancy reports
+func (ps *ParkingService) GetReportService() *ReportService {
+	return ps.reports
+}
+
+func (ps *ParkingService) GetReservationService() *ReservationService {
+	return ps.reservations
+}
+
+func (ps *ParkingService) GetParkingHistory(licensePlate string) ([]*models.ParkingTicket, error) {
+	history := ps.tickets.FindByPlate(licensePlate)
 
 	if len(history) == 0 {
-		return nil, errors.New("no parking history found for this vehicle")
+		return nil, notFound("no parking history found for this vehicle")
 	}
 
 	return history, nil
 }
 
 func (ps *ParkingService) GetActiveTicket(licensePlate string) (*models.ParkingTicket, error) {
-	for _, ticket := range globalTicketManager.tickets {
-		if ticket.LicensePlate == licensePlate && ticket.IsActive {
-			return ticket, nil
-		}
+	if ticket, err := ps.tickets.FindActiveByPlate(licensePlate); err == nil {
+		return ticket, nil
 	}
 
-	return nil, errors.New("no active ticket found for this vehicle")
+	return nil, errNoActiveTicketFound
+}
+
+func (ps *ParkingService) GetTicket(ticketID string) (*models.ParkingTicket, error) {
+	return ps.tickets.FindByID(ticketID)
+}
+
+func (ps *ParkingService) GetActiveTickets() []*models.ParkingTicket {
+	return ps.tickets.FindActive()
+}
+
+// ticketHistory returns the repository's indexed history queries
+func (ps *ParkingService) ticketHistory() (TicketHistory, error) {
+	history, ok := ps.tickets.(TicketHistory)
+	if !ok {
+		return nil, errors.New("ticket repository does not support history queries")
+	}
+	return history, nil
+}
+
+func (ps *ParkingService) GetTicketsByLot(lotID string) ([]*models.ParkingTicket, error) {
+	history, err := ps.ticketHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history.FindByLot(lotID), nil
+}
+
+func (ps *ParkingService) GetTicketsParkedBetween(from, to time.Time) ([]*models.ParkingTicket, error) {
+	if !from.Before(to) {
+		return nil, invalid("time range start must be before its end")
+	}
+
+	history, err := ps.ticketHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history.FindParkedBetween(from, to), nil
 }
 
 // UC9: Even distribution parking strategy
 func (ps *ParkingService) SetDefaultStrategy(strategy models.ParkingStrategy) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 	ps.defaultStrategy = strategy
 }
 
+func (ps *ParkingService) GetDefaultStrategy() models.ParkingStrategy {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.defaultStrategy
+}
+
+// ParkCarWithDefaultStrategy parks through an attendant using the service's default strategy
+func (ps *ParkingService) ParkCarWithDefaultStrategy(car *models.Car, attendantID string) (*models.ParkingDecision, error) {
+	return ps.ParkCarWithStrategy(car, attendantID, ps.GetDefaultStrategy())
+}
+
+// SetBillingService bills with billing from now on, on the service's clock
+func (ps *ParkingService) SetBillingService(billing *BillingService) {
+	billing.SetClock(ps.GetClock())
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.billing = billing
+}
+
+func (ps *ParkingService) GetBillingService() *BillingService {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.billing
+}
+
+// SetClock runs the service, its lots, billing and event bus on clock. Tests
+// and simulations pass a models.FakeClock to make hours pass instantly.
+func (ps *ParkingService) SetClock(clock models.Clock) {
+	ps.clockMu.Lock()
+	ps.clock = clock
+	ps.clockMu.Unlock()
+
+	for _, lot := range ps.getLots() {
+		lot.SetClock(clock)
+	}
+	ps.GetBillingService().SetClock(clock)
+	ps.bus.SetClock(clock)
+}
+
+func (ps *ParkingService) GetClock() models.Clock {
+	ps.clockMu.RLock()
+	defer ps.clockMu.RUnlock()
+	return ps.clock
+}
+
+// now is the time on the service's clock
+func (ps *ParkingService) now() time.Time {
+	return ps.GetClock().Now()
+}
+
+// SetTicketIDGenerator changes how the IDs of new tickets are made; tickets
+// already issued keep theirs
+func (ps *ParkingService) SetTicketIDGenerator(ticketIDs models.TicketIDGenerator) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.ticketIDs = ticketIDs
+}
+
+func (ps *ParkingService) GetTicketIDGenerator() models.TicketIDGenerator {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.ticketIDs
+}
+
+// VerifyTicketID checks a ticket ID the way a gate scanner would, without
+// looking the ticket up: its signature if the generator can verify its own
+// IDs, otherwise only its check character
+func (ps *ParkingService) VerifyTicketID(ticketID string) error {
+	if verifier, ok := ps.GetTicketIDGenerator().(models.TicketIDVerifier); ok {
+		return verifier.VerifyTicketID(ticketID)
+	}
+	return models.CheckTicketID(ticketID)
+}
+
 func (ps *ParkingService) ParkCarWithStrategy(car *models.Car, attendantID string, strategy models.ParkingStrategy) (*models.ParkingDecision, error) {
 	if car == nil {
-		return nil, errors.New("car cannot be nil")
+		return nil, ErrNilCar
 	}
 
 	attendant := ps.FindAttendantByID(attendantID)
 	if attendant == nil {
-		return nil, errors.New("attendant not found")
+		return nil, ErrAttendantNotFound
 	}
 
 	// Use strategy-based decision making
-	decision, err := attendant.MakeParkingDecisionWithStrategy(ps.lots, car, strategy)
+	decision, err := attendant.MakeParkingDecisionWithStrategy(ps.getLots(), car, strategy)
 	if err != nil {
 		return nil, err
 	}
 
 	// Execute the parking decision
-	lot := ps.findLotByID(decision.LotID)
-	if lot == nil {
-		return nil, errors.New("lot specified in decision not found")
+	return ps.executeDecision(car, decision)
+}
+
+// ParkCarWithStrategyAndTicket parks through an attendant using the strategy
+// and issues a ticket, so the stay is billed on unpark like a gate park
+func (ps *ParkingService) ParkCarWithStrategyAndTicket(car *models.Car, attendantID string, strategy models.ParkingStrategy) (*models.ParkingTicket, error) {
+	if car == nil {
+		return nil, ErrNilCar
+	}
+	if _, err := ps.tickets.FindActiveByPlate(car.LicensePlate); err == nil {
+		return nil, ErrActiveTicketExists
 	}
 
-	err = lot.ParkCar(car)
+	decision, err := ps.ParkCarWithStrategy(car, attendantID, strategy)
 	if err != nil {
 		return nil, err
 	}
-
-	return decision, nil
+	lot := ps.findLotByID(decision.LotID)
+	space := lot.FindCar(car.LicensePlate)
+	if space == nil {
+		return nil, ErrCarNotFound
+	}
+	return ps.issueTicket(lot, space, car, "")
 }
 
 func (ps *ParkingService) GetLotUtilization() []*models.LotUtilization {
 	var utilizations []*models.LotUtilization
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		utilization := models.CalculateLotUtilization(lot)
 		utilizations = append(utilizations, utilization)
 	}
@@ -408,7 +931,7 @@ func (ps *ParkingService) ParkCarEvenDistribution(car *models.Car, attendantID s
 // UC10-UC11: Advanced parking strategies
 func (ps *ParkingService) ParkHandicapCar(car *models.Car, attendantID string) (*models.ParkingDecision, error) {
 	if !car.IsHandicap {
-		return nil, errors.New("car is not registered as handicap vehicle")
+		return nil, invalid("car is not registered as handicap vehicle")
 	}
 
 	strategy := models.NewHandicapPriorityStrategy()
@@ -417,7 +940,7 @@ func (ps *ParkingService) ParkHandicapCar(car *models.Car, attendantID string) (
 
 func (ps *ParkingService) ParkLargeVehicle(car *models.Car, attendantID string) (*models.ParkingDecision, error) {
 	if car.Size != models.LargeVehicle {
-		return nil, errors.New("car is not classified as large vehicle")
+		return nil, invalid("car is not classified as large vehicle")
 	}
 
 	strategy := models.NewLargeVehicleStrategy()
@@ -432,10 +955,10 @@ func (ps *ParkingService) ParkCarSmart(car *models.Car, attendantID string) (*mo
 func (ps *ParkingService) GetHandicapSpacesCount() map[string]int {
 	handicapCounts := make(map[string]int)
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		count := 0
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil && space.ParkedCar.IsHandicap {
+			if car := space.GetParkedCar(); car != nil && car.IsHandicap && !space.IsExtension() {
 				count++
 			}
 		}
@@ -448,10 +971,10 @@ func (ps *ParkingService) GetHandicapSpacesCount() map[string]int {
 func (ps *ParkingService) GetLargeVehicleSpacesCount() map[string]int {
 	largeCounts := make(map[string]int)
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		count := 0
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil && space.ParkedCar.Size == models.LargeVehicle {
+			if car := space.GetParkedCar(); car != nil && car.Size == models.LargeVehicle && !space.IsExtension() {
 				count++
 			}
 		}
@@ -464,7 +987,7 @@ func (ps *ParkingService) GetLargeVehicleSpacesCount() map[string]int {
 func (ps *ParkingService) GetDetailedLotAnalytics() map[string]map[string]interface{} {
 	analytics := make(map[string]map[string]interface{})
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		util := models.CalculateLotUtilization(lot)
 
 		handicapCount := 0
@@ -472,12 +995,12 @@ func (ps *ParkingService) GetDetailedLotAnalytics() map[string]map[string]interf
 		smallVehicleCount := 0
 
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				if space.ParkedCar.IsHandicap {
+			if car := space.GetParkedCar(); car != nil && !space.IsExtension() {
+				if car.IsHandicap {
 					handicapCount++
 				}
 
-				switch space.ParkedCar.Size {
+				switch car.Size {
 				case models.SmallVehicle:
 					smallVehicleCount++
 				case models.LargeVehicle:
@@ -490,6 +1013,7 @@ func (ps *ParkingService) GetDetailedLotAnalytics() map[string]map[string]interf
 			"TotalSpaces":      util.TotalSpaces,
 			"OccupiedSpaces":   util.OccupiedSpaces,
 			"AvailableSpaces":  util.AvailableSpaces,
+			"ParkedVehicles":   util.ParkedVehicles,
 			"UtilizationRate":  util.UtilizationRate,
 			"HandicapVehicles": handicapCount,
 			"LargeVehicles":    largeVehicleCount,
@@ -505,7 +1029,7 @@ func (ps *ParkingService) GetBestLotForLargeVehicle() (*models.ParkingLot, int,
 	var bestLot *models.ParkingLot
 	maxSpaces := -1
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if !lot.IsFull() {
 			available := lot.GetAvailableSpaces()
 			if available > maxSpaces {
@@ -516,7 +1040,7 @@ func (ps *ParkingService) GetBestLotForLargeVehicle() (*models.ParkingLot, int,
 	}
 
 	if bestLot == nil {
-		return nil, 0, errors.New("no lots available for large vehicles")
+		return nil, 0, conflict("no lots available for large vehicles")
 	}
 
 	return bestLot, maxSpaces, nil
@@ -543,7 +1067,7 @@ func (ps *ParkingService) GetLargeVehicleRecommendations() map[string]interface{
 
 	// Find alternative lots suitable for large vehicles
 	var alternatives []string
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		if lot.ID != bestLot.ID && !lot.IsFull() && lot.GetAvailableSpaces() >= 3 {
 			alternatives = append(alternatives, lot.ID)
 		}
@@ -556,7 +1080,7 @@ func (ps *ParkingService) GetLargeVehicleRecommendations() map[string]interface{
 func (ps *ParkingService) ValidateLargeVehicleCapacity() map[string]bool {
 	validation := make(map[string]bool)
 
-	for _, lot := range ps.lots {
+	for _, lot := range ps.getLots() {
 		utilizationRate := float64(lot.GetOccupiedSpaces()) / float64(lot.Capacity) * 100
 		validation[lot.ID] = utilizationRate <= 70.0 // Suitable if 70% or less occupied
 	}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaymentMethod is how a driver pays a bill
+type PaymentMethod string
+
+const (
+	PaymentCash   PaymentMethod = "cash"
+	PaymentCard   PaymentMethod = "card"
+	PaymentWallet PaymentMethod = "wallet"
+)
+
+// ParsePaymentMethod accepts cash, card or wallet
+func ParsePaymentMethod(name string) (PaymentMethod, error) {
+	switch method := PaymentMethod(strings.ToLower(name)); method {
+	case PaymentCash, PaymentCard, PaymentWallet:
+		return method, nil
+	default:
+		return "", invalid("unknown payment method %q", name)
+	}
+}
+
+// PaymentProvider moves money for the payment service. Charge returns the
+// provider's reference for the transaction, which Refund takes back.
+type PaymentProvider interface {
+	Name() string
+	Charge(method PaymentMethod, amount float64, reference string) (string, error)
+	Refund(transactionID string, amount float64) error
+}
+
+// LocalPaymentProvider settles every payment in process without a payment
+// network. It is meant for tests and demos; Decline makes a method fail.
+type LocalPaymentProvider struct {
+	declined     map[PaymentMethod]bool
+	transactions []*LocalTransaction
+	mu           sync.Mutex
+}
+
+// LocalTransaction is a charge taken by the local provider
+type LocalTransaction struct {
+	ID        string
+	Method    PaymentMethod
+	Amount    float64
+	Refunded  float64
+	Reference string
+}
+
+func NewLocalPaymentProvider() *LocalPaymentProvider {
+	return &LocalPaymentProvider{declined: make(map[PaymentMethod]bool)}
+}
+
+func (lp *LocalPaymentProvider) Name() string {
+	return "local"
+}
+
+// Decline makes later charges by the method fail, or succeed again when declined is false
+func (lp *LocalPaymentProvider) Decline(method PaymentMethod, declined bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.declined[method] = declined
+}
+
+func (lp *LocalPaymentProvider) Charge(method PaymentMethod, amount float64, reference string) (string, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.declined[method] {
+		return "", paymentRequired("%s payment declined", method)
+	}
+	transaction := &LocalTransaction{
+		// Unique across restarts, since refunds may come from a later process
+		ID:        fmt.Sprintf("LOCAL-%d-%d", time.Now().UnixNano(), len(lp.transactions)+1),
+		Method:    method,
+		Amount:    amount,
+		Reference: reference,
+	}
+	lp.transactions = append(lp.transactions, transaction)
+	return transaction.ID, nil
+}
+
+func (lp *LocalPaymentProvider) Refund(transactionID string, amount float64) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	var transaction *LocalTransaction
+	for _, candidate := range lp.transactions {
+		if candidate.ID == transactionID {
+			transaction = candidate
+		}
+	}
+	if transaction == nil {
+		// Taken before a restart; the payment service has already checked the amount
+		return nil
+	}
+	if amount > transaction.Amount-transaction.Refunded {
+		return invalid("refund cannot be more than transaction %s", transactionID)
+	}
+	transaction.Refunded += amount
+	return nil
+}
+
+// Transactions returns copies of every charge taken so far
+func (lp *LocalPaymentProvider) Transactions() []LocalTransaction {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	transactions := make([]LocalTransaction, 0, len(lp.transactions))
+	for _, transaction := range lp.transactions {
+		transactions = append(transactions, *transaction)
+	}
+	return transactions
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Payment is money taken against the bill of a ticket
+type Payment struct {
+	ID          string
+	TicketID    string
+	Method      PaymentMethod
+	Amount      float64 // Applied to the bill
+	Change      float64 // Cash handed back when more was tendered than was due
+	Provider    string
+	ProviderRef string
+	PaidAt      time.Time
+	Refunds     []Refund
+}
+
+// Refund is money returned from a payment
+type Refund struct {
+	Amount     float64
+	RefundedAt time.Time
+}
+
+// Net is what the payment still contributes to the bill after refunds
+func (p *Payment) Net() float64 {
+	net := p.Amount
+	for _, refund := range p.Refunds {
+		net -= refund.Amount
+	}
+	return roundCents(net)
+}
+
+// Receipt is the payment record of one bill
+type Receipt struct {
+	Bill       *Bill
+	Payments   []*Payment
+	AmountPaid float64
+	BalanceDue float64
+	IssuedAt   time.Time
+}
+
+// PaymentService keeps the latest exit bill of each ticket with the payments
+// and refunds made against it. Bills are settled once payments cover them or
+// an attendant has overridden the check. Without a provider no payments can
+// be taken and unparking is not gated on settlement.
+type PaymentService struct {
+	provider PaymentProvider
+	bills    map[string]*Bill // By ticket ID
+	payments []*Payment
+	journal  func(entry *JournalEntry)
+	now      func() time.Time
+	mu       sync.Mutex // Guards provider, bills and payments; held across provider calls
+}
+
+func NewPaymentService() *PaymentService {
+	return &PaymentService{bills: make(map[string]*Bill), now: time.Now}
+}
+
+// SetProvider takes payments through provider; nil stops taking payments and
+// lets cars leave unpaid
+func (pm *PaymentService) SetProvider(provider PaymentProvider) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.provider = provider
+}
+
+func (pm *PaymentService) Provider() PaymentProvider {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.provider
+}
+
+// RequiresSettlement reports whether cars must settle their bill before leaving
+func (pm *PaymentService) RequiresSettlement() bool {
+	return pm.Provider() != nil
+}
+
+// RecordBill makes bill the one payments for its ticket are taken against,
+// replacing an earlier quote. Payments already made still count towards it.
+func (pm *PaymentService) RecordBill(bill *Bill) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.bills[bill.TicketID] = bill
+	pm.journalBill(bill)
+}
+
+func (pm *PaymentService) GetBill(ticketID string) (*Bill, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	bill, ok := pm.bills[ticketID]
+	if !ok {
+		return nil, ErrBillNotFound
+	}
+	return bill, nil
+}
+
+// Pay charges amount by method against the ticket's bill. Cash beyond the
+// balance due is returned as change; other methods must not exceed it.
+func (pm *PaymentService) Pay(ticketID string, method PaymentMethod, amount float64) (*Payment, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.provider == nil {
+		return nil, ErrNoPaymentProvider
+	}
+	if amount <= 0 {
+		return nil, invalid("payment amount must be positive")
+	}
+	bill, ok := pm.bills[ticketID]
+	if !ok {
+		return nil, ErrBillNotFound
+	}
+	due := pm.balanceLocked(bill)
+	if due <= 0 {
+		return nil, conflict("bill is already settled")
+	}
+
+	payment := &Payment{
+		ID:       fmt.Sprintf("PAY-%06d", len(pm.payments)+1),
+		TicketID: ticketID,
+		Method:   method,
+		Amount:   roundCents(amount),
+		Provider: pm.provider.Name(),
+	}
+	if payment.Amount > due {
+		if method != PaymentCash {
+			return nil, invalid("payment cannot be more than the balance due")
+		}
+		payment.Change = roundCents(payment.Amount - due)
+		payment.Amount = due
+	}
+
+	ref, err := pm.provider.Charge(method, payment.Amount, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	payment.ProviderRef = ref
+	payment.PaidAt = pm.now()
+	pm.payments = append(pm.payments, payment)
+	pm.journalPayment(payment)
+
+	saved := *payment
+	return &saved, nil
+}
+
+// Refund returns amount from a payment, reopening that much of its bill
+func (pm *PaymentService) Refund(paymentID string, amount float64) (*Payment, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.provider == nil {
+		return nil, ErrNoPaymentProvider
+	}
+	if amount <= 0 {
+		return nil, invalid("refund amount must be positive")
+	}
+	var payment *Payment
+	for _, candidate := range pm.payments {
+		if candidate.ID == paymentID {
+			payment = candidate
+			break
+		}
+	}
+	if payment == nil {
+		return nil, notFound("payment not found")
+	}
+	amount = roundCents(amount)
+	if amount > payment.Net() {
+		return nil, invalid("refund cannot be more than the amount paid")
+	}
+
+	if err := pm.provider.Refund(payment.ProviderRef, amount); err != nil {
+		return nil, err
+	}
+	payment.Refunds = append(payment.Refunds, Refund{Amount: amount, RefundedAt: pm.now()})
+	pm.journalPayment(payment)
+
+	saved := *payment
+	return &saved, nil
+}
+
+// GetPayments returns the payments made against the ticket's bill, oldest first
+func (pm *PaymentService) GetPayments(ticketID string) []*Payment {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.paymentsLocked(ticketID)
+}
+
+// BalanceDue is what remains to be paid on the ticket's bill
+func (pm *PaymentService) BalanceDue(ticketID string) (float64, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	bill, ok := pm.bills[ticketID]
+	if !ok {
+		return 0, ErrBillNotFound
+	}
+	return pm.balanceLocked(bill), nil
+}
+
+func (pm *PaymentService) GetReceipt(ticketID string) (*Receipt, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	bill, ok := pm.bills[ticketID]
+	if !ok {
+		return nil, ErrBillNotFound
+	}
+	receipt := &Receipt{
+		Bill:       bill,
+		Payments:   pm.paymentsLocked(ticketID),
+		BalanceDue: pm.balanceLocked(bill),
+		IssuedAt:   pm.now(),
+	}
+	for _, payment := range receipt.Payments {
+		receipt.AmountPaid += payment.Net()
+	}
+	receipt.AmountPaid = roundCents(receipt.AmountPaid)
+	return receipt, nil
+}
+
+// settle makes bill, the stay priced as the car leaves, the one payments
+// for its ticket are taken against, and returns it once the payments made so
+// far cover it or an attendant has overridden the gate. The ticket must have
+// been quoted before; an override on the quote carries over.
+func (pm *PaymentService) settle(bill *Bill) (*Bill, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	quoted, ok := pm.bills[bill.TicketID]
+	if !ok {
+		return nil, notSettled("bill is not settled: request the exit bill and pay it first")
+	}
+	bill.SettlementOverride = quoted.SettlementOverride
+	pm.bills[bill.TicketID] = bill
+	pm.journalBill(bill)
+	if bill.SettlementOverride != "" {
+		return bill, nil
+	}
+	if due := pm.balanceLocked(bill); due > 0 {
+		return nil, notSettled("bill is not settled: $%.2f due", due)
+	}
+	return bill, nil
+}
+
+func (pm *PaymentService) balanceLocked(bill *Bill) float64 {
+	due := bill.TotalAmount
+	for _, payment := range pm.payments {
+		if payment.TicketID == bill.TicketID {
+			due -= payment.Net()
+		}
+	}
+	return roundCents(due)
+}
+
+func (pm *PaymentService) paymentsLocked(ticketID string) []*Payment {
+	var payments []*Payment
+	for _, payment := range pm.payments {
+		if payment.TicketID == ticketID {
+			saved := *payment
+			payments = append(payments, &saved)
+		}
+	}
+	return payments
+}
+
+// restore loads bills and payments from a snapshot
+func (pm *PaymentService) restore(bills []*Bill, payments []*Payment) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for _, bill := range bills {
+		pm.bills[bill.TicketID] = bill
+	}
+	pm.payments = append(pm.payments, payments...)
+}
+
+// snapshot copies every bill, ordered by ticket ID, and every payment
+func (pm *PaymentService) snapshot() ([]*Bill, []*Payment) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	bills := make([]*Bill, 0, len(pm.bills))
+	for _, bill := range pm.bills {
+		saved := *bill
+		bills = append(bills, &saved)
+	}
+	sort.Slice(bills, func(i, j int) bool { return bills[i].TicketID < bills[j].TicketID })
+
+	payments := make([]*Payment, 0, len(pm.payments))
+	for _, payment := range pm.payments {
+		saved := *payment
+		payments = append(payments, &saved)
+	}
+	return bills, payments
+}
+
+func (pm *PaymentService) journalBill(bill *Bill) {
+	if pm.journal != nil {
+		saved := *bill
+		pm.journal(&JournalEntry{Type: EntryBillSaved, Bill: &saved})
+	}
+}
+
+func (pm *PaymentService) journalPayment(payment *Payment) {
+	if pm.journal != nil {
+		saved := *payment
+		saved.Refunds = append([]Refund(nil), payment.Refunds...)
+		pm.journal(&JournalEntry{Type: EntryPaymentSaved, Payment: &saved})
+	}
+}
+
+func (r *Receipt) PrintReceipt() string {
+	var payments strings.Builder
+	for _, payment := range r.Payments {
+		fmt.Fprintf(&payments, "  %-12s %-8s $%.2f\n", payment.ID, payment.Method, payment.Amount)
+		if payment.Change > 0 {
+			fmt.Fprintf(&payments, "  %-21s $%.2f\n", "Change", payment.Change)
+		}
+		for _, refund := range payment.Refunds {
+			fmt.Fprintf(&payments, "  %-21s $%.2f\n", "Refund", -refund.Amount)
+		}
+	}
+
+	return fmt.Sprintf(`
+=================================
+        PAYMENT RECEIPT
+=================================
+Ticket ID: %s
+License Plate: %s
+Issued At: %s
+Bill Total: $%.2f
+%s---------------------------------
+Amount Paid: $%.2f
+Balance Due: $%.2f
+=================================
+`,
+		r.Bill.TicketID,
+		r.Bill.LicensePlate,
+		r.IssuedAt.Format("2006-01-02 15:04:05"),
+		r.Bill.TotalAmount,
+		payments.String(),
+		r.AmountPaid,
+		r.BalanceDue,
+	)
+}
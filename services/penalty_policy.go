@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// PenaltyPolicy prices lost tickets and stays beyond a lot's maximum. The
+// zero value bills a lost ticket at the maximum daily price and never
+// charges for overstays.
+type PenaltyPolicy struct {
+	// LostTicketFee is the flat lost-ticket charge; the tariff's price for a full day when zero
+	LostTicketFee float64
+	// MaxStay is the longest allowed stay per lot ID; lots without an entry have no limit
+	MaxStay map[string]time.Duration
+	// OverstayFee is charged once when a stay exceeds its lot's maximum
+	OverstayFee float64
+	// OverstayHourlyRate is charged per started hour beyond the maximum
+	OverstayHourlyRate float64
+}
+
+// overstay returns how far a stay in the lot ran past its maximum, if at all
+func (pp PenaltyPolicy) overstay(lotID string, stay time.Duration) time.Duration {
+	limit, ok := pp.MaxStay[lotID]
+	if !ok || limit <= 0 || stay <= limit {
+		return 0
+	}
+	return stay - limit
+}
+
+func (pp PenaltyPolicy) overstayPenalty(lotID string, overstay time.Duration) Adjustment {
+	hours := math.Ceil(overstay.Hours())
+	return Adjustment{
+		Reason: fmt.Sprintf("Overstay of %s beyond %s limit", overstay.Round(time.Minute), pp.MaxStay[lotID]),
+		Amount: pp.OverstayFee + hours*pp.OverstayHourlyRate,
+	}
+}
+
+// lostTicketFee is the flat fee, or what the tariff charges for a full day
+// starting at the given time
+func (pp PenaltyPolicy) lostTicketFee(tariff Tariff, from time.Time) float64 {
+	if pp.LostTicketFee > 0 {
+		return pp.LostTicketFee
+	}
+	return totalOf(tariff.Charge(from, from.Add(24*time.Hour)))
+}
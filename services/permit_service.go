@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"parking-lot-system/models"
+	"sync"
+	"time"
+)
+
+// PermitService keeps the permits of subscriber plates. Cars with a valid
+// permit park without hourly billing in the permit's lots, in their
+// dedicated space when they have one; their tickets record the permit.
+type PermitService struct {
+	parking *ParkingService
+	permits []*models.Permit
+	journal func(entry *JournalEntry)
+	mu      sync.Mutex // Guards permits; taken before any lot lock
+}
+
+func newPermitService(parking *ParkingService) *PermitService {
+	return &PermitService{
+		parking: parking,
+		journal: parking.journal,
+	}
+}
+
+// Issue validates and stores the permit, giving it an ID, and keeps its
+// dedicated space, if any, for the holder
+func (ps *PermitService) Issue(permit *models.Permit) (*models.Permit, error) {
+	if permit == nil {
+		return nil, invalid("permit cannot be nil")
+	}
+	if permit.LicensePlate == "" {
+		return nil, ErrEmptyLicensePlate
+	}
+	if !permit.ValidUntil.After(permit.ValidFrom) {
+		return nil, invalid("permit end must be after its start")
+	}
+	for _, lotID := range permit.LotIDs {
+		if ps.parking.findLotByID(lotID) == nil {
+			return nil, errParkingLotNotFound
+		}
+	}
+	if permit.HasDedicatedSpace() && len(permit.LotIDs) != 1 {
+		return nil, invalid("dedicated space must be in the permit's only lot")
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	now := ps.parking.now()
+	for _, other := range ps.permits {
+		if other.EndsAt().Before(now) || !other.EndsAt().After(permit.ValidFrom) || !permit.ValidUntil.After(other.ValidFrom) {
+			continue
+		}
+		if other.LicensePlate == permit.LicensePlate {
+			return nil, conflict("vehicle already has a permit for that period")
+		}
+		if permit.HasDedicatedSpace() && other.HasDedicatedSpace() && other.LotIDs[0] == permit.LotIDs[0] && other.SpaceID == permit.SpaceID {
+			return nil, conflict("parking space is already dedicated to a permit")
+		}
+	}
+
+	issued := *permit
+	issued.LotIDs = append([]string(nil), permit.LotIDs...)
+	issued.ID = fmt.Sprintf("PRM-%06d", len(ps.permits)+1)
+	issued.RevokedAt = time.Time{}
+	issued.CreatedAt = now
+	if issued.HasDedicatedSpace() {
+		lot := ps.parking.findLotByID(issued.LotIDs[0])
+		if err := lot.DedicateSpace(issued.SpaceID, issued.LicensePlate, issued.ValidFrom, issued.ValidUntil); err != nil {
+			return nil, err
+		}
+	}
+	ps.permits = append(ps.permits, &issued)
+	ps.journalPermit(&issued)
+
+	saved := issued
+	return &saved, nil
+}
+
+// Renew moves the end of an unrevoked permit to until
+func (ps *PermitService) Renew(permitID string, until time.Time) (*models.Permit, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	permit := ps.findLocked(permitID)
+	if permit == nil {
+		return nil, ErrPermitNotFound
+	}
+	if !permit.RevokedAt.IsZero() {
+		return nil, ErrPermitNotActive
+	}
+	if !until.After(permit.ValidFrom) {
+		return nil, invalid("permit end must be after its start")
+	}
+	permit.ValidUntil = until
+	ps.dedicateLocked(permit)
+	ps.journalPermit(permit)
+
+	saved := *permit
+	return &saved, nil
+}
+
+// Revoke ends the permit now; stays already under way are billed normally from now on
+func (ps *PermitService) Revoke(permitID string) (*models.Permit, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	permit := ps.findLocked(permitID)
+	if permit == nil {
+		return nil, ErrPermitNotFound
+	}
+	now := ps.parking.now()
+	if !now.Before(permit.EndsAt()) {
+		return nil, ErrPermitNotActive
+	}
+	permit.RevokedAt = now
+	ps.dedicateLocked(permit)
+	ps.journalPermit(permit)
+
+	saved := *permit
+	return &saved, nil
+}
+
+func (ps *PermitService) GetPermit(permitID string) (*models.Permit, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	permit := ps.findLocked(permitID)
+	if permit == nil {
+		return nil, ErrPermitNotFound
+	}
+	saved := *permit
+	return &saved, nil
+}
+
+// GetPermits lists every permit in the order they were issued
+func (ps *PermitService) GetPermits() []*models.Permit {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	permits := make([]*models.Permit, 0, len(ps.permits))
+	for _, permit := range ps.permits {
+		saved := *permit
+		permits = append(permits, &saved)
+	}
+	return permits
+}
+
+// ValidPermit returns the plate's permit valid at the given time, or nil
+func (ps *PermitService) ValidPermit(licensePlate string, at time.Time) *models.Permit {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, permit := range ps.permits {
+		if permit.LicensePlate == licensePlate && permit.IsValidAt(at) {
+			saved := *permit
+			return &saved
+		}
+	}
+	return nil
+}
+
+// dedicatedSpacesIn keeps the lot's dedicated spaces for their holders; used
+// for lots added after their permits were issued or restored
+func (ps *PermitService) dedicatedSpacesIn(lot *models.ParkingLot) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, permit := range ps.permits {
+		if permit.HasDedicatedSpace() && permit.LotIDs[0] == lot.ID {
+			lot.DedicateSpace(permit.SpaceID, permit.LicensePlate, permit.ValidFrom, permit.EndsAt())
+		}
+	}
+}
+
+// dedicateLocked keeps the permit's space, if any, while the permit is valid
+func (ps *PermitService) dedicateLocked(permit *models.Permit) {
+	if !permit.HasDedicatedSpace() {
+		return
+	}
+	if lot := ps.parking.findLotByID(permit.LotIDs[0]); lot != nil {
+		lot.DedicateSpace(permit.SpaceID, permit.LicensePlate, permit.ValidFrom, permit.EndsAt())
+	}
+}
+
+func (ps *PermitService) findLocked(permitID string) *models.Permit {
+	for _, permit := range ps.permits {
+		if permit.ID == permitID {
+			return permit
+		}
+	}
+	return nil
+}
+
+// restore loads permits from a snapshot and keeps their dedicated spaces
+func (ps *PermitService) restore(permits []*models.Permit) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.permits = append(ps.permits, permits...)
+	for _, permit := range permits {
+		ps.dedicateLocked(permit)
+	}
+}
+
+// snapshot copies every permit
+func (ps *PermitService) snapshot() []*models.Permit {
+	return ps.GetPermits()
+}
+
+func (ps *PermitService) journalPermit(permit *models.Permit) {
+	saved := *permit
+	ps.journal(&JournalEntry{Type: EntryPermitSaved, Permit: &saved})
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"parking-lot-system/models"
+	"sort"
+	"time"
+)
+
+// Journal entry types written for every durable state change
+const (
+	EntryLotAdded         = "lot_added"
+	EntrySpaceOccupied    = "space_occupied"
+	EntrySpaceReleased    = "space_released"
+	EntryTicketSaved      = "ticket_saved"
+	EntryAttendantSaved   = "attendant_saved"
+	EntryStaffSaved       = "security_staff_saved"
+	EntryBillSaved        = "bill_saved"
+	EntryPaymentSaved     = "payment_saved"
+	EntryReservationSaved = "reservation_saved"
+	EntryPermitSaved      = "permit_saved"
+	EntryWaitlistSaved    = "waitlist_saved"
+	EntryDeadLetterSaved  = "dead_letter_saved"
+	EntryOccupancySampled = "occupancy_sampled"
+)
+
+// JournalEntry is one state change. Each entry overwrites the state of a single
+// key (a space, a ticket or a staff member), so replaying entries on top of a
+// snapshot taken at any later point still yields the final state.
+type JournalEntry struct {
+	Sequence    uint64                   `json:"seq"`
+	Type        string                   `json:"type"`
+	LotID       string                   `json:"lotId,omitempty"`
+	Capacity    int                      `json:"capacity,omitempty"`
+	SpaceSpecs  []models.SpaceSpec       `json:"spaceSpecs,omitempty"`
+	SpaceID     int                      `json:"spaceId,omitempty"`
+	Car         *models.Car              `json:"car,omitempty"`
+	At          time.Time                `json:"at,omitempty"`
+	Ticket      *models.ParkingTicket    `json:"ticket,omitempty"`
+	Attendant   *models.ParkingAttendant `json:"attendant,omitempty"`
+	Staff       *models.SecurityStaff    `json:"staff,omitempty"`
+	Bill        *Bill                    `json:"bill,omitempty"`
+	Payment     *Payment                 `json:"payment,omitempty"`
+	Reservation *models.Reservation      `json:"reservation,omitempty"`
+	Permit      *models.Permit           `json:"permit,omitempty"`
+	Waitlist    *models.WaitlistEntry    `json:"waitlist,omitempty"`
+	DeadLetter  *models.DeadLetter       `json:"deadLetter,omitempty"`
+	Sample      *models.OccupancySample  `json:"sample,omitempty"`
+}
+
+// Snapshot is the complete durable state of a ParkingService
+type Snapshot struct {
+	Sequence      uint64
+	Lots          []*LotSnapshot
+	Tickets       []*models.ParkingTicket
+	Attendants    []*models.ParkingAttendant
+	SecurityStaff []*models.SecurityStaff
+	Bills         []*Bill                   `json:",omitempty"` // Latest exit bill of each ticket
+	Payments      []*Payment                `json:",omitempty"`
+	Reservations  []*models.Reservation     `json:",omitempty"`
+	Permits       []*models.Permit          `json:",omitempty"`
+	Waitlist      []*models.WaitlistEntry   `json:",omitempty"`
+	DeadLetters   []*models.DeadLetter      `json:",omitempty"` // Failed webhook deliveries
+	Occupancy     []*models.OccupancySample `json:",omitempty"` // Occupancy history of every lot, oldest first
+}
+
+type LotSnapshot struct {
+	ID         string
+	Capacity   int
+	SpaceSpecs []models.SpaceSpec `json:",omitempty"` // Absent for lots saved before spaces had types
+	Spaces     []*SpaceSnapshot   // Occupied spaces only
+}
+
+type SpaceSnapshot struct {
+	SpaceID  int
+	Car      *models.Car
+	ParkedAt time.Time
+}
+
+// StateStore makes ParkingService state survive restarts
+type StateStore interface {
+	// Append durably records the entry and assigns its Sequence
+	Append(entry *JournalEntry) error
+	LastSequence() uint64
+	// SaveSnapshot stores the snapshot and may discard entries it covers
+	SaveSnapshot(snapshot *Snapshot) error
+	// Load returns the latest snapshot (nil if none) and the entries after it
+	Load() (*Snapshot, []*JournalEntry, error)
+	Close() error
+}
+
+// Apply replays a journal entry onto the snapshot
+func (s *Snapshot) Apply(entry *JournalEntry) {
+	switch entry.Type {
+	case EntryLotAdded:
+		if s.findLot(entry.LotID) == nil {
+			s.Lots = append(s.Lots, &LotSnapshot{ID: entry.LotID, Capacity: entry.Capacity, SpaceSpecs: entry.SpaceSpecs})
+		}
+	case EntrySpaceOccupied:
+		if lot := s.findLot(entry.LotID); lot != nil {
+			lot.removeSpace(entry.SpaceID)
+			lot.Spaces = append(lot.Spaces, &SpaceSnapshot{SpaceID: entry.SpaceID, Car: entry.Car, ParkedAt: entry.At})
+		}
+	case EntrySpaceReleased:
+		if lot := s.findLot(entry.LotID); lot != nil {
+			lot.removeSpace(entry.SpaceID)
+		}
+	case EntryTicketSaved:
+		for i, ticket := range s.Tickets {
+			if ticket.ID == entry.Ticket.ID {
+				s.Tickets[i] = entry.Ticket
+				return
+			}
+		}
+		s.Tickets = append(s.Tickets, entry.Ticket)
+	case EntryAttendantSaved:
+		for i, attendant := range s.Attendants {
+			if attendant.ID == entry.Attendant.ID {
+				s.Attendants[i] = entry.Attendant
+				return
+			}
+		}
+		s.Attendants = append(s.Attendants, entry.Attendant)
+	case EntryStaffSaved:
+		for i, staff := range s.SecurityStaff {
+			if staff.ID == entry.Staff.ID {
+				s.SecurityStaff[i] = entry.Staff
+				return
+			}
+		}
+		s.SecurityStaff = append(s.SecurityStaff, entry.Staff)
+	case EntryBillSaved:
+		for i, bill := range s.Bills {
+			if bill.TicketID == entry.Bill.TicketID {
+				s.Bills[i] = entry.Bill
+				return
+			}
+		}
+		s.Bills = append(s.Bills, entry.Bill)
+	case EntryPaymentSaved:
+		for i, payment := range s.Payments {
+			if payment.ID == entry.Payment.ID {
+				s.Payments[i] = entry.Payment
+				return
+			}
+		}
+		s.Payments = append(s.Payments, entry.Payment)
+	case EntryReservationSaved:
+		for i, reservation := range s.Reservations {
+			if reservation.ID == entry.Reservation.ID {
+				s.Reservations[i] = entry.Reservation
+				return
+			}
+		}
+		s.Reservations = append(s.Reservations, entry.Reservation)
+	case EntryPermitSaved:
+		for i, permit := range s.Permits {
+			if permit.ID == entry.Permit.ID {
+				s.Permits[i] = entry.Permit
+				return
+			}
+		}
+		s.Permits = append(s.Permits, entry.Permit)
+	case EntryWaitlistSaved:
+		for i, queued := range s.Waitlist {
+			if queued.ID == entry.Waitlist.ID {
+				s.Waitlist[i] = entry.Waitlist
+				return
+			}
+		}
+		s.Waitlist = append(s.Waitlist, entry.Waitlist)
+	case EntryDeadLetterSaved:
+		for i, letter := range s.DeadLetters {
+			if letter.ID == entry.DeadLetter.ID {
+				s.DeadLetters[i] = entry.DeadLetter
+				return
+			}
+		}
+		s.DeadLetters = append(s.DeadLetters, entry.DeadLetter)
+	case EntryOccupancySampled:
+		// Keyed by lot and time; a sample the snapshot already holds is
+		// recent, so search from the end
+		for i := len(s.Occupancy) - 1; i >= 0; i-- {
+			if sample := s.Occupancy[i]; sample.LotID == entry.Sample.LotID && sample.At.Equal(entry.Sample.At) {
+				s.Occupancy[i] = entry.Sample
+				return
+			}
+		}
+		s.Occupancy = append(s.Occupancy, entry.Sample)
+	}
+	if entry.Sequence > s.Sequence {
+		s.Sequence = entry.Sequence
+	}
+}
+
+func (s *Snapshot) findLot(lotID string) *LotSnapshot {
+	for _, lot := range s.Lots {
+		if lot.ID == lotID {
+			return lot
+		}
+	}
+	return nil
+}
+
+func (l *LotSnapshot) removeSpace(spaceID int) {
+	for i, space := range l.Spaces {
+		if space.SpaceID == spaceID {
+			l.Spaces = append(l.Spaces[:i], l.Spaces[i+1:]...)
+			return
+		}
+	}
+}
+
+// RestoreParkingService rebuilds a service from the store and journals every
+// later change back into it
+func RestoreParkingService(store StateStore) (*ParkingService, error) {
+	if store == nil {
+		return nil, errors.New("state store cannot be nil")
+	}
+
+	snapshot, entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		snapshot = &Snapshot{}
+	}
+	for _, entry := range entries {
+		snapshot.Apply(entry)
+	}
+
+	ps := NewParkingService()
+	for _, lotState := range snapshot.Lots {
+		lot := models.NewParkingLot(lotState.ID, lotState.Capacity)
+		if len(lotState.SpaceSpecs) > 0 {
+			lot = models.NewParkingLotWithSpecs(lotState.ID, lotState.SpaceSpecs)
+		}
+		for _, space := range lotState.Spaces {
+			if err := lot.RestoreSpace(space.SpaceID, space.Car, space.ParkedAt); err != nil {
+				return nil, err
+			}
+		}
+		if err := ps.AddLot(lot); err != nil {
+			return nil, err
+		}
+	}
+
+	tickets := snapshot.Tickets
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].ParkedAt.Before(tickets[j].ParkedAt)
+	})
+	for _, ticket := range tickets {
+		if err := ps.tickets.Save(ticket); err != nil {
+			return nil, err
+		}
+	}
+
+	ps.attendants = append(ps.attendants, snapshot.Attendants...)
+	ps.securityStaff = append(ps.securityStaff, snapshot.SecurityStaff...)
+	ps.payments.restore(snapshot.Bills, snapshot.Payments)
+	ps.reservations.restore(snapshot.Reservations)
+	ps.permits.restore(snapshot.Permits)
+	ps.waitlist.restore(snapshot.Waitlist)
+	ps.webhooks.restore(snapshot.DeadLetters)
+	ps.occupancy.restore(snapshot.Occupancy)
+
+	ps.attachStore(store)
+	// Expire reservations missed while the service was down
+	ps.reservations.Refresh(ps.now())
+	ps.waitlist.Refresh(ps.now())
+	return ps, nil
+}
+
+func (ps *ParkingService) attachStore(store StateStore) {
+	ps.mu.Lock()
+	ps.store = store
+	ps.mu.Unlock()
+
+	ps.AddOccupancyListener(&lotJournal{store: store})
+}
+
+// journal records a change if the service has a store; failures are logged
+// rather than failing the parking operation that already happened
+func (ps *ParkingService) journal(entry *JournalEntry) {
+	ps.mu.RLock()
+	store := ps.store
+	ps.mu.RUnlock()
+
+	appendEntry(store, entry)
+}
+
+func appendEntry(store StateStore, entry *JournalEntry) {
+	if store == nil {
+		return
+	}
+	if err := store.Append(entry); err != nil {
+		log.Printf("warning: failed to persist %s entry: %v", entry.Type, err)
+	}
+}
+
+func (ps *ParkingService) journalTicket(ticket *models.ParkingTicket) {
+	saved := *ticket
+	ps.journal(&JournalEntry{Type: EntryTicketSaved, Ticket: &saved})
+}
+
+// SaveSnapshot writes the current state so the journal can be compacted
+func (ps *ParkingService) SaveSnapshot() error {
+	ps.mu.RLock()
+	store := ps.store
+	ps.mu.RUnlock()
+
+	if store == nil {
+		return errors.New("no state store configured")
+	}
+
+	// Entries after this sequence are replayed on top of the snapshot
+	snapshot := &Snapshot{Sequence: store.LastSequence()}
+
+	for _, lot := range ps.getLots() {
+		lotState := &LotSnapshot{ID: lot.ID, Capacity: lot.Capacity, SpaceSpecs: lot.GetSpaceSpecs()}
+		for _, space := range lot.Spaces {
+			if car, parkedAt := space.GetOccupancy(); car != nil {
+				parked := *car
+				lotState.Spaces = append(lotState.Spaces, &SpaceSnapshot{SpaceID: space.ID, Car: &parked, ParkedAt: parkedAt})
+			}
+		}
+		snapshot.Lots = append(snapshot.Lots, lotState)
+	}
+
+	for _, ticket := range ps.tickets.FindAll() {
+		saved := *ticket
+		snapshot.Tickets = append(snapshot.Tickets, &saved)
+	}
+	for _, attendant := range ps.GetAttendants() {
+		saved := *attendant
+		snapshot.Attendants = append(snapshot.Attendants, &saved)
+	}
+	for _, staff := range ps.GetSecurityStaff() {
+		saved := *staff
+		snapshot.SecurityStaff = append(snapshot.SecurityStaff, &saved)
+	}
+	snapshot.Bills, snapshot.Payments = ps.payments.snapshot()
+	snapshot.Reservations = ps.reservations.snapshot()
+	snapshot.Permits = ps.permits.snapshot()
+	snapshot.Waitlist = ps.waitlist.snapshot()
+	snapshot.DeadLetters = ps.webhooks.snapshot()
+	snapshot.Occupancy = ps.occupancy.snapshot()
+
+	return store.SaveSnapshot(snapshot)
+}
+
+// StartPeriodicSnapshots saves a snapshot every interval until stop is called
+func (ps *ParkingService) StartPeriodicSnapshots(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := ps.SaveSnapshot(); err != nil {
+					log.Printf("warning: periodic snapshot failed: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// lotJournal forwards lot occupancy changes into the store. It is called with
+// the lot locked, so it must not take the service lock.
+type lotJournal struct {
+	store StateStore
+}
+
+func (lj *lotJournal) OnSpaceOccupied(lotID string, spaceID int, car *models.Car, parkedAt time.Time) {
+	parked := *car
+	appendEntry(lj.store, &JournalEntry{Type: EntrySpaceOccupied, LotID: lotID, SpaceID: spaceID, Car: &parked, At: parkedAt})
+}
+
+func (lj *lotJournal) OnSpaceReleased(lotID string, spaceID int, licensePlate string, releasedAt time.Time) {
+	appendEntry(lj.store, &JournalEntry{Type: EntrySpaceReleased, LotID: lotID, SpaceID: spaceID, At: releasedAt})
+}
@@ -30,16 +30,16 @@ type VehicleInvestigationInfo struct {
 func (ps *PoliceService) FindWhiteCars() ([]*VehicleInvestigationInfo, error) {
 	var whiteCars []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				if strings.ToLower(car.Color) == "white" {
 					info := &VehicleInvestigationInfo{
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID), // FIXED: Convert int to string
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					// Try to find attendant info if available
@@ -60,16 +60,16 @@ func (ps *PoliceService) FindWhiteCars() ([]*VehicleInvestigationInfo, error) {
 func (ps *PoliceService) FindBlueToyotaCars() ([]*VehicleInvestigationInfo, error) {
 	var blueToyotas []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				if strings.ToLower(car.Color) == "blue" && strings.ToLower(car.Make) == "toyota" {
 					info := &VehicleInvestigationInfo{
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID), // FIXED: Convert int to string
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					// Get attendant info for robbery investigation
@@ -95,10 +95,10 @@ func (ps *PoliceService) FindBlueToyotaCars() ([]*VehicleInvestigationInfo, erro
 func (ps *PoliceService) FindCarsByColorAndMake(color, make string) ([]*VehicleInvestigationInfo, error) {
 	var matchingCars []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				colorMatch := color == "" || strings.ToLower(car.Color) == strings.ToLower(color)
 				makeMatch := make == "" || strings.ToLower(car.Make) == strings.ToLower(make)
 
@@ -107,7 +107,7 @@ func (ps *PoliceService) FindCarsByColorAndMake(color, make string) ([]*VehicleI
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID), // FIXED: Convert int to string
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
@@ -129,7 +129,7 @@ func (ps *PoliceService) FindCarsByColorAndMake(color, make string) ([]*VehicleI
 func (ps *PoliceService) GenerateInvestigationReport(vehicles []*VehicleInvestigationInfo, caseType string) string {
 	report := "=== POLICE INVESTIGATION REPORT ===\n"
 	report += "Case Type: " + caseType + "\n"
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
+	report += "Generated: " + ps.parkingService.now().Format("2006-01-02 15:04:05") + "\n"
 	report += fmt.Sprintf("Total Vehicles Found: %d\n\n", len(vehicles))
 
 	for i, vehicle := range vehicles {
@@ -165,7 +165,7 @@ func (ps *PoliceService) GenerateRobberyInvestigationReport(suspectDescription s
 	if suspectDescription != "" {
 		report += "Suspect Description: " + suspectDescription + "\n"
 	}
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
+	report += "Generated: " + ps.parkingService.now().Format("2006-01-02 15:04:05") + "\n"
 	report += fmt.Sprintf("Blue Toyota Vehicles Found: %d\n\n", len(blueToyotas))
 
 	for i, vehicle := range blueToyotas {
@@ -288,16 +288,16 @@ func (ps *PoliceService) ValidateRobberyEvidence() map[string]interface{} {
 func (ps *PoliceService) FindBMWCars() ([]*VehicleInvestigationInfo, error) {
 	var bmwCars []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				if strings.ToLower(car.Make) == "bmw" {
 					info := &VehicleInvestigationInfo{
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					// Get attendant info if available
@@ -320,20 +320,21 @@ func (ps *PoliceService) FindBMWCars() ([]*VehicleInvestigationInfo, error) {
 // UC15: Find cars parked in the last specified minutes
 func (ps *PoliceService) FindCarsParkedInLastMinutes(minutes int) ([]*VehicleInvestigationInfo, error) {
 	var recentCars []*VehicleInvestigationInfo
-	cutoffTime := time.Now().Add(-time.Duration(minutes) * time.Minute)
+	cutoffTime := ps.parkingService.now().Add(-time.Duration(minutes) * time.Minute)
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				if space.ParkedAt.After(cutoffTime) {
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
+				if parkedAt.After(cutoffTime) {
 					info := &VehicleInvestigationInfo{
-						Car:      space.ParkedCar,
+						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
-					if ticket, err := ps.parkingService.GetActiveTicket(space.ParkedCar.LicensePlate); err == nil {
+					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
 						info.AttendantID = ticket.AttendantID
 						if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
 							info.AttendantName = attendant.Name
@@ -353,21 +354,22 @@ func (ps *PoliceService) FindCarsParkedInLastMinutes(minutes int) ([]*VehicleInv
 func (ps *PoliceService) FindHandicapCarsInRows(rows []string) ([]*VehicleInvestigationInfo, error) {
 	var handicapCars []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil && space.ParkedCar.IsHandicap {
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() && car.IsHandicap {
 				spaceRow := space.GetRowAssignment()
 				
 				for _, targetRow := range rows {
 					if strings.ToUpper(spaceRow) == strings.ToUpper(targetRow) {
 						info := &VehicleInvestigationInfo{
-							Car:      space.ParkedCar,
+							Car:      car,
 							LotID:    lot.ID,
 							SpaceID:  fmt.Sprintf("%d", space.ID),
-							ParkedAt: space.ParkedAt,
+							ParkedAt: parkedAt,
 						}
 
-						if ticket, err := ps.parkingService.GetActiveTicket(space.ParkedCar.LicensePlate); err == nil {
+						if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
 							info.AttendantID = ticket.AttendantID
 							if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
 								info.AttendantName = attendant.Name
@@ -389,18 +391,19 @@ func (ps *PoliceService) FindHandicapCarsInRows(rows []string) ([]*VehicleInvest
 func (ps *PoliceService) GetAllCarsInLot(lotID string) ([]*VehicleInvestigationInfo, error) {
 	var allCars []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		if lot.ID == lotID {
 			for _, space := range lot.Spaces {
-				if space.IsOccupied && space.ParkedCar != nil {
+				car, parkedAt := space.GetOccupancy()
+				if car != nil && !space.IsExtension() {
 					info := &VehicleInvestigationInfo{
-						Car:      space.ParkedCar,
+						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
-					if ticket, err := ps.parkingService.GetActiveTicket(space.ParkedCar.LicensePlate); err == nil {
+					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
 						info.AttendantID = ticket.AttendantID
 						if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
 							info.AttendantName = attendant.Name
@@ -414,24 +417,24 @@ func (ps *PoliceService) GetAllCarsInLot(lotID string) ([]*VehicleInvestigationI
 		}
 	}
 
-	return nil, fmt.Errorf("parking lot %s not found", lotID)
+	return nil, models.NewError(ErrLotNotFound, fmt.Sprintf("parking lot %s not found", lotID))
 }
 
 // UC17: Detect potentially fraudulent license plates
 func (ps *PoliceService) DetectFraudulentPlates() ([]*VehicleInvestigationInfo, error) {
 	var suspiciousVehicles []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				
-				if ps.isSuspiciousLicensePlate(car.LicensePlate) {
+				if isSuspiciousLicensePlate(car.LicensePlate) {
 					info := &VehicleInvestigationInfo{
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
@@ -451,7 +454,7 @@ func (ps *PoliceService) DetectFraudulentPlates() ([]*VehicleInvestigationInfo,
 }
 
 // UC17: Check if a license plate is potentially fraudulent
-func (ps *PoliceService) isSuspiciousLicensePlate(plate string) bool {
+func isSuspiciousLicensePlate(plate string) bool {
 	plate = strings.ToUpper(strings.TrimSpace(plate))
 	
 	patterns := []string{
@@ -481,7 +484,7 @@ func (ps *PoliceService) isSuspiciousLicensePlate(plate string) bool {
 		}
 	}
 	
-	if ps.hasSequentialPattern(plate) {
+	if hasSequentialPattern(plate) {
 		return true
 	}
 	
@@ -489,7 +492,7 @@ func (ps *PoliceService) isSuspiciousLicensePlate(plate string) bool {
 }
 
 // UC17: Check for sequential patterns in license plates
-func (ps *PoliceService) hasSequentialPattern(plate string) bool {
+func hasSequentialPattern(plate string) bool {
 	if len(plate) < 3 {
 		return false
 	}
@@ -529,7 +532,7 @@ func (ps *PoliceService) GenerateCompleteLotInvestigationReport(lotID string) st
 	report += "Investigation Type: Complete Lot Analysis\n"
 	report += "Target Lot: " + lotID + "\n"
 	report += "Investigation Focus: Fraudulent License Plates\n"
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
+	report += "Generated: " + ps.parkingService.now().Format("2006-01-02 15:04:05") + "\n"
 	report += fmt.Sprintf("Total Vehicles in Lot: %d\n", len(allCars))
 	report += fmt.Sprintf("Suspicious Vehicles Found: %d\n\n", len(lotFraudulentCars))
 
@@ -625,7 +628,7 @@ func (ps *PoliceService) GetCompleteInvestigationSummary() map[string]interface{
 	fraudulentCars, _ := ps.DetectFraudulentPlates()
 	summary["fraudulentPlatesCount"] = len(fraudulentCars)
 
-	summary["investigationTimestamp"] = time.Now().Format("2006-01-02 15:04:05")
+	summary["investigationTimestamp"] = ps.parkingService.now().Format("2006-01-02 15:04:05")
 	summary["totalInvestigationTypes"] = 6
 
 	return summary
@@ -633,26 +636,24 @@ func (ps *PoliceService) GetCompleteInvestigationSummary() map[string]interface{
 
 // UC17: Make IsSuspiciousLicensePlate public for testing
 func (ps *PoliceService) IsSuspiciousLicensePlate(plate string) bool {
-	return ps.isSuspiciousLicensePlate(plate)
+	return isSuspiciousLicensePlate(plate)
 }
 
-// General police query methods
-func (ps *PoliceService) FindCarsByColorAndMake(color, make string) ([]*VehicleInvestigationInfo, error) {
-	var matchingCars []*VehicleInvestigationInfo
+// UC15: Get recent parking activity with flexible time range
+func (ps *PoliceService) GetRecentParkingActivity(timeRange time.Duration) ([]*VehicleInvestigationInfo, error) {
+	var recentActivity []*VehicleInvestigationInfo
+	cutoffTime := ps.parkingService.now().Add(-timeRange)
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
-				colorMatch := color == "" || strings.ToLower(car.Color) == strings.ToLower(color)
-				makeMatch := make == "" || strings.ToLower(car.Make) == strings.ToLower(make)
-
-				if colorMatch && makeMatch {
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
+				if parkedAt.After(cutoffTime) {
 					info := &VehicleInvestigationInfo{
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
@@ -662,171 +663,48 @@ func (ps *PoliceService) FindCarsByColorAndMake(color, make string) ([]*VehicleI
 						}
 					}
 
-					matchingCars = append(matchingCars, info)
+					recentActivity = append(recentActivity, info)
 				}
 			}
 		}
 	}
 
-	return matchingCars, nil
-}
-
-func (ps *PoliceService) GenerateInvestigationReport(vehicles []*VehicleInvestigationInfo, caseType string) string {
-	report := "=== POLICE INVESTIGATION REPORT ===\n"
-	report += "Case Type: " + caseType + "\n"
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
-	report += fmt.Sprintf("Total Vehicles Found: %d\n\n", len(vehicles))
-
-	for i, vehicle := range vehicles {
-		report += fmt.Sprintf("Vehicle %d:\n", i+1)
-		report += "  License Plate: " + vehicle.Car.LicensePlate + "\n"
-		report += "  Driver: " + vehicle.Car.DriverName + "\n"
-		report += "  Color: " + vehicle.Car.Color + "\n"
-		report += "  Make: " + vehicle.Car.Make + "\n"
-		report += "  Location: Lot " + vehicle.LotID + ", Space " + vehicle.SpaceID + "\n"
-		report += "  Parked At: " + vehicle.ParkedAt.Format("2006-01-02 15:04:05") + "\n"
-		if vehicle.AttendantID != "" {
-			report += "  Attendant ID: " + vehicle.AttendantID + "\n"
-			if vehicle.AttendantName != "" {
-				report += "  Attendant Name: " + vehicle.AttendantName + "\n"
-			}
-		}
-		report += "\n"
-	}
-
-	return report
-}
-
-// UC13: Additional robbery investigation methods
-func (ps *PoliceService) GenerateRobberyInvestigationReport(suspectDescription string) string {
-	blueToyotas, err := ps.FindBlueToyotaCars()
-	if err != nil {
-		return "Error generating robbery investigation report: " + err.Error()
-	}
-
-	report := "=== ROBBERY INVESTIGATION REPORT ===\n"
-	report += "Case Type: Armed Robbery\n"
-	report += "Target Vehicle: Blue Toyota\n"
-	if suspectDescription != "" {
-		report += "Suspect Description: " + suspectDescription + "\n"
-	}
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
-	report += fmt.Sprintf("Blue Toyota Vehicles Found: %d\n\n", len(blueToyotas))
-
-	for i, vehicle := range blueToyotas {
-		report += fmt.Sprintf("SUSPECT VEHICLE %d:\n", i+1)
-		report += "  License Plate: " + vehicle.Car.LicensePlate + "\n"
-		report += "  Driver Name: " + vehicle.Car.DriverName + "\n"
-		report += "  Location: Lot " + vehicle.LotID + ", Space " + vehicle.SpaceID + "\n"
-		report += "  Time Parked: " + vehicle.ParkedAt.Format("2006-01-02 15:04:05") + "\n"
-
-		if vehicle.AttendantID != "" {
-			report += "  Parking Attendant: " + vehicle.AttendantName + " (ID: " + vehicle.AttendantID + ")\n"
-			report += "  Attendant Status: Active\n"
-		}
-
-		report += "  Vehicle Details:\n"
-		report += "    Size: " + vehicle.Car.GetVehicleSizeString() + "\n"
-		if vehicle.Car.IsHandicap {
-			report += "    Special Status: Handicap Vehicle\n"
-		}
-
-		report += "\n"
-	}
-
-	if len(blueToyotas) == 0 {
-		report += "NO MATCHING VEHICLES FOUND\n"
-		report += "Recommendation: Expand search parameters\n"
-	} else {
-		report += "INVESTIGATION RECOMMENDATIONS:\n"
-		report += "1. Interview all identified drivers\n"
-		report += "2. Review parking attendant logs\n"
-		report += "3. Check security footage for time periods listed above\n"
-		report += "4. Verify attendant identification and background\n"
-	}
-
-	return report
-}
-
-func (ps *PoliceService) GetBlueToyotaCount() int {
-	blueToyotas, err := ps.FindBlueToyotaCars()
-	if err != nil {
-		return 0
-	}
-	return len(blueToyotas)
+	return recentActivity, nil
 }
 
-func (ps *PoliceService) ValidateRobberyEvidence() map[string]interface{} {
-	evidence := make(map[string]interface{})
-
-	blueToyotas, err := ps.FindBlueToyotaCars()
+// UC15: Get parking activity from ticket history, including cars that have left
+func (ps *PoliceService) GetParkingActivityBetween(from, to time.Time) ([]*VehicleInvestigationInfo, error) {
+	tickets, err := ps.parkingService.GetTicketsParkedBetween(from, to)
 	if err != nil {
-		evidence["error"] = err.Error()
-		return evidence
+		return nil, err
 	}
 
-	evidence["totalSuspectVehicles"] = len(blueToyotas)
-	evidence["caseStrength"] = func() string {
-		if len(blueToyotas) == 0 {
-			return "Weak - No suspect vehicles found"
-		} else if len(blueToyotas) == 1 {
-			return "Strong - Single suspect vehicle identified"
-		} else {
-			return "Moderate - Multiple suspect vehicles require investigation"
+	var activity []*VehicleInvestigationInfo
+	for _, ticket := range tickets {
+		car := &models.Car{LicensePlate: ticket.LicensePlate}
+		if ticket.IsActive {
+			if space, err := ps.parkingService.FindCar(ticket.LicensePlate); err == nil {
+				if parked := space.GetParkedCar(); parked != nil {
+					car = parked
+				}
+			}
 		}
-	}()
 
-	var attendantCount int
-	for _, vehicle := range blueToyotas {
-		if vehicle.AttendantID != "" {
-			attendantCount++
+		info := &VehicleInvestigationInfo{
+			Car:         car,
+			LotID:       ticket.LotID,
+			SpaceID:     ticket.SpaceID,
+			ParkedAt:    ticket.ParkedAt,
+			AttendantID: ticket.AttendantID,
 		}
-	}
-
-	evidence["attendantWitnesses"] = attendantCount
-	evidence["evidenceQuality"] = func() string {
-		if attendantCount == len(blueToyotas) {
-			return "High - All vehicles have attendant witnesses"
-		} else if attendantCount > 0 {
-			return "Medium - Some attendant witnesses available"
-		} else {
-			return "Low - No attendant witnesses"
+		if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
+			info.AttendantName = attendant.Name
 		}
-	}()
-
-	return evidence
-}
 
-// UC15: Get recent parking activity with flexible time range
-func (ps *PoliceService) GetRecentParkingActivity(timeRange time.Duration) ([]*VehicleInvestigationInfo, error) {
-	var recentActivity []*VehicleInvestigationInfo
-	cutoffTime := time.Now().Add(-timeRange)
-
-	for _, lot := range ps.parkingService.lots {
-		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				if space.ParkedAt.After(cutoffTime) {
-					info := &VehicleInvestigationInfo{
-						Car:      space.ParkedCar,
-						LotID:    lot.ID,
-						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
-					}
-
-					if ticket, err := ps.parkingService.GetActiveTicket(space.ParkedCar.LicensePlate); err == nil {
-						info.AttendantID = ticket.AttendantID
-						if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
-							info.AttendantName = attendant.Name
-						}
-					}
-
-					recentActivity = append(recentActivity, info)
-				}
-			}
-		}
+		activity = append(activity, info)
 	}
 
-	return recentActivity, nil
+	return activity, nil
 }
 
 // UC15: Generate time-based investigation report for bomb threats
@@ -839,11 +717,11 @@ func (ps *PoliceService) GenerateTimeBasedInvestigationReport(minutes int) strin
 	report := "=== TIME-BASED INVESTIGATION REPORT ===\n"
 	report += "Alert Type: Bomb Threat Investigation\n"
 	report += "Time Range: Last " + fmt.Sprintf("%d", minutes) + " minutes\n"
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
+	report += "Generated: " + ps.parkingService.now().Format("2006-01-02 15:04:05") + "\n"
 	report += fmt.Sprintf("Vehicles Found: %d\n\n", len(recentCars))
 
 	for i, vehicle := range recentCars {
-		timeSinceParked := time.Since(vehicle.ParkedAt)
+		timeSinceParked := ps.parkingService.now().Sub(vehicle.ParkedAt)
 		report += fmt.Sprintf("RECENT VEHICLE %d:\n", i+1)
 		report += "  License Plate: " + vehicle.Car.LicensePlate + "\n"
 		report += "  Driver Name: " + vehicle.Car.DriverName + "\n"
@@ -892,11 +770,11 @@ func (ps *PoliceService) GetVehicleCountByTimeWindow(minutes int) map[string]int
 
 	result["timeWindow"] = fmt.Sprintf("%d minutes", minutes)
 	result["totalVehicles"] = len(recentCars)
-	result["timestamp"] = time.Now().Format("2006-01-02 15:04:05")
+	result["timestamp"] = ps.parkingService.now().Format("2006-01-02 15:04:05")
 
 	// Categorize by time buckets
 	var last15min, last30min, older int
-	now := time.Now()
+	now := ps.parkingService.now()
 
 	for _, vehicle := range recentCars {
 		minutesAgo := int(now.Sub(vehicle.ParkedAt).Minutes())
@@ -920,10 +798,10 @@ func (ps *PoliceService) GetVehicleCountByTimeWindow(minutes int) map[string]int
 func (ps *PoliceService) GetVehiclesByLocationCriteria(size models.VehicleSize, handicapOnly bool, rows []string) ([]*VehicleInvestigationInfo, error) {
 	var matchingVehicles []*VehicleInvestigationInfo
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				car := space.ParkedCar
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				
 				// Check criteria
 				sizeMatch := (size == models.SmallVehicle && car.Size == models.SmallVehicle) ||
@@ -946,7 +824,7 @@ func (ps *PoliceService) GetVehiclesByLocationCriteria(size models.VehicleSize,
 						Car:      car,
 						LotID:    lot.ID,
 						SpaceID:  fmt.Sprintf("%d", space.ID),
-						ParkedAt: space.ParkedAt,
+						ParkedAt: parkedAt,
 					}
 
 					if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
@@ -971,17 +849,18 @@ func (ps *PoliceService) ValidateHandicapPermitFraud() map[string]interface{} {
 
 	// Find all handicap cars
 	var allHandicapCars []*VehicleInvestigationInfo
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil && space.ParkedCar.IsHandicap {
+			car, parkedAt := space.GetOccupancy()
+			if car != nil && !space.IsExtension() && car.IsHandicap {
 				info := &VehicleInvestigationInfo{
-					Car:      space.ParkedCar,
+					Car:      car,
 					LotID:    lot.ID,
 					SpaceID:  fmt.Sprintf("%d", space.ID),
-					ParkedAt: space.ParkedAt,
+					ParkedAt: parkedAt,
 				}
 
-				if ticket, err := ps.parkingService.GetActiveTicket(space.ParkedCar.LicensePlate); err == nil {
+				if ticket, err := ps.parkingService.GetActiveTicket(car.LicensePlate); err == nil {
 					info.AttendantID = ticket.AttendantID
 					if attendant := ps.parkingService.FindAttendantByID(ticket.AttendantID); attendant != nil {
 						info.AttendantName = attendant.Name
@@ -1017,7 +896,7 @@ func (ps *PoliceService) ValidateHandicapPermitFraud() map[string]interface{} {
 	}()
 
 	validation["investigationRequired"] = len(suspiciousRows) > 0
-	validation["timestamp"] = time.Now().Format("2006-01-02 15:04:05")
+	validation["timestamp"] = ps.parkingService.now().Format("2006-01-02 15:04:05")
 
 	return validation
 }
@@ -1032,7 +911,7 @@ func (ps *PoliceService) GenerateHandicapFraudInvestigationReport() string {
 	report := "=== HANDICAP PERMIT FRAUD INVESTIGATION REPORT ===\n"
 	report += "Investigation Type: Handicap Permit Fraud\n"
 	report += "Target Locations: Rows B and D\n"
-	report += "Generated: " + time.Now().Format("2006-01-02 15:04:05") + "\n"
+	report += "Generated: " + ps.parkingService.now().Format("2006-01-02 15:04:05") + "\n"
 	report += fmt.Sprintf("Suspicious Vehicles Found: %d\n\n", len(suspiciousVehicles))
 
 	for i, vehicle := range suspiciousVehicles {
@@ -1073,7 +952,7 @@ func (ps *PoliceService) GenerateHandicapFraudInvestigationReport() string {
 
 // UC16: Helper method to find parking space by lot and space ID
 func (ps *PoliceService) findParkingSpace(lotID, spaceID string) *models.ParkingSpace {
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		if lot.ID == lotID {
 			for _, space := range lot.Spaces {
 				if fmt.Sprintf("%d", space.ID) == spaceID {
@@ -1089,26 +968,29 @@ func (ps *PoliceService) findParkingSpace(lotID, spaceID string) *models.Parking
 func (ps *PoliceService) GetLocationStatistics() map[string]interface{} {
 	stats := make(map[string]interface{})
 	
-	rowCounts := map[string]int{"A": 0, "B": 0, "C": 0, "D": 0}
-	handicapByRow := map[string]int{"A": 0, "B": 0, "C": 0, "D": 0}
-	sizeByRow := map[string]map[string]int{
-		"A": {"Small": 0, "Medium": 0, "Large": 0},
-		"B": {"Small": 0, "Medium": 0, "Large": 0},
-		"C": {"Small": 0, "Medium": 0, "Large": 0},
-		"D": {"Small": 0, "Medium": 0, "Large": 0},
-	}
+	// Every row of every lot layout is reported, even when empty
+	rowCounts := make(map[string]int)
+	handicapByRow := make(map[string]int)
+	sizeByRow := make(map[string]map[string]int)
 
-	for _, lot := range ps.parkingService.lots {
+	for _, lot := range ps.parkingService.getLots() {
 		for _, space := range lot.Spaces {
-			if space.IsOccupied && space.ParkedCar != nil {
-				row := space.GetRowAssignment()
+			row := space.GetRowAssignment()
+			if _, seen := sizeByRow[row]; !seen {
+				rowCounts[row] = 0
+				handicapByRow[row] = 0
+				sizeByRow[row] = map[string]int{"Small": 0, "Medium": 0, "Large": 0}
+			}
+
+			car, _ := space.GetOccupancy()
+			if car != nil && !space.IsExtension() {
 				rowCounts[row]++
 
-				if space.ParkedCar.IsHandicap {
+				if car.IsHandicap {
 					handicapByRow[row]++
 				}
 
-				sizeStr := space.ParkedCar.GetVehicleSizeString()
+				sizeStr := car.GetVehicleSizeString()
 				sizeByRow[row][sizeStr]++
 			}
 		}
@@ -1117,7 +999,7 @@ func (ps *PoliceService) GetLocationStatistics() map[string]interface{} {
 	stats["totalVehiclesByRow"] = rowCounts
 	stats["handicapVehiclesByRow"] = handicapByRow
 	stats["vehicleSizesByRow"] = sizeByRow
-	stats["timestamp"] = time.Now().Format("2006-01-02 15:04:05")
+	stats["timestamp"] = ps.parkingService.now().Format("2006-01-02 15:04:05")
 
 	return stats
 }
@@ -1133,7 +1015,7 @@ func (ps *PoliceService) GetFraudStatistics() map[string]interface{} {
 	}
 
 	stats["totalSuspiciousVehicles"] = len(allFraudulent)
-	stats["timestamp"] = time.Now().Format("2006-01-02 15:04:05")
+	stats["timestamp"] = ps.parkingService.now().Format("2006-01-02 15:04:05")
 
 	// Group by lot
 	lotStats := make(map[string]int)
@@ -1145,8 +1027,8 @@ func (ps *PoliceService) GetFraudStatistics() map[string]interface{} {
 
 	// Calculate fraud rate
 	totalVehicles := 0
-	for _, lot := range ps.parkingService.lots {
-		totalVehicles += lot.GetOccupiedSpaces()
+	for _, lot := range ps.parkingService.getLots() {
+		totalVehicles += lot.GetParkedVehicleCount()
 	}
 
 	if totalVehicles > 0 {
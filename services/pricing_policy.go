@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"parking-lot-system/models"
+)
+
+// PricingPolicy adjusts the tariff price for the vehicle being billed. The
+// zero value charges every vehicle the tariff price.
+type PricingPolicy struct {
+	// SizeMultipliers scale the price by vehicle size; sizes without an entry pay 1x
+	SizeMultipliers map[models.VehicleSize]float64
+	// AccessibleDiscount is the share of the price waived for permit holders, from 0 to 1
+	AccessibleDiscount float64
+	// AccessibleExempt waives the whole price for permit holders
+	AccessibleExempt bool
+}
+
+// Adjustment is a change to the tariff price and the reason for it
+type Adjustment struct {
+	Reason string
+	Amount float64 // Negative for discounts
+}
+
+// adjustmentsFor applies the size multiplier first and then any permit
+// discount to what remains
+func (pp PricingPolicy) adjustmentsFor(car *models.Car, base float64) []Adjustment {
+	var adjustments []Adjustment
+	amount := base
+
+	if multiplier, ok := pp.SizeMultipliers[car.Size]; ok && multiplier != 1 {
+		change := roundCents(amount*multiplier - amount)
+		adjustments = append(adjustments, Adjustment{
+			Reason: fmt.Sprintf("%s vehicle x%.2f", car.GetVehicleSizeString(), multiplier),
+			Amount: change,
+		})
+		amount += change
+	}
+
+	if car.IsHandicap && amount > 0 {
+		switch {
+		case pp.AccessibleExempt:
+			adjustments = append(adjustments, Adjustment{Reason: "Accessible permit exemption", Amount: -amount})
+		case pp.AccessibleDiscount > 0:
+			adjustments = append(adjustments, Adjustment{
+				Reason: fmt.Sprintf("Accessible permit discount %.0f%%", pp.AccessibleDiscount*100),
+				Amount: -roundCents(amount * pp.AccessibleDiscount),
+			})
+		}
+	}
+
+	return adjustments
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report export formats
+const (
+	ReportCSV  = "csv"
+	ReportJSON = "json"
+	ReportText = "text" // Aligned columns for reading as is
+)
+
+var reportExtensions = map[string]string{ReportCSV: ".csv", ReportJSON: ".json", ReportText: ".txt"}
+
+// FileName names the report's file in format after its period, e.g.
+// daily-2026-10-14.csv, weekly-2026-10-12.json or monthly-2026-10.txt
+func (r *Report) FileName(format string) string {
+	label := r.From.Format("2006-01-02")
+	if r.Period == ReportMonthly {
+		label = r.From.Format("2006-01")
+	}
+	return r.Period + "-" + label + reportExtensions[format]
+}
+
+// Title describes the period the report covers
+func (r *Report) Title() string {
+	switch r.Period {
+	case ReportWeekly:
+		return "Weekly report for the week of " + r.From.Format("2006-01-02")
+	case ReportMonthly:
+		return "Monthly report for " + r.From.Format("January 2006")
+	default:
+		return "Daily report for " + r.From.Format("2006-01-02")
+	}
+}
+
+// Write exports the report as CSV, JSON or aligned text
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case ReportCSV:
+		return r.writeCSV(w)
+	case ReportJSON:
+		return r.writeJSON(w)
+	case ReportText:
+		return r.writeText(w)
+	default:
+		return invalid("report format must be csv, json or text")
+	}
+}
+
+// rows are the lots followed by the total, labelled TOTAL
+func (r *Report) rows() []*LotReport {
+	total := *r.Total
+	total.LotID = "TOTAL"
+	return append(append([]*LotReport(nil), r.Lots...), &total)
+}
+
+// writeCSV puts one lot per row, each repeating the period so files can be
+// concatenated
+func (r *Report) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	headers := []string{"period", "from", "to", "lot_id", "tickets", "revenue", "average_duration_minutes"}
+	for _, size := range reportSizes {
+		headers = append(headers, strings.ToLower(size))
+	}
+	headers = append(headers, "handicap", "peak_occupancy_percent", "peak_at")
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, lr := range r.rows() {
+		row := []string{
+			r.Period, r.From.Format(time.RFC3339), r.To.Format(time.RFC3339), lr.LotID,
+			strconv.Itoa(lr.Tickets), fmt.Sprintf("%.2f", lr.Revenue), fmt.Sprintf("%.1f", lr.AverageDuration.Minutes()),
+		}
+		for _, size := range reportSizes {
+			row = append(row, strconv.Itoa(lr.TicketsBySize[size]))
+		}
+		peakAt := ""
+		if !lr.PeakAt.IsZero() {
+			peakAt = lr.PeakAt.Format(time.RFC3339)
+		}
+		row = append(row, strconv.Itoa(lr.HandicapTickets), fmt.Sprintf("%.1f", lr.PeakOccupancy*100), peakAt)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type reportDocument struct {
+	Period      string              `json:"period"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Lots        []lotReportDocument `json:"lots"`
+	Total       lotReportDocument   `json:"total"`
+}
+
+type lotReportDocument struct {
+	LotID                  string         `json:"lotId,omitempty"`
+	Tickets                int            `json:"tickets"`
+	Revenue                float64        `json:"revenue"`
+	AverageDurationSeconds float64        `json:"averageDurationSeconds"`
+	TicketsBySize          map[string]int `json:"ticketsBySize"`
+	HandicapTickets        int            `json:"handicapTickets"`
+	PeakOccupancy          float64        `json:"peakOccupancy"`
+	PeakAt                 *time.Time     `json:"peakAt,omitempty"`
+}
+
+func newLotReportDocument(lr *LotReport) lotReportDocument {
+	document := lotReportDocument{
+		LotID:                  lr.LotID,
+		Tickets:                lr.Tickets,
+		Revenue:                lr.Revenue,
+		AverageDurationSeconds: lr.AverageDuration.Seconds(),
+		TicketsBySize:          lr.TicketsBySize,
+		HandicapTickets:        lr.HandicapTickets,
+		PeakOccupancy:          lr.PeakOccupancy,
+	}
+	if !lr.PeakAt.IsZero() {
+		peakAt := lr.PeakAt
+		document.PeakAt = &peakAt
+	}
+	return document
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	document := reportDocument{
+		Period:      r.Period,
+		From:        r.From,
+		To:          r.To,
+		GeneratedAt: r.GeneratedAt,
+		Lots:        make([]lotReportDocument, 0, len(r.Lots)),
+		Total:       newLotReportDocument(r.Total),
+	}
+	for _, lr := range r.Lots {
+		document.Lots = append(document.Lots, newLotReportDocument(lr))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "%s\n%s to %s, generated %s\n\n", r.Title(),
+		r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"), r.GeneratedAt.Format("2006-01-02 15:04"))
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := []string{"LOT", "TICKETS", "REVENUE", "AVG STAY"}
+	for _, size := range reportSizes {
+		headers = append(headers, strings.ToUpper(size))
+	}
+	fmt.Fprintln(writer, strings.Join(append(headers, "HANDICAP", "PEAK", "PEAK AT"), "\t"))
+
+	for _, lr := range r.rows() {
+		row := []string{lr.LotID, strconv.Itoa(lr.Tickets), fmt.Sprintf("$%.2f", lr.Revenue), formatStay(lr.AverageDuration)}
+		for _, size := range reportSizes {
+			row = append(row, strconv.Itoa(lr.TicketsBySize[size]))
+		}
+		peak, peakAt := "-", "-"
+		if !lr.PeakAt.IsZero() {
+			peak = fmt.Sprintf("%.1f%%", lr.PeakOccupancy*100)
+			peakAt = lr.PeakAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintln(writer, strings.Join(append(row, strconv.Itoa(lr.HandicapTickets), peak, peakAt), "\t"))
+	}
+	return writer.Flush()
+}
+
+// formatStay prints a stay to the minute, e.g. 1h05m
+func formatStay(stay time.Duration) string {
+	minutes := int(stay.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"log"
+	"os"
+	"parking-lot-system/models"
+	"path/filepath"
+	"time"
+)
+
+// Report periods, each starting at midnight in the time zone of the time
+// it is asked for
+const (
+	ReportDaily   = "daily"
+	ReportWeekly  = "weekly" // Monday to Sunday
+	ReportMonthly = "monthly"
+)
+
+// reportSizes are the vehicle sizes reports break tickets down by, smallest first
+var reportSizes = []string{"Motorcycle", "Small", "Medium", "Large"}
+
+// ReportPeriodBounds returns the start and end of the period containing at
+func ReportPeriodBounds(period string, at time.Time) (time.Time, time.Time, error) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	switch period {
+	case ReportDaily:
+		return day, day.AddDate(0, 0, 1), nil
+	case ReportWeekly:
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return monday, monday.AddDate(0, 0, 7), nil
+	case ReportMonthly:
+		first := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+		return first, first.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, invalid("report period must be daily, weekly or monthly")
+	}
+}
+
+// LotReport sums up the stays that ended in a lot during a report's period
+type LotReport struct {
+	LotID           string
+	Tickets         int
+	Revenue         float64 // What the stays were billed; stays without a recorded bill add nothing
+	AverageDuration time.Duration
+	TicketsBySize   map[string]int // By vehicle size name; tickets issued before sizes were recorded are left out
+	HandicapTickets int
+	PeakOccupancy   float64   // Highest sampled share of spaces in use, from 0 to 1; zero without samples
+	PeakAt          time.Time // When the peak was sampled; zero without samples
+
+	totalDuration time.Duration
+}
+
+func newLotReport(lotID string) *LotReport {
+	return &LotReport{LotID: lotID, TicketsBySize: make(map[string]int)}
+}
+
+func (lr *LotReport) addStay(ticket *models.ParkingTicket, billed float64) {
+	lr.Tickets++
+	lr.Revenue = roundCents(lr.Revenue + billed)
+	lr.totalDuration += ticket.UnparkedAt.Sub(ticket.ParkedAt)
+	lr.AverageDuration = lr.totalDuration / time.Duration(lr.Tickets)
+	if ticket.VehicleSize != "" {
+		lr.TicketsBySize[ticket.VehicleSize]++
+	}
+	if ticket.IsHandicap {
+		lr.HandicapTickets++
+	}
+}
+
+func (lr *LotReport) addOccupancy(occupied, total int, at time.Time) {
+	if total == 0 {
+		return
+	}
+	if occupancy := float64(occupied) / float64(total); occupancy > lr.PeakOccupancy || lr.PeakAt.IsZero() {
+		lr.PeakOccupancy = occupancy
+		lr.PeakAt = at
+	}
+}
+
+// Report covers the stays that ended from From up to To, lot by lot. A stay
+// counts in the period it ended in, since that is when it was billed.
+type Report struct {
+	Period      string
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	Lots        []*LotReport // In the order the lots were added, then lots no longer in service
+	Total       *LotReport   // Every lot together; its peak is of all spaces at once
+}
+
+// ReportService builds revenue and occupancy reports from the ticket
+// history, the exit bills and the occupancy samples, and writes them to a
+// directory as each period closes
+type ReportService struct {
+	parking *ParkingService
+}
+
+func newReportService(parking *ParkingService) *ReportService {
+	return &ReportService{parking: parking}
+}
+
+// Generate reports on the period containing at
+func (rs *ReportService) Generate(period string, at time.Time) (*Report, error) {
+	from, to, err := ReportPeriodBounds(period, at)
+	if err != nil {
+		return nil, err
+	}
+	history, err := rs.parking.ticketHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Period: period, From: from, To: to, GeneratedAt: rs.parking.now(), Total: newLotReport("")}
+	byLot := make(map[string]*LotReport)
+	lotReport := func(lotID string) *LotReport {
+		if lr, ok := byLot[lotID]; ok {
+			return lr
+		}
+		lr := newLotReport(lotID)
+		byLot[lotID] = lr
+		report.Lots = append(report.Lots, lr)
+		return lr
+	}
+	for _, lot := range rs.parking.getLots() {
+		lotReport(lot.ID)
+	}
+
+	for _, ticket := range history.FindUnparkedBetween(from, to) {
+		var billed float64
+		if bill, err := rs.parking.payments.GetBill(ticket.ID); err == nil {
+			billed = bill.TotalAmount
+		}
+		lotReport(ticket.LotID).addStay(ticket, billed)
+		report.Total.addStay(ticket, billed)
+	}
+
+	// Every lot is sampled at the same instant, so summing by instant gives
+	// the occupancy of all spaces together
+	type combined struct{ occupied, total int }
+	var instants []time.Time
+	all := make(map[time.Time]*combined)
+	for _, lr := range report.Lots {
+		series, err := rs.parking.occupancy.GetSeries(lr.LotID, from, to)
+		if err != nil {
+			continue
+		}
+		for _, sample := range series {
+			if !sample.At.Before(to) {
+				continue
+			}
+			lr.addOccupancy(sample.OccupiedSpaces, sample.TotalSpaces, sample.At)
+			sum, ok := all[sample.At]
+			if !ok {
+				sum = &combined{}
+				all[sample.At] = sum
+				instants = append(instants, sample.At)
+			}
+			sum.occupied += sample.OccupiedSpaces
+			sum.total += sample.TotalSpaces
+		}
+	}
+	for _, at := range instants {
+		report.Total.addOccupancy(all[at].occupied, all[at].total, at)
+	}
+	return report, nil
+}
+
+// ReportSchedule says which reports WriteDue writes, and where
+type ReportSchedule struct {
+	Dir     string
+	Periods []string // Defaults to daily, weekly and monthly
+	Formats []string // Defaults to CSV, JSON and text
+}
+
+func (s ReportSchedule) withDefaults() ReportSchedule {
+	if len(s.Periods) == 0 {
+		s.Periods = []string{ReportDaily, ReportWeekly, ReportMonthly}
+	}
+	if len(s.Formats) == 0 {
+		s.Formats = []string{ReportCSV, ReportJSON, ReportText}
+	}
+	return s
+}
+
+func (s ReportSchedule) validate() error {
+	if s.Dir == "" {
+		return invalid("report directory cannot be empty")
+	}
+	for _, period := range s.Periods {
+		if _, _, err := ReportPeriodBounds(period, time.Time{}); err != nil {
+			return err
+		}
+	}
+	for _, format := range s.Formats {
+		if _, ok := reportExtensions[format]; !ok {
+			return invalid("report format must be csv, json or text")
+		}
+	}
+	return nil
+}
+
+// WriteDue writes the report of each period that last closed, in every
+// format, unless its file is already in the directory. It returns the paths
+// written.
+func (rs *ReportService) WriteDue(schedule ReportSchedule) ([]string, error) {
+	schedule = schedule.withDefaults()
+	if err := schedule.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(schedule.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	now := rs.parking.now()
+	var written []string
+	for _, period := range schedule.Periods {
+		current, _, _ := ReportPeriodBounds(period, now)
+		var report *Report
+		for _, format := range schedule.Formats {
+			if report == nil {
+				var err error
+				if report, err = rs.Generate(period, current.Add(-time.Nanosecond)); err != nil {
+					return written, err
+				}
+			}
+			path := filepath.Join(schedule.Dir, report.FileName(format))
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			if err := writeReportFile(path, report, format); err != nil {
+				return written, err
+			}
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// writeReportFile writes through a temporary file so readers never see a
+// partial report
+func writeReportFile(path string, report *Report, format string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), ".report-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := report.Write(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// StartSchedule writes due reports every interval until stop is called
+func (rs *ReportService) StartSchedule(schedule ReportSchedule, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := rs.WriteDue(schedule); err != nil {
+					log.Printf("warning: scheduled report failed: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
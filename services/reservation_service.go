@@ -0,0 +1,335 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"parking-lot-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReservationPolicy sets when a reservation's spaces are kept from walk-ins
+type ReservationPolicy struct {
+	// HoldBefore is how long before its window starts a reservation's spaces are held
+	HoldBefore time.Duration
+	// GracePeriod is how long after its window starts a no-show keeps the spaces
+	GracePeriod time.Duration
+}
+
+// DefaultReservationPolicy holds spaces from the start of the window and
+// expires no-shows 15 minutes later
+var DefaultReservationPolicy = ReservationPolicy{GracePeriod: 15 * time.Minute}
+
+// ReservationService books lot capacity for future windows. Pending
+// reservations whose hold has begun keep their spaces free in the lot, so
+// walk-ins and strategies cannot take them; arrival turns the reservation into
+// a ticket and no-shows expire after the grace period, releasing the spaces.
+type ReservationService struct {
+	parking      *ParkingService
+	reservations []*models.Reservation
+	quotas       map[string]int // Reservable spaces per lot; the lot's capacity when absent
+	policy       ReservationPolicy
+	journal      func(entry *JournalEntry)
+	mu           sync.Mutex // Guards reservations, quotas and policy; taken before any lot lock
+}
+
+func newReservationService(parking *ParkingService) *ReservationService {
+	return &ReservationService{
+		parking: parking,
+		quotas:  make(map[string]int),
+		policy:  DefaultReservationPolicy,
+		journal: parking.journal,
+	}
+}
+
+func (rs *ReservationService) SetPolicy(policy ReservationPolicy) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.policy = policy
+	rs.applyHoldsLocked(rs.parking.now())
+}
+
+func (rs *ReservationService) GetPolicy() ReservationPolicy {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.policy
+}
+
+// SetLotQuota caps how many of the lot's spaces may be reserved at once
+func (rs *ReservationService) SetLotQuota(lotID string, spaces int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.quotas[lotID] = spaces
+}
+
+// LotQuotas returns the lots with a reservation cap of their own
+func (rs *ReservationService) LotQuotas() map[string]int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	quotas := make(map[string]int, len(rs.quotas))
+	for lotID, spaces := range rs.quotas {
+		quotas[lotID] = spaces
+	}
+	return quotas
+}
+
+// Book reserves space in the lot for the car over [start, end)
+func (rs *ReservationService) Book(car *models.Car, lotID string, start, end time.Time) (*models.Reservation, error) {
+	if car == nil {
+		return nil, ErrNilCar
+	}
+	if !end.After(start) {
+		return nil, invalid("reservation end must be after its start")
+	}
+	now := rs.parking.now()
+	if !end.After(now) {
+		return nil, invalid("reservation window must be in the future")
+	}
+	lot := rs.parking.findLotByID(lotID)
+	if lot == nil {
+		return nil, errParkingLotNotFound
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.expireLocked(now)
+
+	for _, reservation := range rs.reservations {
+		if reservation.IsPending() && reservation.Car.LicensePlate == car.LicensePlate && reservation.Overlaps(start, end) {
+			return nil, conflict("vehicle already has a reservation for that window")
+		}
+	}
+
+	quota, ok := rs.quotas[lotID]
+	if !ok {
+		quota = lot.Capacity
+	}
+	if rs.peakLoadLocked(lotID, start, end)+car.RequiredSpaces() > quota {
+		return nil, conflict("no available reservation capacity in lot for that window")
+	}
+
+	reservation := &models.Reservation{
+		ID:        fmt.Sprintf("RES-%06d", len(rs.reservations)+1),
+		Car:       car,
+		LotID:     lotID,
+		Start:     start,
+		End:       end,
+		Spaces:    car.RequiredSpaces(),
+		Status:    models.ReservationPending,
+		CreatedAt: now,
+	}
+	rs.reservations = append(rs.reservations, reservation)
+	rs.journalReservation(reservation)
+	rs.applyHoldsLocked(now)
+
+	saved := *reservation
+	return &saved, nil
+}
+
+// peakLoadLocked is the most spaces pending reservations in the lot need at
+// any one moment of [start, end). Load only rises when a window starts, so
+// checking the start of the range and of each overlapping window suffices.
+func (rs *ReservationService) peakLoadLocked(lotID string, start, end time.Time) int {
+	points := []time.Time{start}
+	for _, reservation := range rs.reservations {
+		if reservation.LotID == lotID && reservation.IsPending() && reservation.Overlaps(start, end) && reservation.Start.After(start) {
+			points = append(points, reservation.Start)
+		}
+	}
+
+	peak := 0
+	for _, point := range points {
+		load := 0
+		for _, reservation := range rs.reservations {
+			if reservation.LotID == lotID && reservation.IsPending() && !point.Before(reservation.Start) && point.Before(reservation.End) {
+				load += reservation.Spaces
+			}
+		}
+		if load > peak {
+			peak = load
+		}
+	}
+	return peak
+}
+
+// Arrive parks the reserved car in the spaces held for it and issues its ticket
+func (rs *ReservationService) Arrive(reservationID string) (*models.ParkingTicket, error) {
+	now := rs.parking.now()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.expireLocked(now)
+	rs.applyHoldsLocked(now)
+
+	reservation := rs.findLocked(reservationID)
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	if !reservation.IsPending() {
+		return nil, ErrReservationNotActive
+	}
+	if now.Before(reservation.Start.Add(-rs.policy.HoldBefore)) {
+		return nil, invalid("reservation cannot be used before its window")
+	}
+	lot := rs.parking.findLotByID(reservation.LotID)
+	if lot == nil {
+		return nil, errParkingLotNotFound
+	}
+
+	ticket, err := rs.parking.parkReservedWithTicket(lot, reservation.Car)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Status = models.ReservationArrived
+	reservation.TicketID = ticket.ID
+	rs.journalReservation(reservation)
+	rs.applyHoldsLocked(now)
+	return ticket, nil
+}
+
+// Cancel withdraws a pending reservation and releases its spaces
+func (rs *ReservationService) Cancel(reservationID string) (*models.Reservation, error) {
+	now := rs.parking.now()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.expireLocked(now)
+
+	reservation := rs.findLocked(reservationID)
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	if !reservation.IsPending() {
+		return nil, ErrReservationNotActive
+	}
+	reservation.Status = models.ReservationCancelled
+	rs.journalReservation(reservation)
+	rs.applyHoldsLocked(now)
+
+	saved := *reservation
+	return &saved, nil
+}
+
+func (rs *ReservationService) GetReservation(reservationID string) (*models.Reservation, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	reservation := rs.findLocked(reservationID)
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	saved := *reservation
+	return &saved, nil
+}
+
+// GetReservations lists reservations for the lot, or for every lot when
+// lotID is empty, in order of their start
+func (rs *ReservationService) GetReservations(lotID string) []*models.Reservation {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var reservations []*models.Reservation
+	for _, reservation := range rs.reservations {
+		if lotID == "" || reservation.LotID == lotID {
+			saved := *reservation
+			reservations = append(reservations, &saved)
+		}
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].Start.Before(reservations[j].Start)
+	})
+	return reservations
+}
+
+// Refresh expires no-shows and updates the spaces each lot holds as of now.
+// It returns the reservations it expired.
+func (rs *ReservationService) Refresh(now time.Time) []*models.Reservation {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	expired := rs.expireLocked(now)
+	rs.applyHoldsLocked(now)
+	return expired
+}
+
+// StartExpiry refreshes reservations every interval until stop is called
+func (rs *ReservationService) StartExpiry(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				for _, reservation := range rs.Refresh(rs.parking.now()) {
+					log.Printf("reservation %s for %s expired unused", reservation.ID, reservation.Car.LicensePlate)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func (rs *ReservationService) expireLocked(now time.Time) []*models.Reservation {
+	var expired []*models.Reservation
+	for _, reservation := range rs.reservations {
+		if !reservation.IsPending() {
+			continue
+		}
+		if now.After(reservation.Start.Add(rs.policy.GracePeriod)) || !now.Before(reservation.End) {
+			reservation.Status = models.ReservationExpired
+			rs.journalReservation(reservation)
+			saved := *reservation
+			expired = append(expired, &saved)
+		}
+	}
+	return expired
+}
+
+// applyHoldsLocked sets every lot's held spaces to what its pending
+// reservations need as of now
+func (rs *ReservationService) applyHoldsLocked(now time.Time) {
+	held := make(map[string]int)
+	for _, reservation := range rs.reservations {
+		if reservation.IsPending() && !now.Before(reservation.Start.Add(-rs.policy.HoldBefore)) {
+			held[reservation.LotID] += reservation.Spaces
+		}
+	}
+	for _, lot := range rs.parking.getLots() {
+		lot.SetHeldSpaces(held[lot.ID])
+	}
+}
+
+func (rs *ReservationService) findLocked(reservationID string) *models.Reservation {
+	for _, reservation := range rs.reservations {
+		if reservation.ID == reservationID {
+			return reservation
+		}
+	}
+	return nil
+}
+
+// restore loads reservations from a snapshot
+func (rs *ReservationService) restore(reservations []*models.Reservation) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.reservations = append(rs.reservations, reservations...)
+}
+
+// snapshot copies every reservation
+func (rs *ReservationService) snapshot() []*models.Reservation {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	reservations := make([]*models.Reservation, 0, len(rs.reservations))
+	for _, reservation := range rs.reservations {
+		saved := *reservation
+		reservations = append(reservations, &saved)
+	}
+	return reservations
+}
+
+func (rs *ReservationService) journalReservation(reservation *models.Reservation) {
+	saved := *reservation
+	rs.journal(&JournalEntry{Type: EntryReservationSaved, Reservation: &saved})
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Tariff prices a stay. The line items explain each charged period and add
+// up to the amount due.
+type Tariff interface {
+	Charge(parkedAt, unparkedAt time.Time) []LineItem
+	Name() string
+}
+
+// LineItem is one charged period of a stay
+type LineItem struct {
+	Description string
+	Start       time.Time
+	End         time.Time
+	Amount      float64
+}
+
+func totalOf(items []LineItem) float64 {
+	total := 0.0
+	for _, item := range items {
+		total += item.Amount
+	}
+	return total
+}
+
+// HourlyTariff charges a flat rate per started hour with a minimum charge
+type HourlyTariff struct {
+	HourlyRate    float64
+	MinimumCharge float64
+}
+
+func (ht *HourlyTariff) Name() string {
+	return "Hourly"
+}
+
+func (ht *HourlyTariff) Charge(parkedAt, unparkedAt time.Time) []LineItem {
+	hours := unparkedAt.Sub(parkedAt).Hours()
+
+	// Round up to next hour for billing
+	billingHours := math.Ceil(hours)
+	total := billingHours * ht.HourlyRate
+
+	if hours < 1.0 || total < ht.MinimumCharge {
+		return []LineItem{{Description: "Minimum charge", Start: parkedAt, End: unparkedAt, Amount: ht.MinimumCharge}}
+	}
+
+	return []LineItem{{
+		Description: fmt.Sprintf("%d hour(s) at $%.2f/hour", int(billingHours), ht.HourlyRate),
+		Start:       parkedAt,
+		End:         unparkedAt,
+		Amount:      total,
+	}}
+}
+
+// TimeBand is a daily window measured from midnight. An End before Start
+// wraps past midnight, as night rates do.
+type TimeBand struct {
+	Start time.Duration
+	End   time.Duration
+	Price float64 // Per increment started inside the window
+}
+
+func (tb *TimeBand) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if tb.Start <= tb.End {
+		return offset >= tb.Start && offset < tb.End
+	}
+	return offset >= tb.Start || offset < tb.End
+}
+
+// RuleTariff prices the first hour as a block and then charges per started
+// increment. Increments that start at night use the night price, then
+// weekend increments use the weekend price, and the rest the day price.
+// Each 24 hours from entry is capped at DailyCap.
+type RuleTariff struct {
+	TariffName     string
+	FirstHour      float64       // Price of the first hour or any part of it
+	Increment      time.Duration // Billing step after the first hour; 15 minutes when zero
+	IncrementPrice float64       // Day price per increment
+	Night          *TimeBand     // Optional night window and price
+	WeekendPrice   *float64      // Optional price per increment on Saturdays and Sundays
+	DailyCap       float64       // Most charged per 24 hours; no cap when zero
+	GracePeriod    time.Duration // Stays no longer than this are free
+}
+
+func (rt *RuleTariff) Name() string {
+	if rt.TariffName == "" {
+		return "Rule-based"
+	}
+	return rt.TariffName
+}
+
+func (rt *RuleTariff) Charge(parkedAt, unparkedAt time.Time) []LineItem {
+	if rt.GracePeriod > 0 && unparkedAt.Sub(parkedAt) <= rt.GracePeriod {
+		return []LineItem{{Description: "Grace period", Start: parkedAt, End: unparkedAt}}
+	}
+
+	var items []LineItem
+	// The first day is always charged, so even an instant stay pays the first hour
+	for dayStart := parkedAt; dayStart.Equal(parkedAt) || dayStart.Before(unparkedAt); dayStart = dayStart.Add(24 * time.Hour) {
+		dayEnd := dayStart.Add(24 * time.Hour)
+		if dayEnd.After(unparkedAt) {
+			dayEnd = unparkedAt
+		}
+
+		dayItems := rt.chargeDay(dayStart, dayEnd, dayStart.Equal(parkedAt))
+		if rt.DailyCap > 0 && totalOf(dayItems) > rt.DailyCap {
+			dayItems = []LineItem{{Description: "Daily maximum", Start: dayStart, End: dayEnd, Amount: rt.DailyCap}}
+		}
+		items = append(items, dayItems...)
+	}
+	return items
+}
+
+// chargeDay prices one day of a stay, grouping consecutive increments at the
+// same price into one line item
+func (rt *RuleTariff) chargeDay(start, end time.Time, firstDay bool) []LineItem {
+	var items []LineItem
+	t := start
+	if firstDay {
+		firstEnd := start.Add(time.Hour)
+		if firstEnd.After(end) {
+			firstEnd = end
+		}
+		items = append(items, LineItem{Description: "First hour", Start: start, End: firstEnd, Amount: rt.FirstHour})
+		t = start.Add(time.Hour)
+	}
+
+	increment := rt.Increment
+	if increment <= 0 {
+		increment = 15 * time.Minute
+	}
+
+	var label string
+	var price float64
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		items[len(items)-1].Description = fmt.Sprintf("%s: %d x %s at $%.2f", label, count, formatIncrement(increment), price)
+	}
+	for ; t.Before(end); t = t.Add(increment) {
+		nextLabel, nextPrice := rt.priceAt(t)
+		periodEnd := t.Add(increment)
+		if periodEnd.After(end) {
+			periodEnd = end
+		}
+
+		if count > 0 && nextLabel == label {
+			last := &items[len(items)-1]
+			last.End = periodEnd
+			last.Amount += nextPrice
+			count++
+			continue
+		}
+		flush()
+		label, price, count = nextLabel, nextPrice, 1
+		items = append(items, LineItem{Start: t, End: periodEnd, Amount: nextPrice})
+	}
+	flush()
+	return items
+}
+
+func (rt *RuleTariff) priceAt(t time.Time) (string, float64) {
+	if rt.Night != nil && rt.Night.contains(t) {
+		return "Night rate", rt.Night.Price
+	}
+	if rt.WeekendPrice != nil && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return "Weekend rate", *rt.WeekendPrice
+	}
+	return "Day rate", rt.IncrementPrice
+}
+
+func formatIncrement(increment time.Duration) string {
+	if increment%time.Minute == 0 {
+		return fmt.Sprintf("%d min", int(increment/time.Minute))
+	}
+	return increment.String()
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"parking-lot-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TicketRepository stores parking tickets for a single ParkingService
+type TicketRepository interface {
+	Save(ticket *models.ParkingTicket) error
+	FindByID(ticketID string) (*models.ParkingTicket, error)
+	FindByPlate(licensePlate string) []*models.ParkingTicket
+	FindActiveByPlate(licensePlate string) (*models.ParkingTicket, error)
+	FindActive() []*models.ParkingTicket
+	FindAll() []*models.ParkingTicket
+	CompleteActive(licensePlate string, unparkedAt time.Time) (*models.ParkingTicket, error)
+}
+
+// TicketHistory is implemented by repositories that can answer indexed
+// history queries, such as the SQLite store used for long-term reporting
+type TicketHistory interface {
+	FindByLot(lotID string) []*models.ParkingTicket
+	// FindParkedBetween returns tickets with ParkedAt in [from, to), oldest first
+	FindParkedBetween(from, to time.Time) []*models.ParkingTicket
+	// FindUnparkedBetween returns completed tickets with UnparkedAt in [from, to), oldest first
+	FindUnparkedBetween(from, to time.Time) []*models.ParkingTicket
+}
+
+// InMemoryTicketRepository is the default TicketRepository, indexed by
+// ticket ID, license plate and active status. It keeps its own copies of the
+// tickets and hands out copies, so only the repository changes them.
+type InMemoryTicketRepository struct {
+	byID    map[string]*models.ParkingTicket
+	byPlate map[string][]*models.ParkingTicket
+	active  map[string]*models.ParkingTicket // Keyed by license plate
+	mu      sync.RWMutex
+}
+
+func NewInMemoryTicketRepository() *InMemoryTicketRepository {
+	return &InMemoryTicketRepository{
+		byID:    make(map[string]*models.ParkingTicket),
+		byPlate: make(map[string][]*models.ParkingTicket),
+		active:  make(map[string]*models.ParkingTicket),
+	}
+}
+
+func (r *InMemoryTicketRepository) Save(ticket *models.ParkingTicket) error {
+	if ticket == nil {
+		return invalid("ticket cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byID[ticket.ID]; exists {
+		return conflict("ticket already exists")
+	}
+	ticket = copyTicket(ticket)
+	if ticket.IsActive {
+		if _, parked := r.active[ticket.LicensePlate]; parked {
+			return ErrActiveTicketExists
+		}
+		r.active[ticket.LicensePlate] = ticket
+	}
+
+	r.byID[ticket.ID] = ticket
+	r.byPlate[ticket.LicensePlate] = append(r.byPlate[ticket.LicensePlate], ticket)
+	return nil
+}
+
+func (r *InMemoryTicketRepository) FindByID(ticketID string) (*models.ParkingTicket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ticket, exists := r.byID[ticketID]
+	if !exists {
+		return nil, ErrTicketNotFound
+	}
+	return copyTicket(ticket), nil
+}
+
+func (r *InMemoryTicketRepository) FindByPlate(licensePlate string) []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return copyTickets(r.byPlate[licensePlate])
+}
+
+func (r *InMemoryTicketRepository) FindActiveByPlate(licensePlate string) (*models.ParkingTicket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ticket, exists := r.active[licensePlate]
+	if !exists {
+		return nil, ErrActiveTicketNotFound
+	}
+	return copyTicket(ticket), nil
+}
+
+func (r *InMemoryTicketRepository) FindActive() []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tickets := make([]*models.ParkingTicket, 0, len(r.active))
+	for _, ticket := range r.active {
+		tickets = append(tickets, copyTicket(ticket))
+	}
+	return tickets
+}
+
+func (r *InMemoryTicketRepository) FindAll() []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tickets := make([]*models.ParkingTicket, 0, len(r.byID))
+	for _, ticket := range r.byID {
+		tickets = append(tickets, copyTicket(ticket))
+	}
+	return tickets
+}
+
+// CompleteActive closes the plate's active ticket; only one concurrent caller can win
+func (r *InMemoryTicketRepository) CompleteActive(licensePlate string, unparkedAt time.Time) (*models.ParkingTicket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ticket, exists := r.active[licensePlate]
+	if !exists {
+		return nil, ErrActiveTicketNotFound
+	}
+
+	ticket.CompleteParkingAt(unparkedAt)
+	delete(r.active, licensePlate)
+	return copyTicket(ticket), nil
+}
+
+func (r *InMemoryTicketRepository) FindByLot(lotID string) []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tickets []*models.ParkingTicket
+	for _, ticket := range r.byID {
+		if ticket.LotID == lotID {
+			tickets = append(tickets, copyTicket(ticket))
+		}
+	}
+	sortByParkedAt(tickets)
+	return tickets
+}
+
+func (r *InMemoryTicketRepository) FindParkedBetween(from, to time.Time) []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tickets []*models.ParkingTicket
+	for _, ticket := range r.byID {
+		if !ticket.ParkedAt.Before(from) && ticket.ParkedAt.Before(to) {
+			tickets = append(tickets, copyTicket(ticket))
+		}
+	}
+	sortByParkedAt(tickets)
+	return tickets
+}
+
+func (r *InMemoryTicketRepository) FindUnparkedBetween(from, to time.Time) []*models.ParkingTicket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tickets []*models.ParkingTicket
+	for _, ticket := range r.byID {
+		if !ticket.IsActive && !ticket.UnparkedAt.Before(from) && ticket.UnparkedAt.Before(to) {
+			tickets = append(tickets, copyTicket(ticket))
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].UnparkedAt.Before(tickets[j].UnparkedAt)
+	})
+	return tickets
+}
+
+func copyTicket(ticket *models.ParkingTicket) *models.ParkingTicket {
+	saved := *ticket
+	return &saved
+}
+
+func copyTickets(tickets []*models.ParkingTicket) []*models.ParkingTicket {
+	copies := make([]*models.ParkingTicket, len(tickets))
+	for i, ticket := range tickets {
+		copies[i] = copyTicket(ticket)
+	}
+	return copies
+}
+
+func sortByParkedAt(tickets []*models.ParkingTicket) {
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].ParkedAt.Before(tickets[j].ParkedAt)
+	})
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"parking-lot-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WaitlistPolicy sets how long a queued driver has to take an offered space
+type WaitlistPolicy struct {
+	OfferTimeout time.Duration
+}
+
+// DefaultWaitlistPolicy keeps an offered space for 10 minutes
+var DefaultWaitlistPolicy = WaitlistPolicy{OfferTimeout: 10 * time.Minute}
+
+// defaultAverageStay estimates waits before any stay has been completed
+const defaultAverageStay = time.Hour
+
+// WaitlistService queues cars turned away by full lots. Handicap drivers and
+// permit holders go first, otherwise cars are served in the order they
+// joined. Each freed space is kept for the first queued car it fits, which
+// must take it within the offer timeout or lose its place.
+type WaitlistService struct {
+	parking *ParkingService
+	entries []*models.WaitlistEntry
+	policy  WaitlistPolicy
+	journal func(entry *JournalEntry)
+	mu      sync.Mutex // Guards entries and policy; taken before any lot lock
+}
+
+func newWaitlistService(parking *ParkingService) *WaitlistService {
+	return &WaitlistService{
+		parking: parking,
+		policy:  DefaultWaitlistPolicy,
+		journal: parking.journal,
+	}
+}
+
+func (ws *WaitlistService) SetPolicy(policy WaitlistPolicy) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.policy = policy
+}
+
+func (ws *WaitlistService) GetPolicy() WaitlistPolicy {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.policy
+}
+
+// Join queues the car. If a space it fits is already free, the space is
+// offered straight away.
+func (ws *WaitlistService) Join(car *models.Car) (*models.WaitlistEntry, error) {
+	if car == nil {
+		return nil, ErrNilCar
+	}
+	if _, err := ws.parking.tickets.FindActiveByPlate(car.LicensePlate); err == nil {
+		return nil, ErrActiveTicketExists
+	}
+	now := ws.parking.now()
+	priority := car.IsHandicap || ws.parking.permits.ValidPermit(car.LicensePlate, now) != nil
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.expireLocked(now)
+	for _, entry := range ws.entries {
+		if entry.IsQueued() && entry.Car.LicensePlate == car.LicensePlate {
+			return nil, conflict("vehicle is already waitlisted")
+		}
+	}
+
+	entry := &models.WaitlistEntry{
+		ID:       fmt.Sprintf("WL-%06d", len(ws.entries)+1),
+		Car:      car,
+		Priority: priority,
+		JoinedAt: now,
+		Status:   models.WaitlistWaiting,
+	}
+	ws.entries = append(ws.entries, entry)
+	ws.journalEntry(entry)
+	ws.offerLocked(now)
+
+	saved := *entry
+	return &saved, nil
+}
+
+// Accept parks the car in the space offered to it and issues its ticket
+func (ws *WaitlistService) Accept(entryID string) (*models.ParkingTicket, error) {
+	now := ws.parking.now()
+	ws.mu.Lock()
+	ws.expireLocked(now)
+	entry := ws.findLocked(entryID)
+	if entry == nil {
+		ws.mu.Unlock()
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if !entry.IsQueued() {
+		ws.mu.Unlock()
+		return nil, ErrWaitlistEntryNotActive
+	}
+	if entry.Status != models.WaitlistOffered {
+		ws.mu.Unlock()
+		return nil, conflict("no available space has been offered yet")
+	}
+	offered := *entry
+	ws.mu.Unlock()
+
+	// Parking happens unlocked, since a failed ticket frees the space and so calls back in
+	lot := ws.parking.findLotByID(offered.LotID)
+	if lot == nil {
+		return nil, errParkingLotNotFound
+	}
+	space, err := lot.ParkCarInSpace(offered.Car, offered.SpaceID)
+	if err != nil {
+		return nil, err
+	}
+	var permitID string
+	if permit := ws.parking.permits.ValidPermit(offered.Car.LicensePlate, now); permit != nil && permit.AllowsLot(lot.ID) {
+		permitID = permit.ID
+	}
+	ticket, err := ws.parking.issueTicket(lot, space, offered.Car, permitID)
+	if err != nil {
+		return nil, err
+	}
+	// Parked, the car no longer needs the spaces kept; once it leaves they go to the queue
+	ws.releaseRun(lot, offered.SpaceID, offered.Car)
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if entry := ws.findLocked(entryID); entry != nil {
+		entry.Status = models.WaitlistParked
+		entry.TicketID = ticket.ID
+		ws.journalEntry(entry)
+	}
+	return ticket, nil
+}
+
+// Leave takes the car off the waitlist, passing any offered space on
+func (ws *WaitlistService) Leave(entryID string) (*models.WaitlistEntry, error) {
+	now := ws.parking.now()
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.expireLocked(now)
+
+	entry := ws.findLocked(entryID)
+	if entry == nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if !entry.IsQueued() {
+		return nil, ErrWaitlistEntryNotActive
+	}
+	ws.withdrawOfferLocked(entry)
+	entry.Status = models.WaitlistLeft
+	ws.journalEntry(entry)
+	ws.offerLocked(now)
+
+	saved := *entry
+	return &saved, nil
+}
+
+func (ws *WaitlistService) GetEntry(entryID string) (*models.WaitlistEntry, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	entry := ws.findLocked(entryID)
+	if entry == nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	saved := *entry
+	return &saved, nil
+}
+
+// GetQueue lists the queued cars in the order they will be served
+func (ws *WaitlistService) GetQueue() []*models.WaitlistEntry {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	var queue []*models.WaitlistEntry
+	for _, entry := range ws.queueLocked() {
+		saved := *entry
+		queue = append(queue, &saved)
+	}
+	return queue
+}
+
+// Position is the entry's 1-based place among the queued cars still waiting
+// for an offer, or 0 once it has one or has left the queue
+func (ws *WaitlistService) Position(entryID string) (int, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.findLocked(entryID) == nil {
+		return 0, ErrWaitlistEntryNotFound
+	}
+	return ws.positionLocked(entryID), nil
+}
+
+// EstimatedWait guesses how long the car will wait for an offer. Spaces are
+// assumed to free up at the lots' capacity divided by the average completed
+// stay, one car ahead in the queue taking each.
+func (ws *WaitlistService) EstimatedWait(entryID string) (time.Duration, error) {
+	position, err := ws.Position(entryID)
+	if err != nil || position == 0 {
+		return 0, err
+	}
+
+	capacity := 0
+	for _, lot := range ws.parking.getLots() {
+		capacity += lot.Capacity
+	}
+	if capacity == 0 {
+		return 0, conflict("no parking lots to wait for")
+	}
+	return time.Duration(position) * ws.averageStay() / time.Duration(capacity), nil
+}
+
+func (ws *WaitlistService) averageStay() time.Duration {
+	var total time.Duration
+	completed := 0
+	for _, ticket := range ws.parking.tickets.FindAll() {
+		if !ticket.IsActive {
+			total += ticket.GetParkingDurationAt(ws.parking.now())
+			completed++
+		}
+	}
+	if completed == 0 {
+		return defaultAverageStay
+	}
+	return total / time.Duration(completed)
+}
+
+// OnVehicleParked does nothing; only departures free spaces to offer
+func (ws *WaitlistService) OnVehicleParked(lotID string, spaceIDs []int, car *models.Car) {}
+
+// OnVehicleLeft offers the freed spaces to the queue
+func (ws *WaitlistService) OnVehicleLeft(lotID string, spaceIDs []int, car *models.Car) {
+	ws.Refresh(ws.parking.now())
+}
+
+// Refresh expires offers not taken in time and offers free spaces to the
+// queue as of now. It returns the entries whose offers expired.
+func (ws *WaitlistService) Refresh(now time.Time) []*models.WaitlistEntry {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	expired := ws.expireLocked(now)
+	ws.offerLocked(now)
+	return expired
+}
+
+// StartExpiry refreshes the waitlist every interval until stop is called
+func (ws *WaitlistService) StartExpiry(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				for _, entry := range ws.Refresh(ws.parking.now()) {
+					log.Printf("waitlist offer %s for %s expired unused", entry.ID, entry.Car.LicensePlate)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func (ws *WaitlistService) expireLocked(now time.Time) []*models.WaitlistEntry {
+	var expired []*models.WaitlistEntry
+	for _, entry := range ws.entries {
+		if entry.Status == models.WaitlistOffered && !now.Before(entry.OfferExpiresAt) {
+			ws.withdrawOfferLocked(entry)
+			entry.Status = models.WaitlistExpired
+			ws.journalEntry(entry)
+			saved := *entry
+			expired = append(expired, &saved)
+		}
+	}
+	return expired
+}
+
+// offerLocked keeps a free space for each waiting car, in queue order, that
+// fits in one
+func (ws *WaitlistService) offerLocked(now time.Time) {
+	for _, entry := range ws.queueLocked() {
+		if entry.Status != models.WaitlistWaiting {
+			continue
+		}
+		for _, lot := range ws.parking.lotsInParkingOrder(entry.Car) {
+			space := lot.FindAvailableSpace(entry.Car)
+			if space == nil {
+				continue
+			}
+			until := now.Add(ws.policy.OfferTimeout)
+			if err := ws.keepRun(lot, space.ID, entry.Car, until); err != nil {
+				continue
+			}
+			entry.Status = models.WaitlistOffered
+			entry.LotID = lot.ID
+			entry.SpaceID = space.ID
+			entry.OfferedAt = now
+			entry.OfferExpiresAt = until
+			ws.journalEntry(entry)
+			break
+		}
+	}
+}
+
+// withdrawOfferLocked frees the spaces kept for the entry, if any
+func (ws *WaitlistService) withdrawOfferLocked(entry *models.WaitlistEntry) {
+	if entry.Status != models.WaitlistOffered {
+		return
+	}
+	if lot := ws.parking.findLotByID(entry.LotID); lot != nil {
+		ws.releaseRun(lot, entry.SpaceID, entry.Car)
+	}
+}
+
+// keepRun keeps the spaces the car would occupy from spaceID on for its
+// offer until it runs out
+func (ws *WaitlistService) keepRun(lot *models.ParkingLot, spaceID int, car *models.Car, until time.Time) error {
+	run, err := offerRun(lot, spaceID, car)
+	if err != nil {
+		return err
+	}
+	for _, kept := range run {
+		if err := lot.KeepForOffer(kept.ID, car.LicensePlate, until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseRun frees the spaces kept for the car's offer from spaceID on
+func (ws *WaitlistService) releaseRun(lot *models.ParkingLot, spaceID int, car *models.Car) {
+	run, _ := offerRun(lot, spaceID, car)
+	for _, kept := range run {
+		lot.ReleaseOffer(kept.ID, car.LicensePlate)
+	}
+}
+
+// offerRun returns the spaces the car would occupy from spaceID on
+func offerRun(lot *models.ParkingLot, spaceID int, car *models.Car) ([]*models.ParkingSpace, error) {
+	for i, space := range lot.Spaces {
+		if space.ID != spaceID {
+			continue
+		}
+		if i+car.RequiredSpaces() > len(lot.Spaces) {
+			return nil, models.ErrNoContiguousSpaces
+		}
+		return lot.Spaces[i : i+car.RequiredSpaces()], nil
+	}
+	return nil, models.ErrSpaceNotFound
+}
+
+// queueLocked returns the queued entries, priority first, then by arrival
+func (ws *WaitlistService) queueLocked() []*models.WaitlistEntry {
+	var queue []*models.WaitlistEntry
+	for _, entry := range ws.entries {
+		if entry.IsQueued() {
+			queue = append(queue, entry)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		if queue[i].Priority != queue[j].Priority {
+			return queue[i].Priority
+		}
+		return queue[i].JoinedAt.Before(queue[j].JoinedAt)
+	})
+	return queue
+}
+
+func (ws *WaitlistService) positionLocked(entryID string) int {
+	position := 0
+	for _, entry := range ws.queueLocked() {
+		if entry.Status != models.WaitlistWaiting {
+			continue
+		}
+		position++
+		if entry.ID == entryID {
+			return position
+		}
+	}
+	return 0
+}
+
+func (ws *WaitlistService) findLocked(entryID string) *models.WaitlistEntry {
+	for _, entry := range ws.entries {
+		if entry.ID == entryID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// restore loads the waitlist from a snapshot and keeps the spaces offered
+func (ws *WaitlistService) restore(entries []*models.WaitlistEntry) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.entries = append(ws.entries, entries...)
+	for _, entry := range entries {
+		if entry.Status != models.WaitlistOffered {
+			continue
+		}
+		if lot := ws.parking.findLotByID(entry.LotID); lot != nil {
+			ws.keepRun(lot, entry.SpaceID, entry.Car, entry.OfferExpiresAt)
+		}
+	}
+}
+
+// snapshot copies every waitlist entry
+func (ws *WaitlistService) snapshot() []*models.WaitlistEntry {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	entries := make([]*models.WaitlistEntry, 0, len(ws.entries))
+	for _, entry := range ws.entries {
+		saved := *entry
+		entries = append(entries, &saved)
+	}
+	return entries
+}
+
+func (ws *WaitlistService) journalEntry(entry *models.WaitlistEntry) {
+	saved := *entry
+	ws.journal(&JournalEntry{Type: EntryWaitlistSaved, Waitlist: &saved})
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReceivedWebhook is one delivery accepted by a WebhookReceiver
+type ReceivedWebhook struct {
+	EventType  string
+	LotID      string
+	At         time.Time
+	Payload    json.RawMessage
+	Signed     bool // The delivery carried a valid signature
+	ReceivedAt time.Time
+}
+
+// WebhookReceiver is a local webhook endpoint for trying webhooks out without
+// an external service. It checks signatures when given a secret, can be told
+// to fail deliveries so retries can be seen, and keeps what it accepted.
+type WebhookReceiver struct {
+	OnDelivery func(delivery ReceivedWebhook) // Optional, called for each accepted delivery
+
+	secret     string
+	failures   int
+	deliveries []ReceivedWebhook
+	mu         sync.Mutex
+}
+
+// NewWebhookReceiver creates a receiver; with a secret, unsigned or wrongly
+// signed deliveries are refused
+func NewWebhookReceiver(secret string) *WebhookReceiver {
+	return &WebhookReceiver{secret: secret}
+}
+
+// FailNext makes the receiver answer the next n deliveries with 503
+func (wr *WebhookReceiver) FailNext(n int) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.failures = n
+}
+
+// Deliveries lists the accepted deliveries in the order they arrived
+func (wr *WebhookReceiver) Deliveries() []ReceivedWebhook {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]ReceivedWebhook(nil), wr.deliveries...)
+}
+
+func (wr *WebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+	signature := r.Header.Get(WebhookSignatureHeader)
+	signed := wr.secret != "" && VerifyWebhookSignature(wr.secret, body, signature)
+	if wr.secret != "" && !signed {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event struct {
+		Type    string          `json:"type"`
+		LotID   string          `json:"lotId"`
+		At      time.Time       `json:"at"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "body is not a JSON event", http.StatusBadRequest)
+		return
+	}
+
+	wr.mu.Lock()
+	if wr.failures > 0 {
+		wr.failures--
+		wr.mu.Unlock()
+		http.Error(w, "failing on request", http.StatusServiceUnavailable)
+		return
+	}
+	delivery := ReceivedWebhook{
+		EventType:  event.Type,
+		LotID:      event.LotID,
+		At:         event.At,
+		Payload:    event.Payload,
+		Signed:     signed,
+		ReceivedAt: time.Now(),
+	}
+	wr.deliveries = append(wr.deliveries, delivery)
+	onDelivery := wr.OnDelivery
+	wr.mu.Unlock()
+
+	if onDelivery != nil {
+		onDelivery(delivery)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
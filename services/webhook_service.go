@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+	"sync"
+	"time"
+)
+
+// Headers set on every webhook delivery
+const (
+	WebhookEventHeader     = "X-Parking-Event"
+	WebhookSignatureHeader = "X-Parking-Signature" // "sha256=" and the hex HMAC-SHA256 of the body
+)
+
+// WebhookPolicy sets how hard a delivery is retried before it is dead-lettered
+type WebhookPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration // Wait before the first retry, doubled for each later one
+	MaxBackoff     time.Duration
+	Timeout        time.Duration // Per attempt
+}
+
+// DefaultWebhookPolicy tries five times, waiting 1s, 2s, 4s and 8s in between
+var DefaultWebhookPolicy = WebhookPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Timeout:        10 * time.Second,
+}
+
+// WebhookService posts lot events to registered webhooks. Each webhook
+// receives its events in order from its own event bus subscription, so a slow
+// or failing endpoint holds up only itself. Deliveries still failing after
+// the policy's retries, and events that arrive while a webhook's queue is
+// full, are kept as dead letters until redelivered.
+type WebhookService struct {
+	bus         *events.Bus
+	client      *http.Client
+	endpoints   []*webhookEndpoint
+	deadLetters []*models.DeadLetter
+	policy      WebhookPolicy
+	journal     func(entry *JournalEntry)
+	now         func() time.Time
+	closing     chan struct{}
+	closeOnce   sync.Once
+	mu          sync.Mutex // Guards endpoints, deadLetters and policy
+}
+
+type webhookEndpoint struct {
+	webhook      models.Webhook
+	subscription *events.Subscription
+}
+
+func newWebhookService(parking *ParkingService) *WebhookService {
+	return &WebhookService{
+		bus:     parking.bus,
+		client:  &http.Client{},
+		policy:  DefaultWebhookPolicy,
+		journal: parking.journal,
+		now:     parking.now,
+		closing: make(chan struct{}),
+	}
+}
+
+func (ws *WebhookService) SetPolicy(policy WebhookPolicy) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.policy = policy
+}
+
+func (ws *WebhookService) GetPolicy() WebhookPolicy {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.policy
+}
+
+// Register starts posting matching events to the webhook, replacing any
+// webhook registered under the same ID
+func (ws *WebhookService) Register(webhook models.Webhook) (*models.Webhook, error) {
+	if webhook.ID == "" {
+		return nil, invalid("webhook id cannot be empty")
+	}
+	if parsed, err := url.Parse(webhook.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, invalid("webhook URL must be an http or https URL")
+	}
+	filter := events.Filter{LotIDs: append([]string(nil), webhook.LotIDs...)}
+	for _, name := range webhook.Events {
+		eventType, err := events.ParseType(name)
+		if err != nil {
+			return nil, err
+		}
+		filter.Types = append(filter.Types, eventType)
+	}
+
+	registered := webhook
+	registered.LotIDs = append([]string(nil), webhook.LotIDs...)
+	registered.Events = append([]string(nil), webhook.Events...)
+	endpoint := &webhookEndpoint{webhook: registered}
+	endpoint.subscription = ws.bus.SubscribeWithOverflow(filter, events.DefaultBuffer, func(event events.Event) {
+		ws.deliver(endpoint.webhook, event)
+	}, func(event events.Event) {
+		ws.overflow(endpoint.webhook, event)
+	})
+
+	ws.mu.Lock()
+	replaced := false
+	for i, existing := range ws.endpoints {
+		if existing.webhook.ID == webhook.ID {
+			existing.subscription.Unsubscribe()
+			ws.endpoints[i] = endpoint
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		ws.endpoints = append(ws.endpoints, endpoint)
+	}
+	ws.mu.Unlock()
+
+	saved := registered
+	return &saved, nil
+}
+
+// Unregister stops posting events to the webhook; deliveries already queued still go out
+func (ws *WebhookService) Unregister(webhookID string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for i, endpoint := range ws.endpoints {
+		if endpoint.webhook.ID == webhookID {
+			endpoint.subscription.Unsubscribe()
+			ws.endpoints = append(ws.endpoints[:i], ws.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return notFound("webhook not found")
+}
+
+// GetWebhooks lists the registered webhooks in the order they were registered
+func (ws *WebhookService) GetWebhooks() []*models.Webhook {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	webhooks := make([]*models.Webhook, 0, len(ws.endpoints))
+	for _, endpoint := range ws.endpoints {
+		saved := endpoint.webhook
+		webhooks = append(webhooks, &saved)
+	}
+	return webhooks
+}
+
+// GetDeadLetters lists the deliveries still waiting to be redelivered, oldest first
+func (ws *WebhookService) GetDeadLetters() []*models.DeadLetter {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	var letters []*models.DeadLetter
+	for _, letter := range ws.deadLetters {
+		if letter.IsPending() {
+			saved := *letter
+			letters = append(letters, &saved)
+		}
+	}
+	return letters
+}
+
+// Redeliver posts a dead letter once more to its webhook's URL. The letter
+// leaves the queue if the webhook accepts it.
+func (ws *WebhookService) Redeliver(deadLetterID string) (*models.DeadLetter, error) {
+	ws.mu.Lock()
+	letter := ws.findDeadLetterLocked(deadLetterID)
+	if letter == nil {
+		ws.mu.Unlock()
+		return nil, notFound("dead letter not found")
+	}
+	if !letter.IsPending() {
+		ws.mu.Unlock()
+		return nil, conflict("dead letter was already delivered")
+	}
+	pending := *letter
+	secret := ""
+	for _, endpoint := range ws.endpoints {
+		if endpoint.webhook.ID == letter.WebhookID {
+			secret = endpoint.webhook.Secret
+		}
+	}
+	timeout := ws.policy.Timeout
+	ws.mu.Unlock()
+
+	err := ws.post(pending.URL, secret, pending.EventType, pending.Body, timeout)
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	letter.Attempts++
+	if err != nil {
+		letter.LastError = err.Error()
+	} else {
+		letter.DeliveredAt = ws.now()
+	}
+	ws.journalDeadLetter(letter)
+	saved := *letter
+	if err != nil {
+		return &saved, models.NewError(ErrDeliveryFailed, fmt.Sprintf("webhook delivery failed: %v", err))
+	}
+	return &saved, nil
+}
+
+// Close stops taking events and waits for queued deliveries. Deliveries
+// waiting to be retried are dead-lettered at once rather than after their
+// backoff. The webhooks stay listed.
+func (ws *WebhookService) Close() {
+	ws.closeOnce.Do(func() { close(ws.closing) })
+
+	ws.mu.Lock()
+	endpoints := append([]*webhookEndpoint(nil), ws.endpoints...)
+	ws.mu.Unlock()
+
+	for _, endpoint := range endpoints {
+		endpoint.subscription.Unsubscribe()
+	}
+	for _, endpoint := range endpoints {
+		<-endpoint.subscription.Done()
+	}
+}
+
+// deliver posts the event, retrying with exponential backoff, and
+// dead-letters it once the retries run out
+func (ws *WebhookService) deliver(webhook models.Webhook, event events.Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		ws.deadLetter(webhook, event, body, 0, err)
+		return
+	}
+
+	policy := ws.GetPolicy()
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := ws.post(webhook.URL, webhook.Secret, string(event.Type), body, policy.Timeout)
+		if err == nil {
+			return
+		}
+		if attempt >= policy.MaxAttempts {
+			ws.deadLetter(webhook, event, body, attempt, err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ws.closing:
+			ws.deadLetter(webhook, event, body, attempt, err)
+			return
+		}
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// overflow dead-letters an event the webhook's queue had no room for, without
+// posting it, so it can be redelivered once the endpoint catches up
+func (ws *WebhookService) overflow(webhook models.Webhook, event events.Event) {
+	body, err := json.Marshal(event)
+	if err == nil {
+		err = errors.New("webhook queue full: event was not posted")
+	}
+	ws.deadLetter(webhook, event, body, 0, err)
+}
+
+// post makes one delivery attempt; anything but a 2xx response is a failure
+func (ws *WebhookService) post(target, secret, eventType string, body []byte, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	if secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookBody(secret, body))
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (ws *WebhookService) deadLetter(webhook models.Webhook, event events.Event, body []byte, attempts int, err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	letter := &models.DeadLetter{
+		ID:        fmt.Sprintf("DLQ-%06d", len(ws.deadLetters)+1),
+		WebhookID: webhook.ID,
+		URL:       webhook.URL,
+		EventType: string(event.Type),
+		LotID:     event.LotID,
+		Body:      body,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		FailedAt:  ws.now(),
+	}
+	ws.deadLetters = append(ws.deadLetters, letter)
+	ws.journalDeadLetter(letter)
+}
+
+func (ws *WebhookService) findDeadLetterLocked(deadLetterID string) *models.DeadLetter {
+	for _, letter := range ws.deadLetters {
+		if letter.ID == deadLetterID {
+			return letter
+		}
+	}
+	return nil
+}
+
+// restore loads dead letters from a snapshot
+func (ws *WebhookService) restore(deadLetters []*models.DeadLetter) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.deadLetters = append(ws.deadLetters, deadLetters...)
+}
+
+// snapshot copies every dead letter, redelivered ones included so IDs are not reused
+func (ws *WebhookService) snapshot() []*models.DeadLetter {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	letters := make([]*models.DeadLetter, 0, len(ws.deadLetters))
+	for _, letter := range ws.deadLetters {
+		saved := *letter
+		letters = append(letters, &saved)
+	}
+	return letters
+}
+
+func (ws *WebhookService) journalDeadLetter(letter *models.DeadLetter) {
+	saved := *letter
+	ws.journal(&JournalEntry{Type: EntryDeadLetterSaved, DeadLetter: &saved})
+}
+
+// SignWebhookBody returns the signature header value for a delivery body
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is the body's signature under secret
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookBody(secret, body)), []byte(signature))
+}
@@ -0,0 +1,186 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Distribution draws the durations a scenario is built from: gaps between
+// arrivals and how long cars stay
+type Distribution interface {
+	Sample(rng *rand.Rand) time.Duration
+	String() string
+}
+
+// Exponential durations have the given mean. As gaps between arrivals they
+// make a Poisson arrival process.
+type Exponential struct {
+	Mean time.Duration
+}
+
+// Poisson returns the gaps between arrivals coming at ratePerHour on average
+func Poisson(ratePerHour float64) Exponential {
+	return Exponential{Mean: time.Duration(float64(time.Hour) / ratePerHour)}
+}
+
+func (e Exponential) Sample(rng *rand.Rand) time.Duration {
+	return time.Duration(rng.ExpFloat64() * float64(e.Mean))
+}
+
+func (e Exponential) String() string {
+	return "exp:" + e.Mean.String()
+}
+
+// Uniform durations are spread evenly from Min to Max
+type Uniform struct {
+	Min, Max time.Duration
+}
+
+func (u Uniform) Sample(rng *rand.Rand) time.Duration {
+	return u.Min + time.Duration(rng.Int63n(int64(u.Max-u.Min)+1))
+}
+
+func (u Uniform) String() string {
+	return "uniform:" + u.Min.String() + "-" + u.Max.String()
+}
+
+// Fixed durations are always Value
+type Fixed struct {
+	Value time.Duration
+}
+
+func (f Fixed) Sample(*rand.Rand) time.Duration {
+	return f.Value
+}
+
+func (f Fixed) String() string {
+	return "fixed:" + f.Value.String()
+}
+
+// Empirical durations are drawn from observed values, each equally likely
+type Empirical struct {
+	Values []time.Duration
+}
+
+func (e Empirical) Sample(rng *rand.Rand) time.Duration {
+	return e.Values[rng.Intn(len(e.Values))]
+}
+
+func (e Empirical) String() string {
+	values := make([]string, len(e.Values))
+	for i, value := range e.Values {
+		values[i] = value.String()
+	}
+	return "empirical:" + strings.Join(values, ",")
+}
+
+// checkDistribution holds the distributions built in code to what
+// ParseDistribution and ParseArrivals accept: positive durations, and at
+// least one empirical value. Other Distribution implementations are trusted.
+func checkDistribution(d Distribution) error {
+	switch d := d.(type) {
+	case Exponential:
+		if d.Mean <= 0 {
+			return errors.New("exponential mean must be positive")
+		}
+	case Uniform:
+		if d.Min <= 0 || d.Max <= 0 {
+			return errors.New("uniform distribution bounds must be positive")
+		}
+		if d.Max < d.Min {
+			return errors.New("uniform distribution maximum cannot be below its minimum")
+		}
+	case Fixed:
+		if d.Value <= 0 {
+			return errors.New("fixed duration must be positive")
+		}
+	case Empirical:
+		if len(d.Values) == 0 {
+			return errors.New("empirical distribution cannot be empty")
+		}
+		for _, value := range d.Values {
+			if value <= 0 {
+				return errors.New("empirical durations must be positive")
+			}
+		}
+	}
+	return nil
+}
+
+// ParseDistribution reads exp:MEAN, uniform:MIN-MAX, fixed:VALUE or
+// empirical:V1,V2,... with Go durations such as 90m or 2h
+func ParseDistribution(spec string) (Distribution, error) {
+	kind, args, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch strings.ToLower(kind) {
+	case "exp":
+		mean, err := parsePositiveDuration(args)
+		if err != nil {
+			return nil, err
+		}
+		return Exponential{Mean: mean}, nil
+	case "uniform":
+		from, to, ok := strings.Cut(args, "-")
+		if !ok {
+			return nil, errors.New("uniform distribution must be uniform:MIN-MAX")
+		}
+		min, err := parsePositiveDuration(from)
+		if err != nil {
+			return nil, err
+		}
+		max, err := parsePositiveDuration(to)
+		if err != nil {
+			return nil, err
+		}
+		if max < min {
+			return nil, errors.New("uniform distribution maximum cannot be below its minimum")
+		}
+		return Uniform{Min: min, Max: max}, nil
+	case "fixed":
+		value, err := parsePositiveDuration(args)
+		if err != nil {
+			return nil, err
+		}
+		return Fixed{Value: value}, nil
+	case "empirical":
+		var values []time.Duration
+		for _, field := range strings.Split(args, ",") {
+			value, err := parsePositiveDuration(field)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return Empirical{Values: values}, nil
+	default:
+		return nil, fmt.Errorf("unknown distribution %q", spec)
+	}
+}
+
+// ParseArrivals reads poisson:RATE, with RATE in arrivals per hour, or any
+// distribution ParseDistribution accepts as the gaps between arrivals
+func ParseArrivals(spec string) (Distribution, error) {
+	kind, args, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	if strings.ToLower(kind) != "poisson" {
+		return ParseDistribution(spec)
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(args), 64)
+	if err != nil || rate <= 0 {
+		return nil, errors.New("poisson arrival rate must be a positive number per hour")
+	}
+	return Poisson(rate), nil
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,371 @@
+// Package simulation replays synthetic traffic through a ParkingService on a
+// virtual clock, so parking strategies can be compared on the same arrivals
+package simulation
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/rand"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"time"
+)
+
+// attendantID parks every simulated car
+const attendantID = "SIM"
+
+// DefaultStart is a Monday midnight, used when a scenario sets no start
+var DefaultStart = time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+// Scenario describes the traffic a simulation replays. Every strategy run
+// against the same scenario sees the same cars arriving at the same times.
+type Scenario struct {
+	// NewService builds the lots, rates and policies to simulate; each run gets a fresh service
+	NewService func() (*services.ParkingService, error)
+	Start      time.Time
+	Duration   time.Duration
+	Arrivals   Distribution // Gaps between arrivals
+	Dwell      Distribution // How long parked cars stay
+	Mix        VehicleMix
+	Patience   time.Duration // How long a turned-away car queues for a space; zero turns it away at once
+	Seed       int64
+}
+
+func (s *Scenario) validate() error {
+	if s.NewService == nil {
+		return errors.New("simulation needs a service to run against")
+	}
+	if s.Duration <= 0 {
+		return errors.New("simulation duration must be positive")
+	}
+	if s.Arrivals == nil {
+		return errors.New("simulation needs an arrival distribution")
+	}
+	if s.Dwell == nil {
+		return errors.New("simulation needs a dwell-time distribution")
+	}
+	if err := checkDistribution(s.Arrivals); err != nil {
+		return fmt.Errorf("simulation arrivals: %w", err)
+	}
+	if err := checkDistribution(s.Dwell); err != nil {
+		return fmt.Errorf("simulation dwell time: %w", err)
+	}
+	if s.Patience < 0 {
+		return errors.New("simulation patience cannot be negative")
+	}
+	return s.Mix.validate()
+}
+
+// LotResult is how busy one lot was over a run
+type LotResult struct {
+	LotID       string  `json:"lotId"`
+	Capacity    int     `json:"capacity"`
+	Utilization float64 `json:"utilization"` // Occupied share of the lot's spaces, averaged over the run
+	Parked      int     `json:"parked"`
+}
+
+// Result summarises one strategy's run. Cars still parked when the run ends
+// are not billed, so Revenue only counts departures within the run.
+type Result struct {
+	Strategy         string
+	Arrivals         int
+	Parked           int
+	TurnedAway       int // Refused with nowhere to park and no patience to queue
+	Queued           int
+	Abandoned        int // Gave up queueing before a space came free
+	TurnAwayRate     float64
+	Utilization      float64
+	Revenue          float64
+	AverageQueueWait time.Duration // Over queued cars that went on to park
+	MaxQueueWait     time.Duration
+	Lots             []LotResult
+}
+
+// Compare runs the scenario once per strategy, in order
+func Compare(scenario Scenario, strategies ...models.ParkingStrategy) ([]*Result, error) {
+	results := make([]*Result, 0, len(strategies))
+	for _, strategy := range strategies {
+		result, err := Run(scenario, strategy)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+type eventKind int
+
+// Events at the same instant run in this order, so a space freed by a
+// departure can go to a car arriving at that moment
+const (
+	departure eventKind = iota
+	abandon
+	arrival
+)
+
+type simEvent struct {
+	at   time.Time
+	kind eventKind
+	seq  int
+	car  *simCar
+}
+
+type eventQueue []*simEvent
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	if q[i].kind != q[j].kind {
+		return q[i].kind < q[j].kind
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*simEvent)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	event := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return event
+}
+
+type simCar struct {
+	car       *models.Car
+	dwell     time.Duration
+	arrivedAt time.Time
+	queued    bool
+}
+
+// run is the state of one strategy's pass through a scenario
+type run struct {
+	scenario Scenario
+	strategy models.ParkingStrategy
+	service  *services.ParkingService
+	clock    *models.FakeClock
+	rng      *rand.Rand
+	events   eventQueue
+	seq      int
+	queue    []*simCar
+	result   *Result
+
+	lastAt       time.Time
+	occupiedTime map[string]float64 // Space-hours occupied per lot
+	lotParked    map[string]int
+	queueWaits   time.Duration
+	servedQueued int
+}
+
+// Run replays the scenario through a fresh service, parking every car with strategy
+func Run(scenario Scenario, strategy models.ParkingStrategy) (*Result, error) {
+	if strategy == nil {
+		return nil, errors.New("strategy cannot be nil")
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+	if scenario.Start.IsZero() {
+		scenario.Start = DefaultStart
+	}
+
+	service, err := scenario.NewService()
+	if err != nil {
+		return nil, err
+	}
+	defer service.GetWebhookService().Close()
+	clock := models.NewFakeClock(scenario.Start)
+	service.SetClock(clock)
+	service.AddAttendant(models.NewParkingAttendant(attendantID, "Simulated attendant", ""))
+
+	r := &run{
+		scenario:     scenario,
+		strategy:     strategy,
+		service:      service,
+		clock:        clock,
+		rng:          rand.New(rand.NewSource(scenario.Seed)),
+		result:       &Result{Strategy: strategy.GetStrategyName()},
+		lastAt:       scenario.Start,
+		occupiedTime: make(map[string]float64),
+		lotParked:    make(map[string]int),
+	}
+	if err := r.execute(); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+func (r *run) execute() error {
+	end := r.scenario.Start.Add(r.scenario.Duration)
+	r.schedule(r.scenario.Start.Add(r.scenario.Arrivals.Sample(r.rng)), arrival, nil)
+
+	for len(r.events) > 0 {
+		event := heap.Pop(&r.events).(*simEvent)
+		if event.at.After(end) {
+			break
+		}
+		r.advance(event.at)
+
+		var err error
+		switch event.kind {
+		case arrival:
+			err = r.arrive()
+		case departure:
+			err = r.depart(event.car)
+		case abandon:
+			r.abandon(event.car)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r.advance(end)
+	r.summarise()
+	return nil
+}
+
+func (r *run) schedule(at time.Time, kind eventKind, car *simCar) {
+	r.seq++
+	heap.Push(&r.events, &simEvent{at: at, kind: kind, seq: r.seq, car: car})
+}
+
+// advance moves the virtual clock to at, crediting each lot with the spaces
+// occupied since the last event
+func (r *run) advance(at time.Time) {
+	hours := at.Sub(r.lastAt).Hours()
+	if hours > 0 {
+		for _, lot := range r.service.GetLotUtilization() {
+			r.occupiedTime[lot.LotID] += float64(lot.OccupiedSpaces) * hours
+		}
+	}
+	r.lastAt = at
+	r.clock.Set(at)
+}
+
+// arrive draws the next car and the gap to the one after it. Every draw
+// happens here, in arrival order, so parking outcomes never shift the stream.
+func (r *run) arrive() error {
+	now := r.clock.Now()
+	r.result.Arrivals++
+	plate := fmt.Sprintf("SIM%06d", r.result.Arrivals)
+	car := &simCar{
+		car:       r.scenario.Mix.newCar(r.rng, plate),
+		dwell:     r.scenario.Dwell.Sample(r.rng),
+		arrivedAt: now,
+	}
+	gap := r.scenario.Arrivals.Sample(r.rng)
+	if gap <= 0 {
+		// The next arrival would fall at this instant again, forever
+		return fmt.Errorf("simulation arrivals: gaps must be positive, drew %v", gap)
+	}
+	r.schedule(now.Add(gap), arrival, nil)
+
+	if r.park(car) {
+		return nil
+	}
+	if r.scenario.Patience == 0 {
+		r.result.TurnedAway++
+		return nil
+	}
+	car.queued = true
+	r.queue = append(r.queue, car)
+	r.result.Queued++
+	r.schedule(now.Add(r.scenario.Patience), abandon, car)
+	return nil
+}
+
+// park tries to park the car through the strategy and books its departure
+func (r *run) park(car *simCar) bool {
+	ticket, err := r.service.ParkCarWithStrategyAndTicket(car.car, attendantID, r.strategy)
+	if err != nil {
+		return false
+	}
+	r.result.Parked++
+	r.lotParked[ticket.LotID]++
+	r.schedule(r.clock.Now().Add(car.dwell), departure, car)
+	return true
+}
+
+// depart bills the car, paying first when the lots require settlement, then
+// offers the freed space to the queue in arrival order
+func (r *run) depart(car *simCar) error {
+	plate := car.car.LicensePlate
+	if r.service.GetPaymentService().RequiresSettlement() {
+		bill, err := r.service.QuoteExitBill(plate, false)
+		if err != nil {
+			return err
+		}
+		if bill.TotalAmount > 0 {
+			if _, err := r.service.GetPaymentService().Pay(bill.TicketID, services.PaymentCard, bill.TotalAmount); err != nil {
+				return fmt.Errorf("%s: %w", plate, err)
+			}
+		}
+	}
+	_, bill, err := r.service.UnparkCarWithBilling(plate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", plate, err)
+	}
+	r.result.Revenue += bill.TotalAmount
+
+	waiting := r.queue[:0]
+	for _, queued := range r.queue {
+		if !r.park(queued) {
+			waiting = append(waiting, queued)
+			continue
+		}
+		queued.queued = false
+		wait := r.clock.Now().Sub(queued.arrivedAt)
+		r.queueWaits += wait
+		r.servedQueued++
+		if wait > r.result.MaxQueueWait {
+			r.result.MaxQueueWait = wait
+		}
+	}
+	r.queue = waiting
+	return nil
+}
+
+// abandon takes a car off the queue once its patience runs out
+func (r *run) abandon(car *simCar) {
+	if !car.queued {
+		return
+	}
+	car.queued = false
+	for i, queued := range r.queue {
+		if queued == car {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			break
+		}
+	}
+	r.result.Abandoned++
+}
+
+func (r *run) summarise() {
+	hours := r.scenario.Duration.Hours()
+	totalCapacity, totalOccupied := 0, 0.0
+	for _, lot := range r.service.GetLotUtilization() {
+		result := LotResult{LotID: lot.LotID, Capacity: lot.TotalSpaces, Parked: r.lotParked[lot.LotID]}
+		if lot.TotalSpaces > 0 {
+			result.Utilization = r.occupiedTime[lot.LotID] / (float64(lot.TotalSpaces) * hours)
+		}
+		totalCapacity += lot.TotalSpaces
+		totalOccupied += r.occupiedTime[lot.LotID]
+		r.result.Lots = append(r.result.Lots, result)
+	}
+
+	if totalCapacity > 0 {
+		r.result.Utilization = totalOccupied / (float64(totalCapacity) * hours)
+	}
+	if r.result.Arrivals > 0 {
+		r.result.TurnAwayRate = float64(r.result.TurnedAway+r.result.Abandoned) / float64(r.result.Arrivals)
+	}
+	if r.servedQueued > 0 {
+		r.result.AverageQueueWait = r.queueWaits / time.Duration(r.servedQueued)
+	}
+}
@@ -0,0 +1,87 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"parking-lot-system/models"
+	"strconv"
+	"strings"
+)
+
+// VehicleShare is one kind of vehicle in a mix and its relative weight
+type VehicleShare struct {
+	Size     models.VehicleSize
+	Handicap bool
+	Weight   float64
+}
+
+// VehicleMix is the kinds of vehicle arriving, each drawn in proportion to
+// its weight. An empty mix is all medium cars.
+type VehicleMix []VehicleShare
+
+// ParseVehicleMix reads weights such as medium:70,large:20,motorcycle:5,handicap:5,
+// where handicap means medium cars with a handicap permit
+func ParseVehicleMix(spec string) (VehicleMix, error) {
+	var mix VehicleMix
+	for _, field := range strings.Split(spec, ",") {
+		name, weightText, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return nil, fmt.Errorf("vehicle mix entry %q must be KIND:WEIGHT", field)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightText), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("vehicle mix weight %q must be a number of at least 0", weightText)
+		}
+		share := VehicleShare{Size: models.MediumVehicle, Weight: weight}
+		if strings.EqualFold(strings.TrimSpace(name), "handicap") {
+			share.Handicap = true
+		} else if share.Size, err = models.ParseVehicleSize(name); err != nil {
+			return nil, err
+		}
+		mix = append(mix, share)
+	}
+	if err := mix.validate(); err != nil {
+		return nil, err
+	}
+	return mix, nil
+}
+
+func (m VehicleMix) validate() error {
+	total := 0.0
+	for _, share := range m {
+		if share.Weight < 0 {
+			return errors.New("vehicle mix weights cannot be negative")
+		}
+		total += share.Weight
+	}
+	if len(m) > 0 && total == 0 {
+		return errors.New("vehicle mix needs a positive weight")
+	}
+	return nil
+}
+
+// newCar draws a vehicle from the mix
+func (m VehicleMix) newCar(rng *rand.Rand, licensePlate string) *models.Car {
+	car := models.NewCar(licensePlate, "Simulated driver")
+	if len(m) == 0 {
+		return car
+	}
+
+	total := 0.0
+	for _, share := range m {
+		total += share.Weight
+	}
+	pick := rng.Float64() * total
+	chosen := m[len(m)-1]
+	for _, share := range m {
+		if pick < share.Weight {
+			chosen = share
+			break
+		}
+		pick -= share.Weight
+	}
+	car.SetVehicleSize(chosen.Size)
+	car.SetHandicapStatus(chosen.Handicap)
+	return car
+}
@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"parking-lot-system/services"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	walFileName      = "journal.wal"
+	snapshotFileName = "snapshot.json"
+)
+
+// FileStore keeps ParkingService state in a directory on local disk: an
+// append-only write-ahead log plus the latest snapshot. Each log line is
+// "<crc32 hex> <json entry>" so a torn or corrupted tail can be detected.
+type FileStore struct {
+	dir      string
+	wal      *os.File
+	sequence uint64
+	saved    uint64     // Sequence of the snapshot on disk
+	mu       sync.Mutex // Guards wal, sequence and saved, and is held across snapshot writes
+}
+
+// NewFileStore opens (or creates) a store in dir and repairs a corrupted log tail
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	fs := &FileStore{dir: dir}
+
+	snapshot, err := fs.readSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		fs.sequence = snapshot.Sequence
+		fs.saved = snapshot.Sequence
+	}
+
+	entries, err := fs.recoverLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Sequence > fs.sequence {
+			fs.sequence = entry.Sequence
+		}
+	}
+
+	fs.wal, err = os.OpenFile(fs.path(walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+func (fs *FileStore) path(name string) string {
+	return filepath.Join(fs.dir, name)
+}
+
+func (fs *FileStore) Append(entry *services.JournalEntry) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.wal == nil {
+		return errors.New("file store is closed")
+	}
+
+	entry.Sequence = fs.sequence + 1
+	line, err := encodeEntry(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.wal.Write(line); err != nil {
+		return err
+	}
+	if err := fs.wal.Sync(); err != nil {
+		return err
+	}
+
+	fs.sequence = entry.Sequence
+	return nil
+}
+
+func (fs *FileStore) LastSequence() uint64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.sequence
+}
+
+// SaveSnapshot atomically replaces the snapshot, then drops log entries it
+// covers. A snapshot older than the one on disk is ignored, so overlapping
+// snapshots never roll the state back.
+func (fs *FileStore) SaveSnapshot(snapshot *services.Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.wal == nil {
+		return errors.New("file store is closed")
+	}
+	if snapshot.Sequence < fs.saved {
+		return nil
+	}
+	if err := writeFileAtomic(fs.path(snapshotFileName), data); err != nil {
+		return err
+	}
+	fs.saved = snapshot.Sequence
+
+	entries, err := fs.readLog()
+	if err != nil {
+		return err
+	}
+
+	var kept bytes.Buffer
+	for _, entry := range entries {
+		if entry.Sequence <= snapshot.Sequence {
+			continue
+		}
+		line, err := encodeEntry(entry)
+		if err != nil {
+			return err
+		}
+		kept.Write(line)
+	}
+
+	if err := fs.wal.Close(); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(fs.path(walFileName), kept.Bytes()); err != nil {
+		return err
+	}
+	fs.wal, err = os.OpenFile(fs.path(walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	return err
+}
+
+func (fs *FileStore) Load() (*services.Snapshot, []*services.JournalEntry, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	snapshot, err := fs.readSnapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := fs.readLog()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var after []*services.JournalEntry
+	for _, entry := range entries {
+		if snapshot == nil || entry.Sequence > snapshot.Sequence {
+			after = append(after, entry)
+		}
+	}
+
+	return snapshot, after, nil
+}
+
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.wal == nil {
+		return nil
+	}
+	err := fs.wal.Sync()
+	if closeErr := fs.wal.Close(); err == nil {
+		err = closeErr
+	}
+	fs.wal = nil
+	return err
+}
+
+func (fs *FileStore) readSnapshot() (*services.Snapshot, error) {
+	data, err := os.ReadFile(fs.path(snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot services.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("corrupted snapshot %s: %w", fs.path(snapshotFileName), err)
+	}
+	return &snapshot, nil
+}
+
+// recoverLog reads the log and truncates it after the last intact entry
+func (fs *FileStore) recoverLog() ([]*services.JournalEntry, error) {
+	file, err := os.OpenFile(fs.path(walFileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, goodBytes, scanErr := scanLog(file)
+	if scanErr == nil {
+		return entries, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("warning: truncating corrupted write-ahead log %s at byte %d of %d: %v",
+		fs.path(walFileName), goodBytes, info.Size(), scanErr)
+	if err := file.Truncate(goodBytes); err != nil {
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (fs *FileStore) readLog() ([]*services.JournalEntry, error) {
+	file, err := os.Open(fs.path(walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, _, err := scanLog(file)
+	return entries, err
+}
+
+// scanLog returns the intact entries and the byte offset just after the last one
+func scanLog(r io.Reader) ([]*services.JournalEntry, int64, error) {
+	var entries []*services.JournalEntry
+	var offset int64
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return entries, offset, errors.New("incomplete entry at end of log")
+			}
+			return entries, offset, nil
+		}
+		if err != nil {
+			return entries, offset, err
+		}
+
+		entry, err := decodeEntry(line)
+		if err != nil {
+			return entries, offset, err
+		}
+		entries = append(entries, entry)
+		offset += int64(len(line))
+	}
+}
+
+func encodeEntry(entry *services.JournalEntry) ([]byte, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)), nil
+}
+
+func decodeEntry(line []byte) (*services.JournalEntry, error) {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < 10 || line[8] != ' ' {
+		return nil, errors.New("malformed log entry")
+	}
+
+	checksum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil {
+		return nil, errors.New("malformed log entry checksum")
+	}
+	data := line[9:]
+	if crc32.ChecksumIEEE(data) != uint32(checksum) {
+		return nil, errors.New("log entry checksum mismatch")
+	}
+
+	var entry services.JournalEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"parking-lot-system/models"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, no cgo required
+)
+
+// migrations are applied in order; never edit one that has shipped, append a new one
+var migrations = []string{
+	// 1: tickets with look-ups by plate, lot and arrival time
+	`CREATE TABLE tickets (
+		id            TEXT PRIMARY KEY,
+		license_plate TEXT NOT NULL,
+		lot_id        TEXT NOT NULL,
+		space_id      TEXT NOT NULL,
+		parked_at     INTEGER NOT NULL,
+		unparked_at   INTEGER,
+		is_active     INTEGER NOT NULL,
+		attendant_id  TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_tickets_plate ON tickets(license_plate);
+	CREATE INDEX idx_tickets_lot ON tickets(lot_id, parked_at);
+	CREATE INDEX idx_tickets_parked_at ON tickets(parked_at);
+	CREATE UNIQUE INDEX idx_tickets_active_plate ON tickets(license_plate) WHERE is_active = 1;`,
+
+	// 2: space-level occupancy history
+	`CREATE TABLE occupancy_events (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		lot_id        TEXT NOT NULL,
+		space_id      INTEGER NOT NULL,
+		license_plate TEXT NOT NULL,
+		event         TEXT NOT NULL,
+		occurred_at   INTEGER NOT NULL
+	);
+	CREATE INDEX idx_occupancy_lot ON occupancy_events(lot_id, occurred_at);
+	CREATE INDEX idx_occupancy_plate ON occupancy_events(license_plate);`,
+
+	// 3: vehicle details for reporting, and look-ups by departure time
+	`ALTER TABLE tickets ADD COLUMN vehicle_size TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tickets ADD COLUMN is_handicap INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX idx_tickets_unparked_at ON tickets(unparked_at);`,
+
+	// 4: the permit covering each visit, so it is billed as a permit stay on exit
+	`ALTER TABLE tickets ADD COLUMN permit_id TEXT NOT NULL DEFAULT '';`,
+}
+
+// Occupancy event kinds stored in occupancy_events
+const (
+	OccupancyParked   = "parked"
+	OccupancyUnparked = "unparked"
+)
+
+type OccupancyEvent struct {
+	LotID        string
+	SpaceID      int
+	LicensePlate string
+	Event        string
+	OccurredAt   time.Time
+}
+
+// SQLiteStore keeps tickets and occupancy history in SQLite. It implements
+// services.TicketRepository and services.TicketHistory, and records space
+// changes when added as a lot occupancy listener.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the database at path (":memory:" for a throwaway
+// database) and applies any pending schema migrations
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time, and each :memory: connection is its own database
+	db.SetMaxOpenConns(1)
+
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration
+func (s *SQLiteStore) SchemaVersion() (int, error) {
+	var version int
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+const ticketColumns = `id, license_plate, lot_id, space_id, parked_at, unparked_at, is_active, attendant_id, vehicle_size, is_handicap, permit_id`
+
+func (s *SQLiteStore) Save(ticket *models.ParkingTicket) error {
+	if ticket == nil {
+		return models.NewError(models.ErrInvalid, "ticket cannot be nil")
+	}
+
+	_, err := s.db.Exec(`INSERT INTO tickets (`+ticketColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ticket.ID, ticket.LicensePlate, ticket.LotID, ticket.SpaceID,
+		ticket.ParkedAt.UnixNano(), nullableTime(ticket.UnparkedAt), ticket.IsActive, ticket.AttendantID,
+		ticket.VehicleSize, ticket.IsHandicap, ticket.PermitID)
+	return err
+}
+
+func (s *SQLiteStore) FindByID(ticketID string) (*models.ParkingTicket, error) {
+	tickets, err := s.queryTickets(`WHERE id = ?`, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, models.ErrTicketNotFound
+	}
+	return tickets[0], nil
+}
+
+func (s *SQLiteStore) FindByPlate(licensePlate string) []*models.ParkingTicket {
+	return s.mustQueryTickets(`WHERE license_plate = ? ORDER BY parked_at`, licensePlate)
+}
+
+func (s *SQLiteStore) FindActiveByPlate(licensePlate string) (*models.ParkingTicket, error) {
+	tickets, err := s.queryTickets(`WHERE license_plate = ? AND is_active = 1`, licensePlate)
+	if err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, models.ErrActiveTicketNotFound
+	}
+	return tickets[0], nil
+}
+
+func (s *SQLiteStore) FindActive() []*models.ParkingTicket {
+	return s.mustQueryTickets(`WHERE is_active = 1 ORDER BY parked_at`)
+}
+
+func (s *SQLiteStore) FindAll() []*models.ParkingTicket {
+	return s.mustQueryTickets(`ORDER BY parked_at`)
+}
+
+func (s *SQLiteStore) FindByLot(lotID string) []*models.ParkingTicket {
+	return s.mustQueryTickets(`WHERE lot_id = ? ORDER BY parked_at`, lotID)
+}
+
+func (s *SQLiteStore) FindParkedBetween(from, to time.Time) []*models.ParkingTicket {
+	return s.mustQueryTickets(`WHERE parked_at >= ? AND parked_at < ? ORDER BY parked_at`,
+		from.UnixNano(), to.UnixNano())
+}
+
+func (s *SQLiteStore) FindUnparkedBetween(from, to time.Time) []*models.ParkingTicket {
+	return s.mustQueryTickets(`WHERE is_active = 0 AND unparked_at >= ? AND unparked_at < ? ORDER BY unparked_at`,
+		from.UnixNano(), to.UnixNano())
+}
+
+// CompleteActive closes the plate's active ticket inside one transaction
+func (s *SQLiteStore) CompleteActive(licensePlate string, unparkedAt time.Time) (*models.ParkingTicket, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`SELECT `+ticketColumns+` FROM tickets WHERE license_plate = ? AND is_active = 1`, licensePlate)
+	ticket, err := scanTicket(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrActiveTicketNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.CompleteParkingAt(unparkedAt)
+	if _, err := tx.Exec(`UPDATE tickets SET is_active = 0, unparked_at = ? WHERE id = ?`,
+		ticket.UnparkedAt.UnixNano(), ticket.ID); err != nil {
+		return nil, err
+	}
+
+	return ticket, tx.Commit()
+}
+
+func (s *SQLiteStore) queryTickets(clause string, args ...interface{}) ([]*models.ParkingTicket, error) {
+	rows, err := s.db.Query(`SELECT `+ticketColumns+` FROM tickets `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []*models.ParkingTicket
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// mustQueryTickets serves the error-free TicketRepository look-ups; failures are logged
+func (s *SQLiteStore) mustQueryTickets(clause string, args ...interface{}) []*models.ParkingTicket {
+	tickets, err := s.queryTickets(clause, args...)
+	if err != nil {
+		log.Printf("warning: ticket query failed: %v", err)
+	}
+	return tickets
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTicket(row rowScanner) (*models.ParkingTicket, error) {
+	var ticket models.ParkingTicket
+	var parkedAt int64
+	var unparkedAt sql.NullInt64
+
+	err := row.Scan(&ticket.ID, &ticket.LicensePlate, &ticket.LotID, &ticket.SpaceID,
+		&parkedAt, &unparkedAt, &ticket.IsActive, &ticket.AttendantID, &ticket.VehicleSize, &ticket.IsHandicap, &ticket.PermitID)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.ParkedAt = time.Unix(0, parkedAt)
+	if unparkedAt.Valid {
+		ticket.UnparkedAt = time.Unix(0, unparkedAt.Int64)
+	}
+	return &ticket, nil
+}
+
+func nullableTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+// OnSpaceOccupied records a park in the occupancy history
+func (s *SQLiteStore) OnSpaceOccupied(lotID string, spaceID int, car *models.Car, parkedAt time.Time) {
+	s.recordOccupancy(lotID, spaceID, car.LicensePlate, OccupancyParked, parkedAt)
+}
+
+// OnSpaceReleased records an unpark in the occupancy history
+func (s *SQLiteStore) OnSpaceReleased(lotID string, spaceID int, licensePlate string, releasedAt time.Time) {
+	s.recordOccupancy(lotID, spaceID, licensePlate, OccupancyUnparked, releasedAt)
+}
+
+func (s *SQLiteStore) recordOccupancy(lotID string, spaceID int, licensePlate, event string, at time.Time) {
+	_, err := s.db.Exec(`INSERT INTO occupancy_events (lot_id, space_id, license_plate, event, occurred_at) VALUES (?, ?, ?, ?, ?)`,
+		lotID, spaceID, licensePlate, event, at.UnixNano())
+	if err != nil {
+		log.Printf("warning: failed to record occupancy event: %v", err)
+	}
+}
+
+// FindOccupancyEvents returns a lot's space changes in [from, to), oldest first
+func (s *SQLiteStore) FindOccupancyEvents(lotID string, from, to time.Time) ([]*OccupancyEvent, error) {
+	rows, err := s.db.Query(`SELECT lot_id, space_id, license_plate, event, occurred_at FROM occupancy_events
+		WHERE lot_id = ? AND occurred_at >= ? AND occurred_at < ? ORDER BY occurred_at, id`,
+		lotID, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*OccupancyEvent
+	for rows.Next() {
+		var event OccupancyEvent
+		var occurredAt int64
+		if err := rows.Scan(&event.LotID, &event.SpaceID, &event.LicensePlate, &event.Event, &occurredAt); err != nil {
+			return nil, err
+		}
+		event.OccurredAt = time.Unix(0, occurredAt)
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+type countingObserver struct {
+	full      int32
+	available int32
+}
+
+func (o *countingObserver) OnLotFull(lotID string) {
+	atomic.AddInt32(&o.full, 1)
+}
+
+func (o *countingObserver) OnLotAvailable(lotID string) {
+	atomic.AddInt32(&o.available, 1)
+}
+
+func TestUC18_ConcurrentParkingNeverDoubleBooksSpaces(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 100)
+	observer := &countingObserver{}
+	lot.AddObserver(observer)
+
+	// Act - 300 gates race for 100 spaces
+	var wg sync.WaitGroup
+	var parked int32
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			car := models.NewCar(fmt.Sprintf("GATE%03d", i), "Driver")
+			if lot.ParkCar(car) == nil {
+				atomic.AddInt32(&parked, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(100), parked)
+	assert.True(t, lot.IsFull())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&observer.full))
+
+	plates := make(map[string]bool)
+	for _, space := range lot.Spaces {
+		car := space.GetParkedCar()
+		assert.NotNil(t, car)
+		assert.False(t, plates[car.LicensePlate], "car parked twice: %s", car.LicensePlate)
+		plates[car.LicensePlate] = true
+	}
+}
+
+func TestUC18_ConcurrentUnparkingReleasesEachCarOnce(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 50)
+	observer := &countingObserver{}
+	lot.AddObserver(observer)
+	for i := 0; i < 50; i++ {
+		lot.ParkCar(models.NewCar(fmt.Sprintf("CAR%03d", i), "Driver"))
+	}
+
+	// Act - every car is unparked by four gates at once
+	var wg sync.WaitGroup
+	var released int32
+	for i := 0; i < 50; i++ {
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(plate string) {
+				defer wg.Done()
+				if _, err := lot.UnparkCar(plate); err == nil {
+					atomic.AddInt32(&released, 1)
+				}
+			}(fmt.Sprintf("CAR%03d", i))
+		}
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(50), released)
+	assert.Equal(t, 50, lot.GetAvailableSpaces())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&observer.available))
+}
+
+func TestUC18_ConcurrentServiceParkAndUnparkWithTickets(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 60))
+	service.AddLot(models.NewParkingLot("LOT2", 60))
+	service.AddAttendant(models.NewParkingAttendant("ATT001", "Alice", "LOT1"))
+	policeService := services.NewPoliceService(service)
+
+	// Act - park, query and unpark from hundreds of goroutines
+	var wg sync.WaitGroup
+	var billed int32
+	for i := 0; i < 240; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			plate := fmt.Sprintf("UC18-%03d", i)
+			car := models.NewCar(plate, "Driver")
+
+			var err error
+			if i%2 == 0 {
+				_, err = service.ParkCarWithTicket(car)
+			} else {
+				_, err = service.ParkCarWithStrategy(car, "ATT001", models.NewEvenDistributionStrategy())
+			}
+			if err != nil {
+				return
+			}
+
+			service.GetLotUtilization()
+			policeService.FindWhiteCars()
+
+			if i%2 == 0 {
+				if _, _, err := service.UnparkCarWithBilling(plate); err == nil {
+					atomic.AddInt32(&billed, 1)
+				}
+			} else {
+				service.UnparkCar(plate)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(120), billed)
+	for _, utilization := range service.GetLotUtilization() {
+		assert.Equal(t, 0, utilization.OccupiedSpaces)
+	}
+}
+
+// slowSaveRepository stores tickets after a pause, so gates racing to park
+// the same car all reach a space before any ticket is saved
+type slowSaveRepository struct {
+	*services.InMemoryTicketRepository
+}
+
+func (r slowSaveRepository) Save(ticket *models.ParkingTicket) error {
+	time.Sleep(time.Millisecond)
+	return r.InMemoryTicketRepository.Save(ticket)
+}
+
+func TestUC18_SamePlateParkedFromManyGatesGetsOneSpace(t *testing.T) {
+	// Arrange
+	service := services.NewParkingServiceWithRepository(slowSaveRepository{services.NewInMemoryTicketRepository()})
+	service.AddLot(models.NewParkingLot("LOT1", 40))
+	service.AddLot(models.NewParkingLot("LOT2", 40))
+
+	// Act - every gate tries to park the same plate at once
+	var wg sync.WaitGroup
+	var issued int32
+	start := make(chan struct{})
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := service.ParkCarWithTicket(models.NewCar("UC18-DUP", "Driver")); err == nil {
+				atomic.AddInt32(&issued, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// Assert - one ticket, for the one space the plate holds
+	assert.Equal(t, int32(1), issued)
+	ticket, err := service.GetActiveTicket("UC18-DUP")
+	require.NoError(t, err)
+	location, err := service.FindCarWithLocation("UC18-DUP")
+	require.NoError(t, err)
+	assert.Equal(t, ticket.LotID, location.LotID)
+	assert.Equal(t, ticket.SpaceID, location.SpaceID)
+	occupied := 0
+	for _, utilization := range service.GetLotUtilization() {
+		occupied += utilization.OccupiedSpaces
+	}
+	assert.Equal(t, 1, occupied)
+}
+
+func TestUC18_ConcurrentStaffRegistration(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 10))
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			service.AddAttendant(models.NewParkingAttendant(fmt.Sprintf("ATT%03d", i), "Attendant", "LOT1"))
+			service.FindAttendantByID("ATT000")
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			staffID := fmt.Sprintf("SEC%03d", i)
+			service.AddSecurityStaff(models.NewSecurityStaff(staffID, "Officer", "Patrol"))
+			service.AssignSecurityToLot(staffID, "LOT1")
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Len(t, service.GetAttendants(), 200)
+	assert.Len(t, service.GetSecurityStaff(), 200)
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func TestUC19_ServicesDoNotShareTickets(t *testing.T) {
+	// Arrange
+	serviceA := services.NewParkingService()
+	serviceA.AddLot(models.NewParkingLot("LOT1", 3))
+	serviceB := services.NewParkingService()
+	serviceB.AddLot(models.NewParkingLot("LOT1", 3))
+
+	// Act
+	_, err := serviceA.ParkCarWithTicket(models.NewCar("SHARED01", "Tenant A"))
+
+	// Assert
+	assert.NoError(t, err)
+	_, err = serviceB.GetActiveTicket("SHARED01")
+	assert.Error(t, err)
+	_, err = serviceB.GetParkingHistory("SHARED01")
+	assert.Error(t, err)
+}
+
+func TestUC19_InjectedRepositoryReceivesTickets(t *testing.T) {
+	// Arrange
+	repo := services.NewInMemoryTicketRepository()
+	service := services.NewParkingServiceWithRepository(repo)
+	service.AddLot(models.NewParkingLot("LOT1", 3))
+
+	// Act
+	ticket, err := service.ParkCarWithTicket(models.NewCar("REPO001", "Driver"))
+
+	// Assert
+	assert.NoError(t, err)
+	stored, err := repo.FindByID(ticket.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ticket, stored)
+
+	fromService, err := service.GetTicket(ticket.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ticket, fromService)
+}
+
+func TestUC19_RepositoryIndexesTrackActiveStatus(t *testing.T) {
+	// Arrange
+	repo := services.NewInMemoryTicketRepository()
+	first := models.NewParkingTicket("IDX001", "LOT1", "1")
+	first.ID = "T1"
+	second := models.NewParkingTicket("IDX001", "LOT1", "2")
+	second.ID = "T2"
+
+	// Act & Assert
+	assert.NoError(t, repo.Save(first))
+	assert.Error(t, repo.Save(second), "plate already has an active ticket")
+	assert.Len(t, repo.FindActive(), 1)
+
+	completed, err := repo.CompleteActive("IDX001", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, completed.IsActive)
+	assert.Empty(t, repo.FindActive())
+
+	_, err = repo.CompleteActive("IDX001", time.Now())
+	assert.Error(t, err)
+
+	assert.NoError(t, repo.Save(second))
+	active, err := repo.FindActiveByPlate("IDX001")
+	assert.NoError(t, err)
+	assert.Equal(t, "T2", active.ID)
+	assert.Len(t, repo.FindByPlate("IDX001"), 2)
+}
+
+func TestUC19_DuplicateActiveTicketIsRejected(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	lot := models.NewParkingLot("LOT1", 3)
+	service.AddLot(lot)
+	car := models.NewCar("DUP001", "Driver")
+	_, err := service.ParkCarWithTicket(car)
+	assert.NoError(t, err)
+
+	// Act
+	_, err = service.ParkCarWithTicket(car)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 1, lot.GetOccupiedSpaces())
+}
+
+func TestUC19_ReturnedTicketsAreCopies(t *testing.T) {
+	// Arrange
+	repo := services.NewInMemoryTicketRepository()
+	service := services.NewParkingServiceWithRepository(repo)
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	ticket, err := service.ParkCarWithTicket(models.NewCar("COPY001", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	found, err := repo.FindActiveByPlate("COPY001")
+	require.NoError(t, err)
+	found.IsActive = false
+	found.LicensePlate = "EDITED"
+
+	// Assert
+	stored, err := repo.FindByID(ticket.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsActive)
+	assert.Equal(t, "COPY001", stored.LicensePlate)
+	_, err = repo.FindActiveByPlate("COPY001")
+	assert.NoError(t, err)
+	assert.Empty(t, repo.FindByPlate("EDITED"))
+}
+
+func TestUC19_SnapshotsRunAlongsideUnparks(t *testing.T) {
+	// Arrange
+	service, store := openPersistentService(t, t.TempDir())
+	defer store.Close()
+	service.AddLot(models.NewParkingLot("LOT1", 40))
+	var ticketIDs []string
+	for i := 0; i < 40; i++ {
+		ticket, err := service.ParkCarWithTicket(models.NewCar(fmt.Sprintf("SNAP%03d", i), "Driver"))
+		require.NoError(t, err)
+		ticketIDs = append(ticketIDs, ticket.ID)
+	}
+
+	// Act - unpark every car while snapshots and ticket listings run
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				for _, id := range ticketIDs {
+					if ticket, err := service.GetTicket(id); err == nil && !ticket.IsActive {
+						assert.False(t, ticket.UnparkedAt.IsZero())
+					}
+				}
+				assert.NoError(t, service.SaveSnapshot())
+			}
+		}
+	}()
+	var unparked sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		unparked.Add(1)
+		go func(i int) {
+			defer unparked.Done()
+			_, _, err := service.UnparkCarWithBilling(fmt.Sprintf("SNAP%03d", i))
+			assert.NoError(t, err)
+		}(i)
+	}
+	unparked.Wait()
+	close(done)
+	wg.Wait()
+
+	// Assert
+	assert.Empty(t, service.GetActiveTickets())
+	require.NoError(t, service.SaveSnapshot())
+}
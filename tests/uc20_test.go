@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/storage"
+)
+
+func openPersistentService(t *testing.T, dir string) (*services.ParkingService, *storage.FileStore) {
+	store, err := storage.NewFileStore(dir)
+	require.NoError(t, err)
+	service, err := services.RestoreParkingService(store)
+	require.NoError(t, err)
+	return service, store
+}
+
+func TestUC20_ServiceIsRebuiltFromWriteAheadLog(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(models.NewParkingLot("LOT1", 5))
+	service.AddAttendant(models.NewParkingAttendant("ATT001", "Alice", "LOT1"))
+	service.AddSecurityStaff(models.NewSecurityStaff("SEC001", "Bob", "Patrol"))
+	require.NoError(t, service.AssignSecurityToLot("SEC001", "LOT1"))
+
+	openTicket, err := service.ParkCarWithTicket(models.NewCar("OPEN001", "Driver"))
+	require.NoError(t, err)
+	_, err = service.ParkCarWithTicket(models.NewCar("DONE001", "Driver"))
+	require.NoError(t, err)
+	_, _, err = service.UnparkCarWithBilling("DONE001")
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+
+	// Assert
+	lot, err := restored.GetLotStatus("LOT1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lot.GetOccupiedSpaces())
+
+	ticket, err := restored.GetActiveTicket("OPEN001")
+	require.NoError(t, err)
+	assert.Equal(t, openTicket.ID, ticket.ID)
+	assert.True(t, openTicket.ParkedAt.Equal(ticket.ParkedAt))
+
+	space, err := restored.FindCar("OPEN001")
+	require.NoError(t, err)
+	_, parkedAt := space.GetOccupancy()
+	assert.False(t, parkedAt.IsZero())
+
+	history, err := restored.GetParkingHistory("DONE001")
+	require.NoError(t, err)
+	assert.False(t, history[0].IsActive)
+
+	assert.NotNil(t, restored.FindAttendantByID("ATT001"))
+	assert.Equal(t, "LOT1", restored.FindSecurityStaffByID("SEC001").AssignedLot)
+}
+
+func TestUC20_SnapshotCompactsLogAndSurvivesRestart(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(models.NewParkingLot("LOT1", 3))
+	service.ParkCarWithTicket(models.NewCar("SNAP001", "Driver"))
+	require.NoError(t, service.SaveSnapshot())
+
+	// Act - changes after the snapshot live only in the log
+	service.ParkCarWithTicket(models.NewCar("SNAP002", "Driver"))
+	service.UnparkCarWithBilling("SNAP001")
+	require.NoError(t, store.Close())
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+
+	// Assert
+	_, err := restored.FindCar("SNAP001")
+	assert.Error(t, err)
+	_, err = restored.FindCar("SNAP002")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "snapshot.json"))
+	assert.NoError(t, err)
+}
+
+func TestUC20_CorruptedLogTailIsTruncatedWithWarning(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(models.NewParkingLot("LOT1", 3))
+	service.ParkCarWithTicket(models.NewCar("SAFE001", "Driver"))
+	require.NoError(t, store.Close())
+
+	wal, err := os.OpenFile(filepath.Join(dir, "journal.wal"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	wal.WriteString("deadbeef {\"seq\":99,\"type\":\"space_occ")
+	wal.Close()
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+
+	// Assert
+	assert.Contains(t, logged.String(), "truncating corrupted write-ahead log")
+	_, err = restored.FindCar("SAFE001")
+	assert.NoError(t, err)
+
+	restored.ParkCarWithTicket(models.NewCar("AFTER001", "Driver"))
+	require.NoError(t, restoredStore.Close())
+	again, againStore := openPersistentService(t, dir)
+	defer againStore.Close()
+	_, err = again.FindCar("AFTER001")
+	assert.NoError(t, err)
+}
+
+func TestUC20_OlderSnapshotsNeverReplaceNewerOnes(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := storage.NewFileStore(dir)
+	require.NoError(t, err)
+	for _, plate := range []string{"OLD001", "OLD002", "OLD003"} {
+		require.NoError(t, store.Append(&services.JournalEntry{Type: services.EntryTicketSaved, Ticket: models.NewParkingTicket(plate, "LOT1", "1")}))
+	}
+
+	// Act - the periodic snapshot finishes after a later manual one
+	require.NoError(t, store.SaveSnapshot(&services.Snapshot{Sequence: 3}))
+	require.NoError(t, store.SaveSnapshot(&services.Snapshot{Sequence: 1}))
+	require.NoError(t, store.Close())
+	reopened, err := storage.NewFileStore(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	snapshot, entries, err := reopened.Load()
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, uint64(3), snapshot.Sequence)
+	assert.Empty(t, entries)
+	assert.Equal(t, uint64(3), reopened.LastSequence())
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/storage"
+)
+
+func TestUC21_SQLiteStoreMigratesSchema(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "parking.db")
+
+	// Act
+	store, err := storage.NewSQLiteStore(path)
+	require.NoError(t, err)
+	store.Close()
+	reopened, err := storage.NewSQLiteStore(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	// Assert
+	version, err := reopened.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, version)
+}
+
+func TestUC21_TicketsPersistAcrossServiceInstances(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "parking.db")
+	store, err := storage.NewSQLiteStore(path)
+	require.NoError(t, err)
+	service := services.NewParkingServiceWithRepository(store)
+	service.AddLot(models.NewParkingLot("LOT1", 3))
+
+	// Act
+	_, err = service.ParkCarWithTicket(models.NewCar("SQL001", "Driver"))
+	require.NoError(t, err)
+	_, bill, err := service.UnparkCarWithBilling("SQL001")
+	require.NoError(t, err)
+	store.Close()
+
+	reopened, err := storage.NewSQLiteStore(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	later := services.NewParkingServiceWithRepository(reopened)
+
+	// Assert
+	history, err := later.GetParkingHistory("SQL001")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, bill.TicketID, history[0].ID)
+	assert.False(t, history[0].IsActive)
+	assert.False(t, history[0].UnparkedAt.IsZero())
+}
+
+func TestUC21_IndexedQueriesByLotAndTimeRange(t *testing.T) {
+	// Arrange
+	store, err := storage.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+
+	base := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	for i, lotID := range []string{"LOT1", "LOT2", "LOT1"} {
+		ticket := models.NewParkingTicket("HIST00"+string(rune('1'+i)), lotID, "1")
+		ticket.ID = "T" + string(rune('1'+i))
+		ticket.ParkedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, store.Save(ticket))
+	}
+	service := services.NewParkingServiceWithRepository(store)
+
+	// Act
+	lot1, err := service.GetTicketsByLot("LOT1")
+	require.NoError(t, err)
+	window, err := service.GetTicketsParkedBetween(base.Add(30*time.Minute), base.Add(3*time.Hour))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Len(t, lot1, 2)
+	assert.Equal(t, "T1", lot1[0].ID)
+	assert.Len(t, window, 2)
+	assert.Equal(t, "T2", window[0].ID)
+	assert.Equal(t, "T3", window[1].ID)
+}
+
+func TestUC21_OccupancyHistoryAndPoliceActivity(t *testing.T) {
+	// Arrange
+	store, err := storage.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+	service := services.NewParkingServiceWithRepository(store)
+	service.AddOccupancyListener(store)
+	service.AddLot(models.NewParkingLot("LOT1", 3))
+	policeService := services.NewPoliceService(service)
+
+	start := time.Now().Add(-time.Minute)
+	service.ParkCarWithTicket(models.NewCar("GONE001", "Departed Driver"))
+	service.UnparkCarWithBilling("GONE001")
+	service.ParkCarWithTicket(models.NewCar("HERE001", "Present Driver"))
+
+	// Act
+	events, err := store.FindOccupancyEvents("LOT1", start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	activity, err := policeService.GetParkingActivityBetween(start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, events, 3)
+	assert.Equal(t, storage.OccupancyParked, events[0].Event)
+	assert.Equal(t, storage.OccupancyUnparked, events[1].Event)
+	assert.Equal(t, "GONE001", events[1].LicensePlate)
+
+	require.Len(t, activity, 2)
+	assert.Equal(t, "GONE001", activity[0].Car.LicensePlate)
+	assert.Equal(t, "Present Driver", activity[1].Car.DriverName)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func newTestAPI(capacity int) (*services.ParkingService, *httptest.Server) {
+	parking := services.NewParkingService()
+	parking.AddLot(models.NewParkingLot("LOT1", capacity))
+	server := api.NewServer(parking, services.NewBillingService(10.0, 5.0), services.NewPoliceService(parking))
+	return parking, httptest.NewServer(server)
+}
+
+func doJSON(t *testing.T, method, url string, body interface{}, into interface{}) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+	req, err := http.NewRequest(method, url, &payload)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	if into != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(into))
+	}
+	return resp.StatusCode
+}
+
+func TestUC22_ParkReturnsCreatedTicket(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(5)
+	defer server.Close()
+
+	// Act
+	var ticket api.TicketResponse
+	status := doJSON(t, http.MethodPost, server.URL+"/api/park",
+		api.ParkRequest{LicensePlate: "API001", DriverName: "Driver", Color: "Blue"}, &ticket)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "API001", ticket.LicensePlate)
+	assert.Equal(t, "LOT1", ticket.LotID)
+	assert.True(t, ticket.IsActive)
+}
+
+func TestUC22_UnparkReturnsBill(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(5)
+	defer server.Close()
+	var ticket api.TicketResponse
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "API002", DriverName: "Driver"}, &ticket)
+
+	// Act
+	var bill api.BillResponse
+	status := doJSON(t, http.MethodPost, server.URL+"/api/unpark", api.UnparkRequest{LicensePlate: "API002"}, &bill)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ticket.ID, bill.TicketID)
+	assert.Equal(t, 5.0, bill.TotalAmount)
+}
+
+func TestUC22_UnknownCarReturnsNotFound(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(5)
+	defer server.Close()
+
+	// Act
+	var errResp api.ErrorResponse
+	status := doJSON(t, http.MethodGet, server.URL+"/api/cars/MISSING", nil, &errResp)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, http.StatusNotFound, errResp.Status)
+	assert.Equal(t, "car not found", errResp.Message)
+}
+
+func TestUC22_FullLotReturnsConflict(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(1)
+	defer server.Close()
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "API003", DriverName: "Driver"}, nil)
+
+	// Act
+	var errResp api.ErrorResponse
+	status := doJSON(t, http.MethodPost, server.URL+"/api/park",
+		api.ParkRequest{LicensePlate: "API004", DriverName: "Driver"}, &errResp)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, status)
+	assert.NotEmpty(t, errResp.Message)
+}
+
+func TestUC22_FindCarAndLotStatus(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(4)
+	defer server.Close()
+	doJSON(t, http.MethodPost, server.URL+"/api/park",
+		api.ParkRequest{LicensePlate: "API005", DriverName: "Driver", Make: "Toyota"}, nil)
+
+	// Act
+	var location api.CarLocationResponse
+	carStatus := doJSON(t, http.MethodGet, server.URL+"/api/cars/API005", nil, &location)
+	var lot api.LotStatusResponse
+	lotStatus := doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1", nil, &lot)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, carStatus)
+	assert.Equal(t, "Toyota", location.Car.Make)
+	assert.Equal(t, "LOT1", location.LotID)
+	assert.Equal(t, http.StatusOK, lotStatus)
+	assert.Equal(t, 1, lot.OccupiedSpaces)
+	assert.Equal(t, 3, lot.AvailableSpaces)
+}
+
+func TestUC22_AttendantParksWithStrategy(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(4)
+	defer server.Close()
+	createStatus := doJSON(t, http.MethodPost, server.URL+"/api/attendants",
+		api.AttendantRequest{ID: "ATT1", Name: "Sam", LotID: "LOT1"}, nil)
+
+	// Act
+	var decision api.ParkingDecisionResponse
+	status := doJSON(t, http.MethodPost, server.URL+"/api/attendants/ATT1/park",
+		api.ParkWithAttendantRequest{ParkRequest: api.ParkRequest{LicensePlate: "API006", DriverName: "Driver"}, Strategy: "smart"}, &decision)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, createStatus)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "ATT1", decision.AttendantID)
+	assert.Equal(t, "LOT1", decision.LotID)
+}
+
+func TestUC22_MalformedBodyReturnsBadRequest(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(4)
+	defer server.Close()
+
+	// Act
+	resp, err := http.Post(server.URL+"/api/park", "application/json", bytes.NewBufferString("{not json"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUC22_OpenAPIDocumentListsEndpoints(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(4)
+	defer server.Close()
+
+	// Act
+	var doc map[string]interface{}
+	status := doJSON(t, http.MethodGet, server.URL+"/api/openapi.json", nil, &doc)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	paths := doc["paths"].(map[string]interface{})
+	assert.Contains(t, paths, "/api/park")
+	assert.Contains(t, paths, "/api/cars/{plate}")
+	assert.Contains(t, paths["/api/park"], "post")
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	assert.Contains(t, schemas, "TicketResponse")
+	assert.Contains(t, schemas, "ErrorResponse")
+}
+
+func TestUC22_ServiceErrorsCarTheirKind(t *testing.T) {
+	// Arrange
+	parking, server := newTestAPI(1)
+	defer server.Close()
+	require.NoError(t, parking.ParkCar(models.NewCar("KA-01", "Driver")))
+
+	// Act
+	_, findErr := parking.FindCar("KA-99")
+	parkErr := parking.ParkCar(models.NewCar("KA-02", "Driver"))
+	var body map[string]interface{}
+	historyStatus := doJSON(t, http.MethodGet, server.URL+"/api/cars/KA-99/tickets", nil, &body)
+
+	// Assert
+	assert.True(t, errors.Is(findErr, services.ErrCarNotFound))
+	assert.True(t, errors.Is(findErr, services.ErrNotFound))
+	assert.True(t, errors.Is(parkErr, services.ErrConflict))
+	assert.False(t, errors.Is(parkErr, services.ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, historyStatus)
+}
+
+func TestUC22_ConcurrentCreatesAddOneLot(t *testing.T) {
+	// Arrange
+	parking, server := newTestAPI(1)
+	defer server.Close()
+	const requests = 8
+	statuses := make(chan int, requests)
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- doJSON(t, http.MethodPost, server.URL+"/api/lots", api.LotRequest{ID: "LOT2", Capacity: 2}, nil)
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+	againErr := parking.AddLot(models.NewParkingLot("LOT1", 3))
+
+	// Assert
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: requests - 1}, counts)
+	assert.Len(t, parking.GetLotUtilization(), 2)
+	assert.True(t, errors.Is(againErr, services.ErrLotExists))
+	lot, err := parking.GetLotStatus("LOT1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lot.Capacity)
+}
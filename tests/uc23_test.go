@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/cli"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := cli.Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUC23_StatePersistsBetweenInvocations(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	code, _, _ := runCLI(t, "", "--state", dir, "lot", "create", "LOT1", "5")
+	require.Equal(t, 0, code)
+
+	// Act
+	code, _, stderr := runCLI(t, "", "--state", dir, "park", "CLI001", "--driver", "Jane Doe", "--color", "Red")
+	require.Equal(t, 0, code, stderr)
+	code, stdout, _ := runCLI(t, "", "--state", dir, "--output", "json", "find", "CLI001")
+
+	// Assert
+	assert.Equal(t, 0, code)
+	var location api.CarLocationResponse
+	require.NoError(t, json.Unmarshal([]byte(stdout), &location))
+	assert.Equal(t, "LOT1", location.LotID)
+	assert.Equal(t, "Jane Doe", location.Car.DriverName)
+	assert.Equal(t, "Red", location.Car.Color)
+}
+
+func TestUC23_CSVReportOutput(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	runCLI(t, "", "--state", dir, "lot", "create", "LOT1", "4")
+	runCLI(t, "", "--state", dir, "park", "CLI002")
+
+	// Act
+	code, stdout, _ := runCLI(t, "", "--state", dir, "--output", "csv", "report")
+
+	// Assert
+	assert.Equal(t, 0, code)
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"LOT", "CAPACITY", "OCCUPIED", "AVAILABLE", "VEHICLES", "UTILIZATION", "FULL"}, records[0])
+	assert.Equal(t, []string{"LOT1", "4", "1", "3", "1", "25.0%", "false"}, records[1])
+}
+
+func TestUC23_UnparkPrintsBillAndTicketIsClosed(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	runCLI(t, "", "--state", dir, "lot", "create", "LOT1", "4")
+	_, stdout, _ := runCLI(t, "", "--state", dir, "--output", "json", "park", "CLI003")
+	var ticket api.TicketResponse
+	require.NoError(t, json.Unmarshal([]byte(stdout), &ticket))
+
+	// Act
+	code, stdout, _ := runCLI(t, "", "--state", dir, "--output", "json", "unpark", "CLI003")
+	var bill api.BillResponse
+	require.NoError(t, json.Unmarshal([]byte(stdout), &bill))
+	_, shown, _ := runCLI(t, "", "--state", dir, "--output", "json", "ticket", "show", ticket.ID)
+	var closed api.TicketResponse
+	require.NoError(t, json.Unmarshal([]byte(shown), &closed))
+
+	// Assert
+	assert.Equal(t, 0, code)
+	assert.Equal(t, ticket.ID, bill.TicketID)
+	assert.Equal(t, 5.0, bill.TotalAmount)
+	assert.False(t, closed.IsActive)
+}
+
+func TestUC23_ErrorsAndUsageSetExitStatus(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+
+	// Act
+	notFound, _, notFoundErr := runCLI(t, "", "--state", dir, "find", "MISSING")
+	unknown, _, _ := runCLI(t, "", "--state", dir, "fly")
+	badFormat, _, _ := runCLI(t, "", "--state", dir, "--output", "xml", "report")
+
+	// Assert
+	assert.Equal(t, 1, notFound)
+	assert.Contains(t, notFoundErr, "car not found")
+	assert.Equal(t, 2, unknown)
+	assert.Equal(t, 2, badFormat)
+}
+
+func TestUC23_REPLRunsCommandsUntilExit(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	script := strings.Join([]string{
+		"lot create LOT1 2",
+		`park "REPL 1" --driver "Gate Staff"`,
+		"find NOPE",
+		"police search --make \"\"",
+		"exit",
+		"report",
+	}, "\n")
+
+	// Act
+	code, stdout, stderr := runCLI(t, script, "--state", dir, "repl")
+
+	// Assert
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "REPL 1")
+	assert.Contains(t, stderr, "car not found")
+	assert.Equal(t, 1, strings.Count(stdout, "CAPACITY"), "commands after exit must not run")
+}
+
+func TestUC23_RemoteServerMode(t *testing.T) {
+	// Arrange
+	parking := services.NewParkingService()
+	parking.AddLot(models.NewParkingLot("LOT1", 3))
+	server := httptest.NewServer(api.NewServer(parking, services.NewBillingService(10.0, 5.0), services.NewPoliceService(parking)))
+	defer server.Close()
+
+	// Act
+	code, _, stderr := runCLI(t, "", "--server", server.URL, "park", "REMOTE1", "--make", "Toyota")
+
+	// Assert
+	require.Equal(t, 0, code, stderr)
+	_, err := parking.FindCar("REMOTE1")
+	assert.NoError(t, err)
+}
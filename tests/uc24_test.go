@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func newBus(plate string) *models.Car {
+	bus := models.NewCar(plate, "Bus Driver")
+	bus.SetVehicleSize(models.LargeVehicle)
+	bus.SetSpacesNeeded(3)
+	return bus
+}
+
+func TestUC24_CarsUseTheSmallestSpaceThatFits(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLotWithSpaceTypes("LOT1", []models.SpaceType{
+		models.LargeSpace, models.RegularSpace, models.CompactSpace, models.MotorcycleSpace,
+	})
+	motorcycle := models.NewCar("MOTO1", "Rider")
+	motorcycle.SetVehicleSize(models.MotorcycleVehicle)
+	small := models.NewCar("SMALL1", "Driver")
+	small.SetVehicleSize(models.SmallVehicle)
+	medium := models.NewCar("MED1", "Driver")
+
+	// Act
+	motoSpace, motoErr := lot.AssignSpace(motorcycle)
+	smallSpace, smallErr := lot.AssignSpace(small)
+	mediumSpace, mediumErr := lot.AssignSpace(medium)
+
+	// Assert
+	require.NoError(t, motoErr)
+	require.NoError(t, smallErr)
+	require.NoError(t, mediumErr)
+	assert.Equal(t, models.MotorcycleSpace, motoSpace.Type)
+	assert.Equal(t, models.CompactSpace, smallSpace.Type)
+	assert.Equal(t, models.RegularSpace, mediumSpace.Type)
+}
+
+func TestUC24_VehicleIsRejectedWhenNoFreeSpaceFits(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLotWithSpaceTypes("LOT1", []models.SpaceType{models.MotorcycleSpace, models.CompactSpace})
+	truck := models.NewCar("TRUCK1", "Driver")
+	truck.SetVehicleSize(models.LargeVehicle)
+
+	// Act
+	space, err := lot.AssignSpace(truck)
+
+	// Assert
+	assert.Nil(t, space)
+	assert.EqualError(t, err, "no available space fits this vehicle")
+	assert.Nil(t, lot.FindAvailableSpace(truck))
+	assert.False(t, lot.IsFull())
+}
+
+func TestUC24_BusOccupiesContiguousLargeSpaces(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLotWithSpaceTypes("LOT1", []models.SpaceType{
+		models.LargeSpace, models.LargeSpace, models.RegularSpace,
+		models.LargeSpace, models.LargeSpace, models.LargeSpace,
+	})
+
+	// Act
+	anchor, err := lot.AssignSpace(newBus("BUS001"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 4, anchor.ID)
+	for _, id := range []int{4, 5, 6} {
+		assert.Equal(t, "BUS001", lot.Spaces[id-1].GetParkedCar().LicensePlate)
+	}
+	assert.False(t, lot.Spaces[3].IsExtension())
+	assert.True(t, lot.Spaces[4].IsExtension())
+	assert.Equal(t, 3, lot.GetOccupiedSpaces())
+	assert.Equal(t, 1, lot.GetParkedVehicleCount())
+	assert.Equal(t, 4, lot.FindCar("BUS001").ID)
+
+	util := models.CalculateLotUtilization(lot)
+	assert.Equal(t, 3, util.OccupiedSpaces)
+	assert.Equal(t, 1, util.ParkedVehicles)
+	assert.Equal(t, 50.0, util.UtilizationRate)
+}
+
+func TestUC24_UnparkReleasesEverySpaceOfTheVehicle(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 4)
+	_, err := lot.AssignSpace(newBus("BUS002"))
+	require.NoError(t, err)
+
+	// Act
+	car, err := lot.UnparkCar("BUS002")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "BUS002", car.LicensePlate)
+	assert.Equal(t, 4, lot.GetAvailableSpaces())
+	assert.Nil(t, lot.FindCar("BUS002"))
+}
+
+func TestUC24_BusNeedsAnUnbrokenRun(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 5)
+	require.NoError(t, lot.ParkCar(models.NewCar("GAP1", "Driver")))
+	require.NoError(t, lot.ParkCar(models.NewCar("GAP2", "Driver")))
+	require.NoError(t, lot.ParkCar(models.NewCar("GAP3", "Driver")))
+	_, err := lot.UnparkCar("GAP2")
+	require.NoError(t, err)
+
+	// Act
+	_, err = lot.AssignSpace(newBus("BUS003"))
+	_, inSpaceErr := lot.ParkCarInSpace(newBus("BUS004"), 4)
+
+	// Assert
+	assert.EqualError(t, err, "no available space fits this vehicle")
+	assert.EqualError(t, inSpaceErr, "no available contiguous spaces for this vehicle")
+	assert.Equal(t, 3, lot.GetAvailableSpaces())
+}
+
+func TestUC24_LargeVehicleStrategySkipsLotsWithoutLargeSpaces(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLotWithSpaceTypes("REGULAR", []models.SpaceType{
+		models.RegularSpace, models.RegularSpace, models.RegularSpace, models.RegularSpace,
+	}))
+	service.AddLot(models.NewParkingLotWithSpaceTypes("LARGE", []models.SpaceType{
+		models.LargeSpace, models.LargeSpace, models.LargeSpace,
+	}))
+	service.AddAttendant(models.NewParkingAttendant("ATT001", "Alice", "LARGE"))
+
+	// Act
+	decision, err := service.ParkLargeVehicle(newBus("BUS005"), "ATT001")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "LARGE", decision.LotID)
+	lot, _ := service.GetLotStatus("LARGE")
+	assert.True(t, lot.IsFull())
+}
+
+func TestUC24_PoliceSeeMultiSpaceVehicleOnce(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 5))
+	bus := newBus("BUS006")
+	bus.SetColor("Yellow")
+	_, err := service.ParkCarWithTicket(bus)
+	require.NoError(t, err)
+	police := services.NewPoliceService(service)
+
+	// Act
+	vehicles, err := police.FindCarsByColorAndMake("yellow", "")
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, vehicles, 1)
+	assert.Equal(t, "1", vehicles[0].SpaceID)
+}
+
+func TestUC24_AnalyticsCountAMultiSpaceVehicleOnce(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 5))
+	_, err := service.ParkCarWithTicket(newBus("BUS008"))
+	require.NoError(t, err)
+
+	// Act
+	largeCounts := service.GetLargeVehicleSpacesCount()
+	analytics := service.GetDetailedLotAnalytics()
+
+	// Assert
+	assert.Equal(t, 1, largeCounts["LOT1"])
+	assert.Equal(t, 1, analytics["LOT1"]["LargeVehicles"])
+}
+
+func TestUC24_SpaceTypesAndAllocationsSurviveRestart(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(models.NewParkingLotWithSpaceTypes("LOT1", []models.SpaceType{
+		models.CompactSpace, models.LargeSpace, models.LargeSpace, models.LargeSpace,
+	}))
+	_, err := service.ParkCarWithTicket(newBus("BUS007"))
+	require.NoError(t, err)
+	require.NoError(t, service.SaveSnapshot())
+	small := models.NewCar("SMALL2", "Driver")
+	small.SetVehicleSize(models.SmallVehicle)
+	_, err = service.ParkCarWithTicket(small)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	lot, err := restored.GetLotStatus("LOT1")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []models.SpaceType{models.CompactSpace, models.LargeSpace, models.LargeSpace, models.LargeSpace}, lot.GetSpaceTypes())
+	assert.Equal(t, 0, lot.GetAvailableSpaces())
+	assert.Equal(t, 2, lot.GetParkedVehicleCount())
+	assert.True(t, lot.Spaces[2].IsExtension())
+	_, _, err = restored.UnparkCarWithBilling("BUS007")
+	require.NoError(t, err)
+	assert.Equal(t, 3, lot.GetAvailableSpaces())
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func accessibleLot(id string, distances ...float64) *models.ParkingLot {
+	// One regular space first, then an accessible space per distance
+	specs := []models.SpaceSpec{{Type: models.RegularSpace, DistanceToExit: 5}}
+	for _, distance := range distances {
+		specs = append(specs, models.SpaceSpec{Type: models.RegularSpace, Accessible: true, DistanceToExit: distance})
+	}
+	return models.NewParkingLotWithSpecs(id, specs)
+}
+
+func newHandicapCar(plate string) *models.Car {
+	car := models.NewCar(plate, "Permit Holder")
+	car.SetHandicapStatus(true)
+	return car
+}
+
+func TestUC25_HandicapDriverGetsNearestAccessibleSpaceAcrossLots(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(accessibleLot("LOT1", 40, 25))
+	service.AddLot(accessibleLot("LOT2", 30, 10))
+
+	// Act
+	ticket, err := service.ParkCarWithTicket(newHandicapCar("ACC001"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "LOT2", ticket.LotID)
+	assert.Equal(t, "3", ticket.SpaceID)
+}
+
+func TestUC25_HandicapStrategyUsesProximity(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(accessibleLot("LOT1", 50))
+	service.AddLot(accessibleLot("LOT2", 15))
+	service.AddAttendant(models.NewParkingAttendant("ATT001", "Alice", "LOT1"))
+
+	// Act
+	decision, err := service.ParkHandicapCar(newHandicapCar("ACC002"), "ATT001")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "LOT2", decision.LotID)
+	assert.Equal(t, "2", decision.SpaceID)
+}
+
+func TestUC25_OtherCarsStayOutOfAccessibleSpacesByDefault(t *testing.T) {
+	// Arrange
+	lot := accessibleLot("LOT1", 10, 20)
+	require.NoError(t, lot.ParkCar(models.NewCar("REG001", "Driver")))
+
+	// Act
+	_, err := lot.AssignSpace(models.NewCar("REG002", "Driver"))
+	_, inSpaceErr := lot.ParkCarInSpace(models.NewCar("REG003", "Driver"), 2)
+
+	// Assert
+	assert.EqualError(t, err, "no available space fits this vehicle")
+	assert.EqualError(t, inSpaceErr, "parking space is reserved for accessible parking")
+	assert.Equal(t, 2, lot.GetAvailableSpaces())
+}
+
+func TestUC25_OverflowPolicyOpensAccessibleSpacesWhenNearlyFull(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLotWithSpecs("LOT1", []models.SpaceSpec{
+		{Type: models.RegularSpace},
+		{Type: models.RegularSpace},
+		{Type: models.RegularSpace, Accessible: true},
+		{Type: models.RegularSpace, Accessible: true},
+	})
+	lot.SetAccessiblePolicy(models.AccessiblePolicy{AllowOverflow: true, OverflowThreshold: 0.75})
+	require.NoError(t, lot.ParkCar(models.NewCar("REG001", "Driver")))
+	require.NoError(t, lot.ParkCar(models.NewCar("REG002", "Driver")))
+
+	// Act
+	_, belowThreshold := lot.AssignSpace(models.NewCar("REG003", "Driver"))
+	require.NoError(t, lot.ParkCar(newHandicapCar("ACC003")))
+	space, atThreshold := lot.AssignSpace(models.NewCar("REG004", "Driver"))
+
+	// Assert
+	assert.Error(t, belowThreshold)
+	require.NoError(t, atThreshold)
+	assert.True(t, space.IsAccessible)
+}
+
+func TestUC25_HandicapDriverFallsBackToRegularSpace(t *testing.T) {
+	// Arrange
+	lot := accessibleLot("LOT1", 10)
+	require.NoError(t, lot.ParkCar(newHandicapCar("ACC004")))
+
+	// Act
+	space, err := lot.AssignSpace(newHandicapCar("ACC005"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, space.IsAccessible)
+	assert.Nil(t, lot.NearestAccessibleSpace(newHandicapCar("ACC006")))
+}
+
+func TestUC25_AccessibleSpecsSurviveRestart(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(accessibleLot("LOT1", 12.5))
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	lot, err := restored.GetLotStatus("LOT1")
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, lot.Spaces[1].IsAccessible)
+	assert.Equal(t, 12.5, lot.Spaces[1].DistanceToExit)
+	assert.False(t, lot.Spaces[0].IsAccessible)
+}
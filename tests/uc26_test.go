@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func regularRow(name string, spaces int) models.RowLayout {
+	row := models.RowLayout{Name: name}
+	for i := 0; i < spaces; i++ {
+		row.Spaces = append(row.Spaces, models.SpaceSpec{Type: models.RegularSpace})
+	}
+	return row
+}
+
+func garageLayout() models.LotLayout {
+	return models.LotLayout{Levels: []models.LevelLayout{
+		{Name: "P1", Rows: []models.RowLayout{regularRow("North", 2), regularRow("South", 2)}},
+		{Name: "P2", Rows: []models.RowLayout{regularRow("East", 3)}},
+	}}
+}
+
+func TestUC26_CarLocationComesFromLayout(t *testing.T) {
+	// Arrange
+	lot, err := models.NewParkingLotWithLayout("GARAGE", garageLayout())
+	require.NoError(t, err)
+	service := services.NewParkingService()
+	service.AddLot(lot)
+	for _, plate := range []string{"LAY001", "LAY002", "LAY003", "LAY004", "LAY005"} {
+		_, err := service.ParkCarWithTicket(models.NewCar(plate, "Driver"))
+		require.NoError(t, err)
+	}
+
+	// Act
+	location, err := service.FindCarWithLocation("LAY005")
+	directions, dirErr := service.ProvideDirectionsToDriver("LAY005")
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, dirErr)
+	assert.Equal(t, "5", location.SpaceID)
+	assert.Equal(t, "P2", location.Level)
+	assert.Equal(t, "East", location.Row)
+	assert.Equal(t, 1, location.Position)
+	assert.Contains(t, directions, "Level: P2")
+	assert.Equal(t, 7, lot.Capacity)
+}
+
+func TestUC26_DefaultLotKeepsLetteredRows(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 60)
+
+	// Act
+	first, middle, last := lot.Spaces[14], lot.Spaces[25], lot.Spaces[59]
+
+	// Assert
+	assert.Equal(t, "A", first.GetRowAssignment())
+	assert.Equal(t, 15, first.Position)
+	assert.Equal(t, "B", middle.GetRowAssignment())
+	assert.Equal(t, 1, middle.Position)
+	assert.Equal(t, "C", last.GetRowAssignment())
+	assert.Equal(t, 10, last.Position)
+}
+
+func TestUC26_InvalidLayoutsAreRejected(t *testing.T) {
+	cases := map[string]struct {
+		layout  models.LotLayout
+		message string
+	}{
+		"no levels": {models.LotLayout{}, "layout must have at least one level"},
+		"duplicate row": {
+			models.LotLayout{Levels: []models.LevelLayout{{Name: "P1", Rows: []models.RowLayout{regularRow("A", 1), regularRow("A", 1)}}}},
+			`duplicate row "A" on level "P1"`,
+		},
+		"empty row": {
+			models.LotLayout{Levels: []models.LevelLayout{{Name: "P1", Rows: []models.RowLayout{{Name: "A"}}}}},
+			`row "A" on level "P1" must have at least one space`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			lot, err := models.NewParkingLotWithLayout("BAD", tc.layout)
+
+			// Assert
+			assert.Nil(t, lot)
+			assert.EqualError(t, err, tc.message)
+		})
+	}
+}
+
+func TestUC26_PoliceRowQueriesUseLayoutRows(t *testing.T) {
+	// Arrange
+	lot, err := models.NewParkingLotWithLayout("GARAGE", garageLayout())
+	require.NoError(t, err)
+	service := services.NewParkingService()
+	service.AddLot(lot)
+	_, err = lot.ParkCarInSpace(newHandicapCar("HC001"), 3)
+	require.NoError(t, err)
+	_, err = lot.ParkCarInSpace(newHandicapCar("HC002"), 6)
+	require.NoError(t, err)
+	police := services.NewPoliceService(service)
+
+	// Act
+	south, err := police.FindHandicapCarsInRows([]string{"south"})
+	stats := police.GetLocationStatistics()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, south, 1)
+	assert.Equal(t, "HC001", south[0].Car.LicensePlate)
+	rowCounts := stats["totalVehiclesByRow"].(map[string]int)
+	assert.Equal(t, map[string]int{"North": 0, "South": 1, "East": 1}, rowCounts)
+}
+
+func TestUC26_MultiSpaceVehicleStaysInOneRow(t *testing.T) {
+	// Arrange
+	layout := models.LotLayout{Levels: []models.LevelLayout{
+		{Name: "G", Rows: []models.RowLayout{regularRow("A", 2), regularRow("B", 3)}},
+	}}
+	for i := range layout.Levels[0].Rows {
+		for j := range layout.Levels[0].Rows[i].Spaces {
+			layout.Levels[0].Rows[i].Spaces[j].Type = models.LargeSpace
+		}
+	}
+	lot, err := models.NewParkingLotWithLayout("DEPOT", layout)
+	require.NoError(t, err)
+
+	// Act
+	_, spanErr := lot.ParkCarInSpace(newBus("BUS100"), 1)
+	anchor, err := lot.AssignSpace(newBus("BUS101"))
+
+	// Assert
+	assert.EqualError(t, spanErr, "no available contiguous spaces for this vehicle")
+	require.NoError(t, err)
+	assert.Equal(t, 3, anchor.ID)
+	assert.Equal(t, "B", anchor.Row)
+}
+
+func TestUC26_LayoutSurvivesRestart(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	lot, err := models.NewParkingLotWithLayout("GARAGE", garageLayout())
+	require.NoError(t, err)
+	service.AddLot(lot)
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	restoredLot, err := restored.GetLotStatus("GARAGE")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, garageLayout(), restoredLot.GetLayout())
+}
+
+func TestUC26_APIAndCLICreateLotsFromLayouts(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(1)
+	defer server.Close()
+	layout := garageLayout()
+	dir := t.TempDir()
+	layoutFile := filepath.Join(dir, "layout.json")
+	require.NoError(t, os.WriteFile(layoutFile, []byte(`{"levels":[{"name":"L1","rows":[{"name":"Z","spaces":[{"type":"compact"},{"type":"large"}]}]}]}`), 0o644))
+
+	// Act
+	var created api.LotStatusResponse
+	createdStatus := doJSON(t, http.MethodPost, server.URL+"/api/lots", api.LotRequest{ID: "GARAGE", Layout: &layout}, &created)
+	rejectedStatus := doJSON(t, http.MethodPost, server.URL+"/api/lots", api.LotRequest{ID: "EMPTY", Layout: &models.LotLayout{}}, nil)
+	code, stdout, stderr := runCLI(t, "", "--state", dir, "lot", "create", "CLI", "--layout", layoutFile)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, createdStatus)
+	assert.Equal(t, 7, created.Capacity)
+	assert.Equal(t, http.StatusBadRequest, rejectedStatus)
+	require.Equal(t, 0, code, stderr)
+	assert.True(t, strings.Contains(stdout, "CLI"))
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+)
+
+const airportConfig = `
+defaultStrategy: smart
+rates:
+  hourlyRate: 12
+  minimumCharge: 7.5
+lots:
+  - id: SHORT
+    capacity: 4
+  - id: TYPED
+    spaces:
+      - type: compact
+        count: 2
+      - type: large
+        accessible: true
+        distanceToExit: 8
+  - id: GARAGE
+    levels:
+      - name: P1
+        rows:
+          - name: A
+            spaces:
+              - type: regular
+                count: 3
+attendants:
+  - id: ATT001
+    name: Alice
+    lot: SHORT
+securityStaff:
+  - id: SEC001
+    name: Sam
+    position: Officer
+    lot: GARAGE
+`
+
+func TestUC27_YAMLConfigBuildsReadyService(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(airportConfig))
+	require.NoError(t, err)
+
+	// Act
+	service, err := cfg.NewParkingService()
+	require.NoError(t, err)
+	_, err = service.ParkCarWithTicket(models.NewCar("CFG001", "Driver"))
+	require.NoError(t, err)
+	_, bill, err := service.UnparkCarWithBilling("CFG001")
+	require.NoError(t, err)
+
+	// Assert
+	short, _ := service.GetLotStatus("SHORT")
+	typed, _ := service.GetLotStatus("TYPED")
+	garage, _ := service.GetLotStatus("GARAGE")
+	assert.Equal(t, 4, short.Capacity)
+	assert.Equal(t, []models.SpaceType{models.CompactSpace, models.CompactSpace, models.LargeSpace}, typed.GetSpaceTypes())
+	assert.True(t, typed.Spaces[2].IsAccessible)
+	assert.Equal(t, "A", garage.Spaces[2].Row)
+	assert.Equal(t, "P1", garage.Spaces[2].Level)
+	assert.Equal(t, 7.5, bill.TotalAmount)
+	assert.Equal(t, 12.0, bill.HourlyRate)
+	assert.Equal(t, "SHORT", service.FindAttendantByID("ATT001").LotID)
+	assert.Equal(t, "GARAGE", service.FindSecurityStaffByID("SEC001").AssignedLot)
+	assert.Equal(t, models.NewSmartParkingStrategy().GetStrategyName(), service.GetDefaultStrategy().GetStrategyName())
+}
+
+func TestUC27_JSONConfigIsAccepted(t *testing.T) {
+	// Arrange
+	data := "{\n\t\"rates\": {\"hourlyRate\": 8, \"minimumCharge\": 4},\n\t\"lots\": [\n\t\t{\"id\": \"LOT1\", \"capacity\": 10}\n\t]\n}\n"
+
+	// Act
+	cfg, err := config.Parse([]byte(data))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, cfg.Lots, 1)
+	assert.Equal(t, 10, cfg.Lots[0].Capacity)
+	assert.Equal(t, 8.0, cfg.Rates.HourlyRate)
+}
+
+func TestUC27_ValidationErrorsPointAtLines(t *testing.T) {
+	// Arrange
+	data := `lots:
+  - id: LOT1
+    capacity: 5
+  - id: LOT1
+    spaces:
+      - type: huge
+attendants:
+  - id: ATT001
+    name: Alice
+    lot: NOWHERE
+rates:
+  hourlyRate: 0
+`
+
+	// Act
+	_, err := config.Parse([]byte(data))
+
+	// Assert
+	var errs config.ValidationErrors
+	require.True(t, errors.As(err, &errs), "got %v", err)
+	assert.Equal(t, []string{
+		"line 12: hourly rate must be positive",
+		`line 4: duplicate lot "LOT1"`,
+		`line 6: unknown space type "huge"`,
+		`line 10: attendant lot "NOWHERE" is not defined`,
+	}, strings.Split(err.Error(), "\n"))
+}
+
+func TestUC27_UnknownFieldsAreRejectedWithLine(t *testing.T) {
+	// Arrange
+	data := "lots:\n  - id: LOT1\n    capacty: 5\n"
+
+	// Act
+	_, err := config.Parse([]byte(data))
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+	assert.Contains(t, err.Error(), "capacty")
+}
+
+func TestUC27_ReloadAppliesAdditiveChangesAndKeepsOccupancy(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(airportConfig))
+	require.NoError(t, err)
+	service, err := cfg.NewParkingService()
+	require.NoError(t, err)
+	_, err = service.ParkCarWithTicket(models.NewCar("CFG002", "Driver"))
+	require.NoError(t, err)
+
+	updated := strings.Replace(airportConfig, "minimumCharge: 7.5", "minimumCharge: 9", 1)
+	updated = strings.Replace(updated, "capacity: 4", "capacity: 6", 1)
+	updated = strings.Replace(updated, "attendants:", "  - id: OVERFLOW\n    capacity: 20\nattendants:", 1)
+	reloaded, err := config.Parse([]byte(updated))
+	require.NoError(t, err)
+
+	// Act
+	changes, err := reloaded.Apply(service)
+	require.NoError(t, err)
+	again, err := reloaded.Apply(service)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []string{"lot OVERFLOW"}, changes.Added)
+	assert.Equal(t, []string{"rates"}, changes.Updated)
+	assert.Equal(t, []string{"lot SHORT: space changes are not applied to an existing lot"}, changes.Ignored)
+	assert.Empty(t, again.Added)
+	assert.Empty(t, again.Updated)
+
+	short, _ := service.GetLotStatus("SHORT")
+	assert.Equal(t, 4, short.Capacity)
+	assert.NotNil(t, short.FindCar("CFG002"))
+	_, bill, err := service.UnparkCarWithBilling("CFG002")
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, bill.TotalAmount)
+}
+
+func TestUC27_CLIAppliesConfigToState(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(airportConfig), 0o644))
+	badFile := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(badFile, []byte("lots:\n  - capacity: 3\n"), 0o644))
+	state := filepath.Join(dir, "state")
+
+	// Act
+	code, stdout, stderr := runCLI(t, "", "--state", state, "--config", configFile, "report")
+	badCode, _, badStderr := runCLI(t, "", "--state", state, "config", "check", badFile)
+
+	// Assert
+	require.Equal(t, 0, code, stderr)
+	for _, lot := range []string{"SHORT", "TYPED", "GARAGE"} {
+		assert.Contains(t, stdout, lot)
+	}
+	assert.Equal(t, 1, badCode)
+	assert.Contains(t, badStderr, "line 2: lot id cannot be empty")
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+// Wednesday 14 October 2026, 09:00 UTC
+var weekdayMorning = time.Date(2026, time.October, 14, 9, 0, 0, 0, time.UTC)
+
+func standardTariff() *services.RuleTariff {
+	return &services.RuleTariff{
+		TariffName:     "Standard",
+		FirstHour:      3.0,
+		Increment:      15 * time.Minute,
+		IncrementPrice: 1.0,
+	}
+}
+
+func completedTicket(lotID string, parkedAt time.Time, stay time.Duration) *models.ParkingTicket {
+	ticket := models.NewParkingTicket("TAR001", lotID, "1")
+	ticket.ParkedAt = parkedAt
+	ticket.UnparkedAt = parkedAt.Add(stay)
+	ticket.IsActive = false
+	return ticket
+}
+
+func TestUC28_FirstHourThenIncrements(t *testing.T) {
+	// Arrange
+	tariff := standardTariff()
+
+	// Act
+	items := tariff.Charge(weekdayMorning, weekdayMorning.Add(time.Hour+40*time.Minute))
+
+	// Assert
+	require.Len(t, items, 2)
+	assert.Equal(t, "First hour", items[0].Description)
+	assert.Equal(t, 3.0, items[0].Amount)
+	assert.Equal(t, "Day rate: 3 x 15 min at $1.00", items[1].Description)
+	assert.Equal(t, 3.0, items[1].Amount)
+}
+
+func TestUC28_DailyCapLimitsEachDay(t *testing.T) {
+	// Arrange
+	tariff := standardTariff()
+	tariff.DailyCap = 20.0
+
+	// Act
+	items := tariff.Charge(weekdayMorning, weekdayMorning.Add(26*time.Hour))
+
+	// Assert
+	require.Len(t, items, 2)
+	assert.Equal(t, "Daily maximum", items[0].Description)
+	assert.Equal(t, 20.0, items[0].Amount)
+	assert.Equal(t, weekdayMorning.Add(24*time.Hour), items[0].End)
+	assert.Equal(t, "Day rate: 8 x 15 min at $1.00", items[1].Description)
+	assert.Equal(t, 8.0, items[1].Amount)
+}
+
+func TestUC28_NightAndWeekendRates(t *testing.T) {
+	// Arrange
+	weekend := 2.0
+	tariff := standardTariff()
+	tariff.Night = &services.TimeBand{Start: 22 * time.Hour, End: 6 * time.Hour, Price: 0.25}
+	tariff.WeekendPrice = &weekend
+	evening := time.Date(2026, time.October, 14, 21, 0, 0, 0, time.UTC)
+	saturday := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	nightItems := tariff.Charge(evening, evening.Add(2*time.Hour))
+	weekendItems := tariff.Charge(saturday, saturday.Add(90*time.Minute))
+
+	// Assert
+	require.Len(t, nightItems, 2)
+	assert.Equal(t, "Night rate: 4 x 15 min at $0.25", nightItems[1].Description)
+	assert.Equal(t, 1.0, nightItems[1].Amount)
+	require.Len(t, weekendItems, 2)
+	assert.Equal(t, "Weekend rate: 2 x 15 min at $2.00", weekendItems[1].Description)
+	assert.Equal(t, 4.0, weekendItems[1].Amount)
+}
+
+func TestUC28_GracePeriodIsFree(t *testing.T) {
+	// Arrange
+	tariff := standardTariff()
+	tariff.GracePeriod = 10 * time.Minute
+
+	// Act
+	inGrace := tariff.Charge(weekdayMorning, weekdayMorning.Add(9*time.Minute))
+	pastGrace := tariff.Charge(weekdayMorning, weekdayMorning.Add(11*time.Minute))
+
+	// Assert
+	require.Len(t, inGrace, 1)
+	assert.Equal(t, "Grace period", inGrace[0].Description)
+	assert.Equal(t, 0.0, inGrace[0].Amount)
+	assert.Equal(t, 3.0, pastGrace[0].Amount)
+}
+
+func TestUC28_EachLotUsesItsOwnTariff(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetLotTariff("GARAGE", standardTariff())
+
+	// Act
+	garageBill := billing.GenerateBill(completedTicket("GARAGE", weekdayMorning, 2*time.Hour))
+	surfaceBill := billing.GenerateBill(completedTicket("SURFACE", weekdayMorning, 2*time.Hour))
+
+	// Assert
+	assert.Equal(t, "Standard", garageBill.Tariff)
+	assert.Equal(t, 7.0, garageBill.TotalAmount)
+	assert.Equal(t, 0.0, garageBill.HourlyRate)
+	assert.Contains(t, garageBill.PrintBill(), "Day rate: 4 x 15 min at $1.00")
+
+	assert.Equal(t, "Hourly", surfaceBill.Tariff)
+	assert.Equal(t, 20.0, surfaceBill.TotalAmount)
+	require.Len(t, surfaceBill.LineItems, 1)
+	assert.Equal(t, "2 hour(s) at $10.00/hour", surfaceBill.LineItems[0].Description)
+}
+
+func TestUC28_TariffsLoadFromConfig(t *testing.T) {
+	// Arrange
+	data := `
+tariffs:
+  - name: airport
+    firstHour: 4
+    increment: 30m
+    incrementPrice: 2
+    dailyCap: 25
+    gracePeriod: 5m
+    night:
+      start: "23:00"
+      end: "05:00"
+      price: 0.5
+defaultTariff: airport
+lots:
+  - id: LOT1
+    capacity: 2
+  - id: VIP
+    capacity: 2
+    tariff: airport
+`
+	cfg, err := config.Parse([]byte(data))
+	require.NoError(t, err)
+
+	// Act
+	service, err := cfg.NewParkingService()
+	require.NoError(t, err)
+	billing := service.GetBillingService()
+	bill := billing.GenerateBill(completedTicket("LOT1", weekdayMorning, 2*time.Hour))
+
+	// Assert
+	assert.Equal(t, "airport", bill.Tariff)
+	assert.Equal(t, 8.0, bill.TotalAmount)
+	assert.Equal(t, "airport", billing.TariffFor("VIP").Name())
+}
+
+func TestUC28_InvalidTariffsAreReportedWithLines(t *testing.T) {
+	// Arrange
+	data := `tariffs:
+  - name: broken
+    firstHour: -1
+    night:
+      start: "25:00"
+      end: "06:00"
+lots:
+  - id: LOT1
+    capacity: 2
+    tariff: missing
+`
+
+	// Act
+	_, err := config.Parse([]byte(data))
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, "line 3: firstHour cannot be negative\n"+
+		`line 5: invalid clock time "25:00", expected HH:MM`+"\n"+
+		`line 10: tariff "missing" is not defined`, err.Error())
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func sizedCar(plate string, size models.VehicleSize) *models.Car {
+	car := models.NewCar(plate, "Driver")
+	car.SetVehicleSize(size)
+	return car
+}
+
+func TestUC29_SizeMultipliersScaleTheBill(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetPricingPolicy(services.PricingPolicy{
+		SizeMultipliers: map[models.VehicleSize]float64{
+			models.LargeVehicle:      1.5,
+			models.MotorcycleVehicle: 0.5,
+		},
+	})
+	ticket := completedTicket("LOT1", weekdayMorning, 2*time.Hour)
+
+	// Act
+	large := billing.GenerateBillForCar(ticket, sizedCar("BIG001", models.LargeVehicle))
+	motorcycle := billing.GenerateBillForCar(ticket, sizedCar("MOTO01", models.MotorcycleVehicle))
+	medium := billing.GenerateBillForCar(ticket, models.NewCar("MED001", "Driver"))
+
+	// Assert
+	assert.Equal(t, 20.0, large.BaseAmount)
+	assert.Equal(t, 30.0, large.TotalAmount)
+	assert.Equal(t, []services.Adjustment{{Reason: "Large vehicle x1.50", Amount: 10.0}}, large.Adjustments)
+	assert.Equal(t, 10.0, motorcycle.TotalAmount)
+	assert.Equal(t, -10.0, motorcycle.Adjustments[0].Amount)
+	assert.Equal(t, 20.0, medium.TotalAmount)
+	assert.Empty(t, medium.Adjustments)
+}
+
+func TestUC29_AccessibleDiscountAppliesAfterSize(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetPricingPolicy(services.PricingPolicy{
+		SizeMultipliers:    map[models.VehicleSize]float64{models.LargeVehicle: 1.5},
+		AccessibleDiscount: 0.25,
+	})
+	van := sizedCar("VAN001", models.LargeVehicle)
+	van.SetHandicapStatus(true)
+
+	// Act
+	bill := billing.GenerateBillForCar(completedTicket("LOT1", weekdayMorning, 2*time.Hour), van)
+
+	// Assert
+	require.Len(t, bill.Adjustments, 2)
+	assert.Equal(t, "Accessible permit discount 25%", bill.Adjustments[1].Reason)
+	assert.Equal(t, -7.5, bill.Adjustments[1].Amount)
+	assert.Equal(t, 22.5, bill.TotalAmount)
+	assert.Contains(t, bill.PrintBill(), "Accessible permit discount 25%")
+}
+
+func TestUC29_AccessibleExemptionWaivesTheCharge(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	service.GetBillingService().SetPricingPolicy(services.PricingPolicy{AccessibleExempt: true})
+	_, err := service.ParkCarWithTicket(newHandicapCar("EXEMPT1"))
+	require.NoError(t, err)
+
+	// Act
+	_, bill, err := service.UnparkCarWithBilling("EXEMPT1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, bill.BaseAmount)
+	assert.Equal(t, 0.0, bill.TotalAmount)
+	assert.Equal(t, "Accessible permit exemption", bill.Adjustments[0].Reason)
+}
+
+func TestUC29_BillWithoutCarKeepsTariffPrice(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetPricingPolicy(services.PricingPolicy{AccessibleExempt: true})
+
+	// Act
+	bill := billing.GenerateBill(completedTicket("LOT1", weekdayMorning, 3*time.Hour))
+
+	// Assert
+	assert.Equal(t, 30.0, bill.TotalAmount)
+	assert.Empty(t, bill.Adjustments)
+}
+
+func TestUC29_PricingLoadsFromConfig(t *testing.T) {
+	// Arrange
+	data := `
+pricing:
+  sizeMultipliers:
+    large: 2
+    tiny: 0.5
+  accessibleDiscount: 1.5
+lots:
+  - id: LOT1
+    capacity: 2
+`
+	valid := `
+pricing:
+  sizeMultipliers:
+    large: 2
+  accessibleDiscount: 0.5
+lots:
+  - id: LOT1
+    capacity: 2
+`
+
+	// Act
+	_, invalidErr := config.Parse([]byte(data))
+	cfg, err := config.Parse([]byte(valid))
+	require.NoError(t, err)
+	service, err := cfg.NewParkingService()
+	require.NoError(t, err)
+
+	// Assert
+	assert.EqualError(t, invalidErr, "line 5: unknown vehicle size \"tiny\"\n"+
+		"line 6: accessible discount must be between 0 and 1")
+	policy := service.GetBillingService().GetPricingPolicy()
+	assert.Equal(t, 2.0, policy.SizeMultipliers[models.LargeVehicle])
+	assert.Equal(t, 0.5, policy.AccessibleDiscount)
+}
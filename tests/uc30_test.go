@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func TestUC30_LostTicketBillsFlatPenalty(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	service.GetBillingService().SetPenaltyPolicy(services.PenaltyPolicy{LostTicketFee: 50.0})
+	_, err := service.ParkCarWithTicket(models.NewCar("LOST01", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	car, bill, err := service.UnparkCarWithLostTicket("LOST01")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "LOST01", car.LicensePlate)
+	assert.True(t, bill.LostTicket)
+	assert.Equal(t, 0.0, bill.BaseAmount)
+	assert.Equal(t, 50.0, bill.TotalAmount)
+	assert.Equal(t, []services.Adjustment{{Reason: "Lost ticket", Amount: 50.0}}, bill.Penalties)
+	_, err = service.GetActiveTicket("LOST01")
+	assert.Error(t, err)
+}
+
+func TestUC30_LostTicketDefaultsToMaximumDailyRate(t *testing.T) {
+	// Arrange
+	capped := standardTariff()
+	capped.DailyCap = 20.0
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetLotTariff("GARAGE", capped)
+
+	// Act
+	hourly := billing.GenerateLostTicketBill(completedTicket("SURFACE", weekdayMorning, time.Hour))
+	garage := billing.GenerateLostTicketBill(completedTicket("GARAGE", weekdayMorning, time.Hour))
+
+	// Assert
+	assert.Equal(t, 240.0, hourly.TotalAmount)
+	assert.Equal(t, 20.0, garage.TotalAmount)
+}
+
+func TestUC30_LostTicketForCarParkedWithoutTicket(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	service.GetBillingService().SetPenaltyPolicy(services.PenaltyPolicy{LostTicketFee: 35.0})
+	require.NoError(t, service.ParkCar(models.NewCar("NOTKT1", "Driver")))
+
+	// Act
+	_, _, withoutTicket := service.UnparkCarWithBilling("NOTKT1")
+	_, bill, err := service.UnparkCarWithLostTicket("NOTKT1")
+	_, _, missingErr := service.UnparkCarWithLostTicket("NOTKT1")
+
+	// Assert
+	assert.Error(t, withoutTicket)
+	require.NoError(t, err)
+	assert.Equal(t, 35.0, bill.TotalAmount)
+	assert.Equal(t, "LOT1", bill.LotID)
+	history, _ := service.GetParkingHistory("NOTKT1")
+	require.Len(t, history, 1)
+	assert.False(t, history[0].IsActive)
+	assert.EqualError(t, missingErr, "car not found")
+}
+
+func TestUC30_OverstayAddsSurcharge(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	billing.SetPenaltyPolicy(services.PenaltyPolicy{
+		MaxStay:            map[string]time.Duration{"SHORT": 4 * time.Hour},
+		OverstayFee:        25.0,
+		OverstayHourlyRate: 5.0,
+	})
+	car := models.NewCar("OVER01", "Driver")
+
+	// Act
+	over := billing.GenerateBillForCar(completedTicket("SHORT", weekdayMorning, 5*time.Hour+30*time.Minute), car)
+	within := billing.GenerateBillForCar(completedTicket("SHORT", weekdayMorning, 4*time.Hour), car)
+	elsewhere := billing.GenerateBillForCar(completedTicket("LONG", weekdayMorning, 10*time.Hour), car)
+
+	// Assert
+	assert.Equal(t, 90*time.Minute, over.Overstay)
+	require.Len(t, over.Penalties, 1)
+	assert.Equal(t, "Overstay of 1h30m0s beyond 4h0m0s limit", over.Penalties[0].Reason)
+	assert.Equal(t, 35.0, over.Penalties[0].Amount)
+	assert.Equal(t, 60.0+35.0, over.TotalAmount)
+	assert.Zero(t, within.Overstay)
+	assert.Empty(t, within.Penalties)
+	assert.Empty(t, elsewhere.Penalties)
+}
+
+func TestUC30_PenaltiesLoadFromConfigAndAPI(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(`
+penalties:
+  lostTicketFee: 45
+  overstayFee: 10
+lots:
+  - id: LOT1
+    capacity: 2
+    maxStay: 2h
+`))
+	require.NoError(t, err)
+	parking, err := cfg.NewParkingService()
+	require.NoError(t, err)
+	_, err = parking.ParkCarWithTicket(models.NewCar("API001", "Driver"))
+	require.NoError(t, err)
+	server := httptest.NewServer(api.NewServer(parking, nil, services.NewPoliceService(parking)))
+	defer server.Close()
+
+	// Act
+	var bill api.BillResponse
+	status := doJSON(t, http.MethodPost, server.URL+"/api/unpark", api.UnparkRequest{LicensePlate: "API001", LostTicket: true}, &bill)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, bill.LostTicket)
+	assert.Equal(t, 45.0, bill.TotalAmount)
+	require.Len(t, bill.Penalties, 1)
+	assert.Equal(t, 2*time.Hour, parking.GetBillingService().GetPenaltyPolicy().MaxStay["LOT1"])
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func newPayingService(t *testing.T) (*services.ParkingService, *services.LocalPaymentProvider) {
+	t.Helper()
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	provider := services.NewLocalPaymentProvider()
+	service.GetPaymentService().SetProvider(provider)
+	return service, provider
+}
+
+func TestUC31_UnparkWaitsForSettlement(t *testing.T) {
+	// Arrange
+	service, _ := newPayingService(t)
+	payments := service.GetPaymentService()
+	ticket, err := service.ParkCarWithTicket(models.NewCar("PAY001", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	_, _, unquotedErr := service.UnparkCarWithBilling("PAY001")
+	bill, err := service.QuoteExitBill("PAY001", false)
+	require.NoError(t, err)
+	_, err = payments.Pay(ticket.ID, services.PaymentCard, 2.0)
+	require.NoError(t, err)
+	_, _, partialErr := service.UnparkCarWithBilling("PAY001")
+	_, err = payments.Pay(ticket.ID, services.PaymentWallet, 3.0)
+	require.NoError(t, err)
+	_, paid, err := service.UnparkCarWithBilling("PAY001")
+
+	// Assert
+	assert.EqualError(t, unquotedErr, "bill is not settled: request the exit bill and pay it first")
+	assert.Equal(t, 5.0, bill.TotalAmount)
+	assert.EqualError(t, partialErr, "bill is not settled: $3.00 due")
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, paid.TotalAmount)
+	assert.Len(t, payments.GetPayments(ticket.ID), 2)
+	_, err = service.FindCar("PAY001")
+	assert.Error(t, err)
+}
+
+func TestUC31_CashChangeAndCardLimits(t *testing.T) {
+	// Arrange
+	service, provider := newPayingService(t)
+	payments := service.GetPaymentService()
+	ticket, err := service.ParkCarWithTicket(models.NewCar("PAY002", "Driver"))
+	require.NoError(t, err)
+	_, err = service.QuoteExitBill("PAY002", false)
+	require.NoError(t, err)
+	provider.Decline(services.PaymentWallet, true)
+
+	// Act
+	_, cardErr := payments.Pay(ticket.ID, services.PaymentCard, 20.0)
+	_, walletErr := payments.Pay(ticket.ID, services.PaymentWallet, 5.0)
+	_, zeroErr := payments.Pay(ticket.ID, services.PaymentCash, 0)
+	cash, err := payments.Pay(ticket.ID, services.PaymentCash, 20.0)
+	_, settledErr := payments.Pay(ticket.ID, services.PaymentCash, 1.0)
+
+	// Assert
+	assert.EqualError(t, cardErr, "payment cannot be more than the balance due")
+	assert.EqualError(t, walletErr, "wallet payment declined")
+	assert.EqualError(t, zeroErr, "payment amount must be positive")
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, cash.Amount)
+	assert.Equal(t, 15.0, cash.Change)
+	assert.Equal(t, "local", cash.Provider)
+	assert.EqualError(t, settledErr, "bill is already settled")
+	require.Len(t, provider.Transactions(), 1)
+	assert.Equal(t, cash.ProviderRef, provider.Transactions()[0].ID)
+}
+
+func TestUC31_RefundsReopenTheBalance(t *testing.T) {
+	// Arrange
+	service, _ := newPayingService(t)
+	payments := service.GetPaymentService()
+	ticket, err := service.ParkCarWithTicket(models.NewCar("PAY003", "Driver"))
+	require.NoError(t, err)
+	_, err = service.QuoteExitBill("PAY003", false)
+	require.NoError(t, err)
+	payment, err := payments.Pay(ticket.ID, services.PaymentCard, 5.0)
+	require.NoError(t, err)
+
+	// Act
+	refunded, err := payments.Refund(payment.ID, 2.0)
+	require.NoError(t, err)
+	_, tooMuchErr := payments.Refund(payment.ID, 4.0)
+	_, _, unparkErr := service.UnparkCarWithBilling("PAY003")
+	receipt, receiptErr := payments.GetReceipt(ticket.ID)
+
+	// Assert
+	assert.Equal(t, 3.0, refunded.Net())
+	assert.EqualError(t, tooMuchErr, "refund cannot be more than the amount paid")
+	assert.EqualError(t, unparkErr, "bill is not settled: $2.00 due")
+	require.NoError(t, receiptErr)
+	assert.Equal(t, 3.0, receipt.AmountPaid)
+	assert.Equal(t, 2.0, receipt.BalanceDue)
+	assert.Contains(t, receipt.PrintReceipt(), "Balance Due: $2.00")
+}
+
+func TestUC31_AttendantOverridesTheGate(t *testing.T) {
+	// Arrange
+	service, _ := newPayingService(t)
+	service.AddAttendant(models.NewParkingAttendant("ATT1", "Alex", "LOT1"))
+	ticket, err := service.ParkCarWithTicket(models.NewCar("PAY004", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	_, unknownErr := service.OverrideSettlement("PAY004", "NOBODY")
+	_, err = service.OverrideSettlement("PAY004", "ATT1")
+	require.NoError(t, err)
+	_, bill, err := service.UnparkCarWithBilling("PAY004")
+
+	// Assert
+	assert.EqualError(t, unknownErr, "attendant not found")
+	require.NoError(t, err)
+	assert.Equal(t, "ATT1", bill.SettlementOverride)
+	balance, err := service.GetPaymentService().BalanceDue(ticket.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, balance)
+}
+
+func TestUC31_NoProviderLeavesUnparkUngated(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	ticket, err := service.ParkCarWithTicket(models.NewCar("FREE01", "Driver"))
+	require.NoError(t, err)
+	_, err = service.QuoteExitBill("FREE01", false)
+	require.NoError(t, err)
+
+	// Act
+	_, payErr := service.GetPaymentService().Pay(ticket.ID, services.PaymentCard, 5.0)
+	_, bill, err := service.UnparkCarWithBilling("FREE01")
+
+	// Assert
+	assert.EqualError(t, payErr, "no payment provider configured")
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, bill.TotalAmount)
+}
+
+func TestUC31_PaymentsPersistBetweenInvocations(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("payments:\n  provider: local\nlots:\n  - id: LOT1\n    capacity: 2\n"), 0o644))
+	state := filepath.Join(dir, "state")
+	code, _, _ := runCLI(t, "", "--state", state, "--config", configPath, "park", "CLI001")
+	require.Equal(t, 0, code)
+
+	// Act
+	blockedCode, _, blockedErr := runCLI(t, "", "--state", state, "--config", configPath, "unpark", "CLI001")
+	payCode, payOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "pay", "CLI001", "--method", "cash", "--amount", "10")
+	unparkCode, unparkOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "unpark", "CLI001")
+
+	// Assert
+	assert.Equal(t, 1, blockedCode)
+	assert.Contains(t, blockedErr, "bill is not settled")
+	assert.Equal(t, 0, payCode)
+	assert.Contains(t, payOut, "PAY-000001")
+	assert.Contains(t, payOut, "5.00")
+	assert.Equal(t, 0, unparkCode)
+	assert.Contains(t, unparkOut, "CLI001")
+}
+
+func TestUC31_ExitRepricesTheStayAndLostTicketsOweThePenalty(t *testing.T) {
+	// Arrange
+	service, _ := newPayingService(t)
+	service.AddLot(models.NewParkingLot("LOT2", 2))
+	clock := models.NewFakeClock(weekdayMorning)
+	service.SetClock(clock)
+	payments := service.GetPaymentService()
+	late, err := service.ParkCarWithTicket(models.NewCar("PAY007", "Driver"))
+	require.NoError(t, err)
+	lost, err := service.ParkCarWithTicket(models.NewCar("PAY008", "Driver"))
+	require.NoError(t, err)
+	require.NoError(t, service.ParkCar(models.NewCar("PAY009", "Driver")), "parked without a ticket")
+	for _, ticket := range []*models.ParkingTicket{late, lost} {
+		_, err := service.QuoteExitBill(ticket.LicensePlate, false)
+		require.NoError(t, err)
+		_, err = payments.Pay(ticket.ID, services.PaymentCard, 5.0)
+		require.NoError(t, err)
+	}
+
+	// Act
+	clock.Advance(150 * time.Minute)
+	_, _, lateErr := service.UnparkCarWithBilling("PAY007")
+	_, _, lostErr := service.UnparkCarWithLostTicket("PAY008")
+	_, _, untrackedErr := service.UnparkCarWithLostTicket("PAY009")
+	_, err = payments.Pay(late.ID, services.PaymentCard, 25.0)
+	require.NoError(t, err)
+	_, paid, paidErr := service.UnparkCarWithBilling("PAY007")
+
+	// Assert
+	assert.EqualError(t, lateErr, "bill is not settled: $25.00 due", "three hours cost $30, $5 was paid at the quote")
+	assert.Contains(t, lostErr.Error(), "bill is not settled", "a paid ordinary quote does not cover the penalty")
+	assert.EqualError(t, untrackedErr, "bill is not settled: request the exit bill and pay it first")
+	_, err = service.FindCar("PAY009")
+	assert.NoError(t, err, "the car without a ticket is still parked")
+	quote, err := service.QuoteExitBill("PAY009", true)
+	require.NoError(t, err)
+	assert.True(t, quote.LostTicket)
+	require.NoError(t, paidErr)
+	assert.Equal(t, 30.0, paid.TotalAmount)
+}
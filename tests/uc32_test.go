@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func TestUC32_HeldSpacesAreKeptFromWalkIns(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	lot := models.NewParkingLot("LOT1", 2)
+	service.AddLot(lot)
+	reservations := service.GetReservationService()
+	now := time.Now()
+	reservation, err := reservations.Book(models.NewCar("RES001", "Booked"), "LOT1", now, now.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	// Act
+	_, walkInErr := service.ParkCarWithTicket(models.NewCar("WALK01", "Walk-in"))
+	_, blockedErr := service.ParkCarWithTicket(models.NewCar("WALK02", "Walk-in"))
+	ticket, arriveErr := reservations.Arrive(reservation.ID)
+
+	// Assert
+	assert.NoError(t, walkInErr)
+	assert.EqualError(t, blockedErr, "no available parking space")
+	require.NoError(t, arriveErr)
+	assert.Equal(t, "LOT1", ticket.LotID)
+	assert.Equal(t, 0, lot.GetHeldSpaces())
+	arrived, err := reservations.GetReservation(reservation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationArrived, arrived.Status)
+	assert.Equal(t, ticket.ID, arrived.TicketID)
+}
+
+func TestUC32_HoldBeginsBeforeTheWindow(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	lot := models.NewParkingLot("LOT1", 3)
+	service.AddLot(lot)
+	reservations := service.GetReservationService()
+	reservations.SetPolicy(services.ReservationPolicy{HoldBefore: 30 * time.Minute, GracePeriod: 15 * time.Minute})
+	now := time.Now()
+	reservation, err := reservations.Book(models.NewCar("RES002", "Booked"), "LOT1", now.Add(time.Hour), now.Add(3*time.Hour))
+	require.NoError(t, err)
+
+	// Act
+	_, earlyErr := reservations.Arrive(reservation.ID)
+	heldEarly := lot.GetHeldSpaces()
+	reservations.Refresh(now.Add(45 * time.Minute))
+
+	// Assert
+	assert.EqualError(t, earlyErr, "reservation cannot be used before its window")
+	assert.Equal(t, 0, heldEarly)
+	assert.Equal(t, 1, lot.GetHeldSpaces())
+	assert.Equal(t, 2, lot.GetUnreservedSpaces())
+	assert.Equal(t, 3, lot.GetAvailableSpaces())
+}
+
+func TestUC32_NoShowsExpireAfterTheGracePeriod(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	lot := models.NewParkingLot("LOT1", 1)
+	service.AddLot(lot)
+	reservations := service.GetReservationService()
+	now := time.Now()
+	reservation, err := reservations.Book(models.NewCar("RES003", "Booked"), "LOT1", now, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	_, blockedErr := service.ParkCarWithTicket(models.NewCar("WALK03", "Walk-in"))
+
+	// Act
+	expired := reservations.Refresh(now.Add(16 * time.Minute))
+	_, arriveErr := reservations.Arrive(reservation.ID)
+	_, walkInErr := service.ParkCarWithTicket(models.NewCar("WALK03", "Walk-in"))
+
+	// Assert
+	assert.Error(t, blockedErr)
+	require.Len(t, expired, 1)
+	assert.Equal(t, reservation.ID, expired[0].ID)
+	assert.Equal(t, models.ReservationExpired, expired[0].Status)
+	assert.EqualError(t, arriveErr, "reservation is not active")
+	assert.NoError(t, walkInErr)
+}
+
+func TestUC32_BookingsRespectTheLotQuota(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 4))
+	reservations := service.GetReservationService()
+	reservations.SetLotQuota("LOT1", 1)
+	start := time.Now().Add(24 * time.Hour)
+	first, err := reservations.Book(models.NewCar("RES004", "Booked"), "LOT1", start, start.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	// Act
+	_, overlapErr := reservations.Book(models.NewCar("RES005", "Booked"), "LOT1", start.Add(time.Hour), start.Add(3*time.Hour))
+	later, laterErr := reservations.Book(models.NewCar("RES005", "Booked"), "LOT1", start.Add(2*time.Hour), start.Add(4*time.Hour))
+	_, sameCarErr := reservations.Book(models.NewCar("RES004", "Booked"), "LOT1", start.Add(time.Hour), start.Add(90*time.Minute))
+	_, pastErr := reservations.Book(models.NewCar("RES006", "Booked"), "LOT1", start.Add(-48*time.Hour), start.Add(-47*time.Hour))
+	_, unknownLotErr := reservations.Book(models.NewCar("RES006", "Booked"), "NOPE", start, start.Add(time.Hour))
+	cancelled, cancelErr := reservations.Cancel(first.ID)
+	_, rebookErr := reservations.Book(models.NewCar("RES007", "Booked"), "LOT1", start, start.Add(time.Hour))
+	_, recancelErr := reservations.Cancel(first.ID)
+
+	// Assert
+	assert.EqualError(t, overlapErr, "no available reservation capacity in lot for that window")
+	require.NoError(t, laterErr)
+	assert.Equal(t, "RES-000002", later.ID)
+	assert.EqualError(t, sameCarErr, "vehicle already has a reservation for that window")
+	assert.EqualError(t, pastErr, "reservation window must be in the future")
+	assert.EqualError(t, unknownLotErr, "parking lot not found")
+	require.NoError(t, cancelErr)
+	assert.Equal(t, models.ReservationCancelled, cancelled.Status)
+	assert.NoError(t, rebookErr)
+	assert.EqualError(t, recancelErr, "reservation is not active")
+	assert.Len(t, reservations.GetReservations("LOT1"), 3)
+}
+
+func TestUC32_ReservationsOverHTTP(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(1)
+	defer server.Close()
+	now := time.Now()
+
+	// Act
+	var reservation api.ReservationResponse
+	bookStatus := doJSON(t, http.MethodPost, server.URL+"/api/reservations", api.ReservationRequest{
+		ParkRequest: api.ParkRequest{LicensePlate: "RES008", DriverName: "Booked"},
+		LotID:       "LOT1",
+		Start:       now,
+		End:         now.Add(time.Hour),
+	}, &reservation)
+	var lot api.LotStatusResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1", nil, &lot)
+	walkInStatus := doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "WALK04"}, &api.ErrorResponse{})
+	var ticket api.TicketResponse
+	arriveStatus := doJSON(t, http.MethodPost, server.URL+"/api/reservations/"+reservation.ID+"/arrival", nil, &ticket)
+	var listed []api.ReservationResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/reservations?lotId=LOT1", nil, &listed)
+	cancelStatus := doJSON(t, http.MethodDelete, server.URL+"/api/reservations/"+reservation.ID, nil, &api.ErrorResponse{})
+	missingStatus := doJSON(t, http.MethodGet, server.URL+"/api/reservations/RES-999999", nil, &api.ErrorResponse{})
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, bookStatus)
+	assert.Equal(t, "pending", reservation.Status)
+	assert.Equal(t, 1, lot.HeldSpaces)
+	assert.Equal(t, http.StatusConflict, walkInStatus)
+	assert.Equal(t, http.StatusCreated, arriveStatus)
+	assert.Equal(t, "RES008", ticket.LicensePlate)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "arrived", listed[0].Status)
+	assert.Equal(t, ticket.ID, listed[0].TicketID)
+	assert.Equal(t, http.StatusConflict, cancelStatus)
+	assert.Equal(t, http.StatusNotFound, missingStatus)
+}
+
+func TestUC32_ReservationsPersistBetweenInvocations(t *testing.T) {
+	// Arrange
+	state := filepath.Join(t.TempDir(), "state")
+	code, _, _ := runCLI(t, "", "--state", state, "lot", "create", "LOT1", "1")
+	require.Equal(t, 0, code)
+	now := time.Now()
+	from, to := now.Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339)
+
+	// Act
+	reserveCode, reserveOut, _ := runCLI(t, "", "--state", state, "reserve", "CLI002", "LOT1", "--from", from, "--to", to)
+	parkCode, _, _ := runCLI(t, "", "--state", state, "park", "WALK05")
+	arriveCode, arriveOut, _ := runCLI(t, "", "--state", state, "arrive", "RES-000001")
+	listCode, listOut, _ := runCLI(t, "", "--state", state, "reservation", "list", "--lot", "LOT1")
+	badCode, _, _ := runCLI(t, "", "--state", state, "reserve", "CLI003", "LOT1", "--from", "tomorrow", "--to", to)
+
+	// Assert
+	assert.Equal(t, 0, reserveCode)
+	assert.Contains(t, reserveOut, "RES-000001")
+	assert.Equal(t, 1, parkCode)
+	assert.Equal(t, 0, arriveCode)
+	assert.Contains(t, arriveOut, "CLI002")
+	assert.Equal(t, 0, listCode)
+	assert.Contains(t, listOut, "arrived")
+	assert.Equal(t, 2, badCode)
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/storage"
+)
+
+func issuePermit(t *testing.T, service *services.ParkingService, permit *models.Permit) *models.Permit {
+	t.Helper()
+	issued, err := service.GetPermitService().Issue(permit)
+	require.NoError(t, err)
+	return issued
+}
+
+func TestUC33_PermitHoldersParkWithoutHourlyBilling(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	now := time.Now()
+	permit := issuePermit(t, service, &models.Permit{
+		LicensePlate: "SUB001", HolderName: "Subscriber", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0),
+	})
+
+	// Act
+	ticket, err := service.ParkCarWithTicket(models.NewCar("SUB001", "Subscriber"))
+	require.NoError(t, err)
+	_, bill, unparkErr := service.UnparkCarWithBilling("SUB001")
+
+	// Assert
+	assert.Equal(t, "PRM-000001", permit.ID)
+	assert.Equal(t, permit.ID, ticket.PermitID)
+	require.NoError(t, unparkErr)
+	assert.Equal(t, 0.0, bill.TotalAmount)
+	assert.Equal(t, "Permit", bill.Tariff)
+	assert.Equal(t, permit.ID, bill.PermitID)
+	history, err := service.GetParkingHistory("SUB001")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, permit.ID, history[0].PermitID)
+}
+
+func TestUC33_ExpiredPermitsFallBackToNormalBilling(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	now := time.Now()
+	issuePermit(t, service, &models.Permit{
+		LicensePlate: "SUB002", ValidFrom: now.AddDate(0, -2, 0), ValidUntil: now.AddDate(0, -1, 0),
+	})
+	revoked := issuePermit(t, service, &models.Permit{
+		LicensePlate: "SUB003", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0),
+	})
+	_, err := service.GetPermitService().Revoke(revoked.ID)
+	require.NoError(t, err)
+
+	// Act
+	expiredTicket, err := service.ParkCarWithTicket(models.NewCar("SUB002", "Lapsed"))
+	require.NoError(t, err)
+	revokedTicket, err := service.ParkCarWithTicket(models.NewCar("SUB003", "Revoked"))
+	require.NoError(t, err)
+	_, bill, err := service.UnparkCarWithBilling("SUB002")
+	_, revokeAgainErr := service.GetPermitService().Revoke(revoked.ID)
+
+	// Assert
+	assert.Empty(t, expiredTicket.PermitID)
+	assert.Empty(t, revokedTicket.PermitID)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, bill.TotalAmount)
+	assert.Empty(t, bill.PermitID)
+	assert.EqualError(t, revokeAgainErr, "permit is not active")
+}
+
+func TestUC33_PermitEndingMidStayBillsTheRest(t *testing.T) {
+	// Arrange
+	billing := services.NewBillingService(10.0, 5.0)
+	ticket := completedTicket("LOT1", weekdayMorning, 3*time.Hour)
+	ticket.PermitID = "PRM-000001"
+
+	// Act
+	covered := billing.GeneratePermitBill(ticket, nil, "PRM-000001", weekdayMorning.Add(4*time.Hour))
+	partial := billing.GeneratePermitBill(ticket, nil, "PRM-000001", weekdayMorning.Add(time.Hour))
+
+	// Assert
+	assert.Equal(t, 0.0, covered.TotalAmount)
+	assert.Equal(t, 20.0, partial.TotalAmount)
+	assert.Equal(t, weekdayMorning, partial.ParkedAt)
+	assert.Equal(t, 3*time.Hour, partial.Duration)
+	require.NotEmpty(t, partial.LineItems)
+	assert.Equal(t, "Permit PRM-000001", partial.LineItems[0].Description)
+	assert.Equal(t, weekdayMorning.Add(time.Hour), partial.LineItems[0].End)
+}
+
+func TestUC33_DedicatedSpacesAndAllowedLots(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	service.AddLot(models.NewParkingLot("LOT2", 2))
+	now := time.Now()
+	dedicated := issuePermit(t, service, &models.Permit{
+		LicensePlate: "SUB004", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0), LotIDs: []string{"LOT2"}, SpaceID: 1,
+	})
+	_, clashErr := service.GetPermitService().Issue(&models.Permit{
+		LicensePlate: "SUB005", ValidFrom: now, ValidUntil: now.AddDate(0, 1, 0), LotIDs: []string{"LOT2"}, SpaceID: 1,
+	})
+	_, noLotErr := service.GetPermitService().Issue(&models.Permit{
+		LicensePlate: "SUB005", ValidFrom: now, ValidUntil: now.AddDate(0, 1, 0), SpaceID: 2,
+	})
+	lot1Only := issuePermit(t, service, &models.Permit{
+		LicensePlate: "SUB005", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0), LotIDs: []string{"LOT1"},
+	})
+
+	// Act
+	_, err := service.ParkCarWithTicket(models.NewCar("WALK06", "Walk-in"))
+	require.NoError(t, err)
+	_, err = service.ParkCarWithTicket(models.NewCar("WALK07", "Walk-in"))
+	require.NoError(t, err)
+	walkIn, err := service.ParkCarWithTicket(models.NewCar("WALK08", "Walk-in"))
+	require.NoError(t, err)
+	holder, err := service.ParkCarWithTicket(models.NewCar("SUB004", "Holder"))
+	require.NoError(t, err)
+	_, fullErr := service.ParkCarWithTicket(models.NewCar("SUB005", "Elsewhere"))
+
+	// Assert
+	assert.EqualError(t, clashErr, "parking space is already dedicated to a permit")
+	assert.EqualError(t, noLotErr, "dedicated space must be in the permit's only lot")
+	assert.Equal(t, "LOT2", walkIn.LotID)
+	assert.Equal(t, "2", walkIn.SpaceID)
+	assert.Equal(t, "LOT2", holder.LotID)
+	assert.Equal(t, "1", holder.SpaceID)
+	assert.Equal(t, dedicated.ID, holder.PermitID)
+	assert.EqualError(t, fullErr, "no available parking space")
+	assert.Equal(t, []string{"LOT1"}, lot1Only.LotIDs)
+}
+
+func TestUC33_PermitsOutsideTheirLotsAreBilled(t *testing.T) {
+	// Arrange
+	_, server := newTestAPI(1)
+	defer server.Close()
+	now := time.Now()
+	var created api.LotStatusResponse
+	doJSON(t, http.MethodPost, server.URL+"/api/lots", api.LotRequest{ID: "LOT2", Capacity: 1}, &created)
+
+	// Act
+	var permit api.PermitResponse
+	issueStatus := doJSON(t, http.MethodPost, server.URL+"/api/permits", api.PermitRequest{
+		LicensePlate: "SUB006", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0), LotIDs: []string{"LOT2"},
+	}, &permit)
+	var first, second api.TicketResponse
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "SUB006"}, &first)
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "WALK09"}, &second)
+	badStatus := doJSON(t, http.MethodPost, server.URL+"/api/permits", api.PermitRequest{
+		LicensePlate: "SUB007", ValidFrom: now, ValidUntil: now.Add(-time.Hour),
+	}, &api.ErrorResponse{})
+	var listed []api.PermitResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/permits", nil, &listed)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, issueStatus)
+	assert.True(t, permit.IsValid)
+	assert.Equal(t, "LOT2", first.LotID)
+	assert.Equal(t, permit.ID, first.PermitID)
+	assert.Equal(t, "LOT1", second.LotID)
+	assert.Empty(t, second.PermitID)
+	assert.Equal(t, http.StatusBadRequest, badStatus)
+	require.Len(t, listed, 1)
+}
+
+func TestUC33_PermitsPersistBetweenInvocations(t *testing.T) {
+	// Arrange
+	state := filepath.Join(t.TempDir(), "state")
+	code, _, _ := runCLI(t, "", "--state", state, "lot", "create", "LOT1", "2")
+	require.Equal(t, 0, code)
+
+	// Act
+	issueCode, issueOut, _ := runCLI(t, "", "--state", state, "permit", "issue", "CLI004", "--holder", "Sub", "--lots", "LOT1", "--space", "2")
+	parkCode, _, _ := runCLI(t, "", "--state", state, "park", "WALK10")
+	blockedCode, _, _ := runCLI(t, "", "--state", state, "park", "WALK11")
+	holderCode, holderOut, _ := runCLI(t, "", "--state", state, "park", "CLI004")
+	unparkCode, unparkOut, _ := runCLI(t, "", "--state", state, "unpark", "CLI004")
+	listCode, listOut, _ := runCLI(t, "", "--state", state, "permit", "list")
+
+	// Assert
+	assert.Equal(t, 0, issueCode)
+	assert.Contains(t, issueOut, "PRM-000001")
+	assert.Equal(t, 0, parkCode)
+	assert.Equal(t, 1, blockedCode)
+	assert.Equal(t, 0, holderCode)
+	assert.Contains(t, holderOut, "CLI004")
+	assert.Equal(t, 0, unparkCode)
+	assert.Contains(t, unparkOut, "permit PRM-000001")
+	assert.Equal(t, 0, listCode)
+	assert.Contains(t, listOut, "Sub")
+}
+
+func TestUC33_PermitTicketsRoundTripThroughSQLite(t *testing.T) {
+	// Arrange
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "parking.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	service := services.NewParkingServiceWithRepository(store)
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	service.GetPaymentService().SetProvider(services.NewLocalPaymentProvider())
+	now := time.Now()
+	permit := issuePermit(t, service, &models.Permit{
+		LicensePlate: "SQL033", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0),
+	})
+	_, err = service.ParkCarWithTicket(models.NewCar("SQL033", "Subscriber"))
+	require.NoError(t, err)
+
+	// Act
+	active, activeErr := store.FindActiveByPlate("SQL033")
+	_, bill, unparkErr := service.UnparkCarWithBilling("SQL033")
+	history := store.FindByPlate("SQL033")
+
+	// Assert
+	require.NoError(t, activeErr)
+	assert.Equal(t, permit.ID, active.PermitID)
+	require.NoError(t, unparkErr, "a covered stay leaves without paying")
+	assert.Equal(t, "Permit", bill.Tariff)
+	assert.Equal(t, 0.0, bill.TotalAmount)
+	require.Len(t, history, 1)
+	assert.Equal(t, permit.ID, history[0].PermitID)
+}
@@ -0,0 +1,256 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func newFullLot(t *testing.T, capacity int) *services.ParkingService {
+	t.Helper()
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", capacity))
+	for i := 1; i <= capacity; i++ {
+		_, err := service.ParkCarWithTicket(models.NewCar(fmt.Sprintf("FULL%02d", i), "Parked"))
+		require.NoError(t, err)
+	}
+	return service
+}
+
+func TestUC34_FreedSpaceIsOfferedToTheHeadOfTheQueue(t *testing.T) {
+	// Arrange
+	service := newFullLot(t, 1)
+	waitlist := service.GetWaitlistService()
+	first, err := waitlist.Join(models.NewCar("WAIT01", "First"))
+	require.NoError(t, err)
+	second, err := waitlist.Join(models.NewCar("WAIT02", "Second"))
+	require.NoError(t, err)
+
+	// Act
+	_, _, err = service.UnparkCarWithBilling("FULL01")
+	require.NoError(t, err)
+	offered, _ := waitlist.GetEntry(first.ID)
+	_, walkInErr := service.ParkCarWithTicket(models.NewCar("WALK12", "Walk-in"))
+	_, notOfferedErr := waitlist.Accept(second.ID)
+	ticket, acceptErr := waitlist.Accept(first.ID)
+
+	// Assert
+	assert.Equal(t, models.WaitlistWaiting, first.Status)
+	assert.Equal(t, models.WaitlistOffered, offered.Status)
+	assert.Equal(t, "LOT1", offered.LotID)
+	assert.Equal(t, 1, offered.SpaceID)
+	assert.EqualError(t, walkInErr, "no available parking space")
+	assert.EqualError(t, notOfferedErr, "no available space has been offered yet")
+	require.NoError(t, acceptErr)
+	assert.Equal(t, "WAIT01", ticket.LicensePlate)
+	parked, _ := waitlist.GetEntry(first.ID)
+	assert.Equal(t, models.WaitlistParked, parked.Status)
+	assert.Equal(t, ticket.ID, parked.TicketID)
+	position, err := waitlist.Position(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, position)
+}
+
+func TestUC34_HandicapDriversAndPermitHoldersGoFirst(t *testing.T) {
+	// Arrange
+	service := newFullLot(t, 1)
+	now := time.Now()
+	_, err := service.GetPermitService().Issue(&models.Permit{LicensePlate: "WAIT05", ValidFrom: now.Add(-time.Hour), ValidUntil: now.Add(time.Hour)})
+	require.NoError(t, err)
+	waitlist := service.GetWaitlistService()
+	handicapCar := models.NewCar("WAIT04", "Accessible")
+	handicapCar.IsHandicap = true
+
+	// Act
+	plain, err := waitlist.Join(models.NewCar("WAIT03", "Plain"))
+	require.NoError(t, err)
+	handicap, err := waitlist.Join(handicapCar)
+	require.NoError(t, err)
+	permitHolder, err := waitlist.Join(models.NewCar("WAIT05", "Subscriber"))
+	require.NoError(t, err)
+	queue := waitlist.GetQueue()
+	_, _, err = service.UnparkCarWithBilling("FULL01")
+	require.NoError(t, err)
+
+	// Assert
+	assert.False(t, plain.Priority)
+	assert.True(t, handicap.Priority)
+	assert.True(t, permitHolder.Priority)
+	require.Len(t, queue, 3)
+	assert.Equal(t, []string{"WAIT04", "WAIT05", "WAIT03"}, []string{queue[0].Car.LicensePlate, queue[1].Car.LicensePlate, queue[2].Car.LicensePlate})
+	offered, _ := waitlist.GetEntry(handicap.ID)
+	assert.Equal(t, models.WaitlistOffered, offered.Status)
+}
+
+func TestUC34_UntakenOffersExpireAndPassOn(t *testing.T) {
+	// Arrange
+	service := newFullLot(t, 1)
+	waitlist := service.GetWaitlistService()
+	waitlist.SetPolicy(services.WaitlistPolicy{OfferTimeout: 5 * time.Minute})
+	first, err := waitlist.Join(models.NewCar("WAIT06", "Slow"))
+	require.NoError(t, err)
+	second, err := waitlist.Join(models.NewCar("WAIT07", "Next"))
+	require.NoError(t, err)
+	_, _, err = service.UnparkCarWithBilling("FULL01")
+	require.NoError(t, err)
+
+	// Act
+	expired := waitlist.Refresh(time.Now().Add(6 * time.Minute))
+	_, lateErr := waitlist.Accept(first.ID)
+	ticket, err := waitlist.Accept(second.ID)
+
+	// Assert
+	require.Len(t, expired, 1)
+	assert.Equal(t, first.ID, expired[0].ID)
+	assert.Equal(t, models.WaitlistExpired, expired[0].Status)
+	assert.EqualError(t, lateErr, "waitlist entry is not active")
+	require.NoError(t, err)
+	assert.Equal(t, "WAIT07", ticket.LicensePlate)
+}
+
+func TestUC34_EstimatedWaitGrowsWithPosition(t *testing.T) {
+	// Arrange
+	service := newFullLot(t, 2)
+	waitlist := service.GetWaitlistService()
+	first, err := waitlist.Join(models.NewCar("WAIT08", "First"))
+	require.NoError(t, err)
+	second, err := waitlist.Join(models.NewCar("WAIT09", "Second"))
+	require.NoError(t, err)
+
+	// Act
+	firstWait, err := waitlist.EstimatedWait(first.ID)
+	require.NoError(t, err)
+	secondWait, err := waitlist.EstimatedWait(second.ID)
+	require.NoError(t, err)
+	_, duplicateErr := waitlist.Join(models.NewCar("WAIT08", "First"))
+	_, parkedErr := waitlist.Join(models.NewCar("FULL01", "Parked"))
+	left, leaveErr := waitlist.Leave(first.ID)
+	movedUp, err := waitlist.EstimatedWait(second.ID)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 30*time.Minute, firstWait)
+	assert.Equal(t, time.Hour, secondWait)
+	assert.EqualError(t, duplicateErr, "vehicle is already waitlisted")
+	assert.EqualError(t, parkedErr, "vehicle already has an active ticket")
+	require.NoError(t, leaveErr)
+	assert.Equal(t, models.WaitlistLeft, left.Status)
+	assert.Equal(t, 30*time.Minute, movedUp)
+}
+
+func TestUC34_WaitlistOverHTTP(t *testing.T) {
+	// Arrange
+	parking, server := newTestAPI(1)
+	defer server.Close()
+	_, err := parking.ParkCarWithTicket(models.NewCar("FULL01", "Parked"))
+	require.NoError(t, err)
+
+	// Act
+	var entry api.WaitlistEntryResponse
+	joinStatus := doJSON(t, http.MethodPost, server.URL+"/api/waitlist", api.ParkRequest{LicensePlate: "WAIT10"}, &entry)
+	doJSON(t, http.MethodPost, server.URL+"/api/unpark", api.UnparkRequest{LicensePlate: "FULL01"}, nil)
+	var offered api.WaitlistEntryResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/waitlist/"+entry.ID, nil, &offered)
+	var ticket api.TicketResponse
+	acceptStatus := doJSON(t, http.MethodPost, server.URL+"/api/waitlist/"+entry.ID+"/acceptance", nil, &ticket)
+	var queue []api.WaitlistEntryResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/waitlist", nil, &queue)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, joinStatus)
+	assert.Equal(t, 1, entry.Position)
+	assert.Greater(t, entry.EstimatedWaitSeconds, 0.0)
+	assert.Equal(t, "offered", offered.Status)
+	require.NotNil(t, offered.OfferExpiresAt)
+	assert.Equal(t, http.StatusCreated, acceptStatus)
+	assert.Equal(t, "WAIT10", ticket.LicensePlate)
+	assert.Empty(t, queue)
+}
+
+func TestUC34_WaitlistPersistsBetweenInvocations(t *testing.T) {
+	// Arrange
+	state := filepath.Join(t.TempDir(), "state")
+	code, _, _ := runCLI(t, "", "--state", state, "lot", "create", "LOT1", "1")
+	require.Equal(t, 0, code)
+	code, _, _ = runCLI(t, "", "--state", state, "park", "FULL01")
+	require.Equal(t, 0, code)
+
+	// Act
+	joinCode, joinOut, _ := runCLI(t, "", "--state", state, "waitlist", "join", "CLI005")
+	unparkCode, _, _ := runCLI(t, "", "--state", state, "unpark", "FULL01")
+	walkInCode, _, _ := runCLI(t, "", "--state", state, "park", "WALK13")
+	listCode, listOut, _ := runCLI(t, "", "--state", state, "waitlist", "list")
+	acceptCode, acceptOut, _ := runCLI(t, "", "--state", state, "waitlist", "accept", "WL-000001")
+
+	// Assert
+	assert.Equal(t, 0, joinCode)
+	assert.Contains(t, joinOut, "waiting")
+	assert.Equal(t, 0, unparkCode)
+	assert.Equal(t, 1, walkInCode)
+	assert.Equal(t, 0, listCode)
+	assert.Contains(t, listOut, "LOT1/1")
+	assert.Equal(t, 0, acceptCode)
+	assert.Contains(t, acceptOut, "CLI005")
+}
+
+func TestUC34_OffersLeavePermitDedicationsInPlace(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	now := time.Now()
+	issuePermit(t, service, &models.Permit{
+		LicensePlate: "WAIT20", ValidFrom: now.Add(-time.Hour), ValidUntil: now.AddDate(0, 1, 0), LotIDs: []string{"LOT1"}, SpaceID: 1,
+	})
+	_, err := service.ParkCarWithTicket(models.NewCar("FULL20", "Parked"))
+	require.NoError(t, err)
+	waitlist := service.GetWaitlistService()
+
+	// Act: the holder is offered their own space, then walks away
+	holder, err := waitlist.Join(models.NewCar("WAIT20", "Subscriber"))
+	require.NoError(t, err)
+	_, err = waitlist.Leave(holder.ID)
+	require.NoError(t, err)
+	_, walkInErr := service.ParkCarWithTicket(models.NewCar("WALK20", "Walk-in"))
+
+	// Assert
+	assert.Equal(t, models.WaitlistOffered, holder.Status)
+	assert.Equal(t, 1, holder.SpaceID)
+	assert.EqualError(t, walkInErr, "no available parking space")
+	lot, _ := service.GetLotStatus("LOT1")
+	assert.Equal(t, map[int]string{1: "WAIT20"}, lot.GetDedicatedSpaces())
+}
+
+func TestUC34_SpaceOfAnAcceptedOfferGoesToTheNextCarOnceItLeaves(t *testing.T) {
+	// Arrange
+	service := newFullLot(t, 1)
+	waitlist := service.GetWaitlistService()
+	first, err := waitlist.Join(models.NewCar("WAIT21", "First"))
+	require.NoError(t, err)
+	second, err := waitlist.Join(models.NewCar("WAIT22", "Second"))
+	require.NoError(t, err)
+	_, _, err = service.UnparkCarWithBilling("FULL01")
+	require.NoError(t, err)
+	_, err = waitlist.Accept(first.ID)
+	require.NoError(t, err)
+
+	// Act: the first driver leaves well before the offer would have run out
+	_, _, err = service.UnparkCarWithBilling("WAIT21")
+	require.NoError(t, err)
+	offered, err := waitlist.GetEntry(second.ID)
+	require.NoError(t, err)
+	ticket, acceptErr := waitlist.Accept(second.ID)
+
+	// Assert
+	assert.Equal(t, models.WaitlistOffered, offered.Status)
+	assert.Equal(t, 1, offered.SpaceID)
+	require.NoError(t, acceptErr)
+	assert.Equal(t, "1", ticket.SpaceID)
+}
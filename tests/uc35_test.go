@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+// collectEvents subscribes a handler that forwards every matching event to the returned channel
+func collectEvents(service *services.ParkingService, filter events.Filter) (*events.Subscription, <-chan events.Event) {
+	received := make(chan events.Event, 64)
+	sub := service.GetEventBus().Subscribe(filter, func(event events.Event) {
+		received <- event
+	})
+	return sub, received
+}
+
+func nextEvent(t *testing.T, received <-chan events.Event) events.Event {
+	t.Helper()
+	select {
+	case event := <-received:
+		return event
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "no event delivered")
+		return events.Event{}
+	}
+}
+
+func TestUC35_ParkingAndLeavingPublishTypedEvents(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	_, received := collectEvents(service, events.Filter{})
+	before := time.Now()
+
+	// Act
+	ticket, err := service.ParkCarWithTicket(models.NewCar("EVT001", "Driver"))
+	require.NoError(t, err)
+	_, bill, err := service.UnparkCarWithBilling("EVT001")
+	require.NoError(t, err)
+	parked := nextEvent(t, received)
+	issued := nextEvent(t, received)
+	unparked := nextEvent(t, received)
+	billed := nextEvent(t, received)
+
+	// Assert
+	assert.Equal(t, events.CarParked, parked.Type)
+	assert.Equal(t, "LOT1", parked.LotID)
+	assert.False(t, parked.At.Before(before))
+	assert.Equal(t, events.CarParkedPayload{Car: *models.NewCar("EVT001", "Driver"), SpaceIDs: []int{1}}, parked.Payload)
+	assert.Equal(t, events.TicketIssued, issued.Type)
+	assert.Equal(t, ticket.ID, issued.Payload.(events.TicketIssuedPayload).Ticket.ID)
+	assert.Equal(t, events.CarUnparked, unparked.Type)
+	assert.Equal(t, []int{1}, unparked.Payload.(events.CarUnparkedPayload).SpaceIDs)
+	assert.Equal(t, events.BillGenerated, billed.Type)
+	payload := billed.Payload.(events.BillGeneratedPayload)
+	assert.Equal(t, ticket.ID, payload.TicketID)
+	assert.Equal(t, bill.TotalAmount, payload.TotalAmount)
+}
+
+func TestUC35_SubscribersFilterByLotAndType(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 1))
+	service.AddLot(models.NewParkingLot("LOT2", 1))
+	_, lot2 := collectEvents(service, events.Filter{LotIDs: []string{"LOT2"}, Types: []events.Type{events.CarParked}})
+	_, fullness := collectEvents(service, events.Filter{Types: []events.Type{events.LotFull, events.LotAvailable}})
+
+	// Act
+	require.NoError(t, service.ParkCar(models.NewCar("EVT002", "First")))
+	require.NoError(t, service.ParkCar(models.NewCar("EVT003", "Second")))
+	_, err := service.UnparkCar("EVT002")
+	require.NoError(t, err)
+	lot2Parked := nextEvent(t, lot2)
+	lot1Full := nextEvent(t, fullness)
+	lot2Full := nextEvent(t, fullness)
+	lot1Available := nextEvent(t, fullness)
+
+	// Assert
+	assert.Equal(t, "LOT2", lot2Parked.LotID)
+	assert.Equal(t, "EVT003", lot2Parked.Payload.(events.CarParkedPayload).Car.LicensePlate)
+	assert.Empty(t, lot2)
+	assert.Equal(t, events.LotFull, lot1Full.Type)
+	assert.Equal(t, "LOT1", lot1Full.LotID)
+	assert.Equal(t, events.LotFullPayload{Capacity: 1, AvailableSpaces: 0}, lot1Full.Payload)
+	assert.Equal(t, "LOT2", lot2Full.LotID)
+	assert.Equal(t, events.LotAvailable, lot1Available.Type)
+	assert.Equal(t, "LOT1", lot1Available.LotID)
+}
+
+func TestUC35_SlowSubscriberDoesNotBlockParking(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 10))
+	release := make(chan struct{})
+	slow := service.GetEventBus().SubscribeBuffered(events.Filter{Types: []events.Type{events.CarParked}}, 1, func(events.Event) {
+		<-release
+	})
+	defer close(release)
+	_, fast := collectEvents(service, events.Filter{Types: []events.Type{events.CarParked}})
+
+	// Act
+	parked := make(chan error, 1)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			if err := service.ParkCar(models.NewCar(fmt.Sprintf("EVT1%02d", i), "Driver")); err != nil {
+				parked <- err
+				return
+			}
+		}
+		parked <- nil
+	}()
+
+	// Assert
+	select {
+	case err := <-parked:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "parking blocked on a slow subscriber")
+	}
+	for i := 1; i <= 10; i++ {
+		event := nextEvent(t, fast)
+		assert.Equal(t, fmt.Sprintf("EVT1%02d", i), event.Payload.(events.CarParkedPayload).Car.LicensePlate)
+	}
+	assert.GreaterOrEqual(t, slow.Dropped(), uint64(8))
+}
+
+func TestUC35_AttendantsAndSuspiciousPlatesAreReported(t *testing.T) {
+	// Arrange
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	_, received := collectEvents(service, events.Filter{Types: []events.Type{events.AttendantAssigned, events.SuspiciousPlateDetected}})
+
+	// Act
+	service.AddAttendant(models.NewParkingAttendant("ATT1", "Alex", "LOT1"))
+	_, err := service.ParkCarWithAttendant(models.NewCar("EVT004", "Driver"), "ATT1")
+	require.NoError(t, err)
+	require.NoError(t, service.ParkCar(models.NewCar("FAKE99", "Suspect")))
+	onLot := nextEvent(t, received)
+	parking := nextEvent(t, received)
+	suspicious := nextEvent(t, received)
+
+	// Assert
+	assert.Equal(t, events.AttendantAssignedPayload{AttendantID: "ATT1", AttendantName: "Alex"}, onLot.Payload)
+	assert.Equal(t, "LOT1", onLot.LotID)
+	assert.Equal(t, "EVT004", parking.Payload.(events.AttendantAssignedPayload).LicensePlate)
+	assert.NotEmpty(t, parking.Payload.(events.AttendantAssignedPayload).SpaceID)
+	assert.Equal(t, events.SuspiciousPlateDetected, suspicious.Type)
+	assert.Equal(t, "FAKE99", suspicious.Payload.(events.SuspiciousPlateDetectedPayload).LicensePlate)
+}
+
+func TestUC35_UnsubscribedHandlersStopReceiving(t *testing.T) {
+	// Arrange
+	bus := events.NewBus()
+	received := make(chan events.Event, 4)
+	sub := bus.Subscribe(events.Filter{}, func(event events.Event) {
+		received <- event
+	})
+
+	// Act
+	bus.Publish(events.ThresholdCrossed, "LOT1", events.ThresholdCrossedPayload{Threshold: 0.8, Occupancy: 0.85, Rising: true})
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	bus.Publish(events.LotFull, "LOT1", events.LotFullPayload{Capacity: 1})
+	<-sub.Done()
+
+	// Assert
+	require.Len(t, received, 1)
+	event := <-received
+	assert.Equal(t, events.ThresholdCrossed, event.Type)
+	assert.True(t, event.Payload.(events.ThresholdCrossedPayload).Rising)
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/config"
+	"parking-lot-system/events"
+	"parking-lot-system/interfaces"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+// alertRecorder records full, available and threshold notifications in order
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts []string
+}
+
+func (r *alertRecorder) OnLotFull(lotID string) {
+	r.record("full")
+}
+
+func (r *alertRecorder) OnLotAvailable(lotID string) {
+	r.record("available")
+}
+
+func (r *alertRecorder) OnThresholdCrossed(lotID string, threshold, occupancy float64, rising bool) {
+	if rising {
+		r.record(fmt.Sprintf("above %.2f", threshold))
+	} else {
+		r.record(fmt.Sprintf("below %.2f", threshold))
+	}
+}
+
+func (r *alertRecorder) record(alert string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+}
+
+func (r *alertRecorder) Alerts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.alerts...)
+}
+
+func parkCars(t *testing.T, lot *models.ParkingLot, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		require.NoError(t, lot.ParkCar(models.NewCar(fmt.Sprintf("THR%03d", i), "Driver")))
+	}
+}
+
+func TestUC36_ThresholdAlertsUseHysteresis(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 10)
+	recorder := &alertRecorder{}
+	lot.AddObserver(recorder)
+	require.NoError(t, lot.SetOccupancyThresholds([]models.OccupancyThreshold{{Occupancy: 0.8, Hysteresis: 0.15}}))
+
+	// Act
+	parkCars(t, lot, 1, 8)
+	_, err := lot.UnparkCar("THR008")
+	require.NoError(t, err)
+	parkCars(t, lot, 9, 9)
+	raised := lot.GetRaisedThresholds()
+	_, err = lot.UnparkCar("THR009")
+	require.NoError(t, err)
+	_, err = lot.UnparkCar("THR007")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []string{"above 0.80", "below 0.80"}, recorder.Alerts())
+	assert.Equal(t, []models.OccupancyThreshold{{Occupancy: 0.8, Hysteresis: 0.15}}, raised)
+	assert.Empty(t, lot.GetRaisedThresholds())
+}
+
+func TestUC36_ThresholdsAndFullAreReportedInOrder(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 4)
+	recorder := &alertRecorder{}
+	lot.AddObserver(recorder)
+	require.NoError(t, lot.SetOccupancyThresholds([]models.OccupancyThreshold{{Occupancy: 0.75}, {Occupancy: 0.5}}))
+
+	// Act
+	parkCars(t, lot, 1, 4)
+	for i := 1; i <= 4; i++ {
+		_, err := lot.UnparkCar(fmt.Sprintf("THR%03d", i))
+		require.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, []string{"above 0.50", "above 0.75", "full", "available", "below 0.75", "below 0.50"}, recorder.Alerts())
+	assert.Equal(t, []models.OccupancyThreshold{
+		{Occupancy: 0.5, Hysteresis: models.DefaultThresholdHysteresis},
+		{Occupancy: 0.75, Hysteresis: models.DefaultThresholdHysteresis},
+	}, lot.GetOccupancyThresholds())
+}
+
+func TestUC36_InvalidThresholdsAreRejected(t *testing.T) {
+	// Arrange
+	lot := models.NewParkingLot("LOT1", 4)
+	parkCars(t, lot, 1, 3)
+
+	// Act
+	overErr := lot.SetOccupancyThresholds([]models.OccupancyThreshold{{Occupancy: 1.2}})
+	hysteresisErr := lot.SetOccupancyThresholds([]models.OccupancyThreshold{{Occupancy: 0.5, Hysteresis: 0.5}})
+	err := lot.SetOccupancyThresholds([]models.OccupancyThreshold{{Occupancy: 0.5}})
+
+	// Assert
+	assert.EqualError(t, overErr, "threshold occupancy must be above 0 and at most 1")
+	assert.EqualError(t, hysteresisErr, "threshold hysteresis must be at least 0 and below its occupancy")
+	require.NoError(t, err)
+	assert.Len(t, lot.GetRaisedThresholds(), 1, "a lot already past a new threshold starts with its alert raised")
+}
+
+func TestUC36_OwnerAndSecurityObserversReceiveThresholdAlerts(t *testing.T) {
+	// Arrange
+	var owner interfaces.ParkingLotObserver = interfaces.NewOwnerObserver("Owner")
+	var security interfaces.ParkingLotObserver = interfaces.NewSecurityObserver("Guard", "SEC1")
+
+	// Act
+	_, ownerGetsAlerts := owner.(interfaces.ThresholdObserver)
+	_, securityGetsAlerts := security.(interfaces.ThresholdObserver)
+
+	// Assert
+	assert.True(t, ownerGetsAlerts)
+	assert.True(t, securityGetsAlerts)
+}
+
+func TestUC36_ThresholdsPublishEventsAndShowInLotStatus(t *testing.T) {
+	// Arrange
+	parking, server := newTestAPI(2)
+	defer server.Close()
+	require.NoError(t, parking.SetOccupancyThresholds("LOT1", []models.OccupancyThreshold{{Occupancy: 0.5}}))
+	_, received := collectEvents(parking, events.Filter{Types: []events.Type{events.ThresholdCrossed}})
+
+	// Act
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "THR100"}, nil)
+	var status api.LotStatusResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1", nil, &status)
+	crossed := nextEvent(t, received)
+	missingErr := parking.SetOccupancyThresholds("LOT9", nil)
+
+	// Assert
+	assert.Equal(t, []float64{0.5}, status.RaisedAlerts)
+	assert.Equal(t, "LOT1", crossed.LotID)
+	assert.Equal(t, events.ThresholdCrossedPayload{Threshold: 0.5, Occupancy: 0.5, Rising: true}, crossed.Payload)
+	assert.EqualError(t, missingErr, "lot not found")
+}
+
+func TestUC36_ThresholdsLoadFromConfig(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(`
+lots:
+  - id: LOT1
+    capacity: 10
+    thresholds:
+      - occupancy: 0.95
+      - occupancy: 0.8
+        hysteresis: 0.1
+`))
+	require.NoError(t, err)
+	service := services.NewParkingService()
+
+	// Act
+	changes, err := cfg.Apply(service)
+	require.NoError(t, err)
+	again, err := cfg.Apply(service)
+	require.NoError(t, err)
+	_, badErr := config.Parse([]byte(`
+lots:
+  - id: LOT1
+    capacity: 10
+    thresholds:
+      - occupancy: 80
+`))
+
+	// Assert
+	assert.Contains(t, changes.Updated, "occupancy thresholds")
+	assert.NotContains(t, again.Updated, "occupancy thresholds")
+	lot, err := service.GetLotStatus("LOT1")
+	require.NoError(t, err)
+	assert.Equal(t, []models.OccupancyThreshold{{Occupancy: 0.8, Hysteresis: 0.1}, {Occupancy: 0.95, Hysteresis: 0.05}}, lot.GetOccupancyThresholds())
+	require.Error(t, badErr)
+	assert.Contains(t, badErr.Error(), "threshold occupancy must be above 0 and at most 1")
+}
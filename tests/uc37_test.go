@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/config"
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+// fastWebhookPolicy retries quickly so tests do not wait on real backoff
+var fastWebhookPolicy = services.WebhookPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Timeout:        time.Second,
+}
+
+func newWebhookReceiver(t *testing.T, secret string) (*services.WebhookReceiver, *httptest.Server) {
+	t.Helper()
+	receiver := services.NewWebhookReceiver(secret)
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	return receiver, server
+}
+
+func TestUC37_EventsArePostedSignedToMatchingWebhooks(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "s3cret")
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	other := models.NewParkingLot("LOT2", 2)
+	service.AddLot(other)
+	webhooks := service.GetWebhookService()
+	webhooks.SetPolicy(fastWebhookPolicy)
+	_, err := webhooks.Register(models.Webhook{
+		ID: "ops", URL: endpoint.URL, Secret: "s3cret", LotIDs: []string{"LOT1"}, Events: []string{"car_parked"},
+	})
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, service.ParkCar(models.NewCar("HOOK01", "Driver")))
+	require.NoError(t, other.ParkCar(models.NewCar("HOOK02", "Driver")))
+	webhooks.Close()
+
+	// Assert
+	deliveries := receiver.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "car_parked", deliveries[0].EventType)
+	assert.Equal(t, "LOT1", deliveries[0].LotID)
+	assert.True(t, deliveries[0].Signed)
+	var payload struct {
+		Car struct{ LicensePlate string }
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	assert.Equal(t, "HOOK01", payload.Car.LicensePlate)
+	assert.Empty(t, webhooks.GetDeadLetters())
+}
+
+func TestUC37_FailedDeliveriesAreRetried(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "")
+	receiver.FailNext(2)
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 2))
+	webhooks := service.GetWebhookService()
+	webhooks.SetPolicy(fastWebhookPolicy)
+	_, err := webhooks.Register(models.Webhook{ID: "ops", URL: endpoint.URL, Events: []string{"ticket_issued"}})
+	require.NoError(t, err)
+
+	// Act
+	_, err = service.ParkCarWithTicket(models.NewCar("HOOK03", "Driver"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(receiver.Deliveries()) > 0 }, 2*time.Second, 5*time.Millisecond)
+
+	// Assert
+	deliveries := receiver.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "ticket_issued", deliveries[0].EventType)
+	assert.False(t, deliveries[0].Signed)
+	assert.Empty(t, webhooks.GetDeadLetters())
+}
+
+func TestUC37_ExhaustedRetriesAreDeadLetteredAndRedelivered(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "s3cret")
+	receiver.FailNext(4)
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 1))
+	webhooks := service.GetWebhookService()
+	webhooks.SetPolicy(fastWebhookPolicy)
+	_, err := webhooks.Register(models.Webhook{ID: "ops", URL: endpoint.URL, Secret: "s3cret", Events: []string{"lot_full"}})
+	require.NoError(t, err)
+	require.NoError(t, service.ParkCar(models.NewCar("HOOK04", "Driver")))
+	require.Eventually(t, func() bool { return len(webhooks.GetDeadLetters()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	letter := webhooks.GetDeadLetters()[0]
+
+	// Act
+	_, failedErr := webhooks.Redeliver(letter.ID)
+	redelivered, err := webhooks.Redeliver(letter.ID)
+	require.NoError(t, err)
+	_, againErr := webhooks.Redeliver(letter.ID)
+	_, missingErr := webhooks.Redeliver("DLQ-999999")
+
+	// Assert
+	assert.Equal(t, "DLQ-000001", letter.ID)
+	assert.Equal(t, "lot_full", letter.EventType)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Contains(t, letter.LastError, "503")
+	require.Error(t, failedErr)
+	assert.Contains(t, failedErr.Error(), "webhook delivery failed")
+	assert.Equal(t, 5, redelivered.Attempts)
+	assert.False(t, redelivered.IsPending())
+	assert.EqualError(t, againErr, "dead letter was already delivered")
+	assert.EqualError(t, missingErr, "dead letter not found")
+	assert.Empty(t, webhooks.GetDeadLetters())
+	deliveries := receiver.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.True(t, deliveries[0].Signed)
+}
+
+func TestUC37_ReceiverRefusesBadSignatures(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "s3cret")
+	body := []byte(`{"type":"lot_full","lotId":"LOT1","payload":{}}`)
+	send := func(signature string) int {
+		req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		if signature != "" {
+			req.Header.Set(services.WebhookSignatureHeader, signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Act
+	unsigned := send("")
+	forged := send(services.SignWebhookBody("guess", body))
+	signed := send(services.SignWebhookBody("s3cret", body))
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, unsigned)
+	assert.Equal(t, http.StatusUnauthorized, forged)
+	assert.Equal(t, http.StatusNoContent, signed)
+	assert.Len(t, receiver.Deliveries(), 1)
+}
+
+func TestUC37_WebhooksLoadFromConfigAndShowOverHTTP(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "")
+	receiver.FailNext(2)
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+lots:
+  - id: LOT1
+    capacity: 1
+webhooks:
+  maxAttempts: 1
+  endpoints:
+    - id: ops
+      url: %s
+      secret: s3cret
+      lots: [LOT1]
+      events: [lot_full]
+`, endpoint.URL)))
+	require.NoError(t, err)
+	parking := services.NewParkingService()
+	server := httptest.NewServer(api.NewServer(parking, services.NewBillingService(10.0, 5.0), services.NewPoliceService(parking)))
+	defer server.Close()
+	_, badErr := config.Parse([]byte(`
+webhooks:
+  endpoints:
+    - id: ops
+      url: ftp://example.com
+      events: [lot_exploded]
+`))
+
+	// Act
+	changes, err := cfg.Apply(parking)
+	require.NoError(t, err)
+	again, err := cfg.Apply(parking)
+	require.NoError(t, err)
+	require.NoError(t, parking.ParkCar(models.NewCar("HOOK05", "Driver")))
+	parking.GetWebhookService().Close()
+	var webhooks []api.WebhookResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/webhooks", nil, &webhooks)
+	var letters []api.DeadLetterResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/webhooks/dead-letters", nil, &letters)
+	redeliverStatus := doJSON(t, http.MethodPost, server.URL+"/api/webhooks/dead-letters/DLQ-000001/redelivery", nil, nil)
+	missingStatus := doJSON(t, http.MethodPost, server.URL+"/api/webhooks/dead-letters/DLQ-000009/redelivery", nil, nil)
+
+	// Assert
+	assert.Contains(t, changes.Added, "webhook ops")
+	assert.Contains(t, changes.Updated, "webhook policy")
+	assert.Empty(t, again.Added)
+	assert.NotContains(t, again.Updated, "webhooks")
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, api.WebhookResponse{ID: "ops", URL: endpoint.URL, LotIDs: []string{"LOT1"}, Events: []string{"lot_full"}, Signed: true}, webhooks[0])
+	require.Len(t, letters, 1)
+	assert.Equal(t, "lot_full", letters[0].EventType)
+	assert.Equal(t, 1, letters[0].Attempts)
+	assert.Equal(t, http.StatusBadGateway, redeliverStatus)
+	assert.Equal(t, http.StatusNotFound, missingStatus)
+	require.Error(t, badErr)
+	assert.Contains(t, badErr.Error(), "webhook URL must be an http or https URL")
+	assert.Contains(t, badErr.Error(), `unknown event type "lot_exploded"`)
+}
+
+func TestUC37_DeadLettersPersistBetweenInvocations(t *testing.T) {
+	// Arrange
+	receiver, endpoint := newWebhookReceiver(t, "")
+	receiver.FailNext(1)
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state")
+	configPath := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(`
+lots:
+  - id: LOT1
+    capacity: 2
+webhooks:
+  maxAttempts: 1
+  endpoints:
+    - id: ops
+      url: %s
+      events: [car_parked]
+`, endpoint.URL)), 0o644))
+
+	// Act
+	parkCode, _, _ := runCLI(t, "", "--state", state, "--config", configPath, "park", "HOOK06")
+	listCode, listOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "webhook", "dead-letters")
+	hooksCode, hooksOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "webhook", "list")
+	redeliverCode, _, _ := runCLI(t, "", "--state", state, "--config", configPath, "webhook", "redeliver", "DLQ-000001")
+	afterCode, afterOut, _ := runCLI(t, "", "--state", state, "--output", "json", "webhook", "dead-letters")
+
+	// Assert
+	assert.Equal(t, 0, parkCode)
+	assert.Equal(t, 0, listCode)
+	assert.Contains(t, listOut, "DLQ-000001")
+	assert.Contains(t, listOut, "car_parked")
+	assert.Equal(t, 0, hooksCode)
+	assert.Contains(t, hooksOut, endpoint.URL)
+	assert.Equal(t, 0, redeliverCode)
+	assert.Equal(t, 0, afterCode)
+	assert.NotContains(t, afterOut, "DLQ-000001")
+	require.Len(t, receiver.Deliveries(), 1)
+	assert.Equal(t, "car_parked", receiver.Deliveries()[0].EventType)
+}
+
+func TestUC37_EventsOverflowingAWebhookQueueAreDeadLettered(t *testing.T) {
+	// Arrange: the endpoint holds every delivery until released
+	release := make(chan struct{})
+	var received int64
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt64(&received, 1)
+	}))
+	defer endpoint.Close()
+	service := services.NewParkingService()
+	webhooks := service.GetWebhookService()
+	webhooks.SetPolicy(services.WebhookPolicy{MaxAttempts: 1, Timeout: 5 * time.Second})
+	_, err := webhooks.Register(models.Webhook{ID: "slow", URL: endpoint.URL})
+	require.NoError(t, err)
+	published := events.DefaultBuffer + 40
+
+	// Act
+	for i := 0; i < published; i++ {
+		service.GetEventBus().Publish(events.TicketIssued, "LOT1", nil)
+	}
+	overflowed := webhooks.GetDeadLetters()
+	close(release)
+	webhooks.Close()
+
+	// Assert
+	assert.GreaterOrEqual(t, len(overflowed), published-events.DefaultBuffer-1)
+	assert.Equal(t, published, int(atomic.LoadInt64(&received))+len(overflowed), "every event is either posted or dead-lettered")
+	assert.Equal(t, 0, overflowed[0].Attempts)
+	assert.Contains(t, overflowed[0].LastError, "webhook queue full")
+	redelivered, err := webhooks.Redeliver(overflowed[0].ID)
+	require.NoError(t, err)
+	assert.False(t, redelivered.IsPending())
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+// sequentialTicketIDs numbers tickets so tests can predict their IDs
+type sequentialTicketIDs struct {
+	next int
+}
+
+func (s *sequentialTicketIDs) NewTicketID() string {
+	s.next++
+	return fmt.Sprintf("T-%d", s.next)
+}
+
+// retype replaces the character at i with another from the ticket ID alphabet
+func retype(id string, i int) string {
+	replacement := byte('0')
+	if id[i] == '0' {
+		replacement = '1'
+	}
+	return id[:i] + string(replacement) + id[i+1:]
+}
+
+func TestUC38_TicketIDsAreUniqueAndOpaque(t *testing.T) {
+	// Arrange
+	seen := make(map[string]bool)
+
+	// Act
+	for i := 0; i < 1000; i++ {
+		ticket := models.NewParkingTicket("SAME001", "LOT1", "1")
+		seen[ticket.ID] = true
+
+		// Assert
+		require.NoError(t, models.CheckTicketID(ticket.ID))
+		assert.True(t, strings.HasPrefix(ticket.ID, "TK-"))
+		assert.NotContains(t, ticket.ID, "SAME001")
+	}
+	assert.Len(t, seen, 1000)
+}
+
+func TestUC38_MistypedTicketIDsAreRejected(t *testing.T) {
+	// Arrange
+	id := models.NewTicketIDsFrom(nil, rand.New(rand.NewSource(1))).NewTicketID()
+	swapped := id[:5] + id[6:7] + id[5:6] + id[7:]
+	require.NotEqual(t, id, swapped)
+
+	// Act
+	retypedErr := models.CheckTicketID(retype(id, 8))
+	lowerErr := models.CheckTicketID(strings.ToLower(id))
+	shortErr := models.CheckTicketID(id[:len(id)-2])
+	legacyErr := models.CheckTicketID("ABC123_LOT1_1_1700000000")
+	swappedErr := models.CheckTicketID(swapped)
+
+	// Assert
+	assert.EqualError(t, retypedErr, "ticket id check character does not match")
+	assert.NoError(t, lowerErr, "scanners may read IDs in either case")
+	assert.EqualError(t, shortErr, "ticket id is not in the TK- format")
+	assert.EqualError(t, legacyErr, "ticket id is not in the TK- format")
+	assert.EqualError(t, swappedErr, "ticket id check character does not match")
+}
+
+func TestUC38_SignedTicketIDsRejectForgeries(t *testing.T) {
+	// Arrange
+	gate := models.NewTicketIDs([]byte("gate-secret-0123456789"))
+	forger := models.NewTicketIDs([]byte("guessed-secret-000000"))
+	id := gate.NewTicketID()
+
+	// Act
+	validErr := gate.VerifyTicketID(id)
+	forgedErr := gate.VerifyTicketID(forger.NewTicketID())
+	unsignedErr := gate.VerifyTicketID(models.NewTicketIDs(nil).NewTicketID())
+	mistypedErr := gate.VerifyTicketID(retype(id, 4))
+
+	// Assert
+	assert.NoError(t, validErr)
+	assert.Len(t, id, len("TK-")+13+6+1)
+	assert.EqualError(t, forgedErr, "ticket id signature does not match")
+	assert.EqualError(t, unsignedErr, "ticket id is not signed")
+	assert.EqualError(t, mistypedErr, "ticket id check character does not match")
+}
+
+func TestUC38_TicketIDGenerationIsInjectable(t *testing.T) {
+	// Arrange
+	first := models.NewTicketIDsFrom([]byte("gate-secret-0123456789"), rand.New(rand.NewSource(7)))
+	second := models.NewTicketIDsFrom([]byte("gate-secret-0123456789"), rand.New(rand.NewSource(7)))
+	service := services.NewParkingService()
+	service.AddLot(models.NewParkingLot("LOT1", 1))
+	service.SetTicketIDGenerator(&sequentialTicketIDs{})
+
+	// Act
+	seeded := []string{first.NewTicketID(), first.NewTicketID()}
+	reseeded := []string{second.NewTicketID(), second.NewTicketID()}
+	ticket, err := service.ParkCarWithTicket(models.NewCar("SEQ001", "Driver"))
+	require.NoError(t, err)
+	_, _, err = service.UnparkCarWithBilling("SEQ001")
+	require.NoError(t, err)
+	again, err := service.ParkCarWithTicket(models.NewCar("SEQ001", "Driver"))
+	require.NoError(t, err)
+	history, err := service.GetParkingHistory("SEQ001")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, seeded, reseeded)
+	assert.NotEqual(t, seeded[0], seeded[1])
+	assert.Equal(t, "T-1", ticket.ID)
+	assert.Equal(t, "T-2", again.ID)
+	assert.Len(t, history, 2, "a second visit to the same space within a second keeps both tickets")
+	assert.EqualError(t, service.VerifyTicketID("T-1"), "ticket id is not in the TK- format",
+		"generators that cannot verify their own IDs fall back to the check character")
+}
+
+func TestUC38_GatesVerifyTicketsOverHTTP(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(`
+tickets:
+  secret: gate-secret-0123456789
+`))
+	require.NoError(t, err)
+	parking, server := newTestAPI(2)
+	defer server.Close()
+	changes, err := cfg.Apply(parking)
+	require.NoError(t, err)
+	again, err := cfg.Apply(parking)
+	require.NoError(t, err)
+	_, shortErr := config.Parse([]byte(`
+tickets:
+  secret: short
+`))
+
+	// Act
+	var ticket api.TicketResponse
+	doJSON(t, http.MethodPost, server.URL+"/api/park", api.ParkRequest{LicensePlate: "GATE01"}, &ticket)
+	var valid, forged api.TicketVerificationResponse
+	validStatus := doJSON(t, http.MethodGet, server.URL+"/api/tickets/"+ticket.ID+"/verification", nil, &valid)
+	forgedID := models.NewTicketIDs([]byte("guessed-secret-000000")).NewTicketID()
+	doJSON(t, http.MethodGet, server.URL+"/api/tickets/"+url.PathEscape(forgedID)+"/verification", nil, &forged)
+
+	// Assert
+	assert.Contains(t, changes.Updated, "ticket ids")
+	assert.NotContains(t, again.Updated, "ticket ids")
+	assert.Equal(t, http.StatusOK, validStatus)
+	assert.Equal(t, api.TicketVerificationResponse{TicketID: ticket.ID, Valid: true}, valid)
+	assert.False(t, forged.Valid)
+	assert.Equal(t, "ticket id signature does not match", forged.Reason)
+	require.Error(t, shortErr)
+	assert.Contains(t, shortErr.Error(), "ticket secret must be at least 16 characters")
+}
+
+func TestUC38_CLIVerifiesTicketsWithTheConfiguredSecret(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state")
+	configPath := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+lots:
+  - id: LOT1
+    capacity: 2
+tickets:
+  secret: gate-secret-0123456789
+`), 0o644))
+	code, parkOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "--output", "csv", "park", "GATE02")
+	require.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(parkOut), "\n")
+	require.Len(t, lines, 2)
+	id := strings.Split(lines[1], ",")[0]
+
+	// Act
+	validCode, validOut, _ := runCLI(t, "", "--state", state, "--config", configPath, "ticket", "verify", id)
+	forgedCode, _, forgedErr := runCLI(t, "", "--state", state, "--config", configPath,
+		"ticket", "verify", models.NewTicketIDs(nil).NewTicketID())
+
+	// Assert
+	assert.True(t, strings.HasPrefix(id, "TK-"), id)
+	assert.Equal(t, 0, validCode)
+	assert.Contains(t, validOut, id)
+	assert.Equal(t, 1, forgedCode)
+	assert.Contains(t, forgedErr, "ticket id is not signed")
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/config"
+	"parking-lot-system/events"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+)
+
+func newClockedService(t *testing.T, capacity int) (*services.ParkingService, *models.FakeClock) {
+	t.Helper()
+	clock := models.NewFakeClock(weekdayMorning)
+	service := services.NewParkingService()
+	service.SetClock(clock)
+	service.AddLot(models.NewParkingLot("LOT1", capacity))
+	return service, clock
+}
+
+func TestUC39_FakeClockMovesOnlyWhenTold(t *testing.T) {
+	// Arrange
+	clock := models.NewFakeClock(weekdayMorning)
+	lot := models.NewParkingLot("LOT1", 2)
+	lot.SetClock(clock)
+
+	// Act
+	require.NoError(t, lot.ParkCar(models.NewCar("CLK001", "Driver")))
+	later := clock.Advance(90 * time.Minute)
+	space := models.NewParkingSpace(9)
+	space.SetClock(clock)
+	space.Park(models.NewCar("CLK002", "Driver"))
+	clock.Set(weekdayMorning)
+
+	// Assert
+	_, parkedAt := lot.Spaces[0].GetOccupancy()
+	assert.Equal(t, weekdayMorning, parkedAt)
+	assert.Equal(t, weekdayMorning.Add(90*time.Minute), later)
+	assert.Equal(t, later, space.ParkedAt)
+	assert.Equal(t, weekdayMorning, clock.Now())
+	assert.Equal(t, clock, lot.GetClock())
+	assert.Equal(t, models.SystemClock, models.NewParkingLot("LOT2", 1).GetClock())
+}
+
+func TestUC39_MultiHourStaysAreBilledWithoutWaiting(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 2)
+	ticket, err := service.ParkCarWithTicket(models.NewCar("CLK003", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	clock.Advance(3*time.Hour + 30*time.Minute)
+	fee := service.GetBillingService().GenerateBill(ticket).TotalAmount
+	_, bill, err := service.UnparkCarWithBilling("CLK003")
+	require.NoError(t, err)
+	history, err := service.GetParkingHistory("CLK003")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, weekdayMorning, ticket.ParkedAt)
+	assert.Equal(t, 40.0, fee, "four started hours at $10")
+	assert.Equal(t, 3*time.Hour+30*time.Minute, bill.Duration)
+	assert.Equal(t, 40.0, bill.TotalAmount)
+	assert.Equal(t, weekdayMorning.Add(3*time.Hour+30*time.Minute), history[0].UnparkedAt)
+}
+
+func TestUC39_PoliceTimeWindowsFollowTheClock(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 3)
+	police := services.NewPoliceService(service)
+	require.NoError(t, service.ParkCar(models.NewCar("CLK004", "Early")))
+	clock.Advance(45 * time.Minute)
+	require.NoError(t, service.ParkCar(models.NewCar("CLK005", "Late")))
+	clock.Advance(5 * time.Minute)
+
+	// Act
+	recent, err := police.FindCarsParkedInLastMinutes(30)
+	require.NoError(t, err)
+	activity, err := police.GetRecentParkingActivity(time.Hour)
+	require.NoError(t, err)
+	location, err := service.FindCarWithLocation("CLK004")
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, recent, 1)
+	assert.Equal(t, "CLK005", recent[0].Car.LicensePlate)
+	assert.Len(t, activity, 2)
+	assert.Equal(t, weekdayMorning, location.ParkedAt)
+}
+
+func TestUC39_EventsPermitsAndReplacedBillingUseTheClock(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 2)
+	_, received := collectEvents(service, events.Filter{Types: []events.Type{events.CarParked}})
+	issuePermit(t, service, &models.Permit{
+		LicensePlate: "CLK006", ValidFrom: weekdayMorning, ValidUntil: weekdayMorning.Add(24 * time.Hour),
+	})
+	cfg, err := config.Parse([]byte(`
+rates:
+  hourlyRate: 2
+  minimumCharge: 1
+`))
+	require.NoError(t, err)
+	_, err = cfg.Apply(service)
+	require.NoError(t, err)
+
+	// Act
+	clock.Advance(48 * time.Hour)
+	ticket, err := service.ParkCarWithTicket(models.NewCar("CLK006", "Holder"))
+	require.NoError(t, err)
+	parked := nextEvent(t, received)
+	clock.Advance(2 * time.Hour)
+	_, bill, err := service.UnparkCarWithBilling("CLK006")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Empty(t, ticket.PermitID, "the permit expired on the service's clock")
+	assert.Equal(t, weekdayMorning.Add(48*time.Hour), parked.At)
+	assert.Equal(t, 4.0, bill.TotalAmount, "two hours at the configured $2")
+}
+
+func TestUC39_TicketsAndLocationsAreTimedOnTheClock(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 2)
+	_, err := service.ParkCarWithTicket(models.NewCar("CLK007", "Driver"))
+	require.NoError(t, err)
+
+	// Act
+	clock.Advance(2 * time.Hour)
+	ticket, err := service.GetActiveTicket("CLK007")
+	require.NoError(t, err)
+	location, err := service.FindCarWithLocation("CLK007")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, weekdayMorning, ticket.ParkedAt)
+	assert.Equal(t, weekdayMorning, location.ParkedAt)
+	assert.Equal(t, 2*time.Hour, ticket.GetTicketInfoAt(clock.Now())["Duration"])
+}
+
+func TestUC39_DaysOfActivityRunInstantly(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	started := time.Now()
+
+	// Act: three days of two cars an hour, each staying two hours
+	revenue := 0.0
+	for hour := 0; hour < 72; hour++ {
+		for i := 0; i < 2; i++ {
+			if hour >= 2 {
+				_, bill, err := service.UnparkCarWithBilling(fmt.Sprintf("DAY%02d%d", hour-2, i))
+				require.NoError(t, err)
+				revenue += bill.TotalAmount
+			}
+			_, err := service.ParkCarWithTicket(models.NewCar(fmt.Sprintf("DAY%02d%d", hour, i), "Driver"))
+			require.NoError(t, err)
+		}
+		clock.Advance(time.Hour)
+	}
+	secondDay, err := service.GetTicketsParkedBetween(weekdayMorning.Add(24*time.Hour), weekdayMorning.Add(48*time.Hour))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 70*2*20.0, revenue)
+	assert.Len(t, secondDay, 48)
+	assert.Equal(t, weekdayMorning.Add(72*time.Hour), clock.Now())
+	assert.Less(t, time.Since(started), 10*time.Second)
+}
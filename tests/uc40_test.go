@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/config"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/simulation"
+)
+
+// singleLotScenario simulates one untyped lot billed at $10 an hour
+func singleLotScenario(capacity int) simulation.Scenario {
+	return simulation.Scenario{
+		NewService: func() (*services.ParkingService, error) {
+			service := services.NewParkingService()
+			service.SetBillingService(services.NewBillingService(10.0, 5.0))
+			service.AddLot(models.NewParkingLot("LOT1", capacity))
+			return service, nil
+		},
+		Start: weekdayMorning,
+	}
+}
+
+func TestUC40_ArrivalDwellAndMixSpecsAreParsed(t *testing.T) {
+	// Arrange
+	rng := rand.New(rand.NewSource(1))
+
+	// Act
+	poisson, poissonErr := simulation.ParseArrivals("poisson:30")
+	empirical, empiricalErr := simulation.ParseDistribution("empirical:30m,2h")
+	uniform, uniformErr := simulation.ParseDistribution("uniform:1h-3h")
+	mix, mixErr := simulation.ParseVehicleMix("medium:70,large:20,handicap:10")
+	_, unknownErr := simulation.ParseDistribution("normal:2h")
+	_, rateErr := simulation.ParseArrivals("poisson:-4")
+	_, sizeErr := simulation.ParseVehicleMix("jumbo:10")
+
+	// Assert
+	require.NoError(t, poissonErr)
+	require.NoError(t, empiricalErr)
+	require.NoError(t, uniformErr)
+	require.NoError(t, mixErr)
+	assert.Equal(t, simulation.Exponential{Mean: 2 * time.Minute}, poisson)
+	for i := 0; i < 100; i++ {
+		assert.Contains(t, []time.Duration{30 * time.Minute, 2 * time.Hour}, empirical.Sample(rng))
+		sample := uniform.Sample(rng)
+		assert.True(t, sample >= time.Hour && sample <= 3*time.Hour, sample)
+	}
+	require.Len(t, mix, 3)
+	assert.Equal(t, simulation.VehicleShare{Size: models.MediumVehicle, Handicap: true, Weight: 10}, mix[2])
+	assert.EqualError(t, unknownErr, `unknown distribution "normal:2h"`)
+	assert.Error(t, rateErr)
+	assert.Error(t, sizeErr)
+}
+
+func TestUC40_TheSameSeedReplaysTheSameRun(t *testing.T) {
+	// Arrange
+	scenario := singleLotScenario(5)
+	scenario.Duration = 12 * time.Hour
+	scenario.Arrivals = simulation.Poisson(6)
+	scenario.Dwell = simulation.Exponential{Mean: 90 * time.Minute}
+	scenario.Seed = 42
+	reseeded := scenario
+	reseeded.Seed = 43
+
+	// Act
+	first, err := simulation.Run(scenario, models.NewEvenDistributionStrategy())
+	require.NoError(t, err)
+	second, err := simulation.Run(scenario, models.NewEvenDistributionStrategy())
+	require.NoError(t, err)
+	other, err := simulation.Run(reseeded, models.NewEvenDistributionStrategy())
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+	assert.Greater(t, first.Arrivals, 0)
+	assert.Equal(t, first.Arrivals, first.Parked+first.TurnedAway)
+}
+
+func TestUC40_StrategiesAreComparedOnTheSameArrivals(t *testing.T) {
+	// Arrange: every driver holds a handicap permit; only ACCESS has accessible spaces
+	scenario := simulation.Scenario{
+		NewService: func() (*services.ParkingService, error) {
+			service := services.NewParkingService()
+			service.AddLot(models.NewParkingLot("BIG", 8))
+			service.AddLot(models.NewParkingLotWithSpecs("ACCESS", []models.SpaceSpec{
+				{Type: models.RegularSpace, Accessible: true},
+				{Type: models.RegularSpace, Accessible: true},
+				{Type: models.RegularSpace},
+				{Type: models.RegularSpace},
+			}))
+			return service, nil
+		},
+		Duration: 6 * time.Hour,
+		Arrivals: simulation.Fixed{Value: time.Hour},
+		Dwell:    simulation.Fixed{Value: 30 * time.Minute},
+		Mix:      simulation.VehicleMix{{Size: models.MediumVehicle, Handicap: true, Weight: 1}},
+	}
+
+	// Act
+	results, err := simulation.Compare(scenario, models.NewEvenDistributionStrategy(), models.NewHandicapPriorityStrategy())
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, results, 2)
+	even, handicap := results[0], results[1]
+	assert.Equal(t, "Even Distribution Strategy", even.Strategy)
+	assert.Equal(t, "Handicap Priority Strategy", handicap.Strategy)
+	assert.Equal(t, 6, even.Arrivals)
+	assert.Equal(t, even.Arrivals, handicap.Arrivals)
+	require.Len(t, even.Lots, 2)
+	assert.Equal(t, simulation.LotResult{LotID: "BIG", Capacity: 8, Utilization: even.Lots[0].Utilization, Parked: 6}, even.Lots[0])
+	assert.Equal(t, 0, even.Lots[1].Parked)
+	assert.Equal(t, 0, handicap.Lots[0].Parked)
+	assert.Equal(t, 6, handicap.Lots[1].Parked, "accessible spaces are only in ACCESS")
+	assert.Zero(t, handicap.Lots[0].Utilization)
+}
+
+func TestUC40_PatientDriversQueueForFreedSpaces(t *testing.T) {
+	// Arrange: a car every 30 minutes into a single space, each staying 45
+	impatient := singleLotScenario(1)
+	impatient.Duration = 3 * time.Hour
+	impatient.Arrivals = simulation.Fixed{Value: 30 * time.Minute}
+	impatient.Dwell = simulation.Fixed{Value: 45 * time.Minute}
+	patient := impatient
+	patient.Patience = 20 * time.Minute
+
+	// Act
+	turnedAway, err := simulation.Run(impatient, models.NewSmartParkingStrategy())
+	require.NoError(t, err)
+	queued, err := simulation.Run(patient, models.NewSmartParkingStrategy())
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 6, turnedAway.Arrivals)
+	assert.Equal(t, 3, turnedAway.Parked)
+	assert.Equal(t, 3, turnedAway.TurnedAway)
+	assert.InDelta(t, 0.5, turnedAway.TurnAwayRate, 1e-9)
+	assert.Zero(t, turnedAway.Queued)
+	assert.Equal(t, 10.0, turnedAway.Revenue, "two 45-minute stays at the $5 minimum left before the run ended")
+
+	assert.Equal(t, 6, queued.Arrivals)
+	assert.Equal(t, 4, queued.Parked)
+	assert.Equal(t, 4, queued.Queued)
+	assert.Equal(t, 1, queued.Abandoned, "the car arriving at 90 minutes waits longer than its patience")
+	assert.Zero(t, queued.TurnedAway)
+	assert.InDelta(t, 1.0/6, queued.TurnAwayRate, 1e-9)
+	assert.Equal(t, 15*time.Minute, queued.AverageQueueWait)
+	assert.Equal(t, 15*time.Minute, queued.MaxQueueWait)
+	assert.Equal(t, 15.0, queued.Revenue)
+}
+
+func TestUC40_ConfiguredRatesAndUtilizationAreReported(t *testing.T) {
+	// Arrange
+	cfg, err := config.Parse([]byte(`
+lots:
+  - id: LOT1
+    capacity: 2
+rates:
+  hourlyRate: 4
+  minimumCharge: 1
+`))
+	require.NoError(t, err)
+	scenario := simulation.Scenario{
+		NewService: cfg.NewParkingService,
+		Start:      weekdayMorning,
+		Duration:   4 * time.Hour,
+		Arrivals:   simulation.Fixed{Value: time.Hour},
+		Dwell:      simulation.Fixed{Value: time.Hour},
+	}
+	_, invalidErr := simulation.Run(simulation.Scenario{NewService: cfg.NewParkingService}, models.NewEvenDistributionStrategy())
+
+	// Act
+	result, err := simulation.Run(scenario, models.NewEvenDistributionStrategy())
+	require.NoError(t, err)
+
+	// Assert: one space is taken from the first hour on, each car leaving as the next arrives
+	assert.Equal(t, 4, result.Parked)
+	assert.Equal(t, 12.0, result.Revenue, "three one-hour stays at the configured $4")
+	assert.InDelta(t, 3.0/8, result.Utilization, 1e-9)
+	assert.InDelta(t, 3.0/8, result.Lots[0].Utilization, 1e-9)
+	assert.EqualError(t, invalidErr, "simulation duration must be positive")
+}
+
+func TestUC40_DistributionsThatCannotBeSampledAreRejected(t *testing.T) {
+	// Arrange
+	withDistributions := func(arrivals, dwell simulation.Distribution) simulation.Scenario {
+		scenario := singleLotScenario(2)
+		scenario.Duration = time.Hour
+		scenario.Arrivals = arrivals
+		scenario.Dwell = dwell
+		return scenario
+	}
+	hour := simulation.Fixed{Value: time.Hour}
+	cases := map[string]simulation.Scenario{
+		"simulation arrivals: fixed duration must be positive":                            withDistributions(simulation.Fixed{}, hour),
+		"simulation arrivals: uniform distribution bounds must be positive":               withDistributions(simulation.Uniform{}, hour),
+		"simulation arrivals: exponential mean must be positive":                          withDistributions(simulation.Poisson(0), hour),
+		"simulation dwell time: empirical distribution cannot be empty":                   withDistributions(hour, simulation.Empirical{}),
+		"simulation dwell time: uniform distribution maximum cannot be below its minimum": withDistributions(hour, simulation.Uniform{Min: 2 * time.Hour, Max: time.Hour}),
+	}
+
+	for want, scenario := range cases {
+		// Act
+		_, err := simulation.Run(scenario, models.NewEvenDistributionStrategy())
+
+		// Assert
+		assert.EqualError(t, err, want)
+	}
+}
+
+func TestUC40_CLIComparesStrategiesSideBySide(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+lots:
+  - id: NORTH
+    capacity: 10
+  - id: SOUTH
+    capacity: 5
+`), 0o644))
+	state := filepath.Join(dir, "state")
+
+	// Act
+	code, out, _ := runCLI(t, "", "--state", state, "--output", "csv", "simulate", configPath,
+		"--strategies", "even,smart", "--hours", "8", "--arrivals", "poisson:6", "--dwell", "uniform:30m-3h",
+		"--mix", "medium:80,large:10,handicap:10", "--patience", "10m", "--seed", "7")
+	_, replayed, _ := runCLI(t, "", "--state", state, "--output", "csv", "simulate", configPath,
+		"--strategies", "even,smart", "--hours", "8", "--arrivals", "poisson:6", "--dwell", "uniform:30m-3h",
+		"--mix", "medium:80,large:10,handicap:10", "--patience", "10m", "--seed", "7")
+	badCode, _, badErr := runCLI(t, "", "--state", state, "simulate", configPath, "--dwell", "normal:2h")
+	missingCode, _, _ := runCLI(t, "", "--state", state, "simulate")
+
+	// Assert
+	require.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "STRATEGY,ARRIVALS,PARKED"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "Even Distribution Strategy,"), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "Smart Parking Strategy"), lines[2])
+	assert.Contains(t, lines[1], "NORTH ")
+	assert.Contains(t, lines[1], "SOUTH ")
+	assert.Equal(t, out, replayed)
+	assert.Equal(t, 2, badCode)
+	assert.Contains(t, badErr, `unknown distribution "normal:2h"`)
+	assert.Equal(t, 2, missingCode)
+}
+
+// simultaneousArrivals passes the built-in checks but puts no time between cars
+type simultaneousArrivals struct{}
+
+func (simultaneousArrivals) Sample(*rand.Rand) time.Duration { return 0 }
+func (simultaneousArrivals) String() string                  { return "simultaneous" }
+
+func TestUC40_ArrivalGapsThatAreNotPositiveStopTheRun(t *testing.T) {
+	// Arrange
+	scenario := singleLotScenario(2)
+	scenario.Duration = time.Hour
+	scenario.Arrivals = simultaneousArrivals{}
+	scenario.Dwell = simulation.Fixed{Value: time.Hour}
+
+	// Act
+	result, err := simulation.Run(scenario, models.NewEvenDistributionStrategy())
+
+	// Assert
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "simulation arrivals: gaps must be positive, drew 0s")
+}
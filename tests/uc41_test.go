@@ -0,0 +1,344 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/storage"
+)
+
+// setOccupied parks or unparks cars until n of the lot's spaces are taken
+func setOccupied(t *testing.T, service *services.ParkingService, n int) {
+	t.Helper()
+	lot, err := service.GetLotStatus("LOT1")
+	require.NoError(t, err)
+	for occupied := lot.GetOccupiedSpaces(); occupied < n; occupied++ {
+		require.NoError(t, service.ParkCar(models.NewCar(fmt.Sprintf("OCC%03d", occupied+1), "Commuter")))
+	}
+	for occupied := lot.GetOccupiedSpaces(); occupied > n; occupied-- {
+		_, err := service.UnparkCar(fmt.Sprintf("OCC%03d", occupied))
+		require.NoError(t, err)
+	}
+}
+
+// workdayOccupancy is how many of four spaces are taken each hour: half at
+// 08:00, all from 09:00 to 16:00, none overnight
+func workdayOccupancy(hour int) int {
+	switch {
+	case hour == 8:
+		return 2
+	case hour >= 9 && hour <= 16:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// sampleWorkdays records a week of hourly samples of a four-space lot ending
+// at midnight before weekdayMorning, and leaves the clock at that midnight
+func sampleWorkdays(t *testing.T) (*services.ParkingService, *models.FakeClock) {
+	t.Helper()
+	service, clock := newClockedService(t, 4)
+	midnight := weekdayMorning.Truncate(24 * time.Hour)
+	clock.Set(midnight.Add(-7 * 24 * time.Hour))
+	for clock.Now().Before(midnight) {
+		setOccupied(t, service, workdayOccupancy(clock.Now().Hour()))
+		service.GetOccupancyService().Sample()
+		clock.Advance(time.Hour)
+	}
+	return service, clock
+}
+
+func TestUC41_OccupancyIsSampledIntoATimeSeries(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	service.AddLot(models.NewParkingLot("LOT2", 2))
+	occupancy := service.GetOccupancyService()
+
+	// Act
+	occupancy.Sample()
+	clock.Advance(30 * time.Minute)
+	setOccupied(t, service, 3)
+	taken := occupancy.Sample()
+	clock.Advance(30 * time.Minute)
+	occupancy.Sample()
+	all, err := occupancy.GetSeries("LOT1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	window, err := occupancy.GetSeries("LOT1", weekdayMorning.Add(time.Minute), weekdayMorning.Add(45*time.Minute))
+	require.NoError(t, err)
+	_, missingErr := occupancy.GetSeries("NOPE", time.Time{}, time.Time{})
+
+	// Assert
+	require.Len(t, taken, 2, "one sample per lot")
+	assert.Equal(t, models.OccupancySample{LotID: "LOT1", At: weekdayMorning.Add(30 * time.Minute), OccupiedSpaces: 3, TotalSpaces: 4}, taken[0])
+	assert.Equal(t, 0.75, taken[0].Occupancy())
+	assert.Equal(t, "LOT2", taken[1].LotID)
+	require.Len(t, all, 3)
+	assert.Equal(t, weekdayMorning, all[0].At)
+	assert.Equal(t, []models.OccupancySample{taken[0]}, window)
+	assert.EqualError(t, missingErr, "parking lot not found")
+}
+
+func TestUC41_ProfilesAggregateByHourAndWeekday(t *testing.T) {
+	// Arrange
+	service, clock := sampleWorkdays(t)
+
+	// Act
+	profile, err := service.GetOccupancyService().GetProfile("LOT1")
+	require.NoError(t, err)
+	service.GetOccupancyService().SetPolicy(services.OccupancyPolicy{Retention: 3 * 24 * time.Hour, FullOccupancy: 1})
+	clock.Advance(time.Hour)
+	service.GetOccupancyService().Sample()
+	pruned, err := service.GetOccupancyService().GetSeries("LOT1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 7*24, profile.Samples)
+	assert.Equal(t, models.OccupancyBucket{Samples: 7, AverageOccupancy: 0.5, PeakOccupancy: 0.5}, profile.ByHour[8])
+	assert.Equal(t, models.OccupancyBucket{Samples: 7, AverageOccupancy: 1, PeakOccupancy: 1}, profile.ByHour[12])
+	assert.Equal(t, models.OccupancyBucket{Samples: 7}, profile.ByHour[3])
+	assert.Equal(t, 24, profile.ByWeekday[time.Wednesday].Samples)
+	assert.InDelta(t, 8.5/24, profile.ByWeekday[time.Wednesday].AverageOccupancy, 1e-9)
+	assert.Equal(t, 1.0, profile.ByWeekday[time.Wednesday].PeakOccupancy)
+	assert.Len(t, pruned, 3*24, "samples older than the retention are dropped")
+	assert.Equal(t, clock.Now().Add(-3*24*time.Hour), pruned[0].At)
+}
+
+func TestUC41_SeasonalForecastPredictsWhenTheLotFills(t *testing.T) {
+	// Arrange
+	service, clock := sampleWorkdays(t)
+	occupancy := service.GetOccupancyService()
+	occupancy.SetPolicy(services.OccupancyPolicy{Retention: services.DefaultOccupancyPolicy.Retention, FullOccupancy: 0.75})
+	clock.Advance(7 * time.Hour)
+
+	// Act
+	usual, err := occupancy.Forecast("LOT1", 6*time.Hour)
+	require.NoError(t, err)
+	setOccupied(t, service, 2)
+	busy, err := occupancy.Forecast("LOT1", 6*time.Hour)
+	require.NoError(t, err)
+	_, horizonErr := occupancy.Forecast("LOT1", 0)
+
+	// Assert
+	assert.Equal(t, models.ForecastSeasonal, usual.Method)
+	require.Len(t, usual.Points, 6)
+	assert.Equal(t, weekdayMorning.Add(-time.Hour), usual.Points[0].At)
+	assert.InDelta(t, 0.5, usual.Points[0].Occupancy, 1e-9)
+	assert.InDelta(t, 1.0, usual.Points[1].Occupancy, 1e-9)
+	require.NotNil(t, usual.FullAt)
+	assert.Equal(t, weekdayMorning.Add(-30*time.Minute), *usual.FullAt, "three quarters full halfway from 08:00 to 09:00")
+
+	assert.InDelta(t, 0.75, busy.Points[0].Occupancy, 1e-9, "a busier morning than usual carries into the next hour")
+	require.NotNil(t, busy.FullAt)
+	assert.Equal(t, weekdayMorning.Add(-time.Hour), *busy.FullAt)
+	assert.EqualError(t, horizonErr, "forecast horizon must be positive")
+}
+
+func TestUC41_NewLotsAreForecastFromTheirRecentTrend(t *testing.T) {
+	// Arrange: a lot filling a space every 20 minutes, with no earlier days to go on
+	service, clock := newClockedService(t, 4)
+	service.AddLot(models.NewParkingLot("QUIET", 2))
+	for i := 0; i < 3; i++ {
+		setOccupied(t, service, i)
+		service.GetOccupancyService().Sample()
+		clock.Advance(20 * time.Minute)
+	}
+	setOccupied(t, service, 3)
+
+	// Act
+	forecasts, err := service.GetOccupancyService().ForecastAll(3 * time.Hour)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, forecasts, 2)
+	filling, quiet := forecasts[0], forecasts[1]
+	assert.Equal(t, models.ForecastTrend, filling.Method)
+	assert.InDelta(t, 1.0, filling.Points[0].Occupancy, 1e-9, "forecasts never exceed a full lot")
+	require.NotNil(t, filling.FullAt)
+	assert.Equal(t, weekdayMorning.Add(80*time.Minute), filling.FullAt.Round(time.Second))
+	assert.Equal(t, "QUIET", quiet.LotID)
+	assert.Nil(t, quiet.FullAt)
+	assert.Zero(t, quiet.Points[2].Occupancy)
+}
+
+func TestUC41_ForecastsAreServedOverHTTPAndPersisted(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	service, store := openPersistentService(t, dir)
+	service.AddLot(models.NewParkingLot("LOT1", 4))
+	require.NoError(t, service.ParkCar(models.NewCar("HTTP01", "Driver")))
+	service.GetOccupancyService().Sample()
+	require.NoError(t, store.Close())
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	server := httptest.NewServer(api.NewServer(restored, nil, services.NewPoliceService(restored)))
+	defer server.Close()
+
+	// Act
+	var sampled []api.OccupancySampleResponse
+	sampleStatus := doJSON(t, http.MethodPost, server.URL+"/api/occupancy/samples", nil, &sampled)
+	var history []api.OccupancySampleResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1/occupancy", nil, &history)
+	var profile api.OccupancyProfileResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1/occupancy/profile", nil, &profile)
+	var forecast api.OccupancyForecastResponse
+	forecastStatus := doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1/occupancy/forecast?hours=4", nil, &forecast)
+	var all []api.OccupancyForecastResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/occupancy/forecasts", nil, &all)
+	missingStatus := doJSON(t, http.MethodGet, server.URL+"/api/lots/NOPE/occupancy/forecast", nil, nil)
+	badStatus := doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1/occupancy/forecast?hours=soon", nil, nil)
+	badTimeStatus := doJSON(t, http.MethodGet, server.URL+"/api/lots/LOT1/occupancy?from=yesterday", nil, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, sampleStatus)
+	require.Len(t, sampled, 1)
+	assert.Equal(t, 0.25, sampled[0].Occupancy)
+	assert.Len(t, history, 2, "the sample taken before the restart was kept")
+	assert.Equal(t, 2, profile.Samples)
+	assert.Len(t, profile.ByHour, 24)
+	assert.Len(t, profile.ByWeekday, 7)
+	assert.Equal(t, http.StatusOK, forecastStatus)
+	assert.Equal(t, "LOT1", forecast.LotID)
+	assert.Len(t, forecast.Points, 4)
+	assert.Equal(t, 1.0, forecast.FullOccupancy)
+	require.Len(t, all, 1)
+	assert.Len(t, all[0].Points, 24)
+	assert.Equal(t, http.StatusNotFound, missingStatus)
+	assert.Equal(t, http.StatusBadRequest, badStatus)
+	assert.Equal(t, http.StatusBadRequest, badTimeStatus)
+}
+
+func TestUC41_CLIRecordsAndForecastsOccupancy(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state")
+	configPath := filepath.Join(dir, "parking.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+lots:
+  - id: LOT1
+    capacity: 4
+`), 0o644))
+	code, _, _ := runCLI(t, "", "--state", state, "--config", configPath, "park", "CLI041")
+	require.Equal(t, 0, code)
+
+	// Act
+	sampleCode, sampleOut, _ := runCLI(t, "", "--state", state, "--output", "csv", "occupancy", "sample")
+	_, historyOut, _ := runCLI(t, "", "--state", state, "--output", "csv", "occupancy", "history", "LOT1")
+	_, profileOut, _ := runCLI(t, "", "--state", state, "--output", "csv", "occupancy", "profile", "LOT1")
+	forecastCode, forecastOut, _ := runCLI(t, "", "--state", state, "--output", "csv", "occupancy", "forecast", "--hours", "2")
+	missingCode, _, missingErr := runCLI(t, "", "--state", state, "occupancy", "forecast", "NOPE")
+
+	// Assert
+	assert.Equal(t, 0, sampleCode)
+	assert.Contains(t, sampleOut, ",1,4,25.0%")
+	historyLines := strings.Split(strings.TrimSpace(historyOut), "\n")
+	assert.Len(t, historyLines, 2)
+	assert.Contains(t, profileOut, ",1,25.0%,25.0%")
+	assert.Equal(t, 0, forecastCode)
+	forecastLines := strings.Split(strings.TrimSpace(forecastOut), "\n")
+	require.Len(t, forecastLines, 2)
+	assert.Equal(t, "LOT,NOW,PEAK,PEAK AT,FULL AT,METHOD", forecastLines[0])
+	assert.True(t, strings.HasPrefix(forecastLines[1], "LOT1,25.0%,"), forecastLines[1])
+	assert.True(t, strings.HasSuffix(forecastLines[1], ",trend"), forecastLines[1])
+	assert.Equal(t, 1, missingCode)
+	assert.Contains(t, missingErr, "parking lot not found")
+}
+
+func TestUC41_SamplesInBothTheSnapshotAndTheLogAreRestoredOnce(t *testing.T) {
+	// Arrange: the sample was journalled after the snapshot read the log's sequence
+	dir := t.TempDir()
+	store, err := storage.NewFileStore(dir)
+	require.NoError(t, err)
+	sample := models.OccupancySample{LotID: "LOT1", At: time.Now().UTC().Truncate(time.Hour), OccupiedSpaces: 2, TotalSpaces: 4}
+	require.NoError(t, store.Append(&services.JournalEntry{Type: services.EntryOccupancySampled, LotID: "LOT1", Sample: &sample}))
+	require.NoError(t, store.SaveSnapshot(&services.Snapshot{
+		Lots:      []*services.LotSnapshot{{ID: "LOT1", Capacity: 4}},
+		Occupancy: []*models.OccupancySample{&sample},
+	}))
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	series, err := restored.GetOccupancyService().GetSeries("LOT1", time.Time{}, time.Time{})
+	replayed := &services.Snapshot{}
+	replayed.Apply(&services.JournalEntry{Sequence: 1, Type: services.EntryOccupancySampled, Sample: &sample})
+	replayed.Apply(&services.JournalEntry{Sequence: 2, Type: services.EntryOccupancySampled, Sample: &sample})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, series, 1)
+	assert.Len(t, replayed.Occupancy, 1)
+}
+
+func TestUC41_RestoredSamplesAreOrderedAndPruned(t *testing.T) {
+	// Arrange: the log holds samples older than the snapshot's, one past the retention
+	dir := t.TempDir()
+	store, err := storage.NewFileStore(dir)
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Hour)
+	sampleAt := func(at time.Time) *models.OccupancySample {
+		return &models.OccupancySample{LotID: "LOT1", At: at, OccupiedSpaces: 1, TotalSpaces: 4}
+	}
+	for _, at := range []time.Time{now.Add(-9 * 7 * 24 * time.Hour), now.Add(-time.Hour), now.Add(-3 * time.Hour)} {
+		require.NoError(t, store.Append(&services.JournalEntry{Type: services.EntryOccupancySampled, LotID: "LOT1", Sample: sampleAt(at)}))
+	}
+	require.NoError(t, store.SaveSnapshot(&services.Snapshot{
+		Lots:      []*services.LotSnapshot{{ID: "LOT1", Capacity: 4}},
+		Occupancy: []*models.OccupancySample{sampleAt(now.Add(-2 * time.Hour))},
+	}))
+	require.NoError(t, store.Close())
+
+	// Act
+	restored, restoredStore := openPersistentService(t, dir)
+	defer restoredStore.Close()
+	series, err := restored.GetOccupancyService().GetSeries("LOT1", time.Time{}, time.Time{})
+
+	// Assert
+	require.NoError(t, err)
+	var times []time.Time
+	for _, sample := range series {
+		times = append(times, sample.At)
+	}
+	assert.Equal(t, []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Hour)}, times)
+}
+
+func TestUC41_ConcurrentSamplesStayOldestFirst(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	occupancy := service.GetOccupancyService()
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				clock.Advance(time.Minute)
+				occupancy.Sample()
+			}
+		}()
+	}
+	wg.Wait()
+	series, err := occupancy.GetSeries("LOT1", time.Time{}, time.Time{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, series, 800)
+	for i := 1; i < len(series); i++ {
+		assert.False(t, series[i].At.Before(series[i-1].At), "sample %d is older than the one before it", i)
+	}
+}
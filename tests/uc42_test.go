@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"parking-lot-system/api"
+	"parking-lot-system/models"
+	"parking-lot-system/services"
+	"parking-lot-system/storage"
+)
+
+// reportedDay parks four cars at weekdayMorning and unparks three: a medium
+// car after 90 minutes, a large handicap car after 45 and a small car at
+// 01:00 the next day, where it leaves the clock. The motorcycle stays.
+func reportedDay(t *testing.T, service *services.ParkingService, clock *models.FakeClock) {
+	t.Helper()
+	park := func(plate string, size models.VehicleSize, handicap bool) {
+		car := models.NewCar(plate, "Driver")
+		car.SetVehicleSize(size)
+		car.SetHandicapStatus(handicap)
+		_, err := service.ParkCarWithTicket(car)
+		require.NoError(t, err)
+	}
+	unpark := func(plate string) {
+		_, _, err := service.UnparkCarWithBilling(plate)
+		require.NoError(t, err)
+	}
+	park("REP001", models.MediumVehicle, false)
+	park("REP002", models.LargeVehicle, true)
+	park("REP003", models.MotorcycleVehicle, false)
+	park("REP004", models.SmallVehicle, false)
+
+	clock.Advance(45 * time.Minute)
+	unpark("REP002")
+	clock.Advance(45 * time.Minute)
+	unpark("REP001")
+	clock.Set(weekdayMorning.Add(16 * time.Hour))
+	unpark("REP004")
+}
+
+func TestUC42_PeriodsStartAtMidnightMondayAndTheFirstOfTheMonth(t *testing.T) {
+	// Arrange
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	at := time.Date(2026, time.October, 14, 23, 30, 0, 0, zone)
+
+	// Act
+	dayFrom, dayTo, dayErr := services.ReportPeriodBounds(services.ReportDaily, at)
+	weekFrom, weekTo, weekErr := services.ReportPeriodBounds(services.ReportWeekly, at)
+	sundayFrom, _, _ := services.ReportPeriodBounds(services.ReportWeekly, time.Date(2026, time.October, 18, 12, 0, 0, 0, zone))
+	monthFrom, monthTo, monthErr := services.ReportPeriodBounds(services.ReportMonthly, at)
+	_, _, unknownErr := services.ReportPeriodBounds("hourly", at)
+
+	// Assert
+	require.NoError(t, dayErr)
+	require.NoError(t, weekErr)
+	require.NoError(t, monthErr)
+	assert.Equal(t, time.Date(2026, time.October, 14, 0, 0, 0, 0, zone), dayFrom)
+	assert.Equal(t, time.Date(2026, time.October, 15, 0, 0, 0, 0, zone), dayTo)
+	assert.Equal(t, time.Date(2026, time.October, 12, 0, 0, 0, 0, zone), weekFrom)
+	assert.Equal(t, time.Date(2026, time.October, 19, 0, 0, 0, 0, zone), weekTo)
+	assert.Equal(t, weekFrom, sundayFrom, "Sunday closes the week that began on Monday")
+	assert.Equal(t, time.Date(2026, time.October, 1, 0, 0, 0, 0, zone), monthFrom)
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, zone), monthTo)
+	assert.EqualError(t, unknownErr, "report period must be daily, weekly or monthly")
+}
+
+func TestUC42_ReportsSumStaysThatEndedInThePeriod(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	reportedDay(t, service, clock)
+	reports := service.GetReportService()
+
+	// Act
+	daily, err := reports.Generate(services.ReportDaily, weekdayMorning)
+	require.NoError(t, err)
+	nextDay, err := reports.Generate(services.ReportDaily, clock.Now())
+	require.NoError(t, err)
+	weekly, err := reports.Generate(services.ReportWeekly, weekdayMorning)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, daily.Lots, 1)
+	lot := daily.Lots[0]
+	assert.Equal(t, "LOT1", lot.LotID)
+	assert.Equal(t, 2, lot.Tickets, "the motorcycle is still parked and the small car left the next day")
+	assert.Equal(t, 25.0, lot.Revenue, "$20 for two hours and the $5 minimum for 45 minutes")
+	assert.Equal(t, 67*time.Minute+30*time.Second, lot.AverageDuration)
+	assert.Equal(t, map[string]int{"Medium": 1, "Large": 1}, lot.TicketsBySize)
+	assert.Equal(t, 1, lot.HandicapTickets)
+	assert.Equal(t, lot.Tickets, daily.Total.Tickets)
+	assert.Equal(t, lot.Revenue, daily.Total.Revenue)
+
+	assert.Equal(t, 1, nextDay.Total.Tickets)
+	assert.Equal(t, 160.0, nextDay.Total.Revenue)
+	assert.Equal(t, map[string]int{"Small": 1}, nextDay.Total.TicketsBySize)
+
+	assert.Equal(t, 3, weekly.Total.Tickets)
+	assert.Equal(t, 185.0, weekly.Total.Revenue)
+	assert.Equal(t, clock.Now(), weekly.GeneratedAt)
+}
+
+func TestUC42_PeakOccupancyComesFromTheSamples(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	service.AddLot(models.NewParkingLot("LOT2", 4))
+	second, err := service.GetLotStatus("LOT2")
+	require.NoError(t, err)
+	setOccupied(t, service, 3)
+	service.GetOccupancyService().Sample()
+	clock.Advance(time.Hour)
+	setOccupied(t, service, 1)
+	for _, plate := range []string{"PEAK01", "PEAK02", "PEAK03", "PEAK04"} {
+		require.NoError(t, second.ParkCar(models.NewCar(plate, "Driver")))
+	}
+	service.GetOccupancyService().Sample()
+	clock.Set(weekdayMorning.Add(24 * time.Hour))
+	setOccupied(t, service, 4)
+	service.GetOccupancyService().Sample()
+
+	// Act
+	report, err := service.GetReportService().Generate(services.ReportDaily, weekdayMorning)
+	require.NoError(t, err)
+	quiet, err := service.GetReportService().Generate(services.ReportDaily, weekdayMorning.Add(-24*time.Hour))
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, report.Lots, 2)
+	assert.Equal(t, 0.75, report.Lots[0].PeakOccupancy, "the next day's full lot is outside the period")
+	assert.Equal(t, weekdayMorning, report.Lots[0].PeakAt)
+	assert.Equal(t, 1.0, report.Lots[1].PeakOccupancy)
+	assert.Equal(t, weekdayMorning.Add(time.Hour), report.Lots[1].PeakAt)
+	assert.Equal(t, 5.0/8, report.Total.PeakOccupancy, "five of the eight spaces at 10:00")
+	assert.Equal(t, weekdayMorning.Add(time.Hour), report.Total.PeakAt)
+	assert.Zero(t, quiet.Total.PeakOccupancy)
+	assert.True(t, quiet.Total.PeakAt.IsZero())
+}
+
+func TestUC42_ReportsExportAsCSVJSONAndAlignedText(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	reportedDay(t, service, clock)
+	report, err := service.GetReportService().Generate(services.ReportDaily, weekdayMorning)
+	require.NoError(t, err)
+	var csvOut, jsonOut, textOut bytes.Buffer
+
+	// Act
+	require.NoError(t, report.Write(&csvOut, services.ReportCSV))
+	require.NoError(t, report.Write(&jsonOut, services.ReportJSON))
+	require.NoError(t, report.Write(&textOut, services.ReportText))
+	formatErr := report.Write(&bytes.Buffer{}, "pdf")
+
+	// Assert
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"period", "from", "to", "lot_id", "tickets", "revenue", "average_duration_minutes",
+		"motorcycle", "small", "medium", "large", "handicap", "peak_occupancy_percent", "peak_at"}, records[0])
+	assert.Equal(t, []string{"daily", "2026-10-14T00:00:00Z", "2026-10-15T00:00:00Z", "LOT1", "2", "25.00", "67.5",
+		"0", "0", "1", "1", "1", "0.0", ""}, records[1])
+	assert.Equal(t, "TOTAL", records[2][3])
+
+	var document struct {
+		Period string
+		Lots   []struct {
+			LotID                  string
+			Revenue                float64
+			AverageDurationSeconds float64
+			TicketsBySize          map[string]int
+		}
+		Total struct{ Tickets int }
+	}
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &document))
+	assert.Equal(t, "daily", document.Period)
+	require.Len(t, document.Lots, 1)
+	assert.Equal(t, 25.0, document.Lots[0].Revenue)
+	assert.Equal(t, 4050.0, document.Lots[0].AverageDurationSeconds)
+	assert.Equal(t, 1, document.Lots[0].TicketsBySize["Large"])
+	assert.Equal(t, 2, document.Total.Tickets)
+
+	lines := strings.Split(strings.TrimSpace(textOut.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "Daily report for 2026-10-14", lines[0])
+	header, lot, total := lines[3], lines[4], lines[5]
+	assert.True(t, strings.HasPrefix(lot, "LOT1 "), lot)
+	assert.True(t, strings.HasPrefix(total, "TOTAL "), total)
+	assert.Equal(t, strings.Index(header, "REVENUE"), strings.Index(lot, "$25.00"), "columns line up")
+	assert.Equal(t, strings.Index(header, "AVG STAY"), strings.Index(lot, "1h08m"))
+	assert.EqualError(t, formatErr, "report format must be csv, json or text")
+}
+
+func TestUC42_ScheduledReportsAreWrittenOnceEachPeriodCloses(t *testing.T) {
+	// Arrange
+	service, clock := newClockedService(t, 4)
+	reportedDay(t, service, clock)
+	dir := filepath.Join(t.TempDir(), "reports")
+	schedule := services.ReportSchedule{Dir: dir}
+
+	// Act
+	first, err := service.GetReportService().WriteDue(schedule)
+	require.NoError(t, err)
+	again, err := service.GetReportService().WriteDue(schedule)
+	require.NoError(t, err)
+	clock.Advance(24 * time.Hour)
+	next, err := service.GetReportService().WriteDue(services.ReportSchedule{Dir: dir, Periods: []string{services.ReportDaily}, Formats: []string{services.ReportCSV}})
+	require.NoError(t, err)
+	_, invalidErr := service.GetReportService().WriteDue(services.ReportSchedule{Dir: dir, Formats: []string{"pdf"}})
+
+	// Assert
+	names := make([]string, 0, len(first))
+	for _, path := range first {
+		names = append(names, filepath.Base(path))
+	}
+	assert.Equal(t, []string{
+		"daily-2026-10-14.csv", "daily-2026-10-14.json", "daily-2026-10-14.txt",
+		"weekly-2026-10-05.csv", "weekly-2026-10-05.json", "weekly-2026-10-05.txt",
+		"monthly-2026-09.csv", "monthly-2026-09.json", "monthly-2026-09.txt",
+	}, names)
+	assert.Empty(t, again, "reports already in the directory are not rewritten")
+	assert.Equal(t, []string{filepath.Join(dir, "daily-2026-10-15.csv")}, next)
+
+	written, err := os.ReadFile(filepath.Join(dir, "daily-2026-10-14.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "$25.00")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 10, "no temporary files are left behind")
+	assert.EqualError(t, invalidErr, "report format must be csv, json or text")
+}
+
+func TestUC42_ReportsAreServedOverHTTPAndTheCLIFromSQLiteHistory(t *testing.T) {
+	// Arrange
+	store, err := storage.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+	service := services.NewParkingServiceWithRepository(store)
+	clock := models.NewFakeClock(weekdayMorning)
+	service.SetClock(clock)
+	service.AddLot(models.NewParkingLot("LOT1", 4))
+	reportedDay(t, service, clock)
+	server := httptest.NewServer(api.NewServer(service, nil, services.NewPoliceService(service)))
+	defer server.Close()
+	state := filepath.Join(t.TempDir(), "state")
+	runCLI(t, "", "--state", state, "lot", "create", "CLI1", "2")
+	runCLI(t, "", "--state", state, "park", "CLI042", "--size", "large", "--handicap")
+	runCLI(t, "", "--state", state, "unpark", "CLI042")
+
+	// Act
+	var daily api.ReportResponse
+	status := doJSON(t, http.MethodGet, server.URL+"/api/reports/daily?at=2026-10-14T12:00:00Z", nil, &daily)
+	var weekly api.ReportResponse
+	doJSON(t, http.MethodGet, server.URL+"/api/reports/weekly?at=2026-10-14T12:00:00Z", nil, &weekly)
+	badPeriodStatus := doJSON(t, http.MethodGet, server.URL+"/api/reports/hourly", nil, nil)
+	badTimeStatus := doJSON(t, http.MethodGet, server.URL+"/api/reports/daily?at=noon", nil, nil)
+	code, out, _ := runCLI(t, "", "--state", state, "--output", "csv", "report", "daily")
+	badCode, _, badErr := runCLI(t, "", "--state", state, "report", "daily", "--date", "someday")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, daily.Lots, 1)
+	assert.Equal(t, 2, daily.Lots[0].Tickets)
+	assert.Equal(t, 25.0, daily.Lots[0].Revenue)
+	assert.Equal(t, map[string]int{"Medium": 1, "Large": 1}, daily.Lots[0].TicketsBySize, "sizes survive the SQLite round trip")
+	assert.Equal(t, 1, daily.Total.HandicapTickets)
+	assert.Equal(t, 3, weekly.Total.Tickets)
+	assert.Equal(t, http.StatusBadRequest, badPeriodStatus)
+	assert.Equal(t, http.StatusBadRequest, badTimeStatus)
+
+	require.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "LOT,TICKETS,REVENUE,AVG STAY,MOTORCYCLE,SMALL,MEDIUM,LARGE,HANDICAP,PEAK,PEAK AT", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "CLI1,1,5.00,"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], ",0,0,0,1,1,,"), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "TOTAL,1,5.00,"), lines[2])
+	assert.Equal(t, 2, badCode)
+	assert.Contains(t, badErr, "--date must be")
+}
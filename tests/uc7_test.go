@@ -6,6 +6,7 @@ import (
 	"parking-lot-system/services"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestUC7_DriverCanFindTheirCar(t *testing.T) {
@@ -146,7 +147,8 @@ func TestUC7_MultiLotCarFinding(t *testing.T) {
 func TestUC7_CarLocationModel(t *testing.T) {
 	// Arrange
 	car := models.NewCar("ABC123", "John Doe")
-	location := models.NewCarLocation(car, "LOT1", "A1", "A", 1, "ATT001")
+	parkedAt := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	location := models.NewCarLocation(car, "LOT1", "A1", "A", 1, "ATT001", parkedAt)
 
 	// Act
 	info := location.GetLocationInfo()
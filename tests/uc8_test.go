@@ -96,7 +96,9 @@ func TestUC8_CompleteUnparkingWithBilling(t *testing.T) {
 	assert.Equal(t, "DEF456", unparkedCar.LicensePlate)
 	assert.Equal(t, ticket.ID, bill.TicketID)
 	assert.Equal(t, 5.0, bill.TotalAmount) // Minimum charge
-	assert.False(t, ticket.IsActive)
+	completed, err := service.GetTicket(ticket.ID)
+	assert.NoError(t, err)
+	assert.False(t, completed.IsActive)
 }
 
 func TestUC8_ParkingHistoryTracking(t *testing.T) {